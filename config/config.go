@@ -25,6 +25,10 @@ type Config struct {
 		AdminPort    string `mapstructure:"admin_port"`
 		CustomerPort string `mapstructure:"customer_port"`
 		TraderPort   string `mapstructure:"trader_port"`
+		// TrustedProxies lists the addresses or CIDRs of reverse proxies
+		// whose X-Forwarded-For header is believed. Client addresses used
+		// for rate limiting come from the connection when it is empty.
+		TrustedProxies []string `mapstructure:"trusted_proxies"`
 	}
 
 	Cookie struct {
@@ -41,14 +45,148 @@ type Config struct {
 	}
 
 	JWT struct {
-		Secret      string
-		ExpireHours int `mapstructure:"expire_hours"`
+		Secret string
+		// ExpireHours is how long a login lasts without being used: the
+		// lifetime of each refresh token. Access tokens live
+		// AccessTokenMinutes and are renewed with the refresh token.
+		ExpireHours        int `mapstructure:"expire_hours"`
+		AccessTokenMinutes int `mapstructure:"access_token_minutes"`
 	}
 
 	Redis struct {
-		Host string
-		Port int
+		Host     string
+		Port     int
+		Password string
+		DB       int
+	}
+
+	RateLimit struct {
+		// Backend is "memory" or "redis". The memory backend counts per
+		// process; use redis when several instances serve the same routes.
+		Backend string `mapstructure:"backend"`
+		// Groups sets the token bucket of each route group: auth for the
+		// login and account recovery endpoints, counted per IP address, and
+		// api for authenticated routes, counted per user.
+		Groups map[string]struct {
+			RequestsPerMinute float64 `mapstructure:"requests_per_minute"`
+			Burst             int     `mapstructure:"burst"`
+		} `mapstructure:"groups"`
+	} `mapstructure:"rate_limit"`
+
+	Exchange struct {
+		Provider       string
+		BaseURL        string `mapstructure:"base_url"`
+		TimeoutSeconds int    `mapstructure:"timeout_seconds"`
 	}
+
+	PaymentGateway struct {
+		WebhookURL              string `mapstructure:"webhook_url"`
+		WebhookSecret           string `mapstructure:"webhook_secret"`
+		WebhookToleranceSeconds int    `mapstructure:"webhook_tolerance_seconds"`
+
+		Simulator struct {
+			SettlementDelaySeconds int     `mapstructure:"settlement_delay_seconds"`
+			AsyncSettlement        bool    `mapstructure:"async_settlement"`
+			DeclineRate            float64 `mapstructure:"decline_rate"`
+			TimeoutRate            float64 `mapstructure:"timeout_rate"`
+			SettlementFailureRate  float64 `mapstructure:"settlement_failure_rate"`
+			RefundRate             float64 `mapstructure:"refund_rate"`
+			ChargebackRate         float64 `mapstructure:"chargeback_rate"`
+			TimeoutSeconds         int     `mapstructure:"timeout_seconds"`
+			MagicAmounts           bool    `mapstructure:"magic_amounts"`
+		}
+	} `mapstructure:"payment_gateway"`
+
+	FX struct {
+		// FeedURL serves {"base": ..., "rates": {...}}; leave empty to maintain
+		// rates by hand in the admin panel.
+		FeedURL string `mapstructure:"feed_url"`
+	} `mapstructure:"fx"`
+
+	Withdrawals struct {
+		// LimitCurrency is the currency limits and the approval threshold are
+		// set in; withdrawals in other currencies are converted at request time.
+		LimitCurrency           string  `mapstructure:"limit_currency"`
+		SecondApprovalThreshold float64 `mapstructure:"second_approval_threshold"`
+		DepositHoldHours        int     `mapstructure:"deposit_hold_hours"`
+		PasswordChangeHoldHours int     `mapstructure:"password_change_hold_hours"`
+		// Limits is keyed by KYC status. A status without limits cannot
+		// withdraw.
+		Limits map[string]struct {
+			Daily   float64 `mapstructure:"daily"`
+			Monthly float64 `mapstructure:"monthly"`
+		} `mapstructure:"limits"`
+	} `mapstructure:"withdrawals"`
+
+	Transfers struct {
+		// Limits are set in LimitCurrency; transfers in other currencies are
+		// converted at send time. Only senders with approved KYC may transfer.
+		LimitCurrency string  `mapstructure:"limit_currency"`
+		MaxAmount     float64 `mapstructure:"max_amount"`
+		DailyLimit    float64 `mapstructure:"daily_limit"`
+	} `mapstructure:"transfers"`
+
+	TraderPayouts struct {
+		// Revenue a trader has earned since their last payout is swept into a
+		// withdrawal request once it reaches Threshold, in
+		// withdrawals.limit_currency.
+		Threshold float64 `mapstructure:"threshold"`
+		Schedule  string  `mapstructure:"schedule"`
+	} `mapstructure:"trader_payouts"`
+
+	Mail struct {
+		// Driver is "smtp", "file" or "log". The file driver writes each
+		// message to FileDir and the log driver prints it, so local
+		// development needs no mail server.
+		Driver  string `mapstructure:"driver"`
+		From    string `mapstructure:"from"`
+		FileDir string `mapstructure:"file_dir"`
+		SMTP    struct {
+			Host     string `mapstructure:"host"`
+			Port     int    `mapstructure:"port"`
+			Username string `mapstructure:"username"`
+			Password string `mapstructure:"password"`
+		} `mapstructure:"smtp"`
+	} `mapstructure:"mail"`
+
+	AccountTokens struct {
+		// Links in verification and password reset emails point at
+		// LinkBaseURL with the token in the query string. Tokens are signed
+		// with app.secret.
+		LinkBaseURL          string `mapstructure:"link_base_url"`
+		VerifyEmailHours     int    `mapstructure:"verify_email_hours"`
+		PasswordResetMinutes int    `mapstructure:"password_reset_minutes"`
+	} `mapstructure:"account_tokens"`
+
+	LoginLockout struct {
+		// An account is locked for BaseMinutes after Threshold consecutive
+		// failed logins, and twice as long after each further failure, up to
+		// MaxMinutes.
+		Threshold   int `mapstructure:"threshold"`
+		BaseMinutes int `mapstructure:"base_minutes"`
+		MaxMinutes  int `mapstructure:"max_minutes"`
+	} `mapstructure:"login_lockout"`
+
+	TwoFactor struct {
+		// Issuer is the account label authenticator apps show. TOTP secrets
+		// are encrypted at rest with EncryptionKey, or with jwt.secret when it
+		// is empty.
+		Issuer           string `mapstructure:"issuer"`
+		EncryptionKey    string `mapstructure:"encryption_key"`
+		ChallengeMinutes int    `mapstructure:"challenge_minutes"`
+	} `mapstructure:"two_factor"`
+
+	APIKeys struct {
+		// Signing secrets of keys that require signed requests are encrypted
+		// at rest with EncryptionKey, or with jwt.secret when it is empty.
+		// A signed request is rejected once its timestamp is more than
+		// SignatureToleranceSeconds away from the server clock.
+		EncryptionKey             string `mapstructure:"encryption_key"`
+		DefaultExpiryDays         int    `mapstructure:"default_expiry_days"`
+		MaxExpiryDays             int    `mapstructure:"max_expiry_days"`
+		MaxPerUser                int    `mapstructure:"max_per_user"`
+		SignatureToleranceSeconds int    `mapstructure:"signature_tolerance_seconds"`
+	} `mapstructure:"api_keys"`
 }
 
 var AppConfig Config
@@ -84,6 +222,43 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("database.sslmode", "disable")
 	v.SetDefault("server.admin_port", "8080")
 	v.SetDefault("jwt.expire_hours", 24)
+	v.SetDefault("jwt.access_token_minutes", 15)
+	v.SetDefault("exchange.provider", "coingecko")
+	v.SetDefault("exchange.timeout_seconds", 10)
+	v.SetDefault("payment_gateway.webhook_tolerance_seconds", 300)
+	v.SetDefault("payment_gateway.simulator.settlement_delay_seconds", 2)
+	v.SetDefault("payment_gateway.simulator.async_settlement", true)
+	v.SetDefault("payment_gateway.simulator.timeout_seconds", 5)
+	v.SetDefault("payment_gateway.simulator.magic_amounts", true)
+	v.SetDefault("withdrawals.limit_currency", "USD")
+	v.SetDefault("withdrawals.second_approval_threshold", 10000)
+	v.SetDefault("withdrawals.deposit_hold_hours", 24)
+	v.SetDefault("withdrawals.password_change_hold_hours", 24)
+	v.SetDefault("transfers.limit_currency", "USD")
+	v.SetDefault("transfers.max_amount", 5000)
+	v.SetDefault("transfers.daily_limit", 10000)
+	v.SetDefault("trader_payouts.threshold", 100)
+	v.SetDefault("trader_payouts.schedule", "@daily")
+	v.SetDefault("mail.driver", "log")
+	v.SetDefault("mail.from", "TradeVerse <no-reply@tradeverse.local>")
+	v.SetDefault("mail.file_dir", "tmp/mail")
+	v.SetDefault("mail.smtp.port", 587)
+	v.SetDefault("account_tokens.verify_email_hours", 48)
+	v.SetDefault("account_tokens.password_reset_minutes", 30)
+	v.SetDefault("rate_limit.backend", "memory")
+	v.SetDefault("rate_limit.groups.auth.requests_per_minute", 10)
+	v.SetDefault("rate_limit.groups.auth.burst", 5)
+	v.SetDefault("rate_limit.groups.api.requests_per_minute", 300)
+	v.SetDefault("rate_limit.groups.api.burst", 100)
+	v.SetDefault("login_lockout.threshold", 5)
+	v.SetDefault("login_lockout.base_minutes", 1)
+	v.SetDefault("login_lockout.max_minutes", 1440)
+	v.SetDefault("two_factor.issuer", "TradeVerse")
+	v.SetDefault("two_factor.challenge_minutes", 5)
+	v.SetDefault("api_keys.default_expiry_days", 90)
+	v.SetDefault("api_keys.max_expiry_days", 365)
+	v.SetDefault("api_keys.max_per_user", 10)
+	v.SetDefault("api_keys.signature_tolerance_seconds", 300)
 }
 
 func validateConfig(cfg *Config) error {
@@ -32,8 +32,11 @@ func InitializeApp(ctx context.Context) (*App, error) {
 	repos := InitRepositories(db)
 	services := InitServices(repos, db, cfg)
 	r := InitRouter(services, cfg, db)
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, err
+	}
 	SetupTemplatesAndStatic(r)
-	InitCron(services, db)
+	InitCron(services, cfg, db)
 
 	return &App{
 		engine: r,
@@ -1,18 +1,41 @@
 package bootstrap
 
 import (
+	"context"
 	"log"
+	"time"
 
+	"github.com/fathimasithara01/tradeverse/config"
 	"github.com/fathimasithara01/tradeverse/internal/admin/cron"
+	"github.com/fathimasithara01/tradeverse/pkg/stream"
 	"gorm.io/gorm"
 )
 
-func InitCron(s *Services, db *gorm.DB) {
+const streamRelayInterval = 5 * time.Second
+
+func InitCron(s *Services, cfg *config.Config, db *gorm.DB) {
 	cron.StartCronJobs(
 		s.Subscription,
 		s.CustomerSubscription,
 		s.LiveSignal,
+		s.CopyTrade,
+		s.Exchange,
+		s.Candle,
+		s.Performance,
+		s.Reconciliation,
+		s.FX,
+		s.AdminWallet,
+		s.TraderPayout,
+		cfg.TraderPayouts.Schedule,
+		s.Sessions,
+		s.TwoFactor,
+		s.AccountTokens,
+		s.Stream,
 		db,
 	)
 	log.Println("[Bootstrap] Cron jobs initialized")
+
+	if err := stream.NewRelay(db, s.Stream).Start(context.Background(), streamRelayInterval); err != nil {
+		log.Printf("[Bootstrap] Stream relay not started: %v", err)
+	}
 }
@@ -18,6 +18,10 @@ type Controllers struct {
 	Transaction      *controllers.TransactionController
 	Commission       *controllers.CommissionController
 	WebConfiguration *controllers.WebConfigurationController
+	Stream           *controllers.StreamController
+	Reconciliation   *controllers.ReconciliationController
+	FX               *controllers.FXController
+	TraderPayout     *controllers.TraderPayoutController
 }
 
 func InitControllers(svc *Services) *Controllers {
@@ -34,5 +38,9 @@ func InitControllers(svc *Services) *Controllers {
 		Transaction:      controllers.NewTransactionController(svc.Transaction),
 		Commission:       controllers.NewCommissionController(svc.Commission),
 		WebConfiguration: controllers.NewWebConfigurationController(svc.WebConfiguration),
+		Stream:           controllers.NewStreamController(svc.Stream),
+		Reconciliation:   controllers.NewReconciliationController(svc.Reconciliation),
+		FX:               controllers.NewFXController(svc.FX),
+		TraderPayout:     controllers.NewTraderPayoutController(svc.TraderPayout),
 	}
 }
@@ -20,6 +20,12 @@ type Repositories struct {
 	Transaction      repository.ITransactionRepository
 	Commission       repository.ICommissionRepository
 	WebConfig        repository.IWebConfigurationRepository
+	CopyTrade        repository.ICopyTradeRepository
+	Candle           repository.ICandleRepository
+	Performance      repository.IPerformanceRepository
+	Reconciliation   repository.IReconciliationRepository
+	FXRate           repository.IFXRateRepository
+	TraderPayout     repository.ITraderPayoutRepository
 
 	CustomerSubscription *customerRepo.CustomerSubscriptionRepository
 }
@@ -38,6 +44,12 @@ func InitRepositories(db *gorm.DB) *Repositories {
 		Transaction:          repository.NewTransactionRepository(db),
 		Commission:           repository.NewCommissionRepository(db),
 		WebConfig:            repository.NewWebConfigurationRepository(db),
+		CopyTrade:            repository.NewCopyTradeRepository(db),
+		Candle:               repository.NewCandleRepository(db),
+		Performance:          repository.NewPerformanceRepository(db),
+		Reconciliation:       repository.NewReconciliationRepository(db),
+		FXRate:               repository.NewFXRateRepository(db),
+		TraderPayout:         repository.NewTraderPayoutRepository(db),
 		CustomerSubscription: customerRepo.NewCustomerSubscriptionRepository(db), // ← initialize
 
 	}
@@ -29,6 +29,13 @@ func InitRouter(s *Services, cfg *config.Config, db *gorm.DB) *gin.Engine {
 		ctrls.Signal,
 		ctrls.Commission,
 		ctrls.WebConfiguration,
+		ctrls.Stream,
+		ctrls.Reconciliation,
+		ctrls.FX,
+		ctrls.TraderPayout,
+		s.Sessions,
+		s.TwoFactor,
+		s.RateLimiter,
 	)
 
 	return r
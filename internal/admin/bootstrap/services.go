@@ -1,9 +1,24 @@
 package bootstrap
 
 import (
+	"log"
+	"strings"
+	"time"
+
 	"github.com/fathimasithara01/tradeverse/config"
 	"github.com/fathimasithara01/tradeverse/internal/admin/service"
 	customerService "github.com/fathimasithara01/tradeverse/internal/customer/service"
+	"github.com/fathimasithara01/tradeverse/pkg/accounttoken"
+	"github.com/fathimasithara01/tradeverse/pkg/exchange"
+	"github.com/fathimasithara01/tradeverse/pkg/fx"
+	"github.com/fathimasithara01/tradeverse/pkg/lockout"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	paymentgateway "github.com/fathimasithara01/tradeverse/pkg/payment_gateway.go"
+	"github.com/fathimasithara01/tradeverse/pkg/ratelimit"
+	"github.com/fathimasithara01/tradeverse/pkg/session"
+	"github.com/fathimasithara01/tradeverse/pkg/stream"
+	"github.com/fathimasithara01/tradeverse/pkg/twofactor"
+	"github.com/fathimasithara01/tradeverse/pkg/withdrawal"
 
 	"gorm.io/gorm"
 )
@@ -22,11 +37,41 @@ type Services struct {
 	MarketData           service.IMarketDataService
 	Commission           service.ICommissionService
 	WebConfiguration     service.IWebConfigurationService
+	CopyTrade            service.ICopyTradeService
+	Exchange             exchange.ExchangeAdapter
+	Candle               service.ICandleService
+	Performance          service.IPerformanceService
+	Reconciliation       service.IReconciliationService
+	FX                   service.IFXService
+	TraderPayout         service.ITraderPayoutService
+	Stream               *stream.Hub
+	Sessions             *session.Service
+	TwoFactor            *twofactor.Service
+	AccountTokens        *accounttoken.Service
+	RateLimiter          *ratelimit.Limiter
 	CustomerSubscription *customerService.CustomerSubscriptionService
 }
 
 func InitServices(repos *Repositories, db *gorm.DB, cfg *config.Config) *Services {
-	adminWalletService := service.NewAdminWalletService(repos.AdminWallet, db)
+	exchangeAdapter, err := exchange.NewAdapter(exchange.Config{
+		Provider: cfg.Exchange.Provider,
+		BaseURL:  cfg.Exchange.BaseURL,
+		Timeout:  time.Duration(cfg.Exchange.TimeoutSeconds) * time.Second,
+	})
+	if err != nil {
+		log.Printf("[Bootstrap] %v, falling back to the mock exchange", err)
+		exchangeAdapter = exchange.NewLocalMockAdapter()
+	}
+
+	var fxSource fx.Source
+	if cfg.FX.FeedURL != "" {
+		fxSource = fx.NewHTTPSource(cfg.FX.FeedURL)
+	}
+
+	hub := stream.NewHub()
+	paymentClient := paymentgateway.NewSimulatedPaymentClient(paymentgateway.ConfigFromAppConfig(cfg))
+	withdrawals := withdrawal.NewPipeline(db, withdrawal.PolicyFromConfig(cfg), paymentClient)
+	adminWalletService := service.NewAdminWalletService(repos.AdminWallet, db, withdrawals)
 
 	customerSubService := customerService.NewCustomerSubscriptionService(
 		repos.CustomerSubscription,
@@ -36,8 +81,24 @@ func InitServices(repos *Repositories, db *gorm.DB, cfg *config.Config) *Service
 		db,
 	)
 
+	traderPayoutService := service.NewTraderPayoutService(
+		repos.TraderPayout,
+		db,
+		withdrawals,
+		money.FromFloat(cfg.TraderPayouts.Threshold),
+		strings.ToUpper(cfg.Withdrawals.LimitCurrency),
+	)
+
+	rateLimiter, err := ratelimit.LimiterFromAppConfig(cfg)
+	if err != nil {
+		log.Printf("[Bootstrap] %v, falling back to in-memory rate limits", err)
+	}
+
+	sessions := session.NewService(db, session.ConfigFromAppConfig(cfg))
+	twoFactor := twofactor.NewService(db, twofactor.ConfigFromAppConfig(cfg))
+
 	return &Services{
-		User:                 service.NewUserService(repos.User, repos.Role, cfg.JWT.Secret),
+		User:                 service.NewUserService(repos.User, repos.Role, sessions, twoFactor, lockout.PolicyFromAppConfig(cfg)),
 		Role:                 service.NewRoleService(repos.Role, repos.Permission, repos.User),
 		Dashboard:            service.NewDashboardService(repos.Dashboard),
 		Permission:           service.NewPermissionService(repos.Permission),
@@ -45,11 +106,23 @@ func InitServices(repos *Repositories, db *gorm.DB, cfg *config.Config) *Service
 		SubscriptionPlan:     service.NewSubscriptionPlanService(repos.SubscriptionPlan),
 		AdminWallet:          adminWalletService,
 		Subscription:         service.NewSubscriptionService(repos.Subscription, repos.SubscriptionPlan, repos.User, adminWalletService, db),
-		LiveSignal:           service.NewLiveSignalService(repos.Signal),
+		LiveSignal:           service.NewLiveSignalService(repos.Signal, hub),
 		Transaction:          service.NewTransactionService(repos.Transaction),
-		MarketData:           service.NewMarketDataService(),
+		MarketData:           service.NewMarketDataService(exchangeAdapter),
 		Commission:           service.NewCommissionService(repos.Commission, db),
 		WebConfiguration:     service.NewWebConfigurationService(repos.WebConfig),
+		CopyTrade:            service.NewCopyTradeService(repos.CopyTrade, db),
+		Exchange:             exchangeAdapter,
+		Candle:               service.NewCandleService(repos.Candle),
+		Performance:          service.NewPerformanceService(repos.Performance),
+		Reconciliation:       service.NewReconciliationService(repos.Reconciliation),
+		FX:                   service.NewFXService(repos.FXRate, fxSource),
+		TraderPayout:         traderPayoutService,
+		Stream:               hub,
+		Sessions:             sessions,
+		TwoFactor:            twoFactor,
+		AccountTokens:        accounttoken.NewService(db, accounttoken.SecretFromAppConfig(cfg)),
+		RateLimiter:          rateLimiter,
 		CustomerSubscription: customerSubService,
 	}
 }
@@ -1,12 +1,17 @@
 package controllers
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/fathimasithara01/tradeverse/config"
+	"github.com/fathimasithara01/tradeverse/internal/admin/middleware"
 	"github.com/fathimasithara01/tradeverse/internal/admin/service"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/session"
+	"github.com/fathimasithara01/tradeverse/pkg/twofactor"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -35,17 +40,197 @@ func (ctrl *AuthController) ShowTraderRegisterPage(c *gin.Context) {
 func (ctrl *AuthController) LoginUser(c *gin.Context) {
 	email, password := c.PostForm("email"), c.PostForm("password")
 
-	token, user, err := ctrl.UserSvc.Login(email, password)
+	result, err := ctrl.UserSvc.Login(email, password, session.MetaFrom(c))
 	if err != nil {
 		c.Redirect(http.StatusFound, "/login?error="+err.Error())
 		return
 	}
 
-	log.Printf("[LOGIN SUCCESS] User '%s' logged in successfully. Role: %s\n", user.Email, user.Role)
-	c.SetCookie("admin_token", token, 86400, "/", config.AppConfig.Cookie.Domain, true, true)
+	if result.Challenge != nil {
+		maxAge := int(time.Until(result.Challenge.ExpiresAt) / time.Second)
+		c.SetCookie(challengeCookie, result.Challenge.Token, maxAge, "/admin/login", config.AppConfig.Cookie.Domain, true, true)
+		c.Redirect(http.StatusFound, "/admin/login/2fa")
+		return
+	}
+
+	log.Printf("[LOGIN SUCCESS] User '%s' logged in successfully. Role: %s\n", result.User.Email, result.User.Role)
+	middleware.SetSessionCookies(c, &config.AppConfig, result.Tokens)
 	c.Redirect(http.StatusFound, "/admin/dashboard")
 }
 
+// challengeCookie holds the 2FA challenge between the two login pages.
+const challengeCookie = "admin_2fa_challenge"
+
+// ShowTwoFactorPage asks for the code of the login in progress. Admins who
+// have not set up 2FA yet, and must, are given a new authenticator secret to
+// scan first.
+func (ctrl *AuthController) ShowTwoFactorPage(c *gin.Context) {
+	token, err := c.Cookie(challengeCookie)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/admin/login")
+		return
+	}
+
+	data := gin.H{"error": c.Query("error")}
+	enrolment, err := ctrl.UserSvc.BeginLoginEnrolment(token)
+	switch {
+	case err == nil:
+		data["enrolment"] = enrolment
+	case errors.Is(err, twofactor.ErrAlreadyEnabled):
+	default:
+		c.SetCookie(challengeCookie, "", -1, "/admin/login", config.AppConfig.Cookie.Domain, true, true)
+		c.Redirect(http.StatusFound, "/admin/login?error="+err.Error())
+		return
+	}
+	c.HTML(http.StatusOK, "login_2fa.html", data)
+}
+
+// VerifyTwoFactorLogin completes the login with the submitted code. When the
+// code also confirmed a new authenticator, the recovery codes are shown once
+// before continuing to the dashboard.
+func (ctrl *AuthController) VerifyTwoFactorLogin(c *gin.Context) {
+	token, err := c.Cookie(challengeCookie)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/admin/login")
+		return
+	}
+
+	result, err := ctrl.UserSvc.CompleteTwoFactorLogin(token, c.PostForm("code"), session.MetaFrom(c))
+	if err != nil {
+		if errors.Is(err, twofactor.ErrInvalidCode) || errors.Is(err, twofactor.ErrCodeRequired) {
+			c.HTML(http.StatusUnauthorized, "login_2fa.html", gin.H{"error": err.Error()})
+			return
+		}
+		c.SetCookie(challengeCookie, "", -1, "/admin/login", config.AppConfig.Cookie.Domain, true, true)
+		c.Redirect(http.StatusFound, "/admin/login?error="+err.Error())
+		return
+	}
+
+	c.SetCookie(challengeCookie, "", -1, "/admin/login", config.AppConfig.Cookie.Domain, true, true)
+	middleware.SetSessionCookies(c, &config.AppConfig, result.Tokens)
+	log.Printf("[LOGIN SUCCESS] User '%s' logged in with two-factor authentication. Role: %s\n", result.User.Email, result.User.Role)
+	if len(result.RecoveryCodes) > 0 {
+		c.HTML(http.StatusOK, "login_2fa.html", gin.H{"recoveryCodes": result.RecoveryCodes})
+		return
+	}
+	c.Redirect(http.StatusFound, "/admin/dashboard")
+}
+
+// Refresh rotates the refresh token, taken from the JSON body or the panel's
+// refresh cookie, and issues a new access token.
+func (ctrl *AuthController) Refresh(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	_ = c.ShouldBindJSON(&req)
+	if req.RefreshToken == "" {
+		req.RefreshToken, _ = c.Cookie(middleware.RefreshCookie)
+	}
+
+	tokens, err := ctrl.UserSvc.RefreshSession(req.RefreshToken, session.MetaFrom(c))
+	if err != nil {
+		middleware.ClearSessionCookies(c, &config.AppConfig)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to refresh session", "details": err.Error()})
+		return
+	}
+
+	middleware.SetSessionCookies(c, &config.AppConfig, tokens)
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout revokes the current session, or every session of the admin with
+// ?all=true, and clears the panel's cookies.
+func (ctrl *AuthController) Logout(c *gin.Context) {
+	userID := c.GetUint("userID")
+	if err := ctrl.UserSvc.Logout(userID, c.GetString("sessionID"), c.Query("all") == "true"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out", "details": err.Error()})
+		return
+	}
+
+	middleware.ClearSessionCookies(c, &config.AppConfig)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+func twoFactorErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, twofactor.ErrInvalidCode), errors.Is(err, twofactor.ErrCodeRequired):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrTwoFactorMandatory):
+		return http.StatusForbidden
+	case errors.Is(err, twofactor.ErrAlreadyEnabled), errors.Is(err, twofactor.ErrNotEnrolled), errors.Is(err, twofactor.ErrNoPendingEnrolment):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (ctrl *AuthController) GetTwoFactorStatus(c *gin.Context) {
+	status, err := ctrl.UserSvc.TwoFactorStatus(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load two-factor status", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// EnrollTwoFactor starts setting up an authenticator and returns its secret
+// and provisioning URI for the QR code.
+func (ctrl *AuthController) EnrollTwoFactor(c *gin.Context) {
+	enrolment, err := ctrl.UserSvc.BeginTwoFactorEnrolment(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": "Failed to start two-factor enrolment", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, enrolment)
+}
+
+// ConfirmTwoFactor enables 2FA with a code from the new authenticator and
+// returns the recovery codes.
+func (ctrl *AuthController) ConfirmTwoFactor(c *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload", "details": err.Error()})
+		return
+	}
+	codes, err := ctrl.UserSvc.ConfirmTwoFactor(c.GetUint("userID"), req.Code)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": "Failed to enable two-factor authentication", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication enabled", "recovery_codes": codes})
+}
+
+func (ctrl *AuthController) DisableTwoFactor(c *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload", "details": err.Error()})
+		return
+	}
+	if err := ctrl.UserSvc.DisableTwoFactor(c.GetUint("userID"), req.Code); err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": "Failed to disable two-factor authentication", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+func (ctrl *AuthController) RegenerateRecoveryCodes(c *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload", "details": err.Error()})
+		return
+	}
+	codes, err := ctrl.UserSvc.RegenerateRecoveryCodes(c.GetUint("userID"), req.Code)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": "Failed to regenerate recovery codes", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
 func (ctrl *AuthController) RegisterCustomer(c *gin.Context) {
 	var user models.User
 	var profile models.CustomerProfile
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fathimasithara01/tradeverse/internal/admin/repository"
+	"github.com/fathimasithara01/tradeverse/internal/admin/service"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+type FXController struct {
+	FXService service.IFXService
+}
+
+func NewFXController(fxService service.IFXService) *FXController {
+	return &FXController{FXService: fxService}
+}
+
+func (ctrl *FXController) ShowFXRatesPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "financial_fx_rates.html", gin.H{
+		"Title":        "Exchange Rates",
+		"ActiveTab":    "financials",
+		"ActiveSubTab": "fx-rates",
+	})
+}
+
+func (ctrl *FXController) ListRates(c *gin.Context) {
+	rates, err := ctrl.FXService.ListRates(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve exchange rates", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rates)
+}
+
+func (ctrl *FXController) SetRate(c *gin.Context) {
+	var input models.FXRateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload", "details": err.Error()})
+		return
+	}
+
+	adminID := c.MustGet("userID").(uint)
+	rate, err := ctrl.FXService.SetRate(c.Request.Context(), adminID, input)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidFXRate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exchange rate", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save exchange rate", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rate)
+}
+
+func (ctrl *FXController) DeleteRate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exchange rate ID"})
+		return
+	}
+
+	if err := ctrl.FXService.DeleteRate(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, repository.ErrFXRateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Exchange rate not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete exchange rate", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Exchange rate deleted"})
+}
+
+func (ctrl *FXController) RefreshRates(c *gin.Context) {
+	count, err := ctrl.FXService.Refresh(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, service.ErrNoFXRateSource) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No exchange rate feed is configured"})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to refresh exchange rates", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Exchange rates refreshed", "updated": count})
+}
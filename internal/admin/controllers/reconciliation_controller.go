@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fathimasithara01/tradeverse/internal/admin/repository"
+	"github.com/fathimasithara01/tradeverse/internal/admin/service"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+type ReconciliationController struct {
+	ReconciliationService service.IReconciliationService
+}
+
+func NewReconciliationController(reconciliationService service.IReconciliationService) *ReconciliationController {
+	return &ReconciliationController{ReconciliationService: reconciliationService}
+}
+
+func (ctrl *ReconciliationController) ShowReconciliationPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "financial_reconciliation.html", gin.H{
+		"Title":        "Wallet Reconciliation",
+		"ActiveTab":    "financials",
+		"ActiveSubTab": "reconciliation",
+	})
+}
+
+func (ctrl *ReconciliationController) ListDiscrepancies(c *gin.Context) {
+	var filter models.DiscrepancyFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filter parameters", "details": err.Error()})
+		return
+	}
+	if filter.Page == 0 {
+		filter.Page = 1
+	}
+	if filter.Limit == 0 {
+		filter.Limit = 10
+	}
+
+	discrepancies, total, err := ctrl.ReconciliationService.ListDiscrepancies(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve discrepancies", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"discrepancies": discrepancies,
+		"total":         total,
+		"page":          filter.Page,
+		"limit":         filter.Limit,
+	})
+}
+
+func (ctrl *ReconciliationController) GetDiscrepancy(c *gin.Context) {
+	id, ok := discrepancyID(c)
+	if !ok {
+		return
+	}
+
+	discrepancy, err := ctrl.ReconciliationService.GetDiscrepancy(c.Request.Context(), id)
+	if err != nil {
+		respondDiscrepancyError(c, "Failed to retrieve discrepancy", err)
+		return
+	}
+	c.JSON(http.StatusOK, discrepancy)
+}
+
+func (ctrl *ReconciliationController) AnnotateDiscrepancy(c *gin.Context) {
+	id, ok := discrepancyID(c)
+	if !ok {
+		return
+	}
+	var input models.DiscrepancyNoteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload", "details": err.Error()})
+		return
+	}
+
+	adminID := c.MustGet("userID").(uint)
+	discrepancy, err := ctrl.ReconciliationService.Annotate(c.Request.Context(), id, adminID, input.Note)
+	if err != nil {
+		respondDiscrepancyError(c, "Failed to add note", err)
+		return
+	}
+	c.JSON(http.StatusOK, discrepancy)
+}
+
+func (ctrl *ReconciliationController) ResolveDiscrepancy(c *gin.Context) {
+	id, ok := discrepancyID(c)
+	if !ok {
+		return
+	}
+	var input models.DiscrepancyNoteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A resolution note is required", "details": err.Error()})
+		return
+	}
+
+	adminID := c.MustGet("userID").(uint)
+	discrepancy, err := ctrl.ReconciliationService.Resolve(c.Request.Context(), id, adminID, input.Note)
+	if err != nil {
+		respondDiscrepancyError(c, "Failed to resolve discrepancy", err)
+		return
+	}
+	c.JSON(http.StatusOK, discrepancy)
+}
+
+func (ctrl *ReconciliationController) TriggerRun(c *gin.Context) {
+	adminID := c.MustGet("userID").(uint)
+	run, err := ctrl.ReconciliationService.Run(c.Request.Context(), &adminID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Reconciliation run failed", "details": err.Error(), "run": run})
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+func (ctrl *ReconciliationController) ListRuns(c *gin.Context) {
+	runs, err := ctrl.ReconciliationService.ListRuns(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reconciliation runs", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, runs)
+}
+
+func discrepancyID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid discrepancy ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondDiscrepancyError(c *gin.Context, message string, err error) {
+	if errors.Is(err, repository.ErrDiscrepancyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Discrepancy not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+}
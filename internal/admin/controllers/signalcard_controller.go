@@ -2,8 +2,10 @@
 
 	import (
 		"encoding/json"
+		"errors"
 		"log"
 		"net/http"
+		"strconv"
 		"strings"
 		"time"
 
@@ -41,6 +43,22 @@
 		c.JSON(http.StatusOK, signals)
 	}
 
+	func (ctrl *SignalController) GetSignalHistory(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid signal ID"})
+			return
+		}
+
+		history, err := ctrl.liveSignalService.GetSignalHistory(c, uint(id))
+		if err != nil {
+			log.Printf("ERROR: Failed to retrieve history for signal %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve signal history"})
+			return
+		}
+		c.JSON(http.StatusOK, history)
+	}
+
 	func GetSignalCardsPage(c *gin.Context) {
 		c.HTML(http.StatusOK, "signal_cards.html", gin.H{
 			"Title":        "Signal Cards",
@@ -61,6 +79,7 @@
 		var req struct {
 			TraderName    string  `json:"traderName"`
 			Symbol        string  `json:"symbol"`
+			Direction     string  `json:"direction"`
 			StopLoss      float64 `json:"stopLoss"`
 			EntryPrice    float64 `json:"entryPrice"`
 			TargetPrice   float64 `json:"targetPrice"`
@@ -71,6 +90,11 @@
 			StartDate     string  `json:"startDate"`
 			EndDate       string  `json:"endDate"`
 			TotalDuration string  `json:"totalDuration"`
+
+			Targets              []models.SignalTarget `json:"targets"`
+			TrailingType         string                `json:"trailingType"`
+			TrailingValue        float64               `json:"trailingValue"`
+			BreakevenAfterTarget int                   `json:"breakevenAfterTarget"`
 		}
 
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -127,19 +151,28 @@
 			CurrentPrice:   req.CurrentPrice, 
 			Risk:           req.Risk,
 			Strategy:       req.Strategy,
-			Status:         req.Status,
+			Direction:      models.SignalDirection(req.Direction),
+			Targets:        req.Targets,
 			TotalDuration:  req.TotalDuration,
 			TradeStartDate: startDate,
 			TradeEndDate:   endDate,
 			PublishedAt:    time.Now(),
 			CreatedBy:      createdByRole,
 			CreatorID:      creatorID,
+
+			TrailingType:         models.TrailingStopType(req.TrailingType),
+			TrailingValue:        req.TrailingValue,
+			BreakevenAfterTarget: req.BreakevenAfterTarget,
 		}
 
 		log.Printf("Parsed Signal Data before service call: %+v", signal)
 
 		createdSignal, err := ctrl.liveSignalService.CreateSignal(c, &signal)
 		if err != nil {
+			if errors.Is(err, service.ErrInvalidSignal) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			log.Printf("ERROR: Failed to create signal in service: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create signal"})
 			return
@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"github.com/fathimasithara01/tradeverse/pkg/stream"
+	"github.com/gin-gonic/gin"
+)
+
+// StreamController pushes live ticks and every signal event to the admin
+// dashboard, replacing the market-data and signal polling.
+type StreamController struct {
+	Hub *stream.Hub
+}
+
+func NewStreamController(hub *stream.Hub) *StreamController {
+	return &StreamController{Hub: hub}
+}
+
+func (ctrl *StreamController) StreamSSE(c *gin.Context) {
+	stream.ServeSSE(c, ctrl.Hub, nil)
+}
+
+func (ctrl *StreamController) StreamWebSocket(c *gin.Context) {
+	stream.ServeWebSocket(c, ctrl.Hub, nil)
+}
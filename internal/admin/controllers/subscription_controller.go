@@ -9,6 +9,8 @@ import (
 
 	"github.com/fathimasithara01/tradeverse/internal/admin/service"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"github.com/fathimasithara01/tradeverse/pkg/refund"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -17,7 +19,7 @@ type SubscriptionPlanResponseDTO struct {
 	ID              uint    `json:"ID"`
 	Name            string  `json:"name"`
 	Description     string  `json:"description"`
-	Price           float64 `json:"price"`
+	Price           money.Amount `json:"price"`
 	Duration        int     `json:"duration"`
 	Interval        string  `json:"interval"`
 	MaxFollowers    int     `json:"max_followers"`
@@ -27,12 +29,14 @@ type SubscriptionPlanResponseDTO struct {
 	AnalyticsAccess string  `json:"analytics_access"`
 	IsTraderPlan    bool    `json:"is_trader_plan"`
 	IsActive        bool    `json:"is_active"`
+	RefundPolicy     models.RefundPolicy `json:"refund_policy"`
+	RefundWindowDays uint                `json:"refund_window_days"`
 }
 
 type CreateUpdateSubscriptionPlanRequest struct {
 	Name            string  `json:"name" binding:"required"`
 	Description     string  `json:"description"`
-	Price           float64 `json:"price" binding:"required,gt=0"`
+	Price           money.Amount `json:"price" binding:"required,gt=0"`
 	Duration        int     `json:"duration" binding:"required,gt=0"`
 	Interval        string  `json:"interval"`
 	MaxFollowers    int     `json:"max_followers"`
@@ -41,6 +45,8 @@ type CreateUpdateSubscriptionPlanRequest struct {
 	AnalyticsAccess string  `json:"analytics_access"`
 	IsTraderPlan    bool    `json:"is_trader_plan"`
 	IsActive        bool    `json:"is_active"`
+	RefundPolicy     models.RefundPolicy `json:"refund_policy" binding:"omitempty,oneof=NONE FULL_WITHIN_WINDOW PRORATED"`
+	RefundWindowDays uint                `json:"refund_window_days" binding:"required_if=RefundPolicy FULL_WITHIN_WINDOW"`
 }
 
 type SubscriptionController struct {
@@ -138,6 +144,8 @@ func (ctrl *SubscriptionController) GetSubscriptionPlanByID(c *gin.Context) {
 		// AnalyticsAccess: plan.AnalyticsAccess,
 		IsTraderPlan: plan.IsTraderPlan,
 		IsActive:     plan.IsActive,
+		RefundPolicy:     plan.RefundPolicy,
+		RefundWindowDays: plan.RefundWindowDays,
 	}
 
 	c.JSON(http.StatusOK, responsePlan)
@@ -171,6 +179,8 @@ func (ctrl *SubscriptionController) GetSubscriptionPlans(c *gin.Context) {
 			AnalyticsAccess: plan.AnalyticsAccess,
 			IsTraderPlan:    plan.IsTraderPlan,
 			IsActive:        plan.IsActive,
+			RefundPolicy:     plan.RefundPolicy,
+			RefundWindowDays: plan.RefundWindowDays,
 		})
 	}
 	c.JSON(http.StatusOK, responsePlans)
@@ -188,11 +198,55 @@ func (ctrl *SubscriptionController) GetSubscriptions(c *gin.Context) {
 	c.JSON(http.StatusOK, subs)
 }
 
+func (ctrl *SubscriptionController) CancelSubscription(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+
+	cancellation, err := ctrl.SubscriptionService.CancelSubscription(uint(id))
+	if err != nil {
+		log.Printf("Error cancelling subscription %d: %v", id, err)
+		c.JSON(refundErrorStatus(err), gin.H{"error": "Failed to cancel subscription", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Subscription cancelled", "cancellation": cancellation})
+}
+
+func (ctrl *SubscriptionController) CancelTraderSubscription(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+
+	cancellation, err := ctrl.SubscriptionService.CancelTraderSubscription(uint(id))
+	if err != nil {
+		log.Printf("Error cancelling trader subscription %d: %v", id, err)
+		c.JSON(refundErrorStatus(err), gin.H{"error": "Failed to cancel trader subscription", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Trader subscription cancelled", "cancellation": cancellation})
+}
+
+// refundErrorStatus maps subscription cancellation failures to HTTP statuses.
+func refundErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, refund.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, refund.ErrNotActive), errors.Is(err, refund.ErrUnfunded):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func (ctrl *SubscriptionController) CreateCustomerSubscription(c *gin.Context) {
 	var req struct {
 		UserID          uint    `json:"user_id" binding:"required"`
 		PlanID          uint    `json:"plan_id" binding:"required"`
-		AmountPaid      float64 `json:"amount_paid" binding:"required"`
+		AmountPaid      money.Amount `json:"amount_paid" binding:"required"`
 		TransactionID   string  `json:"transaction_id" binding:"required"`
 		IsTraderUpgrade bool    `json:"is_trader_upgrade"`
 	}
@@ -215,7 +269,7 @@ func (ctrl *SubscriptionController) CreateCustomerSubscription(c *gin.Context) {
 		}
 	}
 
-	log.Printf("Payment received for plan %d from user %d: $%.2f. Transaction ID: %s. (To be deposited into admin wallet)",
+	log.Printf("Payment received for plan %d from user %d: $%s. Transaction ID: %s. (To be deposited into admin wallet)",
 		req.PlanID, req.UserID, req.AmountPaid, req.TransactionID)
 
 	c.JSON(http.StatusCreated, subscription)
@@ -240,6 +294,8 @@ func (ctrl *SubscriptionController) CreateSubscriptionPlan(c *gin.Context) {
 		AnalyticsAccess: req.AnalyticsAccess,
 		IsTraderPlan:    req.IsTraderPlan,
 		IsActive:        req.IsActive,
+		RefundPolicy:     req.RefundPolicy.OrNone(),
+		RefundWindowDays: req.RefundWindowDays,
 	}
 
 	if err := ctrl.SubscriptionPlanService.CreateSubscriptionPlan(&newPlan); err != nil {
@@ -262,6 +318,8 @@ func (ctrl *SubscriptionController) CreateSubscriptionPlan(c *gin.Context) {
 		AnalyticsAccess: newPlan.AnalyticsAccess,
 		IsTraderPlan:    newPlan.IsTraderPlan,
 		IsActive:        newPlan.IsActive,
+		RefundPolicy:     newPlan.RefundPolicy,
+		RefundWindowDays: newPlan.RefundWindowDays,
 	})
 }
 
@@ -300,6 +358,8 @@ func (ctrl *SubscriptionController) UpdateSubscriptionPlan(c *gin.Context) {
 	existingPlan.AnalyticsAccess = req.AnalyticsAccess
 	existingPlan.IsTraderPlan = req.IsTraderPlan
 	existingPlan.IsActive = req.IsActive
+	existingPlan.RefundPolicy = req.RefundPolicy.OrNone()
+	existingPlan.RefundWindowDays = req.RefundWindowDays
 
 	if err := ctrl.SubscriptionPlanService.UpdateSubscriptionPlan(existingPlan); err != nil {
 		log.Printf("Error updating subscription plan: %v", err)
@@ -326,6 +386,8 @@ func (ctrl *SubscriptionController) UpdateSubscriptionPlan(c *gin.Context) {
 		AnalyticsAccess: existingPlan.AnalyticsAccess,
 		IsTraderPlan:    existingPlan.IsTraderPlan,
 		IsActive:        existingPlan.IsActive,
+		RefundPolicy:     existingPlan.RefundPolicy,
+		RefundWindowDays: existingPlan.RefundWindowDays,
 	}
 
 	c.JSON(http.StatusOK, responsePlan)
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/fathimasithara01/tradeverse/internal/admin/service"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+type TraderPayoutController struct {
+	TraderPayoutService service.ITraderPayoutService
+}
+
+func NewTraderPayoutController(traderPayoutService service.ITraderPayoutService) *TraderPayoutController {
+	return &TraderPayoutController{TraderPayoutService: traderPayoutService}
+}
+
+func (ctrl *TraderPayoutController) ListPayouts(c *gin.Context) {
+	var pagination models.PaginationParams
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pagination parameters", "details": err.Error()})
+		return
+	}
+
+	payouts, total, err := ctrl.TraderPayoutService.ListPayouts(c.Request.Context(), pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve trader payouts", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"payouts": payouts, "total": total, "page": pagination.Page, "limit": pagination.Limit})
+}
+
+// RunPayouts files due trader payouts now instead of waiting for the schedule.
+func (ctrl *TraderPayoutController) RunPayouts(c *gin.Context) {
+	filed, err := ctrl.TraderPayoutService.Run(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Trader payout run finished with errors", "details": err.Error(), "filed": filed})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Trader payout run finished", "filed": filed})
+}
@@ -144,7 +144,7 @@ func (ctrl *UserController) UpdateUser(c *gin.Context) {
 	userToUpdate.Phone = c.PostForm("Phone")
 
 	if newPassword != "" {
-		if err := userToUpdate.SetPassword(newPassword); err != nil {
+		if err := userToUpdate.ChangePassword(newPassword); err != nil {
 			c.HTML(http.StatusInternalServerError, "edit_user.html", gin.H{
 				"error": "Failed to process new password.",
 				"User":  userToUpdate,
@@ -173,6 +173,11 @@ func (ctrl *UserController) UpdateUser(c *gin.Context) {
 		})
 		return
 	}
+	if newPassword != "" {
+		if err := ctrl.UserSvc.RevokeSessions(userToUpdate.ID, models.RevokeReasonPasswordChange); err != nil {
+			log.Printf("Failed to revoke sessions of user %d after a password change: %v", userToUpdate.ID, err)
+		}
+	}
 	c.Redirect(http.StatusFound, "/admin/users/all")
 }
 
@@ -191,6 +196,48 @@ func (ctrl *UserController) DeleteUser(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
 
+func (ctrl *UserController) BlockUser(c *gin.Context) {
+	ctrl.setBlocked(c, true)
+}
+
+func (ctrl *UserController) UnblockUser(c *gin.Context) {
+	ctrl.setBlocked(c, false)
+}
+
+func (ctrl *UserController) setBlocked(c *gin.Context, blocked bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := ctrl.UserSvc.SetUserBlocked(uint(id), blocked); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user", "details": err.Error()})
+		return
+	}
+
+	message := "User unblocked"
+	if blocked {
+		message = "User blocked and signed out everywhere"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// UnlockUser lifts a lockout caused by failed logins.
+func (ctrl *UserController) UnlockUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := ctrl.UserSvc.UnlockUser(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock user", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked"})
+}
+
 func (ctrl *UserController) GetCustomers(c *gin.Context) {
 	customers, err := ctrl.UserSvc.GetUsersByRole(models.RoleCustomer)
 	if err != nil {
@@ -1,12 +1,16 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/fathimasithara01/tradeverse/internal/admin/service"
+	"github.com/fathimasithara01/tradeverse/pkg/fx"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/withdrawal"
 	"github.com/gin-gonic/gin"
 )
 
@@ -165,9 +169,10 @@ func (ctrl *AdminWalletController) AdminRequestWithdrawal(c *gin.Context) {
 		return
 	}
 
-	res, err := ctrl.AdminWalletService.AdminRequestWithdrawal(req)
+	adminID := c.MustGet("userID").(uint)
+	res, err := ctrl.AdminWalletService.AdminRequestWithdrawal(c.Request.Context(), adminID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process admin withdrawal request", "details": err.Error()})
+		c.JSON(withdrawalErrorStatus(err), gin.H{"error": "Failed to process admin withdrawal request", "details": err.Error()})
 		return
 	}
 	c.JSON(http.StatusCreated, res)
@@ -208,27 +213,67 @@ func (ctrl *AdminWalletController) AdminApproveOrRejectWithdrawal(c *gin.Context
 		return
 	}
 
-	var req struct {
-		Action string `json:"action" binding:"required,oneof=approve reject"`
-	}
+	var req models.WithdrawalReviewInput
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action specified", "details": err.Error()})
 		return
 	}
 
+	adminID := c.MustGet("userID").(uint)
+	var w *models.WithdrawalRequest
 	var serviceErr error
 	if req.Action == "approve" {
-		serviceErr = ctrl.AdminWalletService.ApproveWithdrawalRequest(uint(withdrawalID))
-	} else { 
-		serviceErr = ctrl.AdminWalletService.RejectWithdrawalRequest(uint(withdrawalID))
+		w, serviceErr = ctrl.AdminWalletService.ApproveWithdrawalRequest(c.Request.Context(), uint(withdrawalID), adminID, req.Note)
+	} else {
+		w, serviceErr = ctrl.AdminWalletService.RejectWithdrawalRequest(c.Request.Context(), uint(withdrawalID), adminID, req.Note)
 	}
 
 	if serviceErr != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to %s withdrawal request", req.Action), "details": serviceErr.Error()})
+		c.JSON(withdrawalErrorStatus(serviceErr), gin.H{"error": fmt.Sprintf("Failed to %s withdrawal request", req.Action), "details": serviceErr.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Withdrawal request %sed successfully", req.Action)})
+	message := fmt.Sprintf("Withdrawal request %sd successfully", req.Action)
+	if w.Status == models.TxStatusPending && req.Action == "approve" {
+		message = fmt.Sprintf("Approval recorded (%d of %d)", w.Approvals, w.ApprovalsRequired)
+		if w.Approvals >= w.ApprovalsRequired && w.HoldUntil != nil {
+			message += fmt.Sprintf("; payout held until %s", w.HoldUntil.Format(time.RFC3339))
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "withdrawal": w})
+}
+
+func (ctrl *AdminWalletController) GetWithdrawalHistory(c *gin.Context) {
+	withdrawalID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid withdrawal request ID format"})
+		return
+	}
+
+	w, err := ctrl.AdminWalletService.GetWithdrawalRequest(c.Request.Context(), uint(withdrawalID))
+	if err != nil {
+		c.JSON(withdrawalErrorStatus(err), gin.H{"error": "Failed to retrieve withdrawal request", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
+
+// withdrawalErrorStatus maps withdrawal pipeline errors to HTTP statuses.
+func withdrawalErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, withdrawal.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, withdrawal.ErrSelfApproval):
+		return http.StatusForbidden
+	case errors.Is(err, withdrawal.ErrNotPending), errors.Is(err, withdrawal.ErrAlreadyApproved):
+		return http.StatusConflict
+	case errors.Is(err, withdrawal.ErrInvalidAmount), errors.Is(err, withdrawal.ErrInsufficientFunds):
+		return http.StatusBadRequest
+	case errors.Is(err, withdrawal.ErrLimitExceeded), errors.Is(err, fx.ErrRateNotFound):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 func (ctrl *AdminWalletController) GetPendingWithdrawals(c *gin.Context) {
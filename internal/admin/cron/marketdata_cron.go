@@ -2,78 +2,62 @@ package cron
 
 import (
 	"context"
-	"encoding/json"
-	"io/ioutil"
+	"errors"
 	"log"
-	"net/http"
-	"strings"
+	"time"
 
 	cronn "github.com/robfig/cron/v3"
 
 	"github.com/fathimasithara01/tradeverse/internal/admin/service"
 	customerService "github.com/fathimasithara01/tradeverse/internal/customer/service"
+	"github.com/fathimasithara01/tradeverse/pkg/accounttoken"
+	"github.com/fathimasithara01/tradeverse/pkg/exchange"
+	"github.com/fathimasithara01/tradeverse/pkg/idempotency"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/session"
+	"github.com/fathimasithara01/tradeverse/pkg/stream"
+	"github.com/fathimasithara01/tradeverse/pkg/twofactor"
 	"gorm.io/gorm"
 )
 
-type CoinGeckoCoin struct {
-	ID                       string  `json:"id"`
-	Symbol                   string  `json:"symbol"`
-	Name                     string  `json:"name"`
-	Image                    string  `json:"image"`
-	CurrentPrice             float64 `json:"current_price"`
-	PriceChangePercentage24h float64 `json:"price_change_percentage_24h"`
-	TotalVolume              float64 `json:"total_volume"`
-	MarketCap                float64 `json:"market_cap"`
-}
-
-func FetchAndSaveMarketData(db *gorm.DB) {
-	apiURL := "https://api.coingecko.com/api/v3/coins/markets?vs_currency=usd&order=market_cap_desc&per_page=100&page=1&sparkline=false&price_change_percentage=24h"
-
-	resp, err := http.Get(apiURL)
-	if err != nil {
-		log.Printf("Error fetching market data from API: %v", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(resp.Body)
-		log.Printf("API returned non-OK status: %d, Response: %s", resp.StatusCode, string(body))
-		return
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
+func FetchAndSaveMarketData(ctx context.Context, adapter exchange.ExchangeAdapter, candleService service.ICandleService, hub *stream.Hub, db *gorm.DB) {
+	tickers, err := adapter.GetTickers(ctx)
 	if err != nil {
-		log.Printf("Error reading API response body: %v", err)
+		log.Printf("Error fetching market data from %s: %v", adapter.Name(), err)
 		return
 	}
 
-	var coins []CoinGeckoCoin
-	if err := json.Unmarshal(body, &coins); err != nil {
-		log.Printf("Error unmarshaling API response: %v", err)
-		return
-	}
+	fetchedAt := time.Now()
+	for _, ticker := range tickers {
+		if err := candleService.RecordTick(ctx, ticker.Symbol, ticker.Price, ticker.Volume24H, fetchedAt); err != nil {
+			log.Printf("Error recording candle tick for %s: %v", ticker.Symbol, err)
+		}
 
-	for _, coin := range coins {
 		marketData := models.MarketData{
-			Symbol:         strings.ToUpper(coin.Symbol),
-			Name:           coin.Name,
-			CurrentPrice:   coin.CurrentPrice,
-			PriceChange24H: coin.PriceChangePercentage24h,
-			LogoURL:        coin.Image,
-			Volume24H:      coin.TotalVolume,
-			MarketCap:      coin.MarketCap,
+			Symbol:         ticker.Symbol,
+			Name:           ticker.Name,
+			CurrentPrice:   ticker.Price,
+			PriceChange24H: ticker.Change24H,
+			LogoURL:        ticker.LogoURL,
+			Volume24H:      ticker.Volume24H,
+			MarketCap:      ticker.MarketCap,
 		}
-		log.Printf("Updated price for %s: %.2f", coin.Symbol, coin.CurrentPrice)
+		log.Printf("Updated price for %s: %.2f", ticker.Symbol, ticker.Price)
 
 		result := db.Where(models.MarketData{Symbol: marketData.Symbol}).Assign(marketData).FirstOrCreate(&marketData)
 		if result.Error != nil {
-			log.Printf("Error saving/updating market data for %s: %v", coin.Symbol, result.Error)
+			log.Printf("Error saving/updating market data for %s: %v", ticker.Symbol, result.Error)
 		} else if result.RowsAffected == 0 {
 		} else {
-			log.Printf("Saved/Updated market data for %s (Current Price: %.4f)", coin.Symbol, coin.CurrentPrice)
+			log.Printf("Saved/Updated market data for %s (Current Price: %.4f)", ticker.Symbol, ticker.Price)
 		}
+
+		hub.PublishTick(stream.MarketTick{
+			Symbol:    ticker.Symbol,
+			Price:     ticker.Price,
+			Change24H: ticker.Change24H,
+			Volume24H: ticker.Volume24H,
+		})
 	}
 	log.Println("Market data fetch complete.")
 
@@ -83,6 +67,19 @@ func StartCronJobs(
 	subscriptionService service.ISubscriptionService,
 	customerServiceForTraderSubs *customerService.CustomerSubscriptionService,
 	liveSignalService service.ILiveSignalService,
+	copyTradeService service.ICopyTradeService,
+	adapter exchange.ExchangeAdapter,
+	candleService service.ICandleService,
+	performanceService service.IPerformanceService,
+	reconciliationService service.IReconciliationService,
+	fxService service.IFXService,
+	adminWalletService service.IAdminWalletService,
+	traderPayoutService service.ITraderPayoutService,
+	payoutSchedule string,
+	sessions *session.Service,
+	twoFactor *twofactor.Service,
+	accountTokens *accounttoken.Service,
+	hub *stream.Hub,
 	db *gorm.DB,
 ) {
 	c := cronn.New()
@@ -101,7 +98,14 @@ func StartCronJobs(
 
 	c.AddFunc("@every 5m", func() {
 		log.Println("Starting market data fetch...")
-		FetchAndSaveMarketData(db)
+		FetchAndSaveMarketData(context.Background(), adapter, candleService, hub, db)
+	})
+
+	c.AddFunc("@daily", func() {
+		log.Println("Applying candle retention and downsampling...")
+		if err := candleService.ApplyRetention(context.Background()); err != nil {
+			log.Printf("Error applying candle retention: %v", err)
+		}
 	})
 
 	c.AddFunc("@every 1m", func() {
@@ -118,6 +122,87 @@ func StartCronJobs(
 		}
 	})
 
+	c.AddFunc("@every 15s", func() {
+		if err := copyTradeService.SyncMasterTrades(context.Background()); err != nil {
+			log.Printf("Error syncing master trades to copy followers: %v", err)
+		}
+	})
+
+	c.AddFunc("@every 15m", func() {
+		log.Println("Recalculating trader performance...")
+		if err := performanceService.RecalculateAll(context.Background()); err != nil {
+			log.Printf("Error recalculating trader performance: %v", err)
+		}
+	})
+
+	c.AddFunc("@every 6h", func() {
+		log.Println("Reconciling wallets, deposits, withdrawals and the ledger...")
+		if _, err := reconciliationService.Run(context.Background(), nil); err != nil {
+			log.Printf("Error running wallet reconciliation: %v", err)
+		}
+	})
+
+	c.AddFunc("@every 1h", func() {
+		_, err := fxService.Refresh(context.Background())
+		if err != nil && !errors.Is(err, service.ErrNoFXRateSource) {
+			log.Printf("Error refreshing exchange rates: %v", err)
+		}
+	})
+
+	c.AddFunc("@every 5m", func() {
+		released, err := adminWalletService.ReleaseDueWithdrawals(context.Background())
+		if err != nil {
+			log.Printf("Error releasing approved withdrawals: %v", err)
+		}
+		if released > 0 {
+			log.Printf("Released %d approved withdrawals whose hold has ended", released)
+		}
+	})
+
+	c.AddFunc(payoutSchedule, func() {
+		filed, err := traderPayoutService.Run(context.Background())
+		if err != nil {
+			log.Printf("Error running trader payouts: %v", err)
+		}
+		log.Printf("Filed %d trader payouts", filed)
+	})
+
+	c.AddFunc("@daily", func() {
+		purged, err := idempotency.NewGormStore(db).Purge(context.Background(), time.Now())
+		if err != nil {
+			log.Printf("Error purging expired idempotency keys: %v", err)
+			return
+		}
+		log.Printf("Purged %d expired idempotency keys", purged)
+	})
+
+	c.AddFunc("@hourly", func() {
+		pruned, err := sessions.PruneExpired(time.Now())
+		if err != nil {
+			log.Printf("Error pruning expired sessions: %v", err)
+			return
+		}
+		log.Printf("Pruned %d expired session records", pruned)
+	})
+
+	c.AddFunc("@hourly", func() {
+		pruned, err := twoFactor.PruneExpired(time.Now())
+		if err != nil {
+			log.Printf("Error pruning expired two-factor challenges: %v", err)
+			return
+		}
+		log.Printf("Pruned %d expired two-factor challenges", pruned)
+	})
+
+	c.AddFunc("@daily", func() {
+		pruned, err := accountTokens.PruneExpired(time.Now())
+		if err != nil {
+			log.Printf("Error pruning expired account tokens: %v", err)
+			return
+		}
+		log.Printf("Pruned %d expired email verification and password reset tokens", pruned)
+	})
+
 	c.Start()
 	log.Println("Cron jobs started.")
 }
@@ -1,13 +1,16 @@
 package middleware
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/fathimasithara01/tradeverse/config"
 	"github.com/fathimasithara01/tradeverse/internal/admin/service"
 	"github.com/fathimasithara01/tradeverse/pkg/auth"
+	"github.com/fathimasithara01/tradeverse/pkg/session"
 	"github.com/gin-gonic/gin"
 )
 
@@ -46,16 +49,42 @@ func (a *AuthzMiddleware) RequirePermission(permissionName string) gin.HandlerFu
 	}
 }
 
-func JWTMiddleware(cfg *config.Config) gin.HandlerFunc {
+// Cookies the admin panel keeps its session in.
+const (
+	AccessCookie  = "admin_token"
+	RefreshCookie = "admin_refresh"
+)
+
+// SetSessionCookies stores a session's tokens for the admin panel. The access
+// cookie outlives its token so that an expired one can be renewed from the
+// refresh cookie instead of being dropped by the browser.
+func SetSessionCookies(c *gin.Context, cfg *config.Config, tokens *session.Tokens) {
+	maxAge := int(time.Until(tokens.RefreshExpiresAt) / time.Second)
+	c.SetCookie(AccessCookie, tokens.AccessToken, maxAge, "/", cfg.Cookie.Domain, true, true)
+	c.SetCookie(RefreshCookie, tokens.RefreshToken, maxAge, "/", cfg.Cookie.Domain, true, true)
+}
+
+func ClearSessionCookies(c *gin.Context, cfg *config.Config) {
+	c.SetCookie(AccessCookie, "", -1, "/", cfg.Cookie.Domain, true, true)
+	c.SetCookie(RefreshCookie, "", -1, "/", cfg.Cookie.Domain, true, true)
+}
+
+func JWTMiddleware(cfg *config.Config, sessions *session.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var tokenString string
 
-		cookie, err := c.Cookie("admin_token")
-		if err == nil {
+		cookie, err := c.Cookie(AccessCookie)
+		fromCookie := err == nil
+		if fromCookie {
 			tokenString = cookie
 		} else {
 			authHeader := c.GetHeader("Authorization")
 			if authHeader == "" {
+				if claims, ok := refreshFromCookie(c, cfg, sessions); ok {
+					setClaims(c, claims)
+					c.Next()
+					return
+				}
 				log.Println("[AUTH-WARN] No token cookie and no Authorization header found.")
 				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization token not provided"})
 				return
@@ -68,19 +97,49 @@ func JWTMiddleware(cfg *config.Config) gin.HandlerFunc {
 			tokenString = parts[1]
 		}
 
-		secret := cfg.JWT.Secret
-		claims, err := auth.ValidateJWT(tokenString, secret)
+		claims, err := sessions.Authenticate(tokenString)
+		if err != nil && fromCookie && errors.Is(err, auth.ErrTokenExpired) {
+			if refreshed, ok := refreshFromCookie(c, cfg, sessions); ok {
+				claims, err = refreshed, nil
+			}
+		}
 		if err != nil {
 			log.Printf("[AUTH-ERROR] Token validation failed: %v", err)
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			return
 		}
 
-		c.Set("userID", claims.UserID)
-		c.Set("userEmail", claims.Email)
-		c.Set("userRole", claims.Role)
-		c.Set("roleID", claims.RoleID)
-
+		setClaims(c, claims)
 		c.Next()
 	}
 }
+
+// refreshFromCookie renews the panel's session from the refresh cookie once
+// the access token has expired, so admins are not logged out every few
+// minutes.
+func refreshFromCookie(c *gin.Context, cfg *config.Config, sessions *session.Service) (*auth.AuthClaims, bool) {
+	refreshToken, err := c.Cookie(RefreshCookie)
+	if err != nil || refreshToken == "" {
+		return nil, false
+	}
+	tokens, err := sessions.Refresh(refreshToken, session.MetaFrom(c))
+	if err != nil {
+		log.Printf("[AUTH-WARN] Session refresh failed: %v", err)
+		ClearSessionCookies(c, cfg)
+		return nil, false
+	}
+	claims, err := sessions.Authenticate(tokens.AccessToken)
+	if err != nil {
+		return nil, false
+	}
+	SetSessionCookies(c, cfg, tokens)
+	return claims, true
+}
+
+func setClaims(c *gin.Context, claims *auth.AuthClaims) {
+	c.Set("userID", claims.UserID)
+	c.Set("userEmail", claims.Email)
+	c.Set("userRole", claims.Role)
+	c.Set("roleID", claims.RoleID)
+	c.Set("sessionID", claims.SessionID)
+}
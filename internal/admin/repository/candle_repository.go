@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ICandleRepository interface {
+	UpsertTick(ctx context.Context, candle *models.Candle) error
+	DownsampleBefore(ctx context.Context, source, target models.CandleInterval, before time.Time) (int64, error)
+	GetCandles(ctx context.Context, symbol string, interval models.CandleInterval, from, to time.Time, limit int) ([]models.Candle, error)
+	DeleteOlderThan(ctx context.Context, interval models.CandleInterval, before time.Time) (int64, error)
+}
+
+type CandleRepository struct{ DB *gorm.DB }
+
+func NewCandleRepository(db *gorm.DB) ICandleRepository { return &CandleRepository{DB: db} }
+
+// UpsertTick opens a new bar or folds the tick into the existing one: high and
+// low widen, close and the 24h volume snapshot take the latest value.
+func (r *CandleRepository) UpsertTick(ctx context.Context, candle *models.Candle) error {
+	return r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "symbol"}, {Name: "candle_interval"}, {Name: "open_time"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"high":       gorm.Expr("GREATEST(candles.high, EXCLUDED.high)"),
+			"low":        gorm.Expr("LEAST(candles.low, EXCLUDED.low)"),
+			"close":      gorm.Expr("EXCLUDED.close"),
+			"volume_24h": gorm.Expr("EXCLUDED.volume_24h"),
+			"tick_count": gorm.Expr("candles.tick_count + 1"),
+			"updated_at": gorm.Expr("EXCLUDED.updated_at"),
+		}),
+	}).Create(candle).Error
+}
+
+// DownsampleBefore rolls source bars older than before up into target bars.
+// Target bars that already exist are left alone, so the call is idempotent.
+func (r *CandleRepository) DownsampleBefore(ctx context.Context, source, target models.CandleInterval, before time.Time) (int64, error) {
+	seconds := int64(target.Duration().Seconds())
+	result := r.DB.WithContext(ctx).Exec(`
+		INSERT INTO candles (symbol, candle_interval, open_time, open, high, low, close, volume_24h, tick_count, updated_at)
+		SELECT symbol, ?, to_timestamp(floor(extract(epoch FROM open_time) / ?) * ?) AS bucket,
+			(array_agg(open ORDER BY open_time ASC))[1],
+			MAX(high),
+			MIN(low),
+			(array_agg(close ORDER BY open_time DESC))[1],
+			(array_agg(volume_24h ORDER BY open_time DESC))[1],
+			SUM(tick_count),
+			NOW()
+		FROM candles
+		WHERE candle_interval = ? AND open_time < ?
+		GROUP BY symbol, bucket
+		ON CONFLICT (symbol, candle_interval, open_time) DO NOTHING`,
+		target, seconds, seconds, source, before)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to downsample %s candles into %s: %w", source, target, result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *CandleRepository) GetCandles(ctx context.Context, symbol string, interval models.CandleInterval, from, to time.Time, limit int) ([]models.Candle, error) {
+	var candles []models.Candle
+	err := r.DB.WithContext(ctx).
+		Where("symbol = ? AND candle_interval = ? AND open_time >= ? AND open_time <= ?", symbol, interval, from, to).
+		Order("open_time asc").
+		Limit(limit).
+		Find(&candles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s candles for %s: %w", interval, symbol, err)
+	}
+	return candles, nil
+}
+
+func (r *CandleRepository) DeleteOlderThan(ctx context.Context, interval models.CandleInterval, before time.Time) (int64, error) {
+	result := r.DB.WithContext(ctx).
+		Where("candle_interval = ? AND open_time < ?", interval, before).
+		Delete(&models.Candle{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge %s candles: %w", interval, result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/fathimasithara01/tradeverse/pkg/models"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -27,11 +29,12 @@ func (r *CopyRepository) StartOrUpdateSession(followerID, masterID uint) error {
 		FollowerID: followerID,
 		MasterID:   masterID,
 		IsActive:   true,
+		StartedAt:  time.Now(),
 	}
 
 	return r.DB.Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "follower_id"}, {Name: "master_id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"is_active"}),
+		DoUpdates: clause.AssignmentColumns([]string{"is_active", "started_at"}),
 	}).Create(&session).Error
 }
 
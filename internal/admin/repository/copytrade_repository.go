@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ICopyTradeRepository interface {
+	GetMasterTradesUpdatedSince(ctx context.Context, since time.Time) ([]models.Trade, error)
+	GetActiveSessionsByMaster(ctx context.Context, masterID uint) ([]models.CopySession, error)
+	GetSessionByPair(ctx context.Context, followerID, masterID uint) (*models.CopySession, error)
+	GetFollowerTrade(ctx context.Context, originalTradeID, followerID uint) (*models.Trade, error)
+	GetOpenFollowerTrades(ctx context.Context, originalTradeID uint) ([]models.Trade, error)
+	GetCopyProfile(ctx context.Context, followerID, masterID uint) (*models.CopyProfile, error)
+	CountOpenCopyTrades(ctx context.Context, followerID, masterID uint) (int64, error)
+	GetCopyPnLSince(ctx context.Context, followerID, masterID uint, since time.Time) (float64, error)
+	PauseCopyProfile(ctx context.Context, profile *models.CopyProfile, reason string) error
+
+	CreateTrade(tx *gorm.DB, trade *models.Trade) error
+	UpdateTrade(tx *gorm.DB, trade *models.Trade) error
+	GetWalletForUpdate(tx *gorm.DB, userID uint) (*models.Wallet, error)
+	AddSessionProfit(tx *gorm.DB, sessionID uint, amount float64) error
+
+	CreateTradeLog(ctx context.Context, log *models.TradeLog) error
+
+	GetSyncCursor(ctx context.Context, name string) (*models.CopySyncCursor, error)
+	SaveSyncCursor(ctx context.Context, name string, syncedAt time.Time) error
+}
+
+type CopyTradeRepository struct{ DB *gorm.DB }
+
+func NewCopyTradeRepository(db *gorm.DB) ICopyTradeRepository { return &CopyTradeRepository{DB: db} }
+
+func (r *CopyTradeRepository) GetMasterTradesUpdatedSince(ctx context.Context, since time.Time) ([]models.Trade, error) {
+	var trades []models.Trade
+	err := r.DB.WithContext(ctx).
+		Where("is_copy_trade = ? AND updated_at > ?", false, since).
+		Order("updated_at asc").
+		Find(&trades).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get master trades updated since %s: %w", since.Format(time.RFC3339), err)
+	}
+	return trades, nil
+}
+
+func (r *CopyTradeRepository) GetActiveSessionsByMaster(ctx context.Context, masterID uint) ([]models.CopySession, error) {
+	var sessions []models.CopySession
+	err := r.DB.WithContext(ctx).
+		Where("master_id = ? AND is_active = ?", masterID, true).
+		Find(&sessions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get copy sessions for master %d: %w", masterID, err)
+	}
+	return sessions, nil
+}
+
+func (r *CopyTradeRepository) GetSessionByPair(ctx context.Context, followerID, masterID uint) (*models.CopySession, error) {
+	var session models.CopySession
+	err := r.DB.WithContext(ctx).
+		Where("follower_id = ? AND master_id = ?", followerID, masterID).
+		First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get copy session for follower %d and master %d: %w", followerID, masterID, err)
+	}
+	return &session, nil
+}
+
+func (r *CopyTradeRepository) GetFollowerTrade(ctx context.Context, originalTradeID, followerID uint) (*models.Trade, error) {
+	var trade models.Trade
+	err := r.DB.WithContext(ctx).
+		Where("original_trade_id = ? AND customer_id = ?", originalTradeID, followerID).
+		First(&trade).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get follower trade for master trade %d: %w", originalTradeID, err)
+	}
+	return &trade, nil
+}
+
+func (r *CopyTradeRepository) GetOpenFollowerTrades(ctx context.Context, originalTradeID uint) ([]models.Trade, error) {
+	var trades []models.Trade
+	err := r.DB.WithContext(ctx).
+		Where("original_trade_id = ? AND status IN ?", originalTradeID, []models.TradeStatus{models.TradeStatusOpen, models.TradeStatusPending}).
+		Find(&trades).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open follower trades for master trade %d: %w", originalTradeID, err)
+	}
+	return trades, nil
+}
+
+func (r *CopyTradeRepository) GetCopyProfile(ctx context.Context, followerID, masterID uint) (*models.CopyProfile, error) {
+	var profile models.CopyProfile
+	err := r.DB.WithContext(ctx).
+		Where("follower_id = ? AND master_id = ?", followerID, masterID).
+		First(&profile).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get copy profile for follower %d and master %d: %w", followerID, masterID, err)
+	}
+	return &profile, nil
+}
+
+func (r *CopyTradeRepository) CountOpenCopyTrades(ctx context.Context, followerID, masterID uint) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&models.Trade{}).
+		Where("is_copy_trade = ? AND customer_id = ? AND trader_id = ? AND status IN ?", true, followerID, masterID,
+			[]models.TradeStatus{models.TradeStatusOpen, models.TradeStatusPending}).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count open copy trades for follower %d: %w", followerID, err)
+	}
+	return count, nil
+}
+
+func (r *CopyTradeRepository) GetCopyPnLSince(ctx context.Context, followerID, masterID uint, since time.Time) (float64, error) {
+	var total float64
+	err := r.DB.WithContext(ctx).Model(&models.Trade{}).
+		Select("COALESCE(SUM(pnl), 0)").
+		Where("is_copy_trade = ? AND customer_id = ? AND trader_id = ? AND status = ? AND closed_at >= ?", true, followerID, masterID, models.TradeStatusClosed, since).
+		Scan(&total).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum copy pnl for follower %d: %w", followerID, err)
+	}
+	return total, nil
+}
+
+// PauseCopyProfile marks the profile paused and stops the matching copy session in one transaction.
+func (r *CopyTradeRepository) PauseCopyProfile(ctx context.Context, profile *models.CopyProfile, reason string) error {
+	now := time.Now()
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(profile).Updates(map[string]interface{}{
+			"status":        models.CopyProfilePaused,
+			"paused_reason": reason,
+			"paused_at":     now,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to pause copy profile %d: %w", profile.ID, err)
+		}
+		return tx.Model(&models.CopySession{}).
+			Where("follower_id = ? AND master_id = ?", profile.FollowerID, profile.MasterID).
+			Update("is_active", false).Error
+	})
+}
+
+func (r *CopyTradeRepository) CreateTrade(tx *gorm.DB, trade *models.Trade) error {
+	return tx.Create(trade).Error
+}
+
+func (r *CopyTradeRepository) UpdateTrade(tx *gorm.DB, trade *models.Trade) error {
+	return tx.Save(trade).Error
+}
+
+func (r *CopyTradeRepository) GetWalletForUpdate(tx *gorm.DB, userID uint) (*models.Wallet, error) {
+	var wallet models.Wallet
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Scopes(models.PrimaryWallet).
+		Where("user_id = ?", userID).
+		First(&wallet).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("wallet not found for user %d", userID)
+		}
+		return nil, fmt.Errorf("failed to lock wallet for user %d: %w", userID, err)
+	}
+	return &wallet, nil
+}
+
+func (r *CopyTradeRepository) AddSessionProfit(tx *gorm.DB, sessionID uint, amount float64) error {
+	return tx.Model(&models.CopySession{}).
+		Where("id = ?", sessionID).
+		Update("current_profit", gorm.Expr("current_profit + ?", amount)).Error
+}
+
+func (r *CopyTradeRepository) CreateTradeLog(ctx context.Context, log *models.TradeLog) error {
+	return r.DB.WithContext(ctx).Create(log).Error
+}
+
+func (r *CopyTradeRepository) GetSyncCursor(ctx context.Context, name string) (*models.CopySyncCursor, error) {
+	var cursor models.CopySyncCursor
+	err := r.DB.WithContext(ctx).Where("name = ?", name).First(&cursor).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get copy sync cursor %q: %w", name, err)
+	}
+	return &cursor, nil
+}
+
+// SaveSyncCursor upserts the named cursor.
+func (r *CopyTradeRepository) SaveSyncCursor(ctx context.Context, name string, syncedAt time.Time) error {
+	cursor := models.CopySyncCursor{Name: name, SyncedAt: syncedAt}
+	err := r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"synced_at", "updated_at"}),
+	}).Create(&cursor).Error
+	if err != nil {
+		return fmt.Errorf("failed to save copy sync cursor %q: %w", name, err)
+	}
+	return nil
+}
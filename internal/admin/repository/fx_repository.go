@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrFXRateNotFound = errors.New("exchange rate not found")
+
+type IFXRateRepository interface {
+	ListRates(ctx context.Context) ([]models.FXRate, error)
+	UpsertRate(ctx context.Context, rate *models.FXRate) error
+	DeleteRate(ctx context.Context, id uint) error
+}
+
+type FXRateRepository struct{ DB *gorm.DB }
+
+func NewFXRateRepository(db *gorm.DB) IFXRateRepository {
+	return &FXRateRepository{DB: db}
+}
+
+func (r *FXRateRepository) ListRates(ctx context.Context) ([]models.FXRate, error) {
+	var rates []models.FXRate
+	if err := r.DB.WithContext(ctx).Order("base_currency ASC, quote_currency ASC").Find(&rates).Error; err != nil {
+		return nil, fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+	return rates, nil
+}
+
+// UpsertRate stores the rate for its pair, replacing the current one.
+func (r *FXRateRepository) UpsertRate(ctx context.Context, rate *models.FXRate) error {
+	rate.UpdatedAt = time.Now()
+	err := r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base_currency"}, {Name: "quote_currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "updated_by", "updated_at"}),
+	}).Create(rate).Error
+	if err != nil {
+		return fmt.Errorf("failed to save %s/%s rate: %w", rate.BaseCurrency, rate.QuoteCurrency, err)
+	}
+	return nil
+}
+
+func (r *FXRateRepository) DeleteRate(ctx context.Context, id uint) error {
+	result := r.DB.WithContext(ctx).Delete(&models.FXRate{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete exchange rate %d: %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrFXRateNotFound
+	}
+	return nil
+}
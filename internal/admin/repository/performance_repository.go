@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IPerformanceRepository interface {
+	GetTraderIDs(ctx context.Context) ([]uint, error)
+	GetClosedTrades(ctx context.Context, traderID uint, since time.Time) ([]models.Trade, error)
+	GetResolvedSignals(ctx context.Context, traderID uint, since time.Time) ([]models.Signal, error)
+	CountActiveCopiers(ctx context.Context, traderID uint) (int64, error)
+	UpsertPerformance(ctx context.Context, perf *models.TraderPerformance) error
+}
+
+type PerformanceRepository struct{ DB *gorm.DB }
+
+func NewPerformanceRepository(db *gorm.DB) IPerformanceRepository {
+	return &PerformanceRepository{DB: db}
+}
+
+func (r *PerformanceRepository) GetTraderIDs(ctx context.Context) ([]uint, error) {
+	var ids []uint
+	if err := r.DB.WithContext(ctx).Model(&models.User{}).
+		Where("role = ?", models.RoleTrader).
+		Order("id ASC").
+		Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to list traders: %w", err)
+	}
+	return ids, nil
+}
+
+// GetClosedTrades returns the trader's own closed trades, oldest first. Copy
+// trades are excluded.
+func (r *PerformanceRepository) GetClosedTrades(ctx context.Context, traderID uint, since time.Time) ([]models.Trade, error) {
+	var trades []models.Trade
+	query := r.DB.WithContext(ctx).
+		Where("trader_id = ? AND status = ? AND is_copy_trade = ? AND closed_at IS NOT NULL", traderID, models.TradeStatusClosed, false)
+	if !since.IsZero() {
+		query = query.Where("closed_at >= ?", since)
+	}
+	if err := query.Order("closed_at ASC").Find(&trades).Error; err != nil {
+		return nil, fmt.Errorf("failed to get closed trades for trader %d: %w", traderID, err)
+	}
+	return trades, nil
+}
+
+func (r *PerformanceRepository) GetResolvedSignals(ctx context.Context, traderID uint, since time.Time) ([]models.Signal, error) {
+	var signals []models.Signal
+	query := r.DB.WithContext(ctx).
+		Where("trader_id = ? AND status IN ? AND exit_price > 0", traderID, []models.SignalStatus{
+			models.SignalStatusTargetHit, models.SignalStatusStopLoss, models.SignalStatusExpired, models.SignalStatusCancelled,
+		})
+	if !since.IsZero() {
+		query = query.Where("deactivated_at >= ?", since)
+	}
+	if err := query.Find(&signals).Error; err != nil {
+		return nil, fmt.Errorf("failed to get resolved signals for trader %d: %w", traderID, err)
+	}
+	return signals, nil
+}
+
+// CountActiveCopiers counts distinct followers with an active copy profile or
+// copy session on the trader.
+func (r *PerformanceRepository) CountActiveCopiers(ctx context.Context, traderID uint) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Raw(`
+		SELECT COUNT(DISTINCT follower_id) FROM (
+			SELECT follower_id FROM copy_profiles WHERE master_id = ? AND status = ? AND deleted_at IS NULL
+			UNION
+			SELECT follower_id FROM copy_sessions WHERE master_id = ? AND is_active = ? AND deleted_at IS NULL
+		) AS copiers`,
+		traderID, models.CopyProfileActive, traderID, true).Scan(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count copiers for trader %d: %w", traderID, err)
+	}
+	return count, nil
+}
+
+// UpsertPerformance writes the computed metrics, leaving the profile fields the
+// trader edits (bio, trading style, visibility) untouched.
+func (r *PerformanceRepository) UpsertPerformance(ctx context.Context, perf *models.TraderPerformance) error {
+	err := r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "trader_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"total_roi", "daily_roi", "weekly_roi", "monthly_roi", "max_drawdown",
+			"win_rate", "loss_rate", "average_profit", "average_loss",
+			"total_trades", "winning_trades", "losing_trades", "active_copiers",
+			"total_pnl", "sharpe_ratio", "sortino_ratio",
+			"resolved_signals", "signal_win_rate", "average_signal_r",
+			"last_updated", "updated_at",
+		}),
+	}).Create(perf).Error
+	if err != nil {
+		return fmt.Errorf("failed to save performance for trader %d: %w", perf.TraderID, err)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/ledger"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrDiscrepancyNotFound = errors.New("discrepancy not found")
+
+type IReconciliationRepository interface {
+	ListWallets(ctx context.Context) ([]models.Wallet, error)
+	GetWalletTransactions(ctx context.Context, walletID uint) ([]models.WalletTransaction, error)
+	FindOrphanDeposits(ctx context.Context) ([]models.DepositRequest, error)
+	FindOrphanWithdrawals(ctx context.Context) ([]models.WithdrawalRequest, error)
+	VerifyLedger(ctx context.Context) (*ledger.Report, error)
+
+	CreateRun(ctx context.Context, run *models.ReconciliationRun) error
+	UpdateRun(ctx context.Context, run *models.ReconciliationRun) error
+	ListRuns(ctx context.Context, limit int) ([]models.ReconciliationRun, error)
+
+	UpsertDiscrepancy(ctx context.Context, d *models.WalletDiscrepancy) error
+	ListDiscrepancies(ctx context.Context, filter models.DiscrepancyFilter) ([]models.WalletDiscrepancy, int64, error)
+	GetDiscrepancy(ctx context.Context, id uint) (*models.WalletDiscrepancy, error)
+	UpdateDiscrepancy(ctx context.Context, d *models.WalletDiscrepancy) error
+}
+
+type ReconciliationRepository struct{ DB *gorm.DB }
+
+func NewReconciliationRepository(db *gorm.DB) IReconciliationRepository {
+	return &ReconciliationRepository{DB: db}
+}
+
+func (r *ReconciliationRepository) ListWallets(ctx context.Context) ([]models.Wallet, error) {
+	var wallets []models.Wallet
+	if err := r.DB.WithContext(ctx).Order("id ASC").Find(&wallets).Error; err != nil {
+		return nil, fmt.Errorf("failed to list wallets: %w", err)
+	}
+	return wallets, nil
+}
+
+func (r *ReconciliationRepository) GetWalletTransactions(ctx context.Context, walletID uint) ([]models.WalletTransaction, error) {
+	var txs []models.WalletTransaction
+	if err := r.DB.WithContext(ctx).
+		Where("wallet_id = ?", walletID).
+		Order("created_at ASC, id ASC").
+		Find(&txs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get transactions for wallet %d: %w", walletID, err)
+	}
+	return txs, nil
+}
+
+// FindOrphanDeposits returns successful deposits whose wallet transaction is
+// missing, either because it was never linked or because the row is gone.
+func (r *ReconciliationRepository) FindOrphanDeposits(ctx context.Context) ([]models.DepositRequest, error) {
+	var deposits []models.DepositRequest
+	if err := r.DB.WithContext(ctx).
+		Where("status = ?", models.TxStatusSuccess).
+		Where("wallet_transaction_id IS NULL OR NOT EXISTS (SELECT 1 FROM wallet_transactions wt WHERE wt.id = deposit_requests.wallet_transaction_id AND wt.deleted_at IS NULL)").
+		Order("id ASC").
+		Find(&deposits).Error; err != nil {
+		return nil, fmt.Errorf("failed to find orphan deposits: %w", err)
+	}
+	return deposits, nil
+}
+
+// FindOrphanWithdrawals returns withdrawals that took or are holding money but
+// have no wallet transaction behind them.
+func (r *ReconciliationRepository) FindOrphanWithdrawals(ctx context.Context) ([]models.WithdrawalRequest, error) {
+	var withdrawals []models.WithdrawalRequest
+	if err := r.DB.WithContext(ctx).
+		Where("status IN ?", []models.TransactionStatus{models.TxStatusPending, models.TxStatusProcessing, models.TxStatusSuccess}).
+		Where("wallet_transaction_id IS NULL OR NOT EXISTS (SELECT 1 FROM wallet_transactions wt WHERE wt.id = withdrawal_requests.wallet_transaction_id AND wt.deleted_at IS NULL)").
+		Order("id ASC").
+		Find(&withdrawals).Error; err != nil {
+		return nil, fmt.Errorf("failed to find orphan withdrawals: %w", err)
+	}
+	return withdrawals, nil
+}
+
+func (r *ReconciliationRepository) VerifyLedger(ctx context.Context) (*ledger.Report, error) {
+	return ledger.Verify(ctx, r.DB)
+}
+
+func (r *ReconciliationRepository) CreateRun(ctx context.Context, run *models.ReconciliationRun) error {
+	if err := r.DB.WithContext(ctx).Create(run).Error; err != nil {
+		return fmt.Errorf("failed to create reconciliation run: %w", err)
+	}
+	return nil
+}
+
+func (r *ReconciliationRepository) UpdateRun(ctx context.Context, run *models.ReconciliationRun) error {
+	if err := r.DB.WithContext(ctx).Save(run).Error; err != nil {
+		return fmt.Errorf("failed to update reconciliation run %d: %w", run.ID, err)
+	}
+	return nil
+}
+
+func (r *ReconciliationRepository) ListRuns(ctx context.Context, limit int) ([]models.ReconciliationRun, error) {
+	var runs []models.ReconciliationRun
+	if err := r.DB.WithContext(ctx).Order("started_at DESC").Limit(limit).Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list reconciliation runs: %w", err)
+	}
+	return runs, nil
+}
+
+// UpsertDiscrepancy records a discrepancy or, when one with the same
+// fingerprint exists, refreshes what was seen without touching its review
+// status or notes.
+func (r *ReconciliationRepository) UpsertDiscrepancy(ctx context.Context, d *models.WalletDiscrepancy) error {
+	now := time.Now()
+	if d.FirstSeenAt.IsZero() {
+		d.FirstSeenAt = now
+	}
+	d.LastSeenAt = now
+	if d.Status == "" {
+		d.Status = models.DiscrepancyStatusOpen
+	}
+
+	err := r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "fingerprint"}},
+		DoUpdates: clause.AssignmentColumns([]string{"run_id", "expected", "actual", "details", "last_seen_at", "updated_at"}),
+	}).Create(d).Error
+	if err != nil {
+		return fmt.Errorf("failed to store discrepancy %s: %w", d.Fingerprint, err)
+	}
+	return nil
+}
+
+func (r *ReconciliationRepository) ListDiscrepancies(ctx context.Context, filter models.DiscrepancyFilter) ([]models.WalletDiscrepancy, int64, error) {
+	query := r.DB.WithContext(ctx).Model(&models.WalletDiscrepancy{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Kind != "" {
+		query = query.Where("kind = ?", filter.Kind)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count discrepancies: %w", err)
+	}
+
+	var items []models.WalletDiscrepancy
+	if err := query.Order("last_seen_at DESC, id DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&items).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list discrepancies: %w", err)
+	}
+	return items, total, nil
+}
+
+func (r *ReconciliationRepository) GetDiscrepancy(ctx context.Context, id uint) (*models.WalletDiscrepancy, error) {
+	var d models.WalletDiscrepancy
+	if err := r.DB.WithContext(ctx).First(&d, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDiscrepancyNotFound
+		}
+		return nil, fmt.Errorf("failed to get discrepancy %d: %w", id, err)
+	}
+	return &d, nil
+}
+
+func (r *ReconciliationRepository) UpdateDiscrepancy(ctx context.Context, d *models.WalletDiscrepancy) error {
+	if err := r.DB.WithContext(ctx).Save(d).Error; err != nil {
+		return fmt.Errorf("failed to update discrepancy %d: %w", d.ID, err)
+	}
+	return nil
+}
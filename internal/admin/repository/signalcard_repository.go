@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/fathimasithara01/tradeverse/pkg/exchange"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
 	"gorm.io/gorm"
 )
@@ -14,7 +16,9 @@ type ISignalRepository interface {
 	GetAllSignals(ctx context.Context) ([]models.Signal, error)
 	GetMarketDataBySymbol(ctx context.Context, symbol string) (*models.MarketData, error)
 	UpdateSignalCurrentPrice(ctx context.Context, signalID uint, newPrice float64) error
-	UpdateSignalStatus(ctx context.Context, signalID uint, newStatus string) error
+	TransitionSignalStatus(ctx context.Context, signal *models.Signal, next models.SignalStatus, price float64, reason string) (bool, error)
+	SaveSignalProgress(ctx context.Context, signal *models.Signal) error
+	GetSignalHistory(ctx context.Context, signalID uint) ([]models.SignalStatusHistory, error)
 	GetActiveAndPendingSignals(ctx context.Context) ([]models.Signal, error)
 }
 
@@ -26,6 +30,11 @@ func NewSignalRepository(db *gorm.DB) ISignalRepository {
 	return &SignalRepository{db: db}
 }
 
+// withTargets loads a signal's target ladder in order.
+func withTargets(db *gorm.DB) *gorm.DB {
+	return db.Preload("Targets", func(db *gorm.DB) *gorm.DB { return db.Order("sequence ASC") })
+}
+
 func (r *SignalRepository) CreateSignal(ctx context.Context, signal *models.Signal) (*models.Signal, error) {
 	if err := r.db.WithContext(ctx).Create(signal).Error; err != nil {
 		return nil, fmt.Errorf("failed to create signal: %w", err)
@@ -35,7 +44,7 @@ func (r *SignalRepository) CreateSignal(ctx context.Context, signal *models.Sign
 
 func (r *SignalRepository) GetAllSignals(ctx context.Context) ([]models.Signal, error) {
 	var signals []models.Signal
-	if err := r.db.WithContext(ctx).Find(&signals).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(withTargets).Find(&signals).Error; err != nil {
 		return nil, fmt.Errorf("failed to get all signals: %w", err)
 	}
 	return signals, nil
@@ -43,7 +52,7 @@ func (r *SignalRepository) GetAllSignals(ctx context.Context) ([]models.Signal,
 
 func (r *SignalRepository) GetActiveAndPendingSignals(ctx context.Context) ([]models.Signal, error) {
 	var signals []models.Signal
-	if err := r.db.WithContext(ctx).Where("status = ? OR status = ?", "Active", "Pending").Find(&signals).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(withTargets).Where("status IN ?", []models.SignalStatus{models.SignalStatusActive, models.SignalStatusPending}).Find(&signals).Error; err != nil {
 		return nil, fmt.Errorf("failed to get active/pending signals: %w", err)
 	}
 	return signals, nil
@@ -54,8 +63,9 @@ func (r *SignalRepository) GetMarketDataBySymbol(ctx context.Context, symbol str
 
 	symbol = strings.ToUpper(symbol)
 
+	// Signals are quoted as "BTCUSDT" while market data is stored by base asset.
 	err := r.db.WithContext(ctx).
-		Where("UPPER(symbol) = ?", symbol).
+		Where("UPPER(symbol) IN ?", []string{symbol, exchange.BaseSymbol(symbol)}).
 		First(&marketData).Error
 
 	if err != nil {
@@ -79,13 +89,91 @@ func (r *SignalRepository) UpdateSignalCurrentPrice(ctx context.Context, signalI
 	return nil
 }
 
-func (r *SignalRepository) UpdateSignalStatus(ctx context.Context, signalID uint, newStatus string) error {
-	result := r.db.WithContext(ctx).Model(&models.Signal{}).Where("id = ?", signalID).Update("status", newStatus)
-	if result.Error != nil {
-		return fmt.Errorf("failed to update signal status: %w", result.Error)
+// TransitionSignalStatus moves the signal from its loaded status to next and
+// records the change in the history table. It returns false when the status
+// changed underneath us, e.g. because the trader service's cron got there
+// first.
+func (r *SignalRepository) TransitionSignalStatus(ctx context.Context, signal *models.Signal, next models.SignalStatus, price float64, reason string) (bool, error) {
+	if !signal.Status.CanTransitionTo(next) {
+		return false, fmt.Errorf("%w: %s to %s", models.ErrInvalidSignalTransition, signal.Status, next)
 	}
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": next}
+	if next.IsTerminal() {
+		updates["deactivated_at"] = now
+		updates["exit_price"] = signal.ExitPrice
+		updates["realized_r"] = signal.RealizedR
+	}
+
+	moved := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Signal{}).Where("id = ? AND status = ?", signal.ID, signal.Status).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		moved = true
+		return tx.Create(&models.SignalStatusHistory{
+			SignalID:   signal.ID,
+			FromStatus: signal.Status,
+			ToStatus:   next,
+			Price:      price,
+			Reason:     reason,
+			ChangedAt:  now,
+		}).Error
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to update signal status: %w", err)
+	}
+	return moved, nil
+}
+
+// SaveSignalProgress persists what Signal.Advance changed on an Active signal:
+// the stop, the best price, the realised R and newly hit targets. The stop and
+// watermark only move in the signal's favour, so the admin and trader crons
+// can both run it without undoing each other.
+func (r *SignalRepository) SaveSignalProgress(ctx context.Context, signal *models.Signal) error {
+	best := "GREATEST"
+	if signal.Direction == models.SignalDirectionShort {
+		best = "LEAST"
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Signal{}).
+			Where("id = ? AND status = ?", signal.ID, models.SignalStatusActive).
+			Updates(map[string]interface{}{
+				"stop_loss":      gorm.Expr("CASE WHEN stop_loss = 0 THEN ? ELSE "+best+"(stop_loss, ?) END", signal.StopLoss, signal.StopLoss),
+				"high_watermark": gorm.Expr("CASE WHEN high_watermark = 0 THEN ? ELSE "+best+"(high_watermark, ?) END", signal.HighWatermark, signal.HighWatermark),
+				"realized_r":     signal.RealizedR,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		for _, t := range signal.Targets {
+			if t.HitAt == nil {
+				continue
+			}
+			if err := tx.Model(&models.SignalTarget{}).
+				Where("id = ? AND hit_at IS NULL", t.ID).
+				Updates(map[string]interface{}{"hit_at": t.HitAt, "hit_price": t.HitPrice}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save signal progress: %w", err)
 	}
 	return nil
 }
+
+func (r *SignalRepository) GetSignalHistory(ctx context.Context, signalID uint) ([]models.SignalStatusHistory, error) {
+	var history []models.SignalStatusHistory
+	if err := r.db.WithContext(ctx).Where("signal_id = ?", signalID).Order("changed_at ASC, id ASC").Find(&history).Error; err != nil {
+		return nil, fmt.Errorf("failed to get signal history: %w", err)
+	}
+	return history, nil
+}
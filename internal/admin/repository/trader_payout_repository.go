@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"gorm.io/gorm"
+)
+
+type ITraderPayoutRepository interface {
+	ListAutoPayoutAccounts(ctx context.Context) ([]models.TraderPayoutAccount, error)
+	UnpaidRevenue(ctx context.Context, traderID uint) ([]models.UnpaidTraderRevenue, error)
+	HasPendingPayout(ctx context.Context, traderID uint, currency string) (bool, error)
+	WalletBalance(ctx context.Context, traderID uint, currency string) (money.Amount, error)
+	ListPayouts(ctx context.Context, pagination models.PaginationParams) ([]models.TraderPayout, int64, error)
+}
+
+type TraderPayoutRepository struct{ DB *gorm.DB }
+
+func NewTraderPayoutRepository(db *gorm.DB) ITraderPayoutRepository {
+	return &TraderPayoutRepository{DB: db}
+}
+
+func (r *TraderPayoutRepository) ListAutoPayoutAccounts(ctx context.Context) ([]models.TraderPayoutAccount, error) {
+	var accounts []models.TraderPayoutAccount
+	if err := r.DB.WithContext(ctx).Where("auto_payout = ?", true).Order("trader_id ASC").Find(&accounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to list payout accounts: %w", err)
+	}
+	return accounts, nil
+}
+
+// UnpaidRevenue sums, per currency, the trader revenue credited after the
+// last revenue transaction each earlier payout covered, less the refunds
+// taken back from the trader since. Payouts whose withdrawal was rejected or
+// reversed returned their funds and so do not count as covering anything.
+func (r *TraderPayoutRepository) UnpaidRevenue(ctx context.Context, traderID uint) ([]models.UnpaidTraderRevenue, error) {
+	var unpaid []models.UnpaidTraderRevenue
+	err := r.DB.WithContext(ctx).Raw(`SELECT wt.currency,
+			SUM(CASE WHEN wt.type = ? THEN wt.amount ELSE -wt.amount END) AS amount,
+			MAX(wt.id) AS through_id
+		FROM wallet_transactions wt
+		WHERE wt.user_id = ? AND wt.deleted_at IS NULL
+			AND (wt.type = ? OR (wt.type = ? AND wt.transaction_type = ?))
+			AND wt.id > COALESCE((SELECT MAX(p.revenue_through_id) FROM trader_payouts p
+				JOIN withdrawal_requests w ON w.id = p.withdrawal_id
+				WHERE p.trader_id = wt.user_id AND p.currency = wt.currency AND w.status NOT IN ?), 0)
+		GROUP BY wt.currency
+		ORDER BY wt.currency`,
+		models.TxTypeTraderRevenue, traderID,
+		models.TxTypeTraderRevenue, models.TxTypeReversal, models.TxTypeDebit, returnedWithdrawalStatuses).
+		Scan(&unpaid).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum unpaid revenue for trader %d: %w", traderID, err)
+	}
+	return unpaid, nil
+}
+
+// returnedWithdrawalStatuses are the end states in which a withdrawal's
+// funds went back to the wallet.
+var returnedWithdrawalStatuses = []models.TransactionStatus{
+	models.TxStatusRejected, models.TxStatusReversed, models.TxStatusFailed, models.TxStatusCancelled,
+}
+
+// HasPendingPayout reports whether an earlier payout in the currency is still
+// waiting on its withdrawal.
+func (r *TraderPayoutRepository) HasPendingPayout(ctx context.Context, traderID uint, currency string) (bool, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&models.TraderPayout{}).
+		Joins("JOIN withdrawal_requests w ON w.id = trader_payouts.withdrawal_id").
+		Where("trader_payouts.trader_id = ? AND trader_payouts.currency = ? AND w.status IN ?", traderID, currency,
+			[]models.TransactionStatus{models.TxStatusPending, models.TxStatusProcessing}).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check pending %s payouts of trader %d: %w", currency, traderID, err)
+	}
+	return count > 0, nil
+}
+
+func (r *TraderPayoutRepository) WalletBalance(ctx context.Context, traderID uint, currency string) (money.Amount, error) {
+	var balances []money.Amount
+	if err := r.DB.WithContext(ctx).Model(&models.Wallet{}).
+		Where("user_id = ? AND currency = ?", traderID, currency).
+		Limit(1).
+		Pluck("balance", &balances).Error; err != nil {
+		return 0, fmt.Errorf("failed to get %s wallet of trader %d: %w", currency, traderID, err)
+	}
+	if len(balances) == 0 {
+		return 0, nil
+	}
+	return balances[0], nil
+}
+
+func (r *TraderPayoutRepository) ListPayouts(ctx context.Context, pagination models.PaginationParams) ([]models.TraderPayout, int64, error) {
+	query := r.DB.WithContext(ctx).Model(&models.TraderPayout{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count trader payouts: %w", err)
+	}
+
+	var payouts []models.TraderPayout
+	if err := query.Preload("Withdrawal").
+		Order("created_at DESC, id DESC").
+		Offset((pagination.Page - 1) * pagination.Limit).
+		Limit(pagination.Limit).
+		Find(&payouts).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list trader payouts: %w", err)
+	}
+	return payouts, total, nil
+}
@@ -7,8 +7,10 @@ import (
 	"math"
 	"time"
 
+	"github.com/fathimasithara01/tradeverse/pkg/lockout"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type IUserRepository interface {
@@ -43,6 +45,9 @@ type IUserRepository interface {
 	GetUsersByRole(role models.UserRole) ([]models.User, error)
 
 	GetAdminProfile(userID uint) (models.User, error)
+
+	RecordFailedLogin(userID uint, policy lockout.Policy, now time.Time) (*models.User, error)
+	ResetFailedLogins(userID uint) error
 }
 
 type UserRepository struct {
@@ -480,3 +485,35 @@ func (r *UserRepository) GetLatestOpenTradeForUser(userID uint) (models.Trade, e
 	}
 	return trade, nil
 }
+
+// RecordFailedLogin counts a failed login and locks the account if the policy
+// says so. It returns the user as updated.
+func (r *UserRepository) RecordFailedLogin(userID uint, policy lockout.Policy, now time.Time) (*models.User, error) {
+	var user models.User
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return err
+		}
+		user.FailedLoginAttempts++
+		if lockFor := policy.LockFor(user.FailedLoginAttempts); lockFor > 0 {
+			until := now.Add(lockFor)
+			user.LockedUntil = &until
+		}
+		return tx.Model(&user).Updates(map[string]interface{}{
+			"failed_login_attempts": user.FailedLoginAttempts,
+			"locked_until":          user.LockedUntil,
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record failed login for user %d: %w", userID, err)
+	}
+	return &user, nil
+}
+
+// ResetFailedLogins clears the failed login count and any lock.
+func (r *UserRepository) ResetFailedLogins(userID uint) error {
+	return r.DB.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"failed_login_attempts": 0,
+		"locked_until":          nil,
+	}).Error
+}
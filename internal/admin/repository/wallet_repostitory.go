@@ -3,7 +3,6 @@ package repository
 import (
 	"errors"
 	"fmt"
-	"time"
 
 	"github.com/fathimasithara01/tradeverse/pkg/models"
 	"gorm.io/gorm"
@@ -11,21 +10,15 @@ import (
 
 type IAdminWalletRepository interface {
 	GetAdminWallet() (*models.Wallet, error)
-	CreateWalletTransaction(tx *gorm.DB, transaction *models.WalletTransaction) error
-	UpdateWalletBalance(tx *gorm.DB, wallet *models.Wallet) error
 	CreateDepositRequest(deposit *models.DepositRequest) error
 	GetDepositRequestByID(depositID uint) (*models.DepositRequest, error)
 	UpdateDepositRequest(deposit *models.DepositRequest) error
-	CreateWithdrawRequest(withdraw *models.WithdrawRequest) error
-	GetWithdrawRequestByID(withdrawID uint) (*models.WithdrawRequest, error)
-	UpdateWithdrawRequest(withdraw *models.WithdrawRequest) error
 	FindAdminUser() (*models.User, error)
 
 	AdminGetWalletTransactions(pagination models.PaginationParams) ([]models.WalletTransaction, int64, error)
 	GetAllWalletTransactions(pagination models.PaginationParams) ([]models.WalletTransaction, int64, error)
 
-	GetPendingWithdrawalRequests(pagination models.PaginationParams) ([]models.WithdrawRequest, int64, error)
-	UpdateCustomerWalletBalance(tx *gorm.DB, wallet *models.Wallet) error
+	GetPendingWithdrawalRequests(pagination models.PaginationParams) ([]models.WithdrawalRequest, int64, error)
 	GetCustomerWallet(userID uint) (*models.Wallet, error)
 
 	GetAllCustomerTransactions(pagination models.PaginationParams) ([]models.AdminTransactionDisplayDTO, int64, error)
@@ -194,7 +187,7 @@ func (r *AdminWalletRepository) GetAdminWallet() (*models.Wallet, error) {
 	}
 
 	var wallet models.Wallet
-	err = r.DB.Where("user_id = ?", adminUser.ID).First(&wallet).Error
+	err = r.DB.Scopes(models.PrimaryWallet).Where("user_id = ?", adminUser.ID).First(&wallet).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("admin wallet not found")
@@ -204,15 +197,6 @@ func (r *AdminWalletRepository) GetAdminWallet() (*models.Wallet, error) {
 	return &wallet, nil
 }
 
-func (r *AdminWalletRepository) CreateWalletTransaction(tx *gorm.DB, transaction *models.WalletTransaction) error {
-	return tx.Create(transaction).Error
-}
-
-func (r *AdminWalletRepository) UpdateWalletBalance(tx *gorm.DB, wallet *models.Wallet) error {
-	wallet.LastUpdated = time.Now()
-	return tx.Save(wallet).Error
-}
-
 func (r *AdminWalletRepository) CreateDepositRequest(deposit *models.DepositRequest) error {
 	return r.DB.Create(deposit).Error
 }
@@ -227,27 +211,13 @@ func (r *AdminWalletRepository) UpdateDepositRequest(deposit *models.DepositRequ
 	return r.DB.Save(deposit).Error
 }
 
-func (r *AdminWalletRepository) CreateWithdrawRequest(withdraw *models.WithdrawRequest) error {
-	return r.DB.Create(withdraw).Error
-}
-
-func (r *AdminWalletRepository) GetWithdrawRequestByID(withdrawID uint) (*models.WithdrawRequest, error) {
-	var withdraw models.WithdrawRequest
-	err := r.DB.Preload("User").First(&withdraw, withdrawID).Error
-	return &withdraw, err
-}
-
-func (r *AdminWalletRepository) UpdateWithdrawRequest(withdraw *models.WithdrawRequest) error {
-	return r.DB.Save(withdraw).Error
-}
-
-func (r *AdminWalletRepository) GetPendingWithdrawalRequests(pagination models.PaginationParams) ([]models.WithdrawRequest, int64, error) {
-	var withdrawals []models.WithdrawRequest
+func (r *AdminWalletRepository) GetPendingWithdrawalRequests(pagination models.PaginationParams) ([]models.WithdrawalRequest, int64, error) {
+	var withdrawals []models.WithdrawalRequest
 	var total int64
 
-	query := r.DB.Preload("User").Where("status = ?", models.TxStatusPending).Order("created_at DESC")
+	query := r.DB.Preload("User").Where("status = ?", models.TxStatusPending).Order("request_time ASC")
 
-	err := query.Model(&models.WithdrawRequest{}).Count(&total).Error
+	err := query.Model(&models.WithdrawalRequest{}).Count(&total).Error
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count pending withdrawal requests: %w", err)
 	}
@@ -260,14 +230,9 @@ func (r *AdminWalletRepository) GetPendingWithdrawalRequests(pagination models.P
 	return withdrawals, total, nil
 }
 
-func (r *AdminWalletRepository) UpdateCustomerWalletBalance(tx *gorm.DB, wallet *models.Wallet) error {
-	wallet.LastUpdated = time.Now()
-	return tx.Save(wallet).Error
-}
-
 func (r *AdminWalletRepository) GetCustomerWallet(userID uint) (*models.Wallet, error) {
 	var wallet models.Wallet
-	err := r.DB.Where("user_id = ?", userID).First(&wallet).Error
+	err := r.DB.Scopes(models.PrimaryWallet).Where("user_id = ?", userID).First(&wallet).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("customer wallet not found")
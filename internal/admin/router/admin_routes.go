@@ -5,6 +5,10 @@ import (
 	"github.com/fathimasithara01/tradeverse/internal/admin/controllers"
 	"github.com/fathimasithara01/tradeverse/internal/admin/middleware"
 	"github.com/fathimasithara01/tradeverse/internal/admin/service"
+	"github.com/fathimasithara01/tradeverse/pkg/idempotency"
+	"github.com/fathimasithara01/tradeverse/pkg/ratelimit"
+	"github.com/fathimasithara01/tradeverse/pkg/session"
+	"github.com/fathimasithara01/tradeverse/pkg/twofactor"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -26,13 +30,26 @@ func WireAdminRoutes(
 	signalCtrl *controllers.SignalController,
 	commissionCtrl *controllers.CommissionController,
 	adminWebConfigController *controllers.WebConfigurationController,
+	streamCtrl *controllers.StreamController,
+	reconciliationCtrl *controllers.ReconciliationController,
+	fxCtrl *controllers.FXController,
+	traderPayoutCtrl *controllers.TraderPayoutController,
+	sessions *session.Service,
+	twoFactor *twofactor.Service,
+	limiter *ratelimit.Limiter,
 ) {
 	authz := middleware.NewAuthzMiddleware(roleService)
+	idempotent := idempotency.Middleware(idempotency.NewGormStore(db), idempotency.Options{})
+	stepUp := twoFactor.RequireCode(authCtrl.UserSvc.TwoFactorRequired)
+	authLimit := limiter.Group("auth", ratelimit.ByIP)
 
 	admin := r.Group("/admin")
 	{
 		admin.GET("/login", authCtrl.ShowLoginPage)
-		admin.POST("/login", authCtrl.LoginUser)
+		admin.POST("/login", authLimit, authCtrl.LoginUser)
+		admin.GET("/login/2fa", authCtrl.ShowTwoFactorPage)
+		admin.POST("/login/2fa", authLimit, authCtrl.VerifyTwoFactorLogin)
+		admin.POST("/auth/refresh", authLimit, authCtrl.Refresh)
 	}
 
 	{
@@ -46,8 +63,16 @@ func WireAdminRoutes(
 			admin.POST("/api/signals", signalCtrl.CreateSignal)
 
 			protected := admin.Group("")
-			protected.Use(middleware.JWTMiddleware(cfg))
+			protected.Use(middleware.JWTMiddleware(cfg, sessions), limiter.Group("api", ratelimit.ByUser))
 			{
+				protected.POST("/auth/logout", authCtrl.Logout)
+
+				protected.GET("/api/2fa", authCtrl.GetTwoFactorStatus)
+				protected.POST("/api/2fa/enroll", authCtrl.EnrollTwoFactor)
+				protected.POST("/api/2fa/confirm", authCtrl.ConfirmTwoFactor)
+				protected.POST("/api/2fa/disable", authCtrl.DisableTwoFactor)
+				protected.POST("/api/2fa/recovery-codes", authCtrl.RegenerateRecoveryCodes)
+
 				protected.GET("/dashboard", authz.RequirePermission("view_dashboard"), dashCtrl.ShowDashboardPage)
 				protected.GET("/dashboard/stats", dashCtrl.GetDashboardStats)
 				protected.GET("/dashboard/charts", dashCtrl.GetChartData)
@@ -55,6 +80,9 @@ func WireAdminRoutes(
 				protected.GET("/dashboard/latest-signups", dashCtrl.GetLatestSignups)
 				protected.GET("/dashboard/market-data", dashCtrl.GetLiveMarketData)
 
+				protected.GET("/api/stream/sse", streamCtrl.StreamSSE)
+				protected.GET("/api/stream/ws", streamCtrl.StreamWebSocket)
+
 				protected.GET("/profile/view", authz.RequirePermission("view_admin_profile"), userCtrl.ShowAdminProfileViewPage)
 				protected.GET("/profile/edit", authz.RequirePermission("edit_admin_profile"), userCtrl.ShowAdminProfileEditPage)
 				protected.GET("/api/profile/view", authz.RequirePermission("view_admin_profile"), userCtrl.GetAdminProfileAPI)
@@ -67,6 +95,7 @@ func WireAdminRoutes(
 
 				protected.GET("/signals", signalCtrl.ShowLiveSignalsPage)
 				protected.GET("/api/signals", signalCtrl.GetLiveSignals)
+				protected.GET("/api/signals/:id/history", signalCtrl.GetSignalHistory)
 
 				protected.GET("/api/users/all", userCtrl.GetAllUsers)
 				protected.GET("/users/all", authz.RequirePermission("manage_users"), userCtrl.ShowUsersPage)
@@ -111,6 +140,9 @@ func WireAdminRoutes(
 				protected.GET("/api/users/for-role-assignment", userCtrl.GetUsersForRoleAssignment)
 				protected.POST("/api/users/assign-role", authz.RequirePermission("manage_roles"), userCtrl.AssignRoleToUser)
 				protected.DELETE("/api/users/:id", authz.RequirePermission("delete_users"), userCtrl.DeleteUser)
+				protected.POST("/api/users/:id/block", authz.RequirePermission("manage_users"), userCtrl.BlockUser)
+				protected.POST("/api/users/:id/unblock", authz.RequirePermission("manage_users"), userCtrl.UnblockUser)
+				protected.POST("/api/users/:id/unlock", authz.RequirePermission("manage_users"), userCtrl.UnlockUser)
 
 				protected.GET("/activity/live", activityCtrl.ShowLiveCopyingPage)
 				protected.GET("/activity/logs", activityCtrl.ShowTradeErrorsPage)
@@ -120,6 +152,8 @@ func WireAdminRoutes(
 
 				protected.GET("/subscriptions", subscriptionController.ShowSubscriptionsPage)
 				protected.GET("/api/subscriptions", subscriptionController.GetSubscriptions)
+				protected.POST("/api/subscriptions/:id/cancel", authz.RequirePermission("manage_wallet"), idempotent, subscriptionController.CancelSubscription)
+				protected.POST("/api/trader-subscriptions/:id/cancel", authz.RequirePermission("manage_wallet"), idempotent, subscriptionController.CancelTraderSubscription)
 				protected.GET("/subscription-plans", subscriptionController.ShowSubscriptionPlansPage)
 				protected.GET("/api/subscription-plans", subscriptionController.GetSubscriptionPlans)
 				protected.POST("/api/subscription-plans", subscriptionController.CreateSubscriptionPlan)
@@ -130,15 +164,15 @@ func WireAdminRoutes(
 
 				protected.GET("/settings/commission", commissionCtrl.ShowCommissionSettingsPage)
 				protected.GET("/api/settings/commission", commissionCtrl.GetCommissionSettings)
-				protected.POST("/api/settings/commission", commissionCtrl.UpdateCommissionSettings)
+				protected.POST("/api/settings/commission", stepUp, commissionCtrl.UpdateCommissionSettings)
 
 				protected.GET("/financials/wallet", adminWalletController.ShowAdminWalletPage)
 				protected.GET("/financials/wallet/transactions", adminWalletController.ShowAdminWalletTransactionPage)
 
 				protected.GET("/financials/api/wallet/summary", adminWalletController.GetAdminWalletSummary)
-				protected.POST("/financials/api/wallet/deposit", adminWalletController.AdminInitiateDeposit)
-				protected.POST("/financials/api/wallet/deposit/:deposit_id/verify", adminWalletController.AdminVerifyDeposit)
-				protected.POST("/financials/api/wallet/withdraw", adminWalletController.AdminRequestWithdrawal)
+				protected.POST("/financials/api/wallet/deposit", authz.RequirePermission("manage_wallet"), idempotent, adminWalletController.AdminInitiateDeposit)
+				protected.POST("/financials/api/wallet/deposit/:deposit_id/verify", authz.RequirePermission("manage_wallet"), idempotent, adminWalletController.AdminVerifyDeposit)
+				protected.POST("/financials/api/wallet/withdraw", authz.RequirePermission("manage_wallet"), stepUp, idempotent, adminWalletController.AdminRequestWithdrawal)
 				protected.GET("/financials/api/wallet/transactions", adminWalletController.AdminGetWalletTransactions)
 				protected.GET("/financials/api/transactions/all", adminWalletController.AdminGetAllPlatformTransactions)
 
@@ -147,7 +181,25 @@ func WireAdminRoutes(
 				protected.GET("/api/customer/transactions", adminWalletController.AdminGetAllCustomerTransactions)
 
 				protected.GET("/financials/api/withdrawals/pending", adminWalletController.GetPendingWithdrawals)
-				protected.POST("/financials/api/withdrawals/:id/action", adminWalletController.AdminApproveOrRejectWithdrawal)
+				protected.POST("/financials/api/withdrawals/:id/action", authz.RequirePermission("manage_wallet"), stepUp, idempotent, adminWalletController.AdminApproveOrRejectWithdrawal)
+				protected.GET("/financials/api/withdrawals/:id/history", adminWalletController.GetWithdrawalHistory)
+
+				protected.GET("/financials/reconciliation", reconciliationCtrl.ShowReconciliationPage)
+				protected.GET("/financials/api/reconciliation/discrepancies", reconciliationCtrl.ListDiscrepancies)
+				protected.GET("/financials/api/reconciliation/discrepancies/:id", reconciliationCtrl.GetDiscrepancy)
+				protected.POST("/financials/api/reconciliation/discrepancies/:id/notes", authz.RequirePermission("manage_wallet"), reconciliationCtrl.AnnotateDiscrepancy)
+				protected.POST("/financials/api/reconciliation/discrepancies/:id/resolve", authz.RequirePermission("manage_wallet"), reconciliationCtrl.ResolveDiscrepancy)
+				protected.POST("/financials/api/reconciliation/run", authz.RequirePermission("manage_wallet"), reconciliationCtrl.TriggerRun)
+				protected.GET("/financials/api/reconciliation/runs", reconciliationCtrl.ListRuns)
+
+				protected.GET("/financials/fx-rates", fxCtrl.ShowFXRatesPage)
+				protected.GET("/financials/api/fx-rates", fxCtrl.ListRates)
+				protected.POST("/financials/api/fx-rates", authz.RequirePermission("manage_wallet"), fxCtrl.SetRate)
+				protected.DELETE("/financials/api/fx-rates/:id", authz.RequirePermission("manage_wallet"), fxCtrl.DeleteRate)
+				protected.POST("/financials/api/fx-rates/refresh", authz.RequirePermission("manage_wallet"), fxCtrl.RefreshRates)
+
+				protected.GET("/financials/api/trader-payouts", traderPayoutCtrl.ListPayouts)
+				protected.POST("/financials/api/trader-payouts/run", authz.RequirePermission("manage_wallet"), idempotent, traderPayoutCtrl.RunPayouts)
 
 				protected.GET("/transactions", tranasactionController.GetTransactionsPage)
 				protected.GET("/api/transactions", tranasactionController.GetTransactionsAPI)
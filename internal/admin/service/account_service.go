@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/internal/admin/repository"
+	"github.com/fathimasithara01/tradeverse/pkg/accounttoken"
+	"github.com/fathimasithara01/tradeverse/pkg/mailer"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/session"
+	"gorm.io/gorm"
+)
+
+// ErrWeakPassword is returned for a new password that does not pass
+// IsValidPassword.
+var ErrWeakPassword = errors.New("password must be at least 8 characters and contain upper and lower case letters, a number and a symbol")
+
+// mailTimeout bounds how long a request waits for an email to be handed off.
+const mailTimeout = 15 * time.Second
+
+// IAccountService covers the account flows that go through the user's email:
+// verifying the address and resetting a forgotten password.
+type IAccountService interface {
+	SendVerificationEmail(email string) error
+	VerifyEmail(token string) error
+	RequestPasswordReset(email string) error
+	ResetPassword(token, newPassword string) error
+	IsVerified(userID uint) (bool, error)
+}
+
+type AccountService struct {
+	DB          *gorm.DB
+	UserRepo    repository.IUserRepository
+	Sessions    *session.Service
+	Tokens      *accounttoken.Service
+	Mailer      mailer.Mailer
+	LinkBaseURL string
+	VerifyTTL   time.Duration
+	ResetTTL    time.Duration
+}
+
+func NewAccountService(db *gorm.DB, userRepo repository.IUserRepository, sessions *session.Service, tokens *accounttoken.Service, mail mailer.Mailer, linkBaseURL string, verifyTTL, resetTTL time.Duration) IAccountService {
+	return &AccountService{
+		DB:          db,
+		UserRepo:    userRepo,
+		Sessions:    sessions,
+		Tokens:      tokens,
+		Mailer:      mail,
+		LinkBaseURL: strings.TrimRight(linkBaseURL, "/"),
+		VerifyTTL:   verifyTTL,
+		ResetTTL:    resetTTL,
+	}
+}
+
+// SendVerificationEmail mails a verification link to the account with this
+// address. Unknown and already verified addresses are ignored, so the result
+// does not reveal which addresses have accounts.
+func (s *AccountService) SendVerificationEmail(email string) error {
+	user, err := s.UserRepo.FindByEmail(email)
+	if err != nil {
+		return nil
+	}
+	if user.IsVerified {
+		return nil
+	}
+
+	token, err := s.Tokens.Issue(user.ID, models.AccountTokenVerifyEmail, s.VerifyTTL)
+	if err != nil {
+		return fmt.Errorf("failed to issue verification token: %w", err)
+	}
+	body := fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening this link:\n\n%s\n\nThe link expires in %s. If you did not create an account, you can ignore this email.\n",
+		user.Name, s.link("/verify-email", token), formatTTL(s.VerifyTTL))
+	return s.send(mailer.Message{To: user.Email, Subject: "Verify your email address", Body: body})
+}
+
+// VerifyEmail marks the token's account as verified.
+func (s *AccountService) VerifyEmail(token string) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		userID, err := s.Tokens.Consume(tx, token, models.AccountTokenVerifyEmail)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("is_verified", true).Error; err != nil {
+			return fmt.Errorf("failed to verify user %d: %w", userID, err)
+		}
+		return nil
+	})
+}
+
+// RequestPasswordReset mails a password reset link to the account with this
+// address. Unknown addresses are ignored, as for SendVerificationEmail.
+func (s *AccountService) RequestPasswordReset(email string) error {
+	user, err := s.UserRepo.FindByEmail(email)
+	if err != nil {
+		return nil
+	}
+	if user.IsBlocked {
+		return nil
+	}
+
+	token, err := s.Tokens.Issue(user.ID, models.AccountTokenPasswordReset, s.ResetTTL)
+	if err != nil {
+		return fmt.Errorf("failed to issue password reset token: %w", err)
+	}
+	body := fmt.Sprintf("Hi %s,\n\nWe received a request to reset your password. Choose a new one here:\n\n%s\n\nThe link expires in %s and can be used once. If you did not ask for this, you can ignore this email; your password has not been changed.\n",
+		user.Name, s.link("/reset-password", token), formatTTL(s.ResetTTL))
+	return s.send(mailer.Message{To: user.Email, Subject: "Reset your password", Body: body})
+}
+
+// ResetPassword sets a new password for the token's account and logs it out
+// everywhere. Following the emailed link also proves the address, so the
+// account is marked verified.
+func (s *AccountService) ResetPassword(token, newPassword string) error {
+	if !IsValidPassword(newPassword) {
+		return ErrWeakPassword
+	}
+
+	var userID uint
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		userID, err = s.Tokens.Consume(tx, token, models.AccountTokenPasswordReset)
+		if err != nil {
+			return err
+		}
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return fmt.Errorf("user %d not found: %w", userID, err)
+		}
+		if err := user.ChangePassword(newPassword); err != nil {
+			return fmt.Errorf("failed to hash password: %w", err)
+		}
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"password":            user.Password,
+			"password_changed_at": user.PasswordChangedAt,
+			"is_verified":         true,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update password of user %d: %w", userID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.Sessions.RevokeUser(userID, models.RevokeReasonPasswordChange)
+}
+
+func (s *AccountService) IsVerified(userID uint) (bool, error) {
+	user, err := s.UserRepo.GetUserByID(userID)
+	if err != nil {
+		return false, fmt.Errorf("user %d not found: %w", userID, err)
+	}
+	return user.IsVerified, nil
+}
+
+func (s *AccountService) link(path, token string) string {
+	return s.LinkBaseURL + path + "?token=" + url.QueryEscape(token)
+}
+
+func (s *AccountService) send(msg mailer.Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+	defer cancel()
+	if err := s.Mailer.Send(ctx, msg); err != nil {
+		log.Printf("[ACCOUNT SERVICE] Failed to send '%s' to %s: %v", msg.Subject, msg.To, err)
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+func formatTTL(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		return fmt.Sprintf("%d hours", int(d/time.Hour))
+	}
+	return fmt.Sprintf("%d minutes", int(d/time.Minute))
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/internal/admin/repository"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+)
+
+const maxCandlesPerQuery = 1000
+
+var ErrInvalidCandleQuery = errors.New("invalid candle query")
+
+// candleRetention is how long each interval is kept. Daily bars are kept forever.
+var candleRetention = map[models.CandleInterval]time.Duration{
+	models.CandleInterval1m: 48 * time.Hour,
+	models.CandleInterval5m: 14 * 24 * time.Hour,
+	models.CandleInterval1h: 180 * 24 * time.Hour,
+}
+
+type ICandleService interface {
+	RecordTick(ctx context.Context, symbol string, price, volume float64, at time.Time) error
+	GetCandles(ctx context.Context, symbol, interval string, from, to time.Time) ([]models.Candle, error)
+	ApplyRetention(ctx context.Context) error
+}
+
+type CandleService struct {
+	Repo repository.ICandleRepository
+}
+
+func NewCandleService(repo repository.ICandleRepository) ICandleService {
+	return &CandleService{Repo: repo}
+}
+
+// RecordTick folds one market data tick into the current bar of every interval.
+func (s *CandleService) RecordTick(ctx context.Context, symbol string, price, volume24h float64, at time.Time) error {
+	if price <= 0 {
+		return nil
+	}
+	symbol = strings.ToUpper(symbol)
+
+	for _, interval := range models.CandleIntervals {
+		candle := &models.Candle{
+			Symbol:    symbol,
+			Interval:  interval,
+			OpenTime:  interval.BucketStart(at),
+			Open:      price,
+			High:      price,
+			Low:       price,
+			Close:     price,
+			Volume24H: volume24h,
+			TickCount: 1,
+			UpdatedAt: at,
+		}
+		if err := s.Repo.UpsertTick(ctx, candle); err != nil {
+			return fmt.Errorf("failed to record %s candle for %s: %w", interval, symbol, err)
+		}
+	}
+	return nil
+}
+
+func (s *CandleService) GetCandles(ctx context.Context, symbol, interval string, from, to time.Time) ([]models.Candle, error) {
+	if strings.TrimSpace(symbol) == "" {
+		return nil, fmt.Errorf("%w: symbol is required", ErrInvalidCandleQuery)
+	}
+	parsed, err := models.ParseCandleInterval(interval)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCandleQuery, err)
+	}
+
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-100 * parsed.Duration())
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidCandleQuery)
+	}
+
+	return s.Repo.GetCandles(ctx, strings.ToUpper(symbol), parsed, from, to, maxCandlesPerQuery)
+}
+
+// ApplyRetention downsamples bars that are about to expire into the next
+// coarser interval and then deletes them.
+func (s *CandleService) ApplyRetention(ctx context.Context) error {
+	for i, interval := range models.CandleIntervals {
+		keep, ok := candleRetention[interval]
+		if !ok {
+			continue
+		}
+		cutoff := time.Now().Add(-keep)
+
+		if i+1 < len(models.CandleIntervals) {
+			target := models.CandleIntervals[i+1]
+			if _, err := s.Repo.DownsampleBefore(ctx, interval, target, cutoff); err != nil {
+				return err
+			}
+		}
+
+		deleted, err := s.Repo.DeleteOlderThan(ctx, interval, cutoff)
+		if err != nil {
+			return err
+		}
+		log.Printf("Candle retention: removed %d %s candles older than %s", deleted, interval, cutoff.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,442 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/internal/admin/repository"
+	"github.com/fathimasithara01/tradeverse/pkg/ledger"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCopyInsufficientFunds = errors.New("insufficient wallet balance to copy trade")
+	ErrCopyMaxOpenTrades     = errors.New("maximum number of open copied trades reached")
+	ErrCopyDailyLossCap      = errors.New("daily loss cap reached, copy profile paused")
+	ErrCopyQuantityTooSmall  = errors.New("copy profile sizing produced a zero quantity")
+)
+
+type ICopyTradeService interface {
+	MirrorOpenedTrade(ctx context.Context, master *models.Trade) error
+	MirrorModifiedTrade(ctx context.Context, master *models.Trade) error
+	MirrorClosedTrade(ctx context.Context, master *models.Trade) error
+	SyncMasterTrades(ctx context.Context) error
+}
+
+// CopyTradeService mirrors a master trader's trades into the wallets of everyone
+// holding an active CopySession on that master.
+type CopyTradeService struct {
+	Repo repository.ICopyTradeRepository
+	DB   *gorm.DB
+
+	mu sync.Mutex
+}
+
+func NewCopyTradeService(repo repository.ICopyTradeRepository, db *gorm.DB) *CopyTradeService {
+	return &CopyTradeService{Repo: repo, DB: db}
+}
+
+// SyncMasterTrades picks up every master trade changed since the stored cursor
+// and replays it onto follower accounts. Opens are mirrored only for trades
+// opened after the cursor; later updates of an open trade only carry their
+// stop-loss and take-profit over. The first run starts the cursor at the
+// current time instead of replaying the whole trade history. When a trade
+// fails to mirror, the cursor stops just before it so the next run retries
+// it; every mirror step is idempotent.
+func (s *CopyTradeService) SyncMasterTrades(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	startedAt := time.Now()
+	cursor, err := s.Repo.GetSyncCursor(ctx, models.CopySyncCursorMasterTrades)
+	if err != nil {
+		return err
+	}
+	if cursor == nil {
+		return s.Repo.SaveSyncCursor(ctx, models.CopySyncCursorMasterTrades, startedAt)
+	}
+
+	since := cursor.SyncedAt
+	trades, err := s.Repo.GetMasterTradesUpdatedSince(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	next := startedAt
+	var errs []error
+	for i := range trades {
+		master := &trades[i]
+		if err := s.mirrorMasterTrade(ctx, master, since); err != nil {
+			errs = append(errs, fmt.Errorf("master trade %d: %w", master.ID, err))
+			// Trades come oldest first, so the first failure bounds the cursor.
+			if retryFrom := master.UpdatedAt.Add(-time.Microsecond); retryFrom.Before(next) {
+				next = retryFrom
+			}
+		}
+	}
+
+	if next.Before(since) {
+		next = since
+	}
+	if err := s.Repo.SaveSyncCursor(ctx, models.CopySyncCursorMasterTrades, next); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *CopyTradeService) mirrorMasterTrade(ctx context.Context, master *models.Trade, since time.Time) error {
+	switch master.Status {
+	case models.TradeStatusOpen:
+		var errs []error
+		if master.OpenedAt != nil && master.OpenedAt.After(since) {
+			errs = append(errs, s.MirrorOpenedTrade(ctx, master))
+		}
+		errs = append(errs, s.MirrorModifiedTrade(ctx, master))
+		return errors.Join(errs...)
+	case models.TradeStatusClosed, models.TradeStatusCancelled:
+		return s.MirrorClosedTrade(ctx, master)
+	}
+	return nil
+}
+
+func (s *CopyTradeService) MirrorOpenedTrade(ctx context.Context, master *models.Trade) error {
+	if master.IsCopyTrade || master.Status != models.TradeStatusOpen {
+		return nil
+	}
+
+	sessions, err := s.Repo.GetActiveSessionsByMaster(ctx, master.TraderID)
+	if err != nil {
+		return err
+	}
+
+	for i := range sessions {
+		session := &sessions[i]
+		if master.OpenedAt != nil && session.StartedAt.After(*master.OpenedAt) {
+			continue
+		}
+
+		existing, err := s.Repo.GetFollowerTrade(ctx, master.ID, session.FollowerID)
+		if err != nil {
+			log.Printf("Copy engine: failed to check follower %d for master trade %d: %v", session.FollowerID, master.ID, err)
+			continue
+		}
+		if existing != nil {
+			continue
+		}
+
+		s.openFollowerTrade(ctx, session, master)
+	}
+	return nil
+}
+
+func (s *CopyTradeService) MirrorModifiedTrade(ctx context.Context, master *models.Trade) error {
+	if master.IsCopyTrade {
+		return nil
+	}
+
+	followers, err := s.Repo.GetOpenFollowerTrades(ctx, master.ID)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range followers {
+		follower := &followers[i]
+		if samePrice(follower.StopLossPrice, master.StopLossPrice) && samePrice(follower.TakeProfitPrice, master.TakeProfitPrice) {
+			continue
+		}
+
+		startedAt := time.Now()
+		follower.StopLossPrice = master.StopLossPrice
+		follower.TakeProfitPrice = master.TakeProfitPrice
+		err := s.Repo.UpdateTrade(s.DB.WithContext(ctx), follower)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("follower trade %d: %w", follower.ID, err))
+		}
+
+		session, _ := s.Repo.GetSessionByPair(ctx, derefUint(follower.CustomerID), master.TraderID)
+		s.recordTradeLog(ctx, session, master, follower, models.CopyActionModify, startedAt, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *CopyTradeService) MirrorClosedTrade(ctx context.Context, master *models.Trade) error {
+	if master.IsCopyTrade {
+		return nil
+	}
+
+	followers, err := s.Repo.GetOpenFollowerTrades(ctx, master.ID)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range followers {
+		follower := &followers[i]
+		session, err := s.Repo.GetSessionByPair(ctx, derefUint(follower.CustomerID), master.TraderID)
+		if err != nil {
+			log.Printf("Copy engine: failed to load session for follower trade %d: %v", follower.ID, err)
+		}
+		if err := s.closeFollowerTrade(ctx, session, master, follower); err != nil {
+			errs = append(errs, fmt.Errorf("follower trade %d: %w", follower.ID, err))
+			continue
+		}
+
+		profile, err := s.Repo.GetCopyProfile(ctx, derefUint(follower.CustomerID), master.TraderID)
+		if err != nil {
+			log.Printf("Copy engine: failed to load copy profile for follower trade %d: %v", follower.ID, err)
+			continue
+		}
+		if profile != nil && profile.IsActive() {
+			if err := s.enforceDailyLossCap(ctx, profile); errors.Is(err, ErrCopyDailyLossCap) {
+				log.Printf("Copy engine: paused copy profile %d after daily loss cap was reached", profile.ID)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkCopyLimits enforces the open-trade and daily-loss limits of a profile
+// before a new follower trade is opened.
+func (s *CopyTradeService) checkCopyLimits(ctx context.Context, profile *models.CopyProfile) error {
+	if profile.MaxOpenTrades > 0 {
+		open, err := s.Repo.CountOpenCopyTrades(ctx, profile.FollowerID, profile.MasterID)
+		if err != nil {
+			return err
+		}
+		if open >= int64(profile.MaxOpenTrades) {
+			return ErrCopyMaxOpenTrades
+		}
+	}
+	return s.enforceDailyLossCap(ctx, profile)
+}
+
+// enforceDailyLossCap pauses the profile once today's realised copy losses reach its cap.
+func (s *CopyTradeService) enforceDailyLossCap(ctx context.Context, profile *models.CopyProfile) error {
+	if profile.DailyLossCap.IsPositive() && profile.ID != 0 {
+		now := time.Now()
+		startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		pnl, err := s.Repo.GetCopyPnLSince(ctx, profile.FollowerID, profile.MasterID, startOfDay)
+		if err != nil {
+			return err
+		}
+		if loss := money.FromFloat(-pnl); loss >= profile.DailyLossCap {
+			reason := fmt.Sprintf("daily loss %s reached cap %s", loss, profile.DailyLossCap)
+			if err := s.Repo.PauseCopyProfile(ctx, profile, reason); err != nil {
+				return err
+			}
+			profile.Status = models.CopyProfilePaused
+			profile.PausedReason = reason
+			return ErrCopyDailyLossCap
+		}
+	}
+	return nil
+}
+
+func (s *CopyTradeService) openFollowerTrade(ctx context.Context, session *models.CopySession, master *models.Trade) {
+	startedAt := time.Now()
+
+	profile, err := s.Repo.GetCopyProfile(ctx, session.FollowerID, session.MasterID)
+	if err != nil {
+		s.recordTradeLog(ctx, session, master, nil, models.CopyActionOpen, startedAt, err)
+		return
+	}
+	if profile == nil {
+		profile = models.DefaultCopyProfile(session.FollowerID, session.MasterID)
+	}
+	if !profile.IsActive() || !profile.AllowsSymbol(master.Symbol) {
+		return
+	}
+	if err := s.checkCopyLimits(ctx, profile); err != nil {
+		s.recordTradeLog(ctx, session, master, nil, models.CopyActionOpen, startedAt, err)
+		return
+	}
+
+	price := master.FillPrice()
+	leverage := master.Leverage
+	if leverage == 0 {
+		leverage = 1
+	}
+
+	follower := &models.Trade{
+		TraderID:        master.TraderID,
+		Symbol:          master.Symbol,
+		TradeType:       master.TradeType,
+		Side:            master.Side,
+		EntryPrice:      price,
+		ExecutedPrice:   &price,
+		Leverage:        leverage,
+		StopLossPrice:   master.StopLossPrice,
+		TakeProfitPrice: master.TakeProfitPrice,
+		Status:          models.TradeStatusOpen,
+		OpenedAt:        models.TimePtr(startedAt),
+		IsCopyTrade:     true,
+		OriginalTradeID: &master.ID,
+		CustomerID:      &session.FollowerID,
+	}
+	if profile.ID != 0 {
+		follower.CopyProfileID = &profile.ID
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		wallet, err := s.Repo.GetWalletForUpdate(tx, session.FollowerID)
+		if err != nil {
+			return err
+		}
+
+		follower.Quantity = profile.CopyQuantity(master, wallet.Balance.Float64())
+		if follower.Quantity <= 0 {
+			return ErrCopyQuantityTooSmall
+		}
+		margin := money.FromFloat(follower.MarginRequired())
+		if wallet.Balance < margin {
+			return fmt.Errorf("%w: balance %s, required %s", ErrCopyInsufficientFunds, wallet.Balance, margin)
+		}
+
+		if err := s.Repo.CreateTrade(tx, follower); err != nil {
+			return fmt.Errorf("failed to create follower trade: %w", err)
+		}
+
+		walletTx := models.WalletTransaction{
+			Type:            models.TxTypeTradeOpeningFunds,
+			TransactionType: models.TxTypeDebit,
+			Name:            "Copy Trade Opening Funds",
+			TransactionID:   fmt.Sprintf("COPY_OPEN_%d_%d_%d", master.ID, follower.ID, time.Now().UnixNano()),
+			TradeID:         &follower.ID,
+			CopyTradeID:     &master.ID,
+		}
+		if _, err := ledger.Post(tx, ledger.Entry{
+			Type:        models.TxTypeTradeOpeningFunds,
+			Reference:   fmt.Sprintf("COPY_OPEN_%d_%d", master.ID, session.FollowerID),
+			Description: fmt.Sprintf("Margin for copying %s %s trade %d of trader %d", master.Side, master.Symbol, master.ID, master.TraderID),
+			Currency:    wallet.Currency,
+			Legs: []ledger.Leg{
+				ledger.Debit(ledger.Wallet(wallet.UserID), margin, &walletTx),
+				ledger.Credit(ledger.TradeMargin(wallet.UserID), margin, nil),
+			},
+		}); err != nil {
+			return fmt.Errorf("failed to debit follower wallet: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		follower.ID = 0
+	}
+
+	s.recordTradeLog(ctx, session, master, follower, models.CopyActionOpen, startedAt, err)
+}
+
+// closeFollowerTrade settles the follower trade at the master's close price.
+// A failure leaves the trade open and is returned so the sync retries it.
+func (s *CopyTradeService) closeFollowerTrade(ctx context.Context, session *models.CopySession, master *models.Trade, follower *models.Trade) error {
+	startedAt := time.Now()
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		margin := money.FromFloat(follower.MarginRequired())
+		var pnl money.Amount
+
+		if master.Status == models.TradeStatusClosed && master.ClosePrice != nil {
+			pnl = money.FromFloat(follower.CalculatePnL(*master.ClosePrice))
+			follower.Status = models.TradeStatusClosed
+			follower.ClosePrice = master.ClosePrice
+			follower.CloseReason = models.CloseReasonCopy
+		} else {
+			follower.Status = models.TradeStatusCancelled
+		}
+		follower.ClosedAt = models.TimePtr(startedAt)
+		realised := pnl.Float64()
+		follower.Pnl = &realised
+
+		payout := money.Max(margin+pnl, 0)
+
+		if err := s.Repo.UpdateTrade(tx, follower); err != nil {
+			return fmt.Errorf("failed to close follower trade: %w", err)
+		}
+
+		// The margin leaves the trade margin account; whatever the follower does
+		// not get back (or the profit on top of it) settles against the market.
+		followerID := derefUint(follower.CustomerID)
+		wallet, err := s.Repo.GetWalletForUpdate(tx, followerID)
+		if err != nil {
+			return err
+		}
+
+		walletTx := models.WalletTransaction{
+			Type:            models.TxTypeTradeClosingFunds,
+			TransactionType: models.TxTypeCredit,
+			Name:            "Copy Trade Closing Funds",
+			TransactionID:   fmt.Sprintf("COPY_CLOSE_%d_%d_%d", master.ID, follower.ID, time.Now().UnixNano()),
+			TradeID:         &follower.ID,
+			CopyTradeID:     &master.ID,
+		}
+		if _, err := ledger.Post(tx, ledger.Entry{
+			Type:        models.TxTypeTradeClosingFunds,
+			Reference:   fmt.Sprintf("COPY_CLOSE_%d_%d", master.ID, followerID),
+			Description: fmt.Sprintf("Margin %s and PnL %s returned for copy of trade %d", margin, pnl, master.ID),
+			Currency:    wallet.Currency,
+			Legs: []ledger.Leg{
+				ledger.Debit(ledger.TradeMargin(followerID), margin, nil),
+				ledger.Credit(ledger.Wallet(followerID), payout, &walletTx),
+				ledger.Credit(ledger.MarketSettlement(), margin-payout, nil),
+			},
+		}); err != nil {
+			return fmt.Errorf("failed to credit follower wallet: %w", err)
+		}
+
+		if session != nil {
+			if err := s.Repo.AddSessionProfit(tx, session.ID, realised); err != nil {
+				return fmt.Errorf("failed to update copy session profit: %w", err)
+			}
+		}
+		return nil
+	})
+
+	s.recordTradeLog(ctx, session, master, follower, models.CopyActionClose, startedAt, err)
+	return err
+}
+
+func (s *CopyTradeService) recordTradeLog(ctx context.Context, session *models.CopySession, master, follower *models.Trade, action models.CopyAction, startedAt time.Time, err error) {
+	entry := &models.TradeLog{
+		MasterTradeID: strconv.FormatUint(uint64(master.ID), 10),
+		Action:        action,
+		Status:        models.LogStatusSuccess,
+		ExecutionTime: time.Since(startedAt).Milliseconds(),
+		Timestamp:     time.Now(),
+	}
+	if session != nil {
+		entry.CopySessionID = session.ID
+	}
+	if follower != nil && follower.ID != 0 {
+		entry.FollowerTradeID = strconv.FormatUint(uint64(follower.ID), 10)
+	}
+	if err != nil {
+		entry.Status = models.LogStatusFailed
+		entry.ErrorMessage = err.Error()
+		log.Printf("Copy engine: %s of master trade %d failed for session %d: %v", action, master.ID, entry.CopySessionID, err)
+	}
+
+	if logErr := s.Repo.CreateTradeLog(ctx, entry); logErr != nil {
+		log.Printf("Copy engine: failed to write trade log for master trade %d: %v", master.ID, logErr)
+	}
+}
+
+func samePrice(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func derefUint(v *uint) uint {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
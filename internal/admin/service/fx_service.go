@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/fathimasithara01/tradeverse/internal/admin/repository"
+	"github.com/fathimasithara01/tradeverse/pkg/fx"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+)
+
+var (
+	ErrInvalidFXRate  = errors.New("invalid exchange rate")
+	ErrNoFXRateSource = errors.New("no exchange rate feed is configured")
+)
+
+type IFXService interface {
+	ListRates(ctx context.Context) ([]models.FXRate, error)
+	SetRate(ctx context.Context, adminID uint, input models.FXRateInput) (*models.FXRate, error)
+	DeleteRate(ctx context.Context, id uint) error
+	Refresh(ctx context.Context) (int, error)
+}
+
+type FXService struct {
+	Repo   repository.IFXRateRepository
+	Source fx.Source
+}
+
+// NewFXService maintains the exchange rate table. source may be nil, in which
+// case rates are only ever set by hand.
+func NewFXService(repo repository.IFXRateRepository, source fx.Source) IFXService {
+	return &FXService{Repo: repo, Source: source}
+}
+
+func (s *FXService) ListRates(ctx context.Context) ([]models.FXRate, error) {
+	return s.Repo.ListRates(ctx)
+}
+
+// SetRate records a rate entered by an admin. It stays until the admin or
+// the feed replaces it.
+func (s *FXService) SetRate(ctx context.Context, adminID uint, input models.FXRateInput) (*models.FXRate, error) {
+	base, quote := strings.ToUpper(strings.TrimSpace(input.BaseCurrency)), strings.ToUpper(strings.TrimSpace(input.QuoteCurrency))
+	if base == quote {
+		return nil, fmt.Errorf("%w: base and quote currency are both %s", ErrInvalidFXRate, base)
+	}
+	if !input.Rate.IsPositive() {
+		return nil, fmt.Errorf("%w: rate must be positive", ErrInvalidFXRate)
+	}
+
+	rate := &models.FXRate{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Rate:          input.Rate,
+		Source:        models.FXSourceManual,
+		UpdatedBy:     &adminID,
+	}
+	if err := s.Repo.UpsertRate(ctx, rate); err != nil {
+		return nil, err
+	}
+	log.Printf("Admin %d set %s/%s rate to %s", adminID, base, quote, input.Rate)
+	return rate, nil
+}
+
+func (s *FXService) DeleteRate(ctx context.Context, id uint) error {
+	return s.Repo.DeleteRate(ctx, id)
+}
+
+// Refresh pulls the latest rates from the feed and stores them, returning how
+// many were updated.
+func (s *FXService) Refresh(ctx context.Context) (int, error) {
+	if s.Source == nil {
+		return 0, ErrNoFXRateSource
+	}
+	rates, err := s.Source.Fetch(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i := range rates {
+		if err := s.Repo.UpsertRate(ctx, &rates[i]); err != nil {
+			return i, err
+		}
+	}
+	log.Printf("Refreshed %d exchange rates from %s", len(rates), s.Source.Name())
+	return len(rates), nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/internal/admin/repository"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+)
+
+type IPerformanceService interface {
+	RecalculateAll(ctx context.Context) error
+	Recalculate(ctx context.Context, traderID uint) (*models.TraderPerformance, error)
+}
+
+type PerformanceService struct {
+	Repo repository.IPerformanceRepository
+}
+
+func NewPerformanceService(repo repository.IPerformanceRepository) IPerformanceService {
+	return &PerformanceService{Repo: repo}
+}
+
+// RecalculateAll refreshes the stored performance snapshot of every trader. A
+// failure for one trader is logged and does not stop the others.
+func (s *PerformanceService) RecalculateAll(ctx context.Context) error {
+	traderIDs, err := s.Repo.GetTraderIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, traderID := range traderIDs {
+		if _, err := s.Recalculate(ctx, traderID); err != nil {
+			log.Printf("Error recalculating performance for trader %d: %v", traderID, err)
+			failed++
+		}
+	}
+	log.Printf("Recalculated performance for %d traders (%d failed).", len(traderIDs)-failed, failed)
+	return nil
+}
+
+func (s *PerformanceService) Recalculate(ctx context.Context, traderID uint) (*models.TraderPerformance, error) {
+	trades, err := s.Repo.GetClosedTrades(ctx, traderID, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	signals, err := s.Repo.GetResolvedSignals(ctx, traderID, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	copiers, err := s.Repo.CountActiveCopiers(ctx, traderID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	all := models.CalculatePerformance(trades, signals, time.Time{}, now)
+	all.ActiveCopiers = uint(copiers)
+	periodROI := func(p models.PerformancePeriod) float64 {
+		return models.CalculatePerformance(trades, nil, p.Since(now), now).ROI
+	}
+
+	perf := &models.TraderPerformance{TraderID: traderID, IsPublicProfile: true}
+	perf.ApplyReport(all,
+		periodROI(models.PerformancePeriodDaily),
+		periodROI(models.PerformancePeriodWeekly),
+		periodROI(models.PerformancePeriodMonthly),
+	)
+	if err := s.Repo.UpsertPerformance(ctx, perf); err != nil {
+		return nil, fmt.Errorf("failed to store performance: %w", err)
+	}
+	return perf, nil
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/internal/admin/repository"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+)
+
+const recentReconciliationRuns = 20
+
+type IReconciliationService interface {
+	Run(ctx context.Context, triggeredBy *uint) (*models.ReconciliationRun, error)
+	ListRuns(ctx context.Context) ([]models.ReconciliationRun, error)
+	ListDiscrepancies(ctx context.Context, filter models.DiscrepancyFilter) ([]models.WalletDiscrepancy, int64, error)
+	GetDiscrepancy(ctx context.Context, id uint) (*models.WalletDiscrepancy, error)
+	Annotate(ctx context.Context, id, adminID uint, note string) (*models.WalletDiscrepancy, error)
+	Resolve(ctx context.Context, id, adminID uint, note string) (*models.WalletDiscrepancy, error)
+}
+
+type ReconciliationService struct {
+	Repo repository.IReconciliationRepository
+}
+
+func NewReconciliationService(repo repository.IReconciliationRepository) IReconciliationService {
+	return &ReconciliationService{Repo: repo}
+}
+
+// Run reconciles every wallet's transaction chain, looks for deposits and
+// withdrawals with no wallet transaction behind them and checks the ledger,
+// storing whatever it finds against a new run. A discrepancy already on record
+// keeps its review status; a resolved one that shows up again stays resolved
+// but its LastSeenAt moves, which is what the review page sorts by.
+func (s *ReconciliationService) Run(ctx context.Context, triggeredBy *uint) (*models.ReconciliationRun, error) {
+	run := &models.ReconciliationRun{
+		Status:      models.ReconciliationRunning,
+		TriggeredBy: triggeredBy,
+		StartedAt:   time.Now(),
+	}
+	if err := s.Repo.CreateRun(ctx, run); err != nil {
+		return nil, err
+	}
+
+	found, err := s.collect(ctx, run)
+	if err == nil {
+		for i := range found {
+			found[i].RunID = run.ID
+			if err = s.Repo.UpsertDiscrepancy(ctx, &found[i]); err != nil {
+				break
+			}
+		}
+	}
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.DiscrepanciesFound = len(found)
+	run.Status = models.ReconciliationCompleted
+	if err != nil {
+		run.Status = models.ReconciliationFailed
+		run.Error = err.Error()
+	}
+	if updateErr := s.Repo.UpdateRun(ctx, run); updateErr != nil {
+		log.Printf("Error saving reconciliation run %d: %v", run.ID, updateErr)
+	}
+	if err != nil {
+		return run, err
+	}
+
+	log.Printf("Reconciliation run %d checked %d wallets and %d transactions, found %d discrepancies.",
+		run.ID, run.WalletsChecked, run.TransactionsChecked, run.DiscrepanciesFound)
+	return run, nil
+}
+
+func (s *ReconciliationService) collect(ctx context.Context, run *models.ReconciliationRun) ([]models.WalletDiscrepancy, error) {
+	wallets, err := s.Repo.ListWallets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []models.WalletDiscrepancy
+	for _, wallet := range wallets {
+		txs, err := s.Repo.GetWalletTransactions(ctx, wallet.ID)
+		if err != nil {
+			return nil, err
+		}
+		run.WalletsChecked++
+		run.TransactionsChecked += len(txs)
+		found = append(found, models.ReconcileWallet(wallet, txs)...)
+	}
+
+	deposits, err := s.Repo.FindOrphanDeposits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deposits {
+		depositID, userID := d.ID, d.UserID
+		found = append(found, models.WalletDiscrepancy{
+			Fingerprint:      fmt.Sprintf("%s:deposit:%d", models.DiscrepancyOrphanDeposit, d.ID),
+			Kind:             models.DiscrepancyOrphanDeposit,
+			UserID:           &userID,
+			DepositRequestID: &depositID,
+			Expected:         d.Amount,
+			Details:          fmt.Sprintf("deposit %d of %s %s succeeded but no wallet transaction records it", d.ID, d.Amount.Format(d.Currency), d.Currency),
+		})
+	}
+
+	withdrawals, err := s.Repo.FindOrphanWithdrawals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range withdrawals {
+		withdrawID, userID := w.ID, w.UserID
+		found = append(found, models.WalletDiscrepancy{
+			Fingerprint:       fmt.Sprintf("%s:withdrawal:%d", models.DiscrepancyOrphanWithdrawal, w.ID),
+			Kind:              models.DiscrepancyOrphanWithdrawal,
+			UserID:            &userID,
+			WithdrawRequestID: &withdrawID,
+			Expected:          w.Amount,
+			Details:           fmt.Sprintf("withdrawal %d of %s %s is %s but no wallet transaction records it", w.ID, w.Amount.Format(w.Currency), w.Currency, w.Status),
+		})
+	}
+
+	report, err := s.Repo.VerifyLedger(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range report.Entries {
+		found = append(found, models.WalletDiscrepancy{
+			Fingerprint: fmt.Sprintf("%s:entry:%d:%s", models.DiscrepancyLedgerMismatch, e.EntryID, e.Currency),
+			Kind:        models.DiscrepancyLedgerMismatch,
+			Actual:      e.Sum,
+			Details:     fmt.Sprintf("journal entry %d does not balance, its %s postings sum to %s", e.EntryID, e.Currency, e.Sum),
+		})
+	}
+	for _, a := range report.Accounts {
+		d := models.WalletDiscrepancy{
+			Fingerprint: fmt.Sprintf("%s:account:%d", models.DiscrepancyLedgerMismatch, a.AccountID),
+			Kind:        models.DiscrepancyLedgerMismatch,
+			WalletID:    a.WalletID,
+			Expected:    a.JournalBalance,
+			Actual:      a.AccountBalance,
+			Details: fmt.Sprintf("ledger account %d (%s, owner %d) holds %s but its postings sum to %s",
+				a.AccountID, a.Kind, a.OwnerID, a.AccountBalance, a.JournalBalance),
+		}
+		if a.WalletBalance != nil && *a.WalletBalance != a.AccountBalance {
+			d.Details += fmt.Sprintf("; wallet balance is %s", *a.WalletBalance)
+		}
+		found = append(found, d)
+	}
+
+	return found, nil
+}
+
+func (s *ReconciliationService) ListRuns(ctx context.Context) ([]models.ReconciliationRun, error) {
+	return s.Repo.ListRuns(ctx, recentReconciliationRuns)
+}
+
+func (s *ReconciliationService) ListDiscrepancies(ctx context.Context, filter models.DiscrepancyFilter) ([]models.WalletDiscrepancy, int64, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+	return s.Repo.ListDiscrepancies(ctx, filter)
+}
+
+func (s *ReconciliationService) GetDiscrepancy(ctx context.Context, id uint) (*models.WalletDiscrepancy, error) {
+	return s.Repo.GetDiscrepancy(ctx, id)
+}
+
+// Annotate appends a note to the discrepancy, stamped with the admin and time.
+func (s *ReconciliationService) Annotate(ctx context.Context, id, adminID uint, note string) (*models.WalletDiscrepancy, error) {
+	d, err := s.Repo.GetDiscrepancy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	appendNote(d, adminID, note)
+	if err := s.Repo.UpdateDiscrepancy(ctx, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Resolve closes the discrepancy. The note explains what was done about it and
+// is kept both as the resolution and in the running notes.
+func (s *ReconciliationService) Resolve(ctx context.Context, id, adminID uint, note string) (*models.WalletDiscrepancy, error) {
+	d, err := s.Repo.GetDiscrepancy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d.Status == models.DiscrepancyStatusResolved {
+		return nil, fmt.Errorf("discrepancy %d is already resolved", id)
+	}
+
+	now := time.Now()
+	d.Status = models.DiscrepancyStatusResolved
+	d.ResolvedBy = &adminID
+	d.ResolvedAt = &now
+	d.ResolutionNote = note
+	appendNote(d, adminID, "Resolved: "+note)
+	if err := s.Repo.UpdateDiscrepancy(ctx, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func appendNote(d *models.WalletDiscrepancy, adminID uint, note string) {
+	line := fmt.Sprintf("[%s admin %d] %s", time.Now().UTC().Format(time.RFC3339), adminID, strings.TrimSpace(note))
+	if d.Notes == "" {
+		d.Notes = line
+		return
+	}
+	d.Notes += "\n" + line
+}
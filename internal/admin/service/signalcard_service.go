@@ -2,12 +2,14 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/fathimasithara01/tradeverse/internal/admin/repository"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/stream"
 )
 
 type ILiveSignalService interface {
@@ -16,25 +18,41 @@ type ILiveSignalService interface {
 	UpdateAllSignalsCurrentPrices(ctx context.Context) error
 	CheckAndSetSignalStatuses(ctx context.Context) error
 	GetMarketDataBySymbol(ctx context.Context, symbol string) (*models.MarketData, error)
+	GetSignalHistory(ctx context.Context, signalID uint) ([]models.SignalStatusHistory, error)
 }
 
+var ErrInvalidSignal = errors.New("invalid signal")
+
 type LiveSignalService struct {
 	signalRepo repository.ISignalRepository
+	hub        *stream.Hub
 }
 
-func NewLiveSignalService(signalRepo repository.ISignalRepository) ILiveSignalService {
-	return &LiveSignalService{signalRepo: signalRepo}
+func NewLiveSignalService(signalRepo repository.ISignalRepository, hub *stream.Hub) ILiveSignalService {
+	return &LiveSignalService{signalRepo: signalRepo, hub: hub}
 }
 
+// CreateSignal stores the signal as Pending. The status cron activates it once
+// its start date has passed and the price reaches the entry.
 func (s *LiveSignalService) CreateSignal(ctx context.Context, signal *models.Signal) (*models.Signal, error) {
-	if signal.TradeStartDate.After(time.Now()) {
-		signal.Status = "Pending"
-	} else {
-		signal.Status = "Active"
+	if err := signal.NormalizeDirection(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignal, err)
+	}
+	if err := signal.PrepareTargets(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignal, err)
 	}
+	if err := signal.ValidateLevels(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignal, err)
+	}
+	signal.Status = models.SignalStatusPending
 	log.Printf("Attempting to create signal: Symbol=%s, Trader=%s, Entry=%.4f, Target=%.4f, SL=%.4f, InitialStatus=%s, InitialCurrentPrice=%.4f",
 		signal.Symbol, signal.TraderName, signal.EntryPrice, signal.TargetPrice, signal.StopLoss, signal.Status, signal.CurrentPrice)
-	return s.signalRepo.CreateSignal(ctx, signal)
+	created, err := s.signalRepo.CreateSignal(ctx, signal)
+	if err != nil {
+		return nil, err
+	}
+	s.hub.PublishSignalCreated(created.TraderID, *created)
+	return created, nil
 }
 
 func (s *LiveSignalService) GetAllSignals(ctx context.Context) ([]models.Signal, error) {
@@ -60,7 +78,7 @@ func (s *LiveSignalService) UpdateAllSignalsCurrentPrices(ctx context.Context) e
 	}
 
 	for _, signal := range signals {
-		if signal.Status == "Target Hit" || signal.Status == "Stop Loss" {
+		if signal.Status.IsTerminal() {
 			continue
 		}
 
@@ -95,7 +113,7 @@ func (s *LiveSignalService) UpdateAllSignalsCurrentPrices(ctx context.Context) e
 }
 
 func (s *LiveSignalService) CheckAndSetSignalStatuses(ctx context.Context) error {
-	log.Println("Starting signal status check (SL/Target/Activation)...")
+	log.Println("Starting signal status check (SL/Target/Activation/Expiry)...")
 
 	signals, err := s.signalRepo.GetActiveAndPendingSignals(ctx)
 	if err != nil {
@@ -104,50 +122,57 @@ func (s *LiveSignalService) CheckAndSetSignalStatuses(ctx context.Context) error
 	}
 	log.Printf("Found %d active/pending signals to check status.", len(signals))
 
+	now := time.Now()
 	for _, signal := range signals {
-		log.Printf("Checking signal ID %d (Symbol: %s, Current Status: %s, Current Price: %.4f, Entry: %.4f, Target: %.4f, SL: %.4f)",
-			signal.ID, signal.Symbol, signal.Status, signal.CurrentPrice, signal.EntryPrice, signal.TargetPrice, signal.StopLoss)
-
-		if signal.Status == "Pending" && !signal.TradeStartDate.After(time.Now()) {
-			log.Printf("Signal ID %d (by %s, %s) TradeStartDate has passed. Transitioning to Active.", signal.ID, signal.TraderName, signal.Symbol)
-			err := s.signalRepo.UpdateSignalStatus(ctx, signal.ID, "Active")
-			if err != nil {
-				log.Printf("Error setting signal ID %d to Active: %v", signal.ID, err)
-			}
-			continue
-		}
-
-		if signal.Status != "Active" {
-			log.Printf("Signal ID %d (Symbol: %s) is not Active (Status: %s), skipping SL/Target check.", signal.ID, signal.Symbol, signal.Status)
-			continue
-		}
-
 		if signal.CurrentPrice == 0 {
-			log.Printf("Warning: Signal ID %d (%s) has zero current price, skipping SL/Target check. Ensure market data is updating.", signal.ID, signal.Symbol)
-			continue
-		}
-		if signal.TargetPrice == 0 && signal.StopLoss == 0 {
-			log.Printf("Warning: Signal ID %d (%s) has zero TargetPrice and StopLoss, skipping SL/Target check. Please review signal data.", signal.ID, signal.Symbol)
-			continue
+			log.Printf("Warning: Signal ID %d (%s) has zero current price. Ensure market data is updating.", signal.ID, signal.Symbol)
 		}
 
-		if signal.StopLoss != 0 && signal.CurrentPrice <= signal.StopLoss {
-			log.Printf("Signal ID %d (by %s, %s) hit Stop Loss at %.4f (SL: %.4f). Updating status.", signal.ID, signal.TraderName, signal.Symbol, signal.CurrentPrice, signal.StopLoss)
-			err := s.signalRepo.UpdateSignalStatus(ctx, signal.ID, "Stop Loss")
-			if err != nil {
-				log.Printf("Error setting signal ID %d to Stop Loss: %v", signal.ID, err)
-			}
-			continue
+		progressed := signal.Advance(signal.CurrentPrice, now)
+		next, ok := signal.NextStatus(signal.CurrentPrice, now)
+		if ok && next.IsTerminal() {
+			signal.Close(next, signal.CurrentPrice)
+			progressed = true
 		}
-
-		if signal.TargetPrice != 0 && signal.CurrentPrice >= signal.TargetPrice {
-			log.Printf("Signal ID %d (by %s, %s) hit Target at %.4f (Target: %.4f). Updating status.", signal.ID, signal.TraderName, signal.Symbol, signal.CurrentPrice, signal.TargetPrice)
-			err := s.signalRepo.UpdateSignalStatus(ctx, signal.ID, "Target Hit")
-			if err != nil {
-				log.Printf("Error setting signal ID %d to Target Hit: %v", signal.ID, err)
+		if progressed {
+			if err := s.signalRepo.SaveSignalProgress(ctx, &signal); err != nil {
+				log.Printf("Error saving progress for signal ID %d: %v", signal.ID, err)
+				continue
 			}
+		}
+		if !ok {
 			continue
 		}
+		log.Printf("Signal ID %d (by %s, %s %s) moving %s -> %s at %.4f (Entry: %.4f, Target: %.4f, SL: %.4f, R: %.2f).",
+			signal.ID, signal.TraderName, signal.Direction, signal.Symbol, signal.Status, next, signal.CurrentPrice, signal.EntryPrice, signal.TargetPrice, signal.StopLoss, signal.RealizedR)
+		s.setStatus(ctx, signal, next)
 	}
 	return nil
 }
+
+func (s *LiveSignalService) GetSignalHistory(ctx context.Context, signalID uint) ([]models.SignalStatusHistory, error) {
+	history, err := s.signalRepo.GetSignalHistory(ctx, signalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signal history: %w", err)
+	}
+	return history, nil
+}
+
+func (s *LiveSignalService) setStatus(ctx context.Context, signal models.Signal, status models.SignalStatus) {
+	moved, err := s.signalRepo.TransitionSignalStatus(ctx, &signal, status, signal.CurrentPrice, "admin signal cron")
+	if err != nil {
+		log.Printf("Error setting signal ID %d to %s: %v", signal.ID, status, err)
+		return
+	}
+	if !moved {
+		return
+	}
+	s.hub.PublishSignalStatus(signal.TraderID, stream.SignalStatusChange{
+		SignalID:     signal.ID,
+		Symbol:       signal.Symbol,
+		From:         signal.Status,
+		To:           status,
+		CurrentPrice: signal.CurrentPrice,
+		RealizedR:    signal.RealizedR,
+	})
+}
@@ -8,11 +8,13 @@ import (
 
 	"github.com/fathimasithara01/tradeverse/internal/admin/repository"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"github.com/fathimasithara01/tradeverse/pkg/refund"
 	"gorm.io/gorm"
 )
 
 type ISubscriptionService interface {
-	CreateSubscription(userID, planID uint, amount float64, transactionID string) (*models.CustomerToTraderSub, error)
+	CreateSubscription(userID, planID uint, amount money.Amount, transactionID string) (*models.CustomerToTraderSub, error)
 	GetAllSubscriptions() ([]models.CustomerToTraderSub, error)
 	GetSubscriptionByID(id uint) (*models.CustomerToTraderSub, error)
 	GetSubscriptionsByUserID(userID uint) ([]models.CustomerToTraderSub, error)
@@ -23,6 +25,9 @@ type ISubscriptionService interface {
 
 	DeactivateExpiredSubscriptions() error
 	UpdateUserTraderStatus(userID uint, status string) error
+
+	CancelSubscription(id uint) (*models.SubscriptionCancellation, error)
+	CancelTraderSubscription(id uint) (*models.SubscriptionCancellation, error)
 }
 
 type SubscriptionService struct {
@@ -143,7 +148,7 @@ func (s *SubscriptionService) UpgradeUserToTrader(userID uint) error {
 	return s.userRepo.UpdateUser(user)
 }
 
-func (s *SubscriptionService) CreateSubscription(userID, planID uint, amount float64, transactionID string) (*models.CustomerToTraderSub, error) {
+func (s *SubscriptionService) CreateSubscription(userID, planID uint, amount money.Amount, transactionID string) (*models.CustomerToTraderSub, error) {
 	var subscription *models.CustomerToTraderSub
 	err := s.DB.Transaction(func(tx *gorm.DB) error {
 		plan, err := s.planRepo.GetSubscriptionPlanByID(planID)
@@ -181,7 +186,7 @@ func (s *SubscriptionService) CreateSubscription(userID, planID uint, amount flo
 		}
 		subscription = newSubscription
 
-		creditDescription := fmt.Sprintf("Subscription payment from User %d for Plan %d (Amount: %.2f)", userID, planID, amount)
+		creditDescription := fmt.Sprintf("Subscription payment from User %d for Plan %d (Amount: %s)", userID, planID, amount)
 		if err := s.adminWalletService.CreditAdminWallet(tx, amount, "INR", creditDescription); err != nil { // Assuming INR as default currency
 			log.Printf("Error crediting admin wallet for subscription: %v", err)
 			return fmt.Errorf("failed to credit admin wallet for subscription: %w", err)
@@ -226,3 +231,33 @@ func (s *SubscriptionService) DeleteSubscription(id uint) error {
 func (s *SubscriptionService) GetSubscriptionPlanByID(id uint) (*models.AdminTraderSubscriptionPlan, error) {
 	return s.planRepo.GetSubscriptionPlanByID(id)
 }
+
+// CancelSubscription cancels any customer's plan subscription and refunds it
+// under the plan's refund policy.
+func (s *SubscriptionService) CancelSubscription(id uint) (*models.SubscriptionCancellation, error) {
+	var cancellation *models.SubscriptionCancellation
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		cancellation, err = refund.CancelSubscription(tx, id, 0, time.Now())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cancellation, nil
+}
+
+// CancelTraderSubscription cancels any customer's trader signal subscription
+// and refunds it from the trader's and the platform's shares.
+func (s *SubscriptionService) CancelTraderSubscription(id uint) (*models.SubscriptionCancellation, error) {
+	var cancellation *models.SubscriptionCancellation
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		cancellation, err = refund.CancelTraderSubscription(tx, id, 0, time.Now())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cancellation, nil
+}
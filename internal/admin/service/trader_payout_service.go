@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/fathimasithara01/tradeverse/internal/admin/repository"
+	"github.com/fathimasithara01/tradeverse/pkg/fx"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"github.com/fathimasithara01/tradeverse/pkg/withdrawal"
+	"gorm.io/gorm"
+)
+
+type ITraderPayoutService interface {
+	Run(ctx context.Context) (int, error)
+	ListPayouts(ctx context.Context, pagination models.PaginationParams) ([]models.TraderPayout, int64, error)
+}
+
+type TraderPayoutService struct {
+	Repo        repository.ITraderPayoutRepository
+	DB          *gorm.DB
+	Withdrawals *withdrawal.Pipeline
+	// Threshold is the least unpaid revenue, in Currency, worth paying out.
+	Threshold money.Amount
+	Currency  string
+}
+
+func NewTraderPayoutService(repo repository.ITraderPayoutRepository, db *gorm.DB, withdrawals *withdrawal.Pipeline, threshold money.Amount, currency string) ITraderPayoutService {
+	return &TraderPayoutService{Repo: repo, DB: db, Withdrawals: withdrawals, Threshold: threshold, Currency: currency}
+}
+
+// Run sweeps the revenue each trader with automatic payouts has earned since
+// their last payout into a withdrawal request to their payout account. The
+// request goes through the same approval and limits as any other withdrawal;
+// one that cannot be filed now is tried again on the next run. It returns how
+// many payouts were filed.
+func (s *TraderPayoutService) Run(ctx context.Context) (int, error) {
+	accounts, err := s.Repo.ListAutoPayoutAccounts(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	filed := 0
+	var errs []error
+	for _, account := range accounts {
+		unpaid, err := s.Repo.UnpaidRevenue(ctx, account.TraderID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, revenue := range unpaid {
+			ok, err := s.payOut(ctx, account, revenue)
+			switch {
+			case errors.Is(err, withdrawal.ErrLimitExceeded), errors.Is(err, withdrawal.ErrInsufficientFunds), errors.Is(err, fx.ErrRateNotFound):
+				log.Printf("Skipping %s payout for trader %d: %v", revenue.Currency, account.TraderID, err)
+			case err != nil:
+				errs = append(errs, fmt.Errorf("trader %d %s payout: %w", account.TraderID, revenue.Currency, err))
+			case ok:
+				filed++
+			}
+		}
+	}
+	return filed, errors.Join(errs...)
+}
+
+// payOut files one payout. Only what is still in the wallet is paid out: a
+// trader who has spent part of their revenue is paid the rest. Nothing is
+// filed while an earlier payout in the currency is pending, so that if it is
+// rejected its revenue is swept again by the next one.
+func (s *TraderPayoutService) payOut(ctx context.Context, account models.TraderPayoutAccount, revenue models.UnpaidTraderRevenue) (bool, error) {
+	pending, err := s.Repo.HasPendingPayout(ctx, account.TraderID, revenue.Currency)
+	if err != nil || pending {
+		return false, err
+	}
+
+	balance, err := s.Repo.WalletBalance(ctx, account.TraderID, revenue.Currency)
+	if err != nil {
+		return false, err
+	}
+	amount := revenue.Amount
+	if balance < amount {
+		amount = balance
+	}
+	if !amount.IsPositive() {
+		return false, nil
+	}
+
+	quote, err := fx.Lookup(s.DB.WithContext(ctx), revenue.Currency, s.Currency)
+	if err != nil {
+		return false, err
+	}
+	if amount.Convert(quote.Rate, s.Currency, money.RoundHalfUp) < s.Threshold {
+		return false, nil
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		w, err := s.Withdrawals.RequestTx(tx, account.TraderID, 0, models.WithdrawalRequestInput{
+			Amount:            amount,
+			Currency:          revenue.Currency,
+			BankAccountNumber: account.BankAccountNumber,
+			BankAccountHolder: account.BankAccountHolder,
+			IFSCCode:          account.IFSCCode,
+		})
+		if err != nil {
+			return err
+		}
+		payout := &models.TraderPayout{
+			TraderID:         account.TraderID,
+			Currency:         revenue.Currency,
+			Amount:           amount,
+			RevenueThroughID: revenue.ThroughID,
+			WithdrawalID:     w.ID,
+		}
+		if err := tx.Create(payout).Error; err != nil {
+			return fmt.Errorf("failed to record payout: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *TraderPayoutService) ListPayouts(ctx context.Context, pagination models.PaginationParams) ([]models.TraderPayout, int64, error) {
+	if pagination.Page < 1 {
+		pagination.Page = 1
+	}
+	if pagination.Limit < 1 {
+		pagination.Limit = 20
+	}
+	return s.Repo.ListPayouts(ctx, pagination)
+}
@@ -12,8 +12,10 @@ import (
 	"time"
 
 	"github.com/fathimasithara01/tradeverse/internal/admin/repository"
-	"github.com/fathimasithara01/tradeverse/pkg/auth"
+	"github.com/fathimasithara01/tradeverse/pkg/lockout"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/session"
+	"github.com/fathimasithara01/tradeverse/pkg/twofactor"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -47,8 +49,42 @@ type AdminUpdateProfileRequest struct {
 	ProfilePic *multipart.FileHeader `form:"profile_pic"`
 }
 
+// ErrTwoFactorMandatory is returned when a user who must use 2FA tries to
+// turn it off.
+var ErrTwoFactorMandatory = errors.New("two-factor authentication is mandatory for this account")
+
+// ErrAccountLocked is returned while failed logins have the account locked.
+var ErrAccountLocked = errors.New("account is temporarily locked after too many failed logins")
+
+// permissionTwoFactorRequired is the permission whose holders, like admins,
+// must use 2FA.
+const permissionTwoFactorRequired = "manage_wallet"
+
+// LoginResult is the outcome of a login step. Tokens is set once the user is
+// fully authenticated; until then Challenge holds the token for the 2FA step.
+// RecoveryCodes is set when the login also completed a 2FA enrolment.
+type LoginResult struct {
+	User          models.User
+	Tokens        *session.Tokens
+	Challenge     *twofactor.Challenge
+	RecoveryCodes []string
+}
+
 type IUserService interface {
-	Login(email, password string) (string, models.User, error)
+	Login(email, password string, meta session.Meta) (*LoginResult, error)
+	CompleteTwoFactorLogin(challengeToken, code string, meta session.Meta) (*LoginResult, error)
+	BeginLoginEnrolment(challengeToken string) (*twofactor.Enrolment, error)
+	RefreshSession(refreshToken string, meta session.Meta) (*session.Tokens, error)
+	Logout(userID uint, sessionID string, allSessions bool) error
+	RevokeSessions(userID uint, reason string) error
+	SetUserBlocked(userID uint, blocked bool) error
+	UnlockUser(userID uint) error
+	TwoFactorRequired(userID uint) (bool, error)
+	TwoFactorStatus(userID uint) (*twofactor.Status, error)
+	BeginTwoFactorEnrolment(userID uint) (*twofactor.Enrolment, error)
+	ConfirmTwoFactor(userID uint, code string) ([]string, error)
+	DisableTwoFactor(userID uint, code string) error
+	RegenerateRecoveryCodes(userID uint, code string) ([]string, error)
 	RegisterCustomer(user models.User, profile models.CustomerProfile) error
 	RegisterTrader(user models.User, profile models.TraderProfile) error
 	CreateTraderByAdmin(user models.User, profile models.TraderProfile) error
@@ -73,16 +109,20 @@ type IUserService interface {
 }
 
 type UserService struct {
-	UserRepo  repository.IUserRepository
-	RoleRepo  repository.IRoleRepository
-	JWTSecret string
+	UserRepo repository.IUserRepository
+	RoleRepo repository.IRoleRepository
+	Sessions  *session.Service
+	TwoFactor *twofactor.Service
+	Lockout   lockout.Policy
 }
 
-func NewUserService(userRepo repository.IUserRepository, roleRepo repository.IRoleRepository, jwtSecret string) IUserService {
+func NewUserService(userRepo repository.IUserRepository, roleRepo repository.IRoleRepository, sessions *session.Service, twoFactor *twofactor.Service, lockoutPolicy lockout.Policy) IUserService {
 	return &UserService{
 		UserRepo:  userRepo,
 		RoleRepo:  roleRepo,
-		JWTSecret: jwtSecret,
+		Sessions:  sessions,
+		TwoFactor: twoFactor,
+		Lockout:   lockoutPolicy,
 	}
 }
 func (s *UserService) GetAdminProfile(userID uint) (models.User, error) {
@@ -170,13 +210,14 @@ func (s *UserService) ChangeAdminPassword(userID uint, oldPassword, newPassword
 		return errors.New("new password does not meet strength requirements (min 8 chars, 1 uppercase, 1 lowercase, 1 number, 1 special char)")
 	}
 
-	hashedPassword, err := hashPassword(newPassword)
-	if err != nil {
+	if err := user.ChangePassword(newPassword); err != nil {
 		return fmt.Errorf("failed to hash new password: %w", err)
 	}
-	user.Password = hashedPassword
 
-	return s.UserRepo.UpdateUser(user)
+	if err := s.UserRepo.UpdateUser(user); err != nil {
+		return err
+	}
+	return s.Sessions.RevokeUser(userID, models.RevokeReasonPasswordChange)
 }
 
 func IsValidPassword(password string) bool {
@@ -208,11 +249,6 @@ func IsValidPassword(password string) bool {
 	return true
 }
 
-func hashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
-}
-
 func checkPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
@@ -244,40 +280,222 @@ func SaveUploadedFile(file *multipart.FileHeader, dst string) error {
 
 	return nil
 }
-func (s *UserService) Login(email, password string) (string, models.User, error) {
+// Login checks the password. Users with 2FA enabled, and users who must use
+// it, get a challenge to complete with CompleteTwoFactorLogin instead of a
+// session. Consecutive wrong passwords lock the account under the lockout
+// policy; while it is locked even the right password is refused.
+func (s *UserService) Login(email, password string, meta session.Meta) (*LoginResult, error) {
 	user, err := s.UserRepo.FindByEmail(email)
 	if err != nil {
 		log.Printf("[LOGIN SERVICE] User '%s' not found or other error: %v", email, err)
-		return "", models.User{}, errors.New("invalid credentials")
+		return nil, errors.New("invalid credentials")
 	}
 
 	if user.IsBlocked {
-		return "", models.User{}, errors.New("account is blocked")
+		return nil, errors.New("account is blocked")
+	}
+
+	now := time.Now()
+	if user.IsLocked(now) {
+		return nil, lockedError(*user.LockedUntil)
 	}
 
 	if !checkPasswordHash(password, user.Password) {
 		log.Printf("[LOGIN SERVICE] Password mismatch for user '%s'", email)
-		return "", models.User{}, errors.New("invalid credentials")
+		updated, err := s.UserRepo.RecordFailedLogin(user.ID, s.Lockout, now)
+		if err != nil {
+			log.Printf("[LOGIN SERVICE] %v", err)
+		} else if updated.IsLocked(now) {
+			log.Printf("[LOGIN SERVICE] User '%s' locked until %s after %d failed logins", email, updated.LockedUntil.Format(time.RFC3339), updated.FailedLoginAttempts)
+			return nil, lockedError(*updated.LockedUntil)
+		}
+		return nil, errors.New("invalid credentials")
+	}
+
+	if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+		if err := s.UserRepo.ResetFailedLogins(user.ID); err != nil {
+			return nil, fmt.Errorf("failed to reset failed logins: %w", err)
+		}
+		user.FailedLoginAttempts, user.LockedUntil = 0, nil
 	}
 
 	if user.RoleID == nil {
 		log.Printf("[LOGIN SERVICE] User '%s' has nil RoleID, fixing...", email)
 		role, err := s.UserRepo.GetRoleByName(user.Role)
 		if err != nil {
-			return "", models.User{}, fmt.Errorf("failed to get role for user %s: %w", email, err)
+			return nil, fmt.Errorf("failed to get role for user %s: %w", email, err)
 		}
 		user.RoleID = &role.ID
 		if err := s.UserRepo.UpdateUser(user); err != nil {
-			return "", models.User{}, fmt.Errorf("failed to update user role info")
+			return nil, fmt.Errorf("failed to update user role info")
+		}
+	}
+
+	required, err := s.twoFactorRequired(user)
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := s.TwoFactor.Enabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled || required {
+		challenge, err := s.TwoFactor.NewChallenge(user.ID, !enabled)
+		if err != nil {
+			return nil, err
 		}
+		return &LoginResult{User: *user, Challenge: challenge}, nil
+	}
+
+	tokens, err := s.Sessions.Issue(user, meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
+	return &LoginResult{User: *user, Tokens: tokens}, nil
+}
+
+// CompleteTwoFactorLogin finishes a login with the code for its challenge and
+// starts the session.
+func (s *UserService) CompleteTwoFactorLogin(challengeToken, code string, meta session.Meta) (*LoginResult, error) {
+	userID, recoveryCodes, err := s.TwoFactor.CompleteChallenge(challengeToken, code)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.UserRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("user %d not found: %w", userID, err)
+	}
+	if user.IsBlocked {
+		return nil, errors.New("account is blocked")
+	}
+	tokens, err := s.Sessions.Issue(user, meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
+	return &LoginResult{User: *user, Tokens: tokens, RecoveryCodes: recoveryCodes}, nil
+}
+
+// BeginLoginEnrolment lets a user who must use 2FA set it up in the middle of
+// logging in, since they cannot get a session without it. The challenge is
+// then completed with a code from the new authenticator.
+func (s *UserService) BeginLoginEnrolment(challengeToken string) (*twofactor.Enrolment, error) {
+	challenge, err := s.TwoFactor.PendingChallenge(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if !challenge.EnrolmentRequired {
+		return nil, twofactor.ErrAlreadyEnabled
+	}
+	return s.BeginTwoFactorEnrolment(challenge.UserID)
+}
+
+func (s *UserService) RefreshSession(refreshToken string, meta session.Meta) (*session.Tokens, error) {
+	return s.Sessions.Refresh(refreshToken, meta)
+}
+
+// Logout ends the session the request was made with, or every session of the
+// user when allSessions is set.
+func (s *UserService) Logout(userID uint, sessionID string, allSessions bool) error {
+	if allSessions {
+		return s.Sessions.RevokeUser(userID, models.RevokeReasonLogout)
+	}
+	return s.Sessions.Logout(userID, sessionID)
+}
+
+func (s *UserService) RevokeSessions(userID uint, reason string) error {
+	return s.Sessions.RevokeUser(userID, reason)
+}
+
+// SetUserBlocked blocks or unblocks an account. Blocking also revokes every
+// session the user has open.
+func (s *UserService) SetUserBlocked(userID uint, blocked bool) error {
+	user, err := s.UserRepo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("user %d not found: %w", userID, err)
+	}
+	user.IsBlocked = blocked
+	if err := s.UserRepo.UpdateUser(user); err != nil {
+		return fmt.Errorf("failed to update user %d: %w", userID, err)
+	}
+	if !blocked {
+		return nil
+	}
+	return s.Sessions.RevokeUser(userID, models.RevokeReasonBlocked)
+}
+
+// UnlockUser lifts a lockout from failed logins and resets the count.
+func (s *UserService) UnlockUser(userID uint) error {
+	if _, err := s.UserRepo.GetUserByID(userID); err != nil {
+		return fmt.Errorf("user %d not found: %w", userID, err)
+	}
+	return s.UserRepo.ResetFailedLogins(userID)
+}
+
+func lockedError(until time.Time) error {
+	return fmt.Errorf("%w; try again after %s", ErrAccountLocked, until.UTC().Format(time.RFC1123))
+}
+
+// TwoFactorRequired reports whether the user must use 2FA: admins and holders
+// of the manage_wallet permission do.
+func (s *UserService) TwoFactorRequired(userID uint) (bool, error) {
+	user, err := s.UserRepo.GetUserByID(userID)
+	if err != nil {
+		return false, fmt.Errorf("user %d not found: %w", userID, err)
+	}
+	return s.twoFactorRequired(user)
+}
+
+func (s *UserService) twoFactorRequired(user *models.User) (bool, error) {
+	if user.Role == models.RoleAdmin {
+		return true, nil
+	}
+	if user.RoleID == nil {
+		return false, nil
+	}
+	has, err := s.RoleRepo.RoleHasPermission(*user.RoleID, permissionTwoFactorRequired)
+	if err != nil {
+		return false, fmt.Errorf("failed to check permissions of user %d: %w", user.ID, err)
+	}
+	return has, nil
+}
+
+func (s *UserService) TwoFactorStatus(userID uint) (*twofactor.Status, error) {
+	status, err := s.TwoFactor.Status(userID)
+	if err != nil {
+		return nil, err
+	}
+	if status.Required, err = s.TwoFactorRequired(userID); err != nil {
+		return nil, err
 	}
+	return status, nil
+}
 
-	token, err := auth.GenerateJWT(user.ID, user.Email, string(user.Role), *user.RoleID, s.JWTSecret)
+func (s *UserService) BeginTwoFactorEnrolment(userID uint) (*twofactor.Enrolment, error) {
+	user, err := s.UserRepo.GetUserByID(userID)
 	if err != nil {
-		return "", models.User{}, fmt.Errorf("failed to generate JWT: %w", err)
+		return nil, fmt.Errorf("user %d not found: %w", userID, err)
 	}
-	return token, *user, nil
+	return s.TwoFactor.BeginEnrolment(user.ID, user.Email)
+}
 
+func (s *UserService) ConfirmTwoFactor(userID uint, code string) ([]string, error) {
+	return s.TwoFactor.ConfirmEnrolment(userID, code)
+}
+
+// DisableTwoFactor turns 2FA off for users who are not required to use it.
+func (s *UserService) DisableTwoFactor(userID uint, code string) error {
+	required, err := s.TwoFactorRequired(userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrTwoFactorMandatory
+	}
+	return s.TwoFactor.Disable(userID, code)
+}
+
+func (s *UserService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	return s.TwoFactor.RegenerateRecoveryCodes(userID, code)
 }
 func (s *UserService) UpdateCustomerProfile(userID uint, user models.User, profile models.CustomerProfile) error {
 	existingUser, err := s.UserRepo.GetUserByIDWithProfile(userID)
@@ -416,7 +634,7 @@ func (s *UserService) DeleteUser(id uint) error {
 	if err != nil {
 		return fmt.Errorf("failed to delete user %d: %w", id, err)
 	}
-	return nil
+	return s.Sessions.RevokeUser(id, models.RevokeReasonDeleted)
 }
 
 func (s *UserService) UpdateUser(userToUpdate *models.User) error {
@@ -1,13 +1,17 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/fathimasithara01/tradeverse/internal/admin/repository"
+	"github.com/fathimasithara01/tradeverse/pkg/ledger"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"github.com/fathimasithara01/tradeverse/pkg/withdrawal"
 	"gorm.io/gorm"
 )
 
@@ -15,27 +19,31 @@ type IAdminWalletService interface {
 	GetAdminWalletSummary() (*models.WalletSummaryResponse, error)
 	AdminInitiateDeposit(input models.DepositRequestInput) (*models.DepositResponse, error)
 	AdminVerifyDeposit(depositID uint, input models.DepositVerifyInput) (*models.DepositResponse, error)
-	AdminRequestWithdrawal(input models.WithdrawalRequestInput) (*models.WithdrawalResponse, error)
+	AdminRequestWithdrawal(ctx context.Context, adminID uint, input models.WithdrawalRequestInput) (*models.WithdrawalResponse, error)
 	AdminGetWalletTransactions(pagination models.PaginationParams) ([]models.WalletTransaction, int64, error)
-	CreditAdminWallet(tx *gorm.DB, amount float64, currency, description string) error
+	CreditAdminWallet(tx *gorm.DB, amount money.Amount, currency, description string) error
 
-	GetPendingWithdrawalRequests(pagination models.PaginationParams) ([]models.WithdrawRequest, int64, error)
-	ApproveWithdrawalRequest(withdrawalID uint) error
-	RejectWithdrawalRequest(withdrawalID uint) error
+	GetPendingWithdrawalRequests(pagination models.PaginationParams) ([]models.WithdrawalRequest, int64, error)
+	ApproveWithdrawalRequest(ctx context.Context, withdrawalID, adminID uint, note string) (*models.WithdrawalRequest, error)
+	RejectWithdrawalRequest(ctx context.Context, withdrawalID, adminID uint, note string) (*models.WithdrawalRequest, error)
+	GetWithdrawalRequest(ctx context.Context, withdrawalID uint) (*models.WithdrawalRequest, error)
+	ReleaseDueWithdrawals(ctx context.Context) (int, error)
 
 	GetAllWalletTransactions(pagination models.PaginationParams) ([]models.WalletTransaction, int64, error) // All platform transactions
 	GetAllCustomerTransactionsWithUserDetails(pagination models.PaginationParams) ([]models.AdminTransactionDisplayDTO, int64, error)
 }
 
 type AdminWalletService struct {
-	Repo repository.IAdminWalletRepository
-	DB   *gorm.DB
+	Repo        repository.IAdminWalletRepository
+	DB          *gorm.DB
+	Withdrawals *withdrawal.Pipeline
 }
 
-func NewAdminWalletService(repo repository.IAdminWalletRepository, db *gorm.DB) *AdminWalletService {
+func NewAdminWalletService(repo repository.IAdminWalletRepository, db *gorm.DB, withdrawals *withdrawal.Pipeline) *AdminWalletService {
 	return &AdminWalletService{
-		Repo: repo,
-		DB:   db,
+		Repo:        repo,
+		DB:          db,
+		Withdrawals: withdrawals,
 	}
 }
 
@@ -117,19 +125,6 @@ func (s *AdminWalletService) AdminVerifyDeposit(depositID uint, input models.Dep
 	}
 
 	err = s.DB.Transaction(func(tx *gorm.DB) error {
-		wallet, err := s.Repo.GetAdminWallet()
-		if err != nil {
-			return fmt.Errorf("failed to get admin wallet for deposit verification: %w", err)
-		}
-
-		balanceBefore := wallet.Balance
-		wallet.Balance += input.Amount
-		wallet.LastUpdated = time.Now()
-
-		if err := s.Repo.UpdateWalletBalance(tx, wallet); err != nil {
-			return fmt.Errorf("failed to update admin wallet balance during deposit: %w", err)
-		}
-
 		depositRequest.Status = models.TxStatusSuccess
 		depositRequest.PaymentGatewayTxID = input.PaymentGatewayTxID
 		depositRequest.CompletionTime = models.TimePtr(time.Now()) // Set completion time
@@ -138,20 +133,20 @@ func (s *AdminWalletService) AdminVerifyDeposit(depositID uint, input models.Dep
 		}
 
 		walletTx := models.WalletTransaction{
-			WalletID:           wallet.ID,
-			UserID:             wallet.UserID,
-			TransactionType:    models.TxTypeDeposit,
-			Amount:             input.Amount,
-			Currency:           wallet.Currency,
-			Status:             models.TxStatusSuccess,
-			ReferenceID:        fmt.Sprintf("DEPOSIT-%d", depositRequest.ID),
+			Type:               models.TxTypeDeposit,
 			PaymentGatewayTxID: input.PaymentGatewayTxID,
-			Description:        "Admin deposit via manual verification",
-			BalanceBefore:      balanceBefore,
-			BalanceAfter:       wallet.Balance,
 		}
-		if err := s.Repo.CreateWalletTransaction(tx, &walletTx); err != nil {
-			return fmt.Errorf("failed to create admin wallet transaction record for deposit: %w", err)
+		if _, err := ledger.Post(tx, ledger.Entry{
+			Type:        models.TxTypeDeposit,
+			Reference:   fmt.Sprintf("DEPOSIT-%d", depositRequest.ID),
+			Description: "Admin deposit via manual verification",
+			Currency:    depositRequest.Currency,
+			Legs: []ledger.Leg{
+				ledger.Debit(ledger.GatewayClearing(depositRequest.Currency), input.Amount, nil),
+				ledger.Credit(ledger.PlatformCommissionIn(depositRequest.Currency), input.Amount, &walletTx),
+			},
+		}); err != nil {
+			return fmt.Errorf("failed to credit admin wallet during deposit: %w", err)
 		}
 
 		// Link the created wallet transaction to the deposit request
@@ -172,99 +167,25 @@ func (s *AdminWalletService) AdminVerifyDeposit(depositID uint, input models.Dep
 	}, nil
 }
 
-// AdminRequestWithdrawal processes a withdrawal request from the admin wallet.
-func (s *AdminWalletService) AdminRequestWithdrawal(input models.WithdrawalRequestInput) (*models.WithdrawalResponse, error) {
+// AdminRequestWithdrawal files a payout from the platform wallet. Like every
+// withdrawal it waits for approval, which must come from another admin.
+func (s *AdminWalletService) AdminRequestWithdrawal(ctx context.Context, adminID uint, input models.WithdrawalRequestInput) (*models.WithdrawalResponse, error) {
 	adminUser, err := s.Repo.FindAdminUser()
 	if err != nil {
 		return nil, fmt.Errorf("admin user not found: %w", err)
 	}
-	wallet, err := s.Repo.GetAdminWallet()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get admin wallet: %w", err)
-	}
-
-	if wallet.Balance < input.Amount {
-		return nil, errors.New("insufficient balance for withdrawal")
-	}
-	if wallet.Currency != input.Currency {
-		return nil, errors.New("currency mismatch for withdrawal")
-	}
-
-	var finalWithdrawRequest models.WithdrawRequest // Declare here to be accessible after transaction
-
-	err = s.DB.Transaction(func(tx *gorm.DB) error {
-		// Re-fetch wallet within transaction to ensure latest state
-		currentWallet, err := s.Repo.GetAdminWallet()
-		if err != nil {
-			return fmt.Errorf("failed to get admin wallet in transaction: %w", err)
-		}
-
-		if currentWallet.Balance < input.Amount {
-			return errors.New("insufficient balance for withdrawal (transaction re-check)")
-		}
-		if currentWallet.Currency != input.Currency {
-			return errors.New("currency mismatch for withdrawal (transaction re-check)")
-		}
-
-		balanceBefore := currentWallet.Balance
-		currentWallet.Balance -= input.Amount // Deduct balance immediately
-		currentWallet.LastUpdated = time.Now()
-
-		if err := s.Repo.UpdateWalletBalance(tx, currentWallet); err != nil {
-			return fmt.Errorf("failed to update admin wallet balance during withdrawal: %w", err)
-		}
-
-		// Populate all fields for the new WithdrawRequest struct
-		withdrawRequest := models.WithdrawRequest{
-			UserID:             adminUser.ID,
-			Amount:             input.Amount,
-			Currency:           input.Currency,
-			Status:             models.TxStatusPending, // Withdrawal is pending approval/processing
-			BankAccountNumber:  input.BankAccountNumber,  // Use from input
-			BankAccountHolder:  input.BankAccountHolder,  // Use from input
-			IFSCCode:           input.IFSCCode,           // Use from input
-			BeneficiaryAccount: input.BeneficiaryAccount, // This might be redundant if individual fields are stored
-			PaymentGateway:     "AdminManualTransfer",
-			PaymentGatewayTxID: "", // Will be filled upon approval
-			RequestTime:        time.Now(),
-		}
-		if err := s.Repo.CreateWithdrawRequest(&withdrawRequest); err != nil {
-			return fmt.Errorf("failed to create admin withdrawal request: %w", err)
-		}
-		finalWithdrawRequest = withdrawRequest // Assign to outer variable
-
-		walletTx := models.WalletTransaction{
-			WalletID:           currentWallet.ID,
-			UserID:             currentWallet.UserID,
-			TransactionType:    models.TxTypeWithdrawal,
-			Amount:             input.Amount,
-			Currency:           currentWallet.Currency,
-			Status:             models.TxStatusPending, // Wallet transaction status is also pending
-			ReferenceID:        fmt.Sprintf("WITHDRAW-%d", withdrawRequest.ID),
-			PaymentGatewayTxID: "",
-			Description:        fmt.Sprintf("Admin withdrawal request to %s (Account: %s)", input.BankAccountHolder, input.BankAccountNumber), // More descriptive
-			BalanceBefore:      balanceBefore,
-			BalanceAfter:       currentWallet.Balance,
-		}
-		if err := s.Repo.CreateWalletTransaction(tx, &walletTx); err != nil {
-			return fmt.Errorf("failed to create admin withdrawal transaction record: %w", err)
-		}
-		// Link the created wallet transaction to the withdrawal request
-		finalWithdrawRequest.WalletTransactionID = &walletTx.ID
-		return s.Repo.UpdateWithdrawRequest(&finalWithdrawRequest)
-	})
 
+	w, err := s.Withdrawals.Request(ctx, adminUser.ID, adminID, input)
 	if err != nil {
 		return nil, err
 	}
 
 	return &models.WithdrawalResponse{
-		WithdrawalID:       finalWithdrawRequest.ID,
-		Amount:             input.Amount,
-		Currency:           input.Currency,
-		Status:             finalWithdrawRequest.Status, // Should be pending
-		Message:            "Admin withdrawal request submitted for processing.",
-		PaymentGatewayTxID: finalWithdrawRequest.PaymentGatewayTxID,
+		WithdrawalID: w.ID,
+		Amount:       w.Amount,
+		Currency:     w.Currency,
+		Status:       w.Status,
+		Message:      fmt.Sprintf("Admin withdrawal request submitted; awaiting %d approval(s) from another admin.", w.ApprovalsRequired),
 	}, nil
 }
 
@@ -274,7 +195,7 @@ func (s *AdminWalletService) AdminGetWalletTransactions(pagination models.Pagina
 }
 
 // CreditAdminWallet adds funds to the admin wallet and records a transaction. This is typically used internally by the system.
-func (s *AdminWalletService) CreditAdminWallet(tx *gorm.DB, amount float64, currency, description string) error {
+func (s *AdminWalletService) CreditAdminWallet(tx *gorm.DB, amount money.Amount, currency, description string) error {
 	adminWallet, err := s.Repo.GetAdminWallet()
 	if err != nil {
 		return fmt.Errorf("failed to get admin wallet for credit: %w", err)
@@ -284,144 +205,58 @@ func (s *AdminWalletService) CreditAdminWallet(tx *gorm.DB, amount float64, curr
 		return fmt.Errorf("currency mismatch for admin wallet credit: expected %s, got %s", adminWallet.Currency, currency)
 	}
 
-	balanceBefore := adminWallet.Balance
-	adminWallet.Balance += amount
-	adminWallet.LastUpdated = time.Now()
-
-	if err := s.Repo.UpdateWalletBalance(tx, adminWallet); err != nil {
-		return fmt.Errorf("failed to update admin wallet balance for credit: %w", err)
-	}
-
+	// Money credited here was collected through the payment gateway.
 	walletTx := models.WalletTransaction{
-		WalletID:        adminWallet.ID,
-		UserID:          adminWallet.UserID,
 		TransactionType: models.TxTypeDeposit, // Or a more specific type like TxTypeCredit if applicable
-		Amount:          amount,
-		Currency:        currency,
-		Status:          models.TxStatusSuccess,
-		Description:     description,
-		BalanceBefore:   balanceBefore,
-		BalanceAfter:    adminWallet.Balance,
 	}
-	if err := s.Repo.CreateWalletTransaction(tx, &walletTx); err != nil {
-		return fmt.Errorf("failed to create admin wallet transaction record for credit: %w", err)
+	if _, err := ledger.Post(tx, ledger.Entry{
+		Type:        models.TxTypeDeposit,
+		Description: description,
+		Currency:    currency,
+		Legs: []ledger.Leg{
+			ledger.Debit(ledger.GatewayClearing(currency), amount, nil),
+			ledger.Credit(ledger.PlatformCommissionIn(currency), amount, &walletTx),
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to credit admin wallet: %w", err)
 	}
 	return nil
 }
 
 // GetPendingWithdrawalRequests retrieves all withdrawal requests that are in a 'Pending' state.
-func (s *AdminWalletService) GetPendingWithdrawalRequests(pagination models.PaginationParams) ([]models.WithdrawRequest, int64, error) {
+func (s *AdminWalletService) GetPendingWithdrawalRequests(pagination models.PaginationParams) ([]models.WithdrawalRequest, int64, error) {
 	return s.Repo.GetPendingWithdrawalRequests(pagination)
 }
 
-// ApproveWithdrawalRequest marks a pending withdrawal request as 'Success' and updates the associated wallet transaction.
-func (s *AdminWalletService) ApproveWithdrawalRequest(withdrawalID uint) error {
-	return s.DB.Transaction(func(tx *gorm.DB) error {
-		withdrawal, err := s.Repo.GetWithdrawRequestByID(withdrawalID)
-		if err != nil {
-			return fmt.Errorf("withdrawal request %d not found: %w", withdrawalID, err)
-		}
-		if withdrawal.Status != models.TxStatusPending {
-			return errors.New("withdrawal request is not pending or already processed")
-		}
-
-		withdrawal.Status = models.TxStatusSuccess
-		// Assign a mock payment gateway transaction ID upon approval
-		withdrawal.PaymentGatewayTxID = fmt.Sprintf("PAYOUT-%d-%s", withdrawal.ID, time.Now().Format("20060102150405"))
-		withdrawal.ProcessingTime = models.TimePtr(time.Now())
-		withdrawal.CompletionTime = models.TimePtr(time.Now())
-
-		if err := s.Repo.UpdateWithdrawRequest(withdrawal); err != nil {
-			return fmt.Errorf("failed to update withdrawal request %d status to SUCCESS: %w", withdrawalID, err)
-		}
-
-		// If there's a linked wallet transaction, update its status too
-		if withdrawal.WalletTransactionID != nil {
-			var walletTx models.WalletTransaction
-			// Ensure to use the transaction (tx) for fetching the wallet transaction
-			if err := tx.First(&walletTx, *withdrawal.WalletTransactionID).Error; err != nil {
-				// Log a warning but don't fail the transaction if the linked transaction is missing
-				log.Printf("Warning: Wallet transaction for withdrawal %d (ID %d) not found: %v", withdrawal.ID, *withdrawal.WalletTransactionID, err)
-			} else {
-				walletTx.Status = models.TxStatusSuccess
-				if err := tx.Save(&walletTx).Error; err != nil {
-					return fmt.Errorf("failed to update linked wallet transaction %d status to SUCCESS: %w", *withdrawal.WalletTransactionID, err)
-				}
-			}
-		}
-
-		return nil
-	})
+// ApproveWithdrawalRequest records adminID's approval; the withdrawal is paid
+// out once it has every approval it needs and any hold has ended.
+func (s *AdminWalletService) ApproveWithdrawalRequest(ctx context.Context, withdrawalID, adminID uint, note string) (*models.WithdrawalRequest, error) {
+	w, err := s.Withdrawals.Approve(ctx, withdrawalID, adminID, note)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Admin %d approved withdrawal %d (%d of %d approvals, status %s)", adminID, w.ID, w.Approvals, w.ApprovalsRequired, w.Status)
+	return w, nil
 }
 
-// RejectWithdrawalRequest marks a pending withdrawal request as 'Rejected', reverses the funds to the customer,
-// and updates the associated wallet transaction and creates a reversal transaction.
-func (s *AdminWalletService) RejectWithdrawalRequest(withdrawalID uint) error {
-	return s.DB.Transaction(func(tx *gorm.DB) error {
-		withdrawal, err := s.Repo.GetWithdrawRequestByID(withdrawalID)
-		if err != nil {
-			return fmt.Errorf("withdrawal request %d not found: %w", withdrawalID, err)
-		}
-		if withdrawal.Status != models.TxStatusPending {
-			return errors.New("withdrawal request is not pending or already processed")
-		}
-
-		// Get the customer's wallet to reverse funds
-		customerWallet, err := s.Repo.GetCustomerWallet(withdrawal.UserID)
-		if err != nil {
-			return fmt.Errorf("customer wallet not found for user %d during rejection: %w", withdrawal.UserID, err)
-		}
-
-		// Revert funds to customer's wallet
-		balanceBeforeReversal := customerWallet.Balance
-		customerWallet.Balance += withdrawal.Amount
-		customerWallet.LastUpdated = time.Now()
-
-		if err := s.Repo.UpdateCustomerWalletBalance(tx, customerWallet); err != nil {
-			return fmt.Errorf("failed to revert funds to customer wallet for withdrawal %d: %w", withdrawal.ID, err)
-		}
-
-		// Update withdrawal request status to Rejected
-		withdrawal.Status = models.TxStatusRejected
-		withdrawal.ProcessingTime = models.TimePtr(time.Now())
-		withdrawal.CompletionTime = models.TimePtr(time.Now()) // Or a specific rejection time
-		if err := s.Repo.UpdateWithdrawRequest(withdrawal); err != nil {
-			return fmt.Errorf("failed to update withdrawal request %d status to REJECTED: %w", withdrawal.ID, err)
-		}
-
-		// Create a new wallet transaction for the reversal
-		reversalTx := models.WalletTransaction{
-			WalletID:        customerWallet.ID,
-			UserID:          customerWallet.UserID,
-			TransactionType: models.TxTypeReversal,
-			Amount:          withdrawal.Amount,
-			Currency:        withdrawal.Currency,
-			Status:          models.TxStatusSuccess, // Reversal itself is a successful action
-			ReferenceID:     fmt.Sprintf("REVERSAL-WITHDRAW-%d", withdrawal.ID),
-			Description:     fmt.Sprintf("Withdrawal request %d rejected, funds returned to wallet.", withdrawal.ID),
-			BalanceBefore:   balanceBeforeReversal,
-			BalanceAfter:    customerWallet.Balance,
-		}
-		if err := s.Repo.CreateWalletTransaction(tx, &reversalTx); err != nil {
-			return fmt.Errorf("failed to create reversal transaction for customer for withdrawal %d: %w", withdrawal.ID, err)
-		}
+// RejectWithdrawalRequest rejects a pending withdrawal and returns its funds to the wallet.
+func (s *AdminWalletService) RejectWithdrawalRequest(ctx context.Context, withdrawalID, adminID uint, note string) (*models.WithdrawalRequest, error) {
+	w, err := s.Withdrawals.Reject(ctx, withdrawalID, adminID, note)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Admin %d rejected withdrawal %d", adminID, w.ID)
+	return w, nil
+}
 
-		// Update the original wallet transaction linked to the withdrawal request
-		if withdrawal.WalletTransactionID != nil {
-			var originalWalletTx models.WalletTransaction
-			if err := tx.First(&originalWalletTx, *withdrawal.WalletTransactionID).Error; err != nil {
-				log.Printf("Warning: Original wallet transaction for withdrawal %d (ID %d) not found for status update: %v", withdrawal.ID, *withdrawal.WalletTransactionID, err)
-			} else {
-				originalWalletTx.Status = models.TxStatusReversed // Mark original withdrawal transaction as reversed
-				originalWalletTx.Description = fmt.Sprintf("Original withdrawal reversed: %s", originalWalletTx.Description)
-				if err := tx.Save(&originalWalletTx).Error; err != nil {
-					return fmt.Errorf("failed to update original linked wallet transaction %d status to REVERSED: %w", *withdrawal.WalletTransactionID, err)
-				}
-			}
-		}
+// GetWithdrawalRequest returns a withdrawal with its audit trail.
+func (s *AdminWalletService) GetWithdrawalRequest(ctx context.Context, withdrawalID uint) (*models.WithdrawalRequest, error) {
+	return s.Withdrawals.Get(ctx, withdrawalID)
+}
 
-		return nil
-	})
+// ReleaseDueWithdrawals pays out approved withdrawals whose hold has ended.
+func (s *AdminWalletService) ReleaseDueWithdrawals(ctx context.Context) (int, error) {
+	return s.Withdrawals.ReleaseDue(ctx)
 }
 
 // Helper function to get a pointer to a time.Time value
@@ -3,6 +3,7 @@ package bootstrap
 import (
 	"context"
 	"log"
+	"time"
 
 	"github.com/fathimasithara01/tradeverse/config"
 	adminRepo "github.com/fathimasithara01/tradeverse/internal/admin/repository"
@@ -15,10 +16,23 @@ import (
 	"github.com/fathimasithara01/tradeverse/internal/customer/service"
 
 	"github.com/fathimasithara01/tradeverse/internal/customer/router"
+	"github.com/fathimasithara01/tradeverse/pkg/accounttoken"
+	"github.com/fathimasithara01/tradeverse/pkg/apikey"
+	"github.com/fathimasithara01/tradeverse/pkg/idempotency"
+	"github.com/fathimasithara01/tradeverse/pkg/lockout"
+	"github.com/fathimasithara01/tradeverse/pkg/mailer"
 	paymentgateway "github.com/fathimasithara01/tradeverse/pkg/payment_gateway.go"
+	"github.com/fathimasithara01/tradeverse/pkg/ratelimit"
+	"github.com/fathimasithara01/tradeverse/pkg/session"
+	"github.com/fathimasithara01/tradeverse/pkg/stream"
+	"github.com/fathimasithara01/tradeverse/pkg/transfer"
+	"github.com/fathimasithara01/tradeverse/pkg/twofactor"
+	"github.com/fathimasithara01/tradeverse/pkg/withdrawal"
 	"github.com/gin-gonic/gin"
 )
 
+const streamRelayInterval = 5 * time.Second
+
 type App struct {
 	engine *gin.Engine
 	port   string
@@ -53,9 +67,16 @@ func InitializeApp() (*App, error) {
 	kycRepo := customerrepo.NewKYCRepository(db)
 	traderRepo := customerrepo.NewTraderRepository(db)
 	customerTraderSubsRepo := customerrepo.NewCustomerTraderSignalSubscriptionRepository(db)
-
-	adminAdminWalletService := adminSvc.NewAdminWalletService(adminAdminWalletRepo, db)
-	customerWalletService := service.NewWalletService(db, customerWalletRepo, paymentgateway.NewSimulatedPaymentClient())
+	copyProfileRepo := customerrepo.NewCopyProfileRepository(db)
+	candleRepo := adminRepo.NewCandleRepository(db)
+
+	paymentClient := paymentgateway.NewSimulatedPaymentClient(paymentgateway.ConfigFromAppConfig(cfg))
+	// Customer-side withdrawals only queue requests; payouts are sent once an
+	// admin approves them, so this pipeline has no gateway.
+	withdrawals := withdrawal.NewPipeline(db, withdrawal.PolicyFromConfig(cfg), nil)
+	transfers := transfer.NewService(db, transfer.PolicyFromConfig(cfg))
+	adminAdminWalletService := adminSvc.NewAdminWalletService(adminAdminWalletRepo, db, withdrawals)
+	customerWalletService := service.NewWalletService(db, customerWalletRepo, paymentClient, withdrawals, transfers)
 	customerSubscriptionPlanService := service.NewCustomerSubscriptionPlanService(customerSubscriptionPlanRepo)
 	customerSubscriptionService := service.NewCustomerSubscriptionService(
 		customerSubscriptionRepo,
@@ -64,12 +85,39 @@ func InitializeApp() (*App, error) {
 		adminUserRepo,
 		db,
 	)
-	userService := adminSvc.NewUserService(userRepo, roleRepo, cfg.JWT.Secret)
+	sessions := session.NewService(db, session.ConfigFromAppConfig(cfg))
+	twoFactor := twofactor.NewService(db, twofactor.ConfigFromAppConfig(cfg))
+	userService := adminSvc.NewUserService(userRepo, roleRepo, sessions, twoFactor, lockout.PolicyFromAppConfig(cfg))
+	limiter, err := ratelimit.LimiterFromAppConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	accountService := adminSvc.NewAccountService(
+		db,
+		userRepo,
+		sessions,
+		accounttoken.NewService(db, accounttoken.SecretFromAppConfig(cfg)),
+		mail,
+		cfg.AccountTokens.LinkBaseURL,
+		time.Duration(cfg.AccountTokens.VerifyEmailHours)*time.Hour,
+		time.Duration(cfg.AccountTokens.PasswordResetMinutes)*time.Minute,
+	)
 	kycService := service.NewKYCService(kycRepo)
-	paymentClient := paymentgateway.NewSimulatedPaymentClient()
-	walletService := service.NewWalletService(db, customerWalletRepo, paymentClient)
+	walletService := service.NewWalletService(db, customerWalletRepo, paymentClient, withdrawals, transfers)
+	paymentWebhookService := service.NewPaymentWebhookService(
+		walletrepo.NewWebhookEventRepository(db),
+		walletService,
+		cfg.PaymentGateway.WebhookSecret,
+		time.Duration(cfg.PaymentGateway.WebhookToleranceSeconds)*time.Second,
+	)
 	traderService := service.NewTraderService(traderRepo, db)
 	customerTraderSubsService := service.NewCustomerTraderSignalSubscriptionService(customerTraderSubsRepo, db)
+	copyProfileService := service.NewCopyProfileService(copyProfileRepo, db)
+	candleService := adminSvc.NewCandleService(candleRepo)
 
 	subscriptionPlanController := controllers.NewSubscriptionPlanController(
 		customerSubscriptionPlanService,
@@ -78,11 +126,21 @@ func InitializeApp() (*App, error) {
 	)
 
 	customerTraderSubsController := controllers.NewCustomerTraderSignalSubscriptionController(customerTraderSubsService)
-	authController := controllers.NewAuthController(userService)
+	authController := controllers.NewAuthController(userService, accountService)
 	profileController := controllers.NewProfileController(userService)
 	kycController := controllers.NewKYCController(kycService)
 	walletController := controllers.NewWalletController(walletService)
 	traderController := controllers.NewTraderController(traderService)
+	copyProfileController := controllers.NewCopyProfileController(copyProfileService)
+	candleController := controllers.NewCandleController(candleService)
+	paymentWebhookController := controllers.NewPaymentWebhookController(paymentWebhookService)
+	apiKeyController := controllers.NewAPIKeyController(apikey.NewService(db, apikey.ConfigFromAppConfig(cfg)))
+
+	hub := stream.NewHub()
+	if err := stream.NewRelay(db, hub).Start(ctx, streamRelayInterval); err != nil {
+		log.Printf("Stream relay not started: %v", err)
+	}
+	streamController := controllers.NewStreamController(hub, customerTraderSubsService)
 
 	r := router.SetupRouter(
 		cfg,
@@ -93,7 +151,19 @@ func InitializeApp() (*App, error) {
 		traderController,
 		customerTraderSubsController,
 		subscriptionPlanController,
+		copyProfileController,
+		candleController,
+		streamController,
+		paymentWebhookController,
+		apiKeyController,
+		idempotency.NewGormStore(db),
+		sessions,
+		twoFactor,
+		limiter,
 	)
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, err
+	}
 
 	return &App{
 		engine: r,
@@ -110,3 +110,26 @@ func (ctrl *CustomerTraderSignalSubscriptionController) IsSubscribedToTrader(c *
 
 	c.JSON(http.StatusOK, gin.H{"is_subscribed": isSubscribed, "trader_id": traderID})
 }
+
+func (ctrl *CustomerTraderSignalSubscriptionController) CancelTraderSubscription(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+	customerID := userID.(uint)
+
+	subscriptionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription ID"})
+		return
+	}
+
+	cancellation, err := ctrl.subsService.CancelSubscription(c, customerID, uint(subscriptionID))
+	if err != nil {
+		c.JSON(refundErrorStatus(err), gin.H{"error": fmt.Sprintf("failed to cancel trader subscription: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "trader subscription cancelled", "cancellation": cancellation})
+}
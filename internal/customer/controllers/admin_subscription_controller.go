@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -8,6 +9,8 @@ import (
 	"time"
 
 	"github.com/fathimasithara01/tradeverse/internal/customer/service"
+	"github.com/fathimasithara01/tradeverse/pkg/fx"
+	"github.com/fathimasithara01/tradeverse/pkg/refund"
 	"github.com/gin-gonic/gin"
 )
 
@@ -88,14 +91,18 @@ func (ctrl *SubscriptionPlanController) SubscribeToPlan(c *gin.Context) {
 
 	transactionID := fmt.Sprintf("SUB_TX_%d_%d_%d", userID, planID, time.Now().UnixNano())
 
-	err = ctrl.WalletService.DebitUserWallet(userID, plan.Price, plan.Currency, "Subscription to "+plan.Name, transactionID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to debit user wallet: " + err.Error()})
-		return
-	}
-
+	// CreateSubscription charges the wallet and pays the platform in the same
+	// ledger entry as the subscription is created.
 	subscription, err := ctrl.SubscriptionService.CreateSubscription(userID, uint(planID), plan.Price, transactionID)
 	if err != nil {
+		if errors.Is(err, service.ErrWalletServiceInsufficientFunds) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to debit user wallet: " + err.Error()})
+			return
+		}
+		if errors.Is(err, fx.ErrRateNotFound) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Cannot convert the plan price to your wallet currency: " + err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subscription: " + err.Error()})
 		return
 	}
@@ -121,14 +128,26 @@ func (ctrl *SubscriptionPlanController) CancelSubscription(c *gin.Context) {
 		return
 	}
 
-	err = ctrl.SubscriptionService.CancelSubscription(userID, uint(subscriptionID))
+	cancellation, err := ctrl.SubscriptionService.CancelSubscription(userID, uint(subscriptionID))
 	if err != nil {
 		log.Printf("Error cancelling subscription: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel subscription: " + err.Error()})
+		c.JSON(refundErrorStatus(err), gin.H{"error": "Failed to cancel subscription: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Subscription cancelled successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Subscription cancelled successfully", "cancellation": cancellation})
+}
+
+// refundErrorStatus maps subscription cancellation failures to HTTP statuses.
+func refundErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, refund.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, refund.ErrNotActive), errors.Is(err, refund.ErrUnfunded):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 func (ctrl *SubscriptionPlanController) GetUserSubscriptions(c *gin.Context) {
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fathimasithara01/tradeverse/pkg/apikey"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyController struct {
+	Keys *apikey.Service
+}
+
+func NewAPIKeyController(keys *apikey.Service) *APIKeyController {
+	return &APIKeyController{Keys: keys}
+}
+
+func (ctrl *APIKeyController) ListKeys(c *gin.Context) {
+	keys, err := ctrl.Keys.List(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"api_keys":         keys,
+		"available_scopes": apikey.ScopesForRole(models.UserRole(c.GetString("userRole"))),
+	})
+}
+
+// CreateKey issues a key. The key, and the signing secret of a key that
+// requires signed requests, are only ever returned by this call.
+func (ctrl *APIKeyController) CreateKey(c *gin.Context) {
+	var req apikey.CreateInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := ctrl.Keys.Create(c.GetUint("userID"), models.UserRole(c.GetString("userRole")), req)
+	if err != nil {
+		c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "API key created. Store the key now; it will not be shown again.",
+		"api_key": created,
+	})
+}
+
+func (ctrl *APIKeyController) RevokeKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+	if err := ctrl.Keys.Revoke(c.GetUint("userID"), uint(id)); err != nil {
+		c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
+
+func apiKeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, apikey.ErrNameRequired), errors.Is(err, apikey.ErrNoScopes),
+		errors.Is(err, apikey.ErrUnknownScope), errors.Is(err, apikey.ErrInvalidExpiry):
+		return http.StatusBadRequest
+	case errors.Is(err, apikey.ErrScopeNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, apikey.ErrTooManyKeys):
+		return http.StatusConflict
+	case errors.Is(err, apikey.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
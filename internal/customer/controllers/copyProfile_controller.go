@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fathimasithara01/tradeverse/internal/customer/service"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+type CopyProfileController struct {
+	copyProfileService service.ICopyProfileService
+}
+
+func NewCopyProfileController(copyProfileService service.ICopyProfileService) *CopyProfileController {
+	return &CopyProfileController{copyProfileService: copyProfileService}
+}
+
+func (ctrl *CopyProfileController) CreateProfile(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var req models.CopyProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	profile, err := ctrl.copyProfileService.CreateProfile(c, userID.(uint), req)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, profile)
+}
+
+func (ctrl *CopyProfileController) ListProfiles(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	profiles, err := ctrl.copyProfileService.ListProfiles(c, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list copy profiles"})
+		return
+	}
+	c.JSON(http.StatusOK, profiles)
+}
+
+func (ctrl *CopyProfileController) GetProfile(c *gin.Context) {
+	userID, profileID, ok := ctrl.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	profile, err := ctrl.copyProfileService.GetProfile(c, userID, profileID)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+func (ctrl *CopyProfileController) UpdateProfile(c *gin.Context) {
+	userID, profileID, ok := ctrl.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateCopyProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	profile, err := ctrl.copyProfileService.UpdateProfile(c, userID, profileID, req)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+func (ctrl *CopyProfileController) PauseProfile(c *gin.Context) {
+	userID, profileID, ok := ctrl.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	profile, err := ctrl.copyProfileService.PauseProfile(c, userID, profileID)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+func (ctrl *CopyProfileController) ResumeProfile(c *gin.Context) {
+	userID, profileID, ok := ctrl.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	profile, err := ctrl.copyProfileService.ResumeProfile(c, userID, profileID)
+	if err != nil {
+		ctrl.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+func (ctrl *CopyProfileController) parseRequest(c *gin.Context) (uint, uint, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return 0, 0, false
+	}
+
+	profileID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid copy profile ID"})
+		return 0, 0, false
+	}
+	return userID.(uint), uint(profileID), true
+}
+
+func (ctrl *CopyProfileController) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrCopyProfileNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCopyProfileExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrMasterNotTrader), errors.Is(err, service.ErrInvalidCopyProfile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process copy profile", "details": err.Error()})
+	}
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/fathimasithara01/tradeverse/internal/customer/repository/walletrepo"
+	"github.com/fathimasithara01/tradeverse/internal/customer/service"
+	paymentgateway "github.com/fathimasithara01/tradeverse/pkg/payment_gateway.go"
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentWebhookController struct {
+	WebhookSvc service.IPaymentWebhookService
+}
+
+func NewPaymentWebhookController(webhookSvc service.IPaymentWebhookService) *PaymentWebhookController {
+	return &PaymentWebhookController{WebhookSvc: webhookSvc}
+}
+
+// HandlePaymentWebhook receives gateway callbacks. Anything other than a 2xx
+// tells the gateway to redeliver, so only failures a redelivery could fix
+// return 5xx.
+func (ctrl *PaymentWebhookController) HandlePaymentWebhook(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "failed to read request body"})
+		return
+	}
+
+	event, err := ctrl.WebhookSvc.HandleDelivery(c.Request.Context(), service.WebhookDelivery{
+		Signature: c.GetHeader(paymentgateway.HeaderSignature),
+		Timestamp: c.GetHeader(paymentgateway.HeaderTimestamp),
+		Nonce:     c.GetHeader(paymentgateway.HeaderNonce),
+		Body:      body,
+	})
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"status": event.Status})
+	case errors.Is(err, paymentgateway.ErrMissingSignature),
+		errors.Is(err, paymentgateway.ErrInvalidSignature),
+		errors.Is(err, paymentgateway.ErrWebhookExpired):
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+	case errors.Is(err, service.ErrWebhookReplayed):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case errors.Is(err, paymentgateway.ErrInvalidPayload):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case errors.Is(err, service.ErrGatewayEventMismatch):
+		// Redelivering the same event will not help; it needs a person.
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error(), "status": event.Status})
+	case errors.Is(err, walletrepo.ErrDepositRequestNotFound), errors.Is(err, walletrepo.ErrWithdrawalRequestNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case errors.Is(err, service.ErrWebhookNotConfigured):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	}
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/internal/customer/service"
+	"github.com/fathimasithara01/tradeverse/pkg/stream"
+	"github.com/gin-gonic/gin"
+)
+
+// subscriptionRefreshInterval controls how quickly a new or expired trader
+// subscription is reflected on an open stream.
+const subscriptionRefreshInterval = time.Minute
+
+type StreamController struct {
+	hub         *stream.Hub
+	subsService service.ICustomerTraderSignalSubscriptionService
+}
+
+func NewStreamController(hub *stream.Hub, subsService service.ICustomerTraderSignalSubscriptionService) *StreamController {
+	return &StreamController{hub: hub, subsService: subsService}
+}
+
+func (ctrl *StreamController) StreamSSE(c *gin.Context) {
+	filter, err := ctrl.customerFilter(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	stream.ServeSSE(c, ctrl.hub, filter)
+}
+
+func (ctrl *StreamController) StreamWebSocket(c *gin.Context) {
+	filter, err := ctrl.customerFilter(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	stream.ServeWebSocket(c, ctrl.hub, filter)
+}
+
+// customerFilter passes every tick and the signal events of the traders the
+// customer is actively subscribed to. The trader set is reloaded in the
+// background for as long as the request is open.
+func (ctrl *StreamController) customerFilter(c *gin.Context) (stream.Filter, error) {
+	customerID := c.MustGet("userID").(uint)
+	traders := &traderSet{}
+	if err := ctrl.loadTraders(c, customerID, traders); err != nil {
+		return nil, err
+	}
+
+	ctx := c.Request.Context()
+	go func() {
+		ticker := time.NewTicker(subscriptionRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := ctrl.loadTraders(ctx, customerID, traders); err != nil {
+					log.Printf("Failed to refresh stream subscriptions for customer %d: %v", customerID, err)
+				}
+			}
+		}
+	}()
+
+	return func(e stream.Event) bool {
+		return e.Type == stream.EventMarketTick || traders.has(e.TraderID)
+	}, nil
+}
+
+func (ctrl *StreamController) loadTraders(ctx context.Context, customerID uint, traders *traderSet) error {
+	subs, err := ctrl.subsService.GetActiveSubscriptions(ctx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to load trader subscriptions: %w", err)
+	}
+	ids := make(map[uint]struct{}, len(subs))
+	for _, sub := range subs {
+		ids[sub.TraderID] = struct{}{}
+	}
+	traders.set(ids)
+	return nil
+}
+
+type traderSet struct {
+	mu  sync.RWMutex
+	ids map[uint]struct{}
+}
+
+func (s *traderSet) set(ids map[uint]struct{}) {
+	s.mu.Lock()
+	s.ids = ids
+	s.mu.Unlock()
+}
+
+func (s *traderSet) has(id uint) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.ids[id]
+	return ok
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/fathimasithara01/tradeverse/internal/customer/service"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -80,7 +81,13 @@ func (ctrl *TraderController) GetTraderPerformance(c *gin.Context) {
 		return
 	}
 
-	_, err = ctrl.traderService.GetTraderDetails(uint(traderID))
+	period, err := models.ParsePerformancePeriod(c.DefaultQuery("period", string(models.PerformancePeriodAll)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := ctrl.traderService.GetTraderDetails(uint(traderID))
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Trader not found or not approved"})
@@ -90,7 +97,7 @@ func (ctrl *TraderController) GetTraderPerformance(c *gin.Context) {
 		return
 	}
 
-	performance, err := ctrl.traderService.GetTraderPerformance(uint(traderID))
+	performance, err := ctrl.traderService.GetTraderPerformance(trader.UserID, period)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve trader performance"})
 		return
@@ -7,7 +7,11 @@ import (
 
 	"github.com/fathimasithara01/tradeverse/internal/customer/repository/walletrepo"
 	"github.com/fathimasithara01/tradeverse/internal/customer/service"
+	"github.com/fathimasithara01/tradeverse/pkg/fx"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
+	paymentgateway "github.com/fathimasithara01/tradeverse/pkg/payment_gateway.go"
+	"github.com/fathimasithara01/tradeverse/pkg/transfer"
+	"github.com/fathimasithara01/tradeverse/pkg/withdrawal"
 	"github.com/gin-gonic/gin"
 )
 
@@ -45,7 +49,7 @@ func (ctrl *WalletController) InitiateDeposit(c *gin.Context) {
 
 	resp, err := ctrl.WalletSvc.InitiateDeposit(userID, input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(gatewayErrorStatus(err), gin.H{"message": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -95,10 +99,10 @@ func (ctrl *WalletController) RequestWithdrawal(c *gin.Context) {
 	if err != nil {
 		statusCode := http.StatusInternalServerError
 		switch {
-		case errors.Is(err, service.ErrWalletServiceInsufficientFunds):
+		case errors.Is(err, withdrawal.ErrInsufficientFunds), errors.Is(err, withdrawal.ErrInvalidAmount):
 			statusCode = http.StatusBadRequest
-		case errors.Is(err, service.ErrUserWalletNotFound):
-			statusCode = http.StatusNotFound
+		case errors.Is(err, withdrawal.ErrLimitExceeded), errors.Is(err, fx.ErrRateNotFound):
+			statusCode = http.StatusUnprocessableEntity
 		}
 		c.JSON(statusCode, gin.H{"message": err.Error()})
 		return
@@ -107,6 +111,75 @@ func (ctrl *WalletController) RequestWithdrawal(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+func (ctrl *WalletController) Transfer(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var input models.TransferInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	sent, err := ctrl.WalletSvc.Transfer(c.Request.Context(), userID, input)
+	if err != nil {
+		c.JSON(transferErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer sent", "transfer": sent})
+}
+
+func (ctrl *WalletController) GetTransfers(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	var pagination models.PaginationParams
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	transfers, totalCount, err := ctrl.WalletSvc.GetTransfers(c.Request.Context(), userID, pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transfers":  transfers,
+		"totalCount": totalCount,
+	})
+}
+
+// transferErrorStatus maps transfer failures to HTTP statuses.
+func transferErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, transfer.ErrRecipientNotFound), errors.Is(err, transfer.ErrWalletNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, transfer.ErrKYCRequired):
+		return http.StatusForbidden
+	case errors.Is(err, transfer.ErrLimitExceeded), errors.Is(err, transfer.ErrFundsOnHold), errors.Is(err, fx.ErrRateNotFound):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, transfer.ErrInvalidAmount), errors.Is(err, transfer.ErrRecipientRequired),
+		errors.Is(err, transfer.ErrRecipientUnavailable), errors.Is(err, transfer.ErrSelfTransfer),
+		errors.Is(err, transfer.ErrInsufficientFunds):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// gatewayErrorStatus maps payment gateway failures to the status a client can
+// act on: a decline is final, a timeout may be retried.
+func gatewayErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, paymentgateway.ErrPaymentDeclined):
+		return http.StatusPaymentRequired
+	case errors.Is(err, paymentgateway.ErrGatewayTimeout):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func (ctrl *WalletController) GetWalletTransactions(c *gin.Context) {
 	userID := c.MustGet("userID").(uint)
 	var pagination models.PaginationParams
@@ -1,28 +1,57 @@
 package middleware
 
 import (
+	"bytes"
+	"io"
 	"net/http"
 	"strings"
+	"time"
 
-	"github.com/fathimasithara01/tradeverse/pkg/auth"
+	"github.com/fathimasithara01/tradeverse/pkg/apikey"
+	"github.com/fathimasithara01/tradeverse/pkg/session"
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// Headers of requests made with a personal API key. The key can also be sent
+// as "Authorization: ApiKey {key}".
+const (
+	HeaderAPIKey       = "X-API-Key"
+	HeaderAPITimestamp = "X-API-Timestamp"
+	HeaderAPISignature = "X-API-Signature"
+)
+
+const (
+	authSchemeBearer = "Bearer"
+	authSchemeAPIKey = "ApiKey"
+)
+
+// AuthMiddleware accepts an access token whose session has not been revoked.
+// When keys is non-nil it also accepts a personal API key, but only on the
+// routes listed in routes and only if the key was granted the route's scope.
+func AuthMiddleware(sessions *session.Service, keys *apikey.Service, routes apikey.Routes) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+		key := c.GetHeader(HeaderAPIKey)
+		if key == "" && strings.HasPrefix(authHeader, authSchemeAPIKey+" ") {
+			key = strings.TrimPrefix(authHeader, authSchemeAPIKey+" ")
+		}
+		if key != "" && keys != nil {
+			authenticateAPIKey(c, keys, routes, key)
+			return
+		}
+
 		if authHeader == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is missing"})
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
+		if len(parts) != 2 || parts[0] != authSchemeBearer {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header format must be Bearer {token}"})
 			return
 		}
 
-		claims, err := auth.ValidateJWT(parts[1], jwtSecret)
+		claims, err := sessions.Authenticate(parts[1])
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			return
@@ -31,7 +60,66 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		c.Set("userID", claims.UserID)
 		c.Set("userEmail", claims.Email)
 		c.Set("userRole", claims.Role)
+		c.Set("sessionID", claims.SessionID)
 
 		c.Next()
 	}
 }
+
+func authenticateAPIKey(c *gin.Context, keys *apikey.Service, routes apikey.Routes, key string) {
+	scope, ok := routes.ScopeFor(c.Request.Method, c.FullPath())
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This endpoint cannot be used with an API key"})
+		return
+	}
+
+	req := apikey.Request{
+		Method:     c.Request.Method,
+		RequestURI: c.Request.URL.RequestURI(),
+		Signature:  c.GetHeader(HeaderAPISignature),
+		Timestamp:  c.GetHeader(HeaderAPITimestamp),
+		IPAddress:  c.ClientIP(),
+	}
+	if req.Signature != "" {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		req.Body = body
+	}
+
+	principal, err := keys.Authenticate(key, req, time.Now())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if !principal.Has(scope) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key is missing the " + string(scope) + " scope"})
+		return
+	}
+
+	c.Set("userID", principal.UserID)
+	c.Set("userEmail", principal.Email)
+	c.Set("userRole", string(principal.Role))
+	c.Set("apiKeyID", principal.KeyID)
+	c.Set("apiKeyScopes", principal.Scopes)
+
+	c.Next()
+}
+
+// StreamAuthMiddleware also accepts the token as ?token=, because browsers
+// cannot set headers on EventSource or WebSocket requests. API keys are not
+// accepted on streams.
+func StreamAuthMiddleware(sessions *session.Service) gin.HandlerFunc {
+	authenticate := AuthMiddleware(sessions, nil, nil)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query("token"); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		authenticate(c)
+	}
+}
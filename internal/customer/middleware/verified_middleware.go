@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireVerifiedEmail refuses the request until the user has verified their
+// email address. It must run after AuthMiddleware.
+func RequireVerifiedEmail(isVerified func(userID uint) (bool, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("userID")
+		verified, err := isVerified(userID)
+		if err != nil {
+			log.Printf("[VERIFIED] failed to check email verification of user %d: %v", userID, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check email verification"})
+			return
+		}
+		if !verified {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Please verify your email address first", "email_verification_required": true})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,108 @@
+package customerrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ICopyProfileRepository interface {
+	IsTrader(ctx context.Context, userID uint) (bool, error)
+	GetByID(ctx context.Context, id uint) (*models.CopyProfile, error)
+	GetByPair(ctx context.Context, followerID, masterID uint) (*models.CopyProfile, error)
+	ListByFollower(ctx context.Context, followerID uint) ([]models.CopyProfile, error)
+	Create(tx *gorm.DB, profile *models.CopyProfile) error
+	Save(tx *gorm.DB, profile *models.CopyProfile) error
+	SetSessionActive(tx *gorm.DB, followerID, masterID uint, active bool) error
+}
+
+type CopyProfileRepository struct {
+	db *gorm.DB
+}
+
+func NewCopyProfileRepository(db *gorm.DB) ICopyProfileRepository {
+	return &CopyProfileRepository{db: db}
+}
+
+func (r *CopyProfileRepository) IsTrader(ctx context.Context, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND role = ?", userID, models.RoleTrader).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check trader %d: %w", userID, err)
+	}
+	return count > 0, nil
+}
+
+func (r *CopyProfileRepository) GetByID(ctx context.Context, id uint) (*models.CopyProfile, error) {
+	var profile models.CopyProfile
+	if err := r.db.WithContext(ctx).First(&profile, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get copy profile %d: %w", id, err)
+	}
+	return &profile, nil
+}
+
+func (r *CopyProfileRepository) GetByPair(ctx context.Context, followerID, masterID uint) (*models.CopyProfile, error) {
+	var profile models.CopyProfile
+	err := r.db.WithContext(ctx).
+		Where("follower_id = ? AND master_id = ?", followerID, masterID).
+		First(&profile).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get copy profile: %w", err)
+	}
+	return &profile, nil
+}
+
+func (r *CopyProfileRepository) ListByFollower(ctx context.Context, followerID uint) ([]models.CopyProfile, error) {
+	var profiles []models.CopyProfile
+	err := r.db.WithContext(ctx).
+		Where("follower_id = ?", followerID).
+		Order("created_at desc").
+		Find(&profiles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list copy profiles: %w", err)
+	}
+	return profiles, nil
+}
+
+func (r *CopyProfileRepository) Create(tx *gorm.DB, profile *models.CopyProfile) error {
+	return tx.Create(profile).Error
+}
+
+func (r *CopyProfileRepository) Save(tx *gorm.DB, profile *models.CopyProfile) error {
+	return tx.Save(profile).Error
+}
+
+// SetSessionActive starts or stops the copy session for a pair. Activating resets
+// StartedAt so trades the master opened while the session was off are not copied,
+// and restores a session that was soft-deleted.
+func (r *CopyProfileRepository) SetSessionActive(tx *gorm.DB, followerID, masterID uint, active bool) error {
+	if !active {
+		return tx.Model(&models.CopySession{}).
+			Where("follower_id = ? AND master_id = ?", followerID, masterID).
+			Update("is_active", false).Error
+	}
+
+	session := models.CopySession{
+		FollowerID: followerID,
+		MasterID:   masterID,
+		IsActive:   true,
+		StartedAt:  time.Now(),
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "follower_id"}, {Name: "master_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"is_active", "started_at", "deleted_at"}),
+	}).Create(&session).Error
+}
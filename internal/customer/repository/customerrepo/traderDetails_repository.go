@@ -1,6 +1,8 @@
 package customerrepo
 
 import (
+	"time"
+
 	"github.com/fathimasithara01/tradeverse/pkg/models"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -69,3 +71,35 @@ func (r *TraderRepository) FindTraderProfileWithUser(traderID uint) (*models.Tra
 	}
 	return &traderProfile, nil
 }
+
+func (r *TraderRepository) FindPerformance(traderUserID uint) (*models.TraderPerformance, error) {
+	var performance models.TraderPerformance
+	if err := r.db.Where("trader_id = ?", traderUserID).First(&performance).Error; err != nil {
+		return nil, err
+	}
+	return &performance, nil
+}
+
+// FindClosedTrades returns the trader's own closed trades since the given time,
+// oldest first. A zero since returns the full history.
+func (r *TraderRepository) FindClosedTrades(traderUserID uint, since time.Time) ([]models.Trade, error) {
+	var trades []models.Trade
+	query := r.db.Where("trader_id = ? AND status = ? AND is_copy_trade = ? AND closed_at IS NOT NULL", traderUserID, models.TradeStatusClosed, false)
+	if !since.IsZero() {
+		query = query.Where("closed_at >= ?", since)
+	}
+	err := query.Order("closed_at ASC").Find(&trades).Error
+	return trades, err
+}
+
+func (r *TraderRepository) FindResolvedSignals(traderUserID uint, since time.Time) ([]models.Signal, error) {
+	var signals []models.Signal
+	query := r.db.Where("trader_id = ? AND status IN ? AND exit_price > 0", traderUserID, []models.SignalStatus{
+		models.SignalStatusTargetHit, models.SignalStatusStopLoss, models.SignalStatusExpired, models.SignalStatusCancelled,
+	})
+	if !since.IsZero() {
+		query = query.Where("deactivated_at >= ?", since)
+	}
+	err := query.Find(&signals).Error
+	return signals, err
+}
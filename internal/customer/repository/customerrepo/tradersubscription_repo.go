@@ -17,8 +17,6 @@ type ICustomerTraderSignalSubscriptionRepository interface {
 	GetActiveTraderSubscriptionsForCustomer(ctx context.Context, customerID uint) ([]models.CustomerTraderSignalSubscription, error)
 	GetAllSignalsFromSubscribedTraders(ctx context.Context, customerID uint) ([]models.Signal, error)
 	GetTraderByID(ctx context.Context, traderID uint) (*models.User, error)
-	UpdateWalletBalance(ctx context.Context, userID uint, amount float64, tx *gorm.DB) error
-	CreateWalletTransaction(ctx context.Context, transaction *models.WalletTransaction, tx *gorm.DB) error
 	GetAdminWallet(ctx context.Context) (*models.Wallet, error)
 	GetTraderWallet(ctx context.Context, traderID uint) (*models.Wallet, error)
 	IsCustomerSubscribedToPlan(ctx context.Context, customerID, planID uint) (bool, error)
@@ -126,6 +124,7 @@ func (r *CustomerTraderSignalSubscriptionRepository) GetAllSignalsFromSubscribed
 	}
 
 	err = r.db.WithContext(ctx).
+		Preload("Targets", func(db *gorm.DB) *gorm.DB { return db.Order("sequence ASC") }).
 		Where("trader_id IN (?)", subscribedTraderIDs).
 		Order("published_at DESC").
 		Find(&signals).Error
@@ -135,15 +134,6 @@ func (r *CustomerTraderSignalSubscriptionRepository) GetAllSignalsFromSubscribed
 	return signals, nil
 }
 
-func (r *CustomerTraderSignalSubscriptionRepository) UpdateWalletBalance(ctx context.Context, userID uint, amount float64, tx *gorm.DB) error {
-	return tx.WithContext(ctx).Model(&models.Wallet{}).Where("user_id = ?", userID).
-		Update("balance", gorm.Expr("balance + ?", amount)).Error
-}
-
-func (r *CustomerTraderSignalSubscriptionRepository) CreateWalletTransaction(ctx context.Context, transaction *models.WalletTransaction, tx *gorm.DB) error {
-	return tx.WithContext(ctx).Create(transaction).Error
-}
-
 func (r *CustomerTraderSignalSubscriptionRepository) GetAdminWallet(ctx context.Context) (*models.Wallet, error) {
 	var adminUser models.User
 
@@ -151,7 +141,7 @@ func (r *CustomerTraderSignalSubscriptionRepository) GetAdminWallet(ctx context.
 		return nil, fmt.Errorf("admin user not found: %w", err)
 	}
 	var adminWallet models.Wallet
-	if err := r.db.WithContext(ctx).Where("user_id = ?", adminUser.ID).First(&adminWallet).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(models.PrimaryWallet).Where("user_id = ?", adminUser.ID).First(&adminWallet).Error; err != nil {
 		return nil, fmt.Errorf("admin wallet not found: %w", err)
 	}
 	return &adminWallet, nil
@@ -159,7 +149,7 @@ func (r *CustomerTraderSignalSubscriptionRepository) GetAdminWallet(ctx context.
 
 func (r *CustomerTraderSignalSubscriptionRepository) GetTraderWallet(ctx context.Context, traderID uint) (*models.Wallet, error) {
 	var traderWallet models.Wallet
-	if err := r.db.WithContext(ctx).Where("user_id = ?", traderID).First(&traderWallet).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(models.PrimaryWallet).Where("user_id = ?", traderID).First(&traderWallet).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("trader wallet not found for trader ID %d", traderID)
 		}
@@ -14,7 +14,6 @@ var (
 
 type IAdminWalletRepository interface {
 	GetAdminWallet() (*models.Wallet, error)
-	UpdateWalletBalance(tx *gorm.DB, adminWallet *models.Wallet) error
 	CreateAdminWallet(adminWallet *models.Wallet) error 
 }
 
@@ -38,10 +37,6 @@ func (r *adminWalletRepository) GetAdminWallet() (*models.Wallet, error) {
 	return &adminWallet, nil
 }
 
-func (r *adminWalletRepository) UpdateWalletBalance(tx *gorm.DB, adminWallet *models.Wallet) error {
-	return tx.Save(adminWallet).Error
-}
-
 func (r *adminWalletRepository) CreateAdminWallet(adminWallet *models.Wallet) error {
 	return r.db.Create(adminWallet).Error
 }
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/fathimasithara01/tradeverse/pkg/ledger"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
@@ -20,21 +23,25 @@ var (
 type WalletRepository interface {
 	GetUserWallet(userID uint) (*models.Wallet, error)
 	GetOrCreateWallet(userID uint) (*models.Wallet, error)
-	UpdateWallet(wallet *models.Wallet) error
-	UpdateWalletTx(tx *gorm.DB, wallet *models.Wallet) error
+	GetCurrencyWallet(tx *gorm.DB, userID uint, currency string) (*models.Wallet, error)
+	ListUserWallets(userID uint) ([]models.Wallet, error)
 
-	DebitWallet(tx *gorm.DB, walletID uint, amount float64, txType models.TransactionType, referenceID, description string) error
-	CreditWallet(tx *gorm.DB, walletID uint, amount float64, txType models.TransactionType, referenceID, description string) (*models.WalletTransaction, error)
-	CreateWalletTransaction(tx *gorm.DB, transaction *models.WalletTransaction) error
+	DebitWallet(tx *gorm.DB, walletID uint, amount money.Amount, txType models.TransactionType, referenceID, description string, counterparty ledger.Account) (*models.WalletTransaction, error)
+	CreditWallet(tx *gorm.DB, walletID uint, amount money.Amount, txType models.TransactionType, referenceID, description string, counterparty ledger.Account) (*models.WalletTransaction, error)
+	HasTransactionReference(tx *gorm.DB, referenceID string) (bool, error)
 	GetWalletTransactions(userID uint, pagination models.PaginationParams) ([]models.WalletTransaction, int64, error)
 
 	CreateDepositRequest(req *models.DepositRequest) error
 	GetDepositRequestByID(id uint) (*models.DepositRequest, error)
 	UpdateDepositRequest(req *models.DepositRequest) error
 	UpdateDepositRequestTx(tx *gorm.DB, req *models.DepositRequest) error
+	LockDepositRequest(tx *gorm.DB, id uint) (*models.DepositRequest, error)
+	LockDepositRequestByGatewayTxID(tx *gorm.DB, pgTxID string) (*models.DepositRequest, error)
 	CreateWithdrawalRequest(req *models.WithdrawalRequest) error
+	CreateWithdrawalRequestTx(tx *gorm.DB, req *models.WithdrawalRequest) error
 	GetWithdrawalRequestByID(id uint) (*models.WithdrawalRequest, error)
 	UpdateWithdrawalRequestTx(tx *gorm.DB, req *models.WithdrawalRequest) error
+	LockWithdrawalRequestByGatewayTxID(tx *gorm.DB, pgTxID string) (*models.WithdrawalRequest, error)
 }
 
 type gormWalletRepository struct {
@@ -47,7 +54,7 @@ func NewWalletRepository(db *gorm.DB) WalletRepository {
 
 func (r *gormWalletRepository) GetUserWallet(userID uint) (*models.Wallet, error) {
 	var wallet models.Wallet
-	if err := r.db.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
+	if err := r.db.Scopes(models.PrimaryWallet).Where("user_id = ?", userID).First(&wallet).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrWalletNotFound
 		}
@@ -58,7 +65,7 @@ func (r *gormWalletRepository) GetUserWallet(userID uint) (*models.Wallet, error
 
 func (r *gormWalletRepository) GetOrCreateWallet(userID uint) (*models.Wallet, error) {
 	var wallet models.Wallet
-	err := r.db.Where("user_id = ?", userID).First(&wallet).Error
+	err := r.db.Scopes(models.PrimaryWallet).Where("user_id = ?", userID).First(&wallet).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		wallet = models.Wallet{
 			UserID:      userID,
@@ -77,90 +84,88 @@ func (r *gormWalletRepository) GetOrCreateWallet(userID uint) (*models.Wallet, e
 	return &wallet, nil
 }
 
-func (r *gormWalletRepository) UpdateWallet(wallet *models.Wallet) error {
-	wallet.LastUpdated = time.Now()
-	return r.db.Save(wallet).Error
-}
-
-func (r *gormWalletRepository) UpdateWalletTx(tx *gorm.DB, wallet *models.Wallet) error {
-	wallet.LastUpdated = time.Now()
-	return tx.Save(wallet).Error
-}
-
-func (r *gormWalletRepository) DebitWallet(tx *gorm.DB, walletID uint, amount float64, txType models.TransactionType, referenceID, description string) error {
+// GetCurrencyWallet returns the user's wallet in currency, opening an empty
+// sub-balance when the user holds none in it yet.
+func (r *gormWalletRepository) GetCurrencyWallet(tx *gorm.DB, userID uint, currency string) (*models.Wallet, error) {
 	var wallet models.Wallet
-	if err := tx.First(&wallet, walletID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return ErrWalletNotFound
-		}
-		return fmt.Errorf("failed to find wallet %d for debit: %w", walletID, err)
+	err := tx.Where("user_id = ? AND currency = ?", userID, currency).First(&wallet).Error
+	if err == nil {
+		return &wallet, nil
 	}
-
-	if wallet.Balance < amount {
-		return ErrInsufficientFunds
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get %s wallet for user %d: %w", currency, userID, err)
 	}
 
-	balanceBefore := wallet.Balance
-	wallet.Balance -= amount
-	wallet.LastUpdated = time.Now()
-	if err := tx.Save(&wallet).Error; err != nil {
-		return fmt.Errorf("failed to update wallet balance during debit: %w", err)
+	if _, err := r.GetUserWallet(userID); err != nil {
+		return nil, err
 	}
-
-	transaction := &models.WalletTransaction{
-		WalletID:        walletID,
-		UserID:          wallet.UserID,
-		TransactionType: txType,
-		Amount:          amount,
-		Currency:        wallet.Currency,
-		Status:          models.TxStatusSuccess,
-		ReferenceID:     referenceID,
-		Description:     description,
-		BalanceBefore:   balanceBefore,
-		BalanceAfter:    wallet.Balance,
+	wallet = models.Wallet{UserID: userID, Currency: currency, SubBalance: true, LastUpdated: time.Now()}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&wallet).Error; err != nil {
+		return nil, fmt.Errorf("failed to open %s sub-balance for user %d: %w", currency, userID, err)
+	}
+	if err := tx.Where("user_id = ? AND currency = ?", userID, currency).First(&wallet).Error; err != nil {
+		return nil, fmt.Errorf("failed to get %s wallet for user %d: %w", currency, userID, err)
 	}
-	if err := tx.Create(transaction).Error; err != nil {
-		return fmt.Errorf("failed to create debit transaction record: %w", err)
+	return &wallet, nil
+}
+
+// ListUserWallets returns the primary wallet followed by the sub-balances.
+func (r *gormWalletRepository) ListUserWallets(userID uint) ([]models.Wallet, error) {
+	var wallets []models.Wallet
+	if err := r.db.Where("user_id = ?", userID).Order("sub_balance ASC, currency ASC").Find(&wallets).Error; err != nil {
+		return nil, fmt.Errorf("failed to list wallets for user %d: %w", userID, err)
 	}
-	return nil
+	return wallets, nil
+}
+
+// DebitWallet moves amount from the wallet to the counterparty ledger account.
+func (r *gormWalletRepository) DebitWallet(tx *gorm.DB, walletID uint, amount money.Amount, txType models.TransactionType, referenceID, description string, counterparty ledger.Account) (*models.WalletTransaction, error) {
+	return r.post(tx, walletID, -amount, txType, referenceID, description, counterparty)
 }
 
-func (r *gormWalletRepository) CreditWallet(tx *gorm.DB, walletID uint, amount float64, txType models.TransactionType, referenceID, description string) (*models.WalletTransaction, error) {
+// CreditWallet moves amount from the counterparty ledger account to the wallet.
+func (r *gormWalletRepository) CreditWallet(tx *gorm.DB, walletID uint, amount money.Amount, txType models.TransactionType, referenceID, description string, counterparty ledger.Account) (*models.WalletTransaction, error) {
+	return r.post(tx, walletID, amount, txType, referenceID, description, counterparty)
+}
+
+func (r *gormWalletRepository) post(tx *gorm.DB, walletID uint, amount money.Amount, txType models.TransactionType, referenceID, description string, counterparty ledger.Account) (*models.WalletTransaction, error) {
 	var wallet models.Wallet
 	if err := tx.First(&wallet, walletID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrWalletNotFound
 		}
-		return nil, fmt.Errorf("failed to find wallet %d for credit: %w", walletID, err)
-	}
-
-	balanceBefore := wallet.Balance
-	wallet.Balance += amount
-	wallet.LastUpdated = time.Now()
-	if err := tx.Save(&wallet).Error; err != nil {
-		return nil, fmt.Errorf("failed to update wallet balance during credit: %w", err)
+		return nil, fmt.Errorf("failed to find wallet %d: %w", walletID, err)
 	}
 
 	transaction := &models.WalletTransaction{
-		WalletID:        walletID,
-		UserID:          wallet.UserID,
-		TransactionType: txType,
-		Amount:          amount,
-		Currency:        wallet.Currency,
-		Status:          models.TxStatusSuccess,
-		ReferenceID:     referenceID,
-		Description:     description,
-		BalanceBefore:   balanceBefore,
-		BalanceAfter:    wallet.Balance,
-	}
-	if err := tx.Create(transaction).Error; err != nil {
-		return nil, fmt.Errorf("failed to create credit transaction record: %w", err)
+		Type:        txType,
+		ReferenceID: referenceID,
+		Description: description,
+	}
+	_, err := ledger.Post(tx, ledger.Entry{
+		Type:        txType,
+		Reference:   referenceID,
+		Description: description,
+		Legs: []ledger.Leg{
+			{Account: ledger.WalletIn(wallet.UserID, wallet.Currency), Amount: amount, Tx: transaction},
+			{Account: counterparty, Amount: -amount},
+		},
+	})
+	if errors.Is(err, ledger.ErrInsufficientFunds) {
+		return nil, ErrInsufficientFunds
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to post %s for wallet %d: %w", txType, walletID, err)
 	}
 	return transaction, nil
 }
 
-func (r *gormWalletRepository) CreateWalletTransaction(tx *gorm.DB, transaction *models.WalletTransaction) error {
-	return tx.Create(transaction).Error
+func (r *gormWalletRepository) HasTransactionReference(tx *gorm.DB, referenceID string) (bool, error) {
+	var count int64
+	if err := tx.Model(&models.WalletTransaction{}).Where("reference_id = ?", referenceID).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to look up wallet transaction %s: %w", referenceID, err)
+	}
+	return count > 0, nil
 }
 
 func (r *gormWalletRepository) GetWalletTransactions(userID uint, pagination models.PaginationParams) ([]models.WalletTransaction, int64, error) {
@@ -214,10 +219,39 @@ func (r *gormWalletRepository) UpdateDepositRequestTx(tx *gorm.DB, req *models.D
 	return tx.Save(req).Error
 }
 
+// LockDepositRequest loads the deposit with a row lock held until tx ends.
+func (r *gormWalletRepository) LockDepositRequest(tx *gorm.DB, id uint) (*models.DepositRequest, error) {
+	var req models.DepositRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&req, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDepositRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to lock deposit request %d: %w", id, err)
+	}
+	return &req, nil
+}
+
+func (r *gormWalletRepository) LockDepositRequestByGatewayTxID(tx *gorm.DB, pgTxID string) (*models.DepositRequest, error) {
+	var req models.DepositRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("payment_gateway_tx_id = ?", pgTxID).
+		First(&req).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDepositRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to lock deposit request for gateway tx %s: %w", pgTxID, err)
+	}
+	return &req, nil
+}
+
 func (r *gormWalletRepository) CreateWithdrawalRequest(req *models.WithdrawalRequest) error {
 	return r.db.Create(req).Error
 }
 
+func (r *gormWalletRepository) CreateWithdrawalRequestTx(tx *gorm.DB, req *models.WithdrawalRequest) error {
+	return tx.Create(req).Error
+}
+
 func (r *gormWalletRepository) GetWithdrawalRequestByID(id uint) (*models.WithdrawalRequest, error) {
 	var req models.WithdrawalRequest
 	if err := r.db.First(&req, id).Error; err != nil {
@@ -232,3 +266,16 @@ func (r *gormWalletRepository) GetWithdrawalRequestByID(id uint) (*models.Withdr
 func (r *gormWalletRepository) UpdateWithdrawalRequestTx(tx *gorm.DB, req *models.WithdrawalRequest) error {
 	return tx.Save(req).Error
 }
+
+func (r *gormWalletRepository) LockWithdrawalRequestByGatewayTxID(tx *gorm.DB, pgTxID string) (*models.WithdrawalRequest, error) {
+	var req models.WithdrawalRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("payment_gateway_tx_id = ?", pgTxID).
+		First(&req).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWithdrawalRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to lock withdrawal request for gateway tx %s: %w", pgTxID, err)
+	}
+	return &req, nil
+}
@@ -0,0 +1,48 @@
+package walletrepo
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrWebhookNonceSeen = errors.New("webhook nonce already used")
+
+type WebhookEventRepository interface {
+	// CreateEvent stores the event, or returns ErrWebhookNonceSeen when an event
+	// with the same nonce was already stored.
+	CreateEvent(event *models.PaymentWebhookEvent) error
+	UpdateEvent(event *models.PaymentWebhookEvent) error
+}
+
+type gormWebhookEventRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookEventRepository(db *gorm.DB) WebhookEventRepository {
+	return &gormWebhookEventRepository{db: db}
+}
+
+func (r *gormWebhookEventRepository) CreateEvent(event *models.PaymentWebhookEvent) error {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "nonce"}},
+		DoNothing: true,
+	}).Create(event)
+	if result.Error != nil {
+		return fmt.Errorf("failed to store webhook event %s: %w", event.EventID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookNonceSeen
+	}
+	return nil
+}
+
+func (r *gormWebhookEventRepository) UpdateEvent(event *models.PaymentWebhookEvent) error {
+	if err := r.db.Save(event).Error; err != nil {
+		return fmt.Errorf("failed to update webhook event %d: %w", event.ID, err)
+	}
+	return nil
+}
@@ -1,12 +1,31 @@
 package router
 
 import (
+	"net/http"
+
 	"github.com/fathimasithara01/tradeverse/config"
 	"github.com/fathimasithara01/tradeverse/internal/customer/controllers"
 	"github.com/fathimasithara01/tradeverse/internal/customer/middleware"
+	"github.com/fathimasithara01/tradeverse/pkg/apikey"
+	"github.com/fathimasithara01/tradeverse/pkg/idempotency"
+	"github.com/fathimasithara01/tradeverse/pkg/ratelimit"
+	"github.com/fathimasithara01/tradeverse/pkg/session"
+	"github.com/fathimasithara01/tradeverse/pkg/twofactor"
 	"github.com/gin-gonic/gin"
 )
 
+// apiKeyRoutes lists the routes that accept personal API keys and the scope
+// each needs. Everything else, including managing the keys themselves, needs
+// a logged-in session.
+var apiKeyRoutes = apikey.Routes{
+	http.MethodGet + " /api/v1/signals":                        apikey.ScopeSignalsRead,
+	http.MethodGet + " /api/v1/my-trader-subscriptions":        apikey.ScopeSignalsRead,
+	http.MethodGet + " /api/v1/subscribed-to-trader/:traderId": apikey.ScopeSignalsRead,
+	http.MethodGet + " /api/v1/wallet/summary":                 apikey.ScopeWalletRead,
+	http.MethodGet + " /api/v1/wallet/transactions":            apikey.ScopeWalletRead,
+	http.MethodGet + " /api/v1/wallet/transfers":               apikey.ScopeWalletRead,
+}
+
 func SetupRouter(
 	cfg *config.Config,
 	authController *controllers.AuthController,
@@ -16,27 +35,70 @@ func SetupRouter(
 	traderController *controllers.TraderController,
 	custmerTraderSignlsController *controllers.CustomerTraderSignalSubscriptionController,
 	subscriptionPlanController *controllers.SubscriptionPlanController,
+	copyProfileController *controllers.CopyProfileController,
+	candleController *controllers.CandleController,
+	streamController *controllers.StreamController,
+	paymentWebhookController *controllers.PaymentWebhookController,
+	apiKeyController *controllers.APIKeyController,
+	idempotencyStore idempotency.Store,
+	sessions *session.Service,
+	twoFactor *twofactor.Service,
+	limiter *ratelimit.Limiter,
 ) *gin.Engine {
 	r := gin.Default()
+	idempotent := idempotency.Middleware(idempotencyStore, idempotency.Options{})
+	stepUp := twoFactor.RequireCode(authController.UserSvc.TwoFactorRequired)
+	verified := middleware.RequireVerifiedEmail(authController.AccountSvc.IsVerified)
+	authLimit := limiter.Group("auth", ratelimit.ByIP)
 
 	public := r.Group("/api/v1")
 	{
-		public.POST("/signup", authController.Signup)
-		public.POST("/login", authController.Login)
+		public.POST("/signup", authLimit, authController.Signup)
+		public.POST("/login", authLimit, authController.Login)
+		public.POST("/auth/refresh", authLimit, authController.Refresh)
+		public.POST("/auth/2fa/verify", authLimit, authController.VerifyTwoFactor)
+		public.POST("/auth/2fa/login-enroll", authLimit, authController.EnrollTwoFactorAtLogin)
+		public.GET("/auth/verify-email", authLimit, authController.VerifyEmail)
+		public.POST("/auth/verify-email", authLimit, authController.VerifyEmail)
+		public.POST("/auth/verify-email/resend", authLimit, authController.ResendVerificationEmail)
+		public.POST("/auth/password/forgot", authLimit, authController.ForgotPassword)
+		public.POST("/auth/password/reset", authLimit, authController.ResetPassword)
 
 		public.GET("/traders", traderController.ListTraders)
 		public.GET("/traders/:trader_id", traderController.GetTraderDetails)
 		public.GET("/traders/:trader_id/performance", traderController.GetTraderPerformance)
+
+		public.GET("/market/candles", candleController.GetCandles)
+
+		public.POST("/webhooks/payment-gateway", paymentWebhookController.HandlePaymentWebhook)
+	}
+
+	streamRoutes := r.Group("/api/v1/stream")
+	streamRoutes.Use(middleware.StreamAuthMiddleware(sessions))
+	{
+		streamRoutes.GET("/sse", streamController.StreamSSE)
+		streamRoutes.GET("/ws", streamController.StreamWebSocket)
 	}
 
 	protected := r.Group("/api/v1")
-	protected.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+	protected.Use(middleware.AuthMiddleware(sessions, apiKeyController.Keys, apiKeyRoutes), limiter.Group("api", ratelimit.ByUser))
 	{
+		protected.POST("/auth/logout", authController.Logout)
+
+		protected.GET("/auth/2fa", authController.TwoFactorStatus)
+		protected.POST("/auth/2fa/enroll", authController.EnrollTwoFactor)
+		protected.POST("/auth/2fa/confirm", authController.ConfirmTwoFactor)
+		protected.POST("/auth/2fa/disable", authController.DisableTwoFactor)
+		protected.POST("/auth/2fa/recovery-codes", authController.RegenerateRecoveryCodes)
+
+		protected.GET("/api-keys", apiKeyController.ListKeys)
+		protected.POST("/api-keys", stepUp, apiKeyController.CreateKey)
+		protected.DELETE("/api-keys/:id", apiKeyController.RevokeKey)
 
 		protected.GET("/subscription-plans", subscriptionPlanController.GetAllSubscriptionPlans)
 		protected.GET("/subscription-plans/:id", subscriptionPlanController.GetSubscriptionPlanByID)
-		protected.POST("/subscription-plans/:id/subscribe", subscriptionPlanController.SubscribeToPlan)
-		protected.DELETE("/my-subscriptions/:id", subscriptionPlanController.CancelSubscription)
+		protected.POST("/subscription-plans/:id/subscribe", verified, idempotent, subscriptionPlanController.SubscribeToPlan)
+		protected.DELETE("/my-subscriptions/:id", idempotent, subscriptionPlanController.CancelSubscription)
 		protected.GET("/my-subscriptions", subscriptionPlanController.GetUserSubscriptions)
 
 		protected.GET("/profile", profileController.GetProfile)
@@ -44,9 +106,10 @@ func SetupRouter(
 		protected.DELETE("/account", profileController.DeleteAccount)
 
 		protected.GET("/traders/plans", custmerTraderSignlsController.GetAvailableTradersWithPlans)
-		protected.POST("/subscribe", custmerTraderSignlsController.SubscribeToTrader)
+		protected.POST("/subscribe", verified, idempotent, custmerTraderSignlsController.SubscribeToTrader)
 		protected.GET("/signals", custmerTraderSignlsController.GetSignalsFromSubscribedTraders)
 		protected.GET("/my-trader-subscriptions", custmerTraderSignlsController.GetMyActiveTraderSubscriptions)
+		protected.DELETE("/my-trader-subscriptions/:id", idempotent, custmerTraderSignlsController.CancelTraderSubscription)
 		protected.GET("/subscribed-to-trader/:traderId", custmerTraderSignlsController.IsSubscribedToTrader)
 
 		kycGroup := protected.Group("/customers")
@@ -55,13 +118,25 @@ func SetupRouter(
 			kycGroup.GET("/kyc/status", kycController.GetKYCStatus)
 		}
 
+		copyRoutes := protected.Group("/copy-profiles")
+		{
+			copyRoutes.POST("", copyProfileController.CreateProfile)
+			copyRoutes.GET("", copyProfileController.ListProfiles)
+			copyRoutes.GET("/:id", copyProfileController.GetProfile)
+			copyRoutes.PUT("/:id", copyProfileController.UpdateProfile)
+			copyRoutes.POST("/:id/pause", copyProfileController.PauseProfile)
+			copyRoutes.POST("/:id/resume", copyProfileController.ResumeProfile)
+		}
+
 		walletRoutes := protected.Group("/wallet")
 		{
 			walletRoutes.GET("/summary", walletCtrl.GetWalletSummary)
-			walletRoutes.POST("/deposit/initiate", walletCtrl.InitiateDeposit)
-			walletRoutes.POST("/deposit/:deposit_id/verify", walletCtrl.VerifyDeposit)
-			walletRoutes.POST("/withdraw/request", walletCtrl.RequestWithdrawal)
+			walletRoutes.POST("/deposit/initiate", verified, idempotent, walletCtrl.InitiateDeposit)
+			walletRoutes.POST("/deposit/:deposit_id/verify", verified, idempotent, walletCtrl.VerifyDeposit)
+			walletRoutes.POST("/withdraw/request", stepUp, idempotent, walletCtrl.RequestWithdrawal)
 			walletRoutes.GET("/transactions", walletCtrl.GetWalletTransactions)
+			walletRoutes.POST("/transfers", stepUp, idempotent, walletCtrl.Transfer)
+			walletRoutes.GET("/transfers", walletCtrl.GetTransfers)
 		}
 	}
 
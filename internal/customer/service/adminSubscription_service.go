@@ -9,14 +9,18 @@ import (
 	adminRepo "github.com/fathimasithara01/tradeverse/internal/admin/repository"
 	adminService "github.com/fathimasithara01/tradeverse/internal/admin/service"
 	"github.com/fathimasithara01/tradeverse/internal/customer/repository/customerrepo"
+	"github.com/fathimasithara01/tradeverse/pkg/fx"
+	"github.com/fathimasithara01/tradeverse/pkg/ledger"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"github.com/fathimasithara01/tradeverse/pkg/refund"
 	"gorm.io/gorm"
 )
 
 type ICustomerSubscriptionService interface {
-	CreateSubscription(userID, planID uint, amount float64, transactionID string) (*models.CustomerToTraderSub, error)
+	CreateSubscription(userID, planID uint, amount money.Amount, transactionID string) (*models.CustomerToTraderSub, error)
 	GetSubscriptionsByUserID(userID uint) ([]models.CustomerToTraderSub, error)
-	CancelSubscription(userID, subscriptionID uint) error
+	CancelSubscription(userID, subscriptionID uint) (*models.SubscriptionCancellation, error)
 	DeactivateExpiredTraderSubscriptions() error
 }
 
@@ -73,7 +77,7 @@ func (s *CustomerSubscriptionService) DeactivateExpiredTraderSubscriptions() err
 	return nil
 }
 
-func (s *CustomerSubscriptionService) CreateSubscription(userID, planID uint, amount float64, transactionID string) (*models.CustomerToTraderSub, error) {
+func (s *CustomerSubscriptionService) CreateSubscription(userID, planID uint, amount money.Amount, transactionID string) (*models.CustomerToTraderSub, error) {
 	var subscription *models.CustomerToTraderSub
 
 	err := s.DB.Transaction(func(tx *gorm.DB) error {
@@ -82,65 +86,36 @@ func (s *CustomerSubscriptionService) CreateSubscription(userID, planID uint, am
 			return fmt.Errorf("plan not found: %w", err)
 		}
 
-		var customerWallet, adminWallet models.Wallet
-		if err := tx.Where("user_id = ?", userID).First(&customerWallet).Error; err != nil {
-			return fmt.Errorf("customer wallet not found: %w", err)
-		}
-		if err := tx.Where("user_id = ?", 1).First(&adminWallet).Error; err != nil { // admin ID = 1
-			return fmt.Errorf("admin wallet not found: %w", err)
-		}
-
-		if customerWallet.Balance < amount {
-			return fmt.Errorf("insufficient wallet balance")
-		}
-
-		beforeCustomer := customerWallet.Balance
-		beforeAdmin := adminWallet.Balance
-
-		customerWallet.Balance -= amount
-		adminWallet.Balance += amount
-		customerWallet.LastUpdated = time.Now()
-		adminWallet.LastUpdated = time.Now()
-
-		if err := tx.Save(&customerWallet).Error; err != nil {
-			return fmt.Errorf("failed to update customer wallet: %w", err)
-		}
-		if err := tx.Save(&adminWallet).Error; err != nil {
-			return fmt.Errorf("failed to update admin wallet: %w", err)
+		charge, err := fx.NewCharge(tx, userID, amount, plan.Currency)
+		if err != nil {
+			return fmt.Errorf("failed to price subscription: %w", err)
 		}
 
-		customerTx := models.WalletTransaction{
-			WalletID:        customerWallet.ID,
-			UserID:          userID,
+		customerTx := &models.WalletTransaction{
 			Type:            models.TxTypeSubscription,
 			TransactionType: models.TxTypeDebit,
-			Amount:          amount,
-			Currency:        plan.Currency,
-			Status:          models.TxStatusSuccess,
 			Description:     fmt.Sprintf("Subscription to %s", plan.Name),
-			BalanceBefore:   beforeCustomer,
-			BalanceAfter:    customerWallet.Balance,
 			TransactionID:   transactionID,
 		}
-		if err := tx.Create(&customerTx).Error; err != nil {
-			return fmt.Errorf("failed to record customer transaction: %w", err)
-		}
-
-		adminTx := models.WalletTransaction{
-			WalletID:        adminWallet.ID,
-			UserID:          1,
+		adminTx := &models.WalletTransaction{
 			Type:            models.TxTypeSubscription,
 			TransactionType: models.TxTypeCredit,
-			Amount:          amount,
 			Currency:        plan.Currency,
-			Status:          models.TxStatusSuccess,
 			Description:     fmt.Sprintf("Received subscription payment from user %d", userID),
-			BalanceBefore:   beforeAdmin,
-			BalanceAfter:    adminWallet.Balance,
 			TransactionID:   transactionID,
 		}
-		if err := tx.Create(&adminTx).Error; err != nil {
-			return fmt.Errorf("failed to record admin transaction: %w", err)
+		if _, err := ledger.Post(tx, ledger.Entry{
+			Type:      models.TxTypeSubscription,
+			Reference: transactionID,
+			Currency:  plan.Currency,
+			Legs: append(charge.Legs(userID, customerTx),
+				ledger.Credit(ledger.PlatformCommissionIn(plan.Currency), amount, adminTx),
+			),
+		}); err != nil {
+			if errors.Is(err, ledger.ErrInsufficientFunds) {
+				return ErrWalletServiceInsufficientFunds
+			}
+			return fmt.Errorf("failed to post subscription payment: %w", err)
 		}
 
 		startDate := time.Now()
@@ -154,6 +129,8 @@ func (s *CustomerSubscriptionService) CreateSubscription(userID, planID uint, am
 			PaymentStatus:      "paid",
 			AmountPaid:         amount,
 			TransactionID:      transactionID,
+			PaidCurrency:       charge.Currency,
+			PaidFXRate:         charge.Rate(),
 		}
 		if err := tx.Create(subscription).Error; err != nil {
 			return fmt.Errorf("failed to create subscription: %w", err)
@@ -197,26 +174,17 @@ func (s *CustomerSubscriptionService) GetSubscriptionsByUserID(userID uint) ([]m
 	return s.customerSubscriptionRepo.GetSubscriptionsByUserID(userID)
 }
 
-func (s *CustomerSubscriptionService) CancelSubscription(userID, subscriptionID uint) error {
-	subscription, err := s.customerSubscriptionRepo.GetSubscriptionByID(subscriptionID)
+// CancelSubscription ends one of the user's plan subscriptions and refunds it
+// under the plan's refund policy.
+func (s *CustomerSubscriptionService) CancelSubscription(userID, subscriptionID uint) (*models.SubscriptionCancellation, error) {
+	var cancellation *models.SubscriptionCancellation
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		cancellation, err = refund.CancelSubscription(tx, subscriptionID, userID, time.Now())
+		return err
+	})
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("subscription not found")
-		}
-		return fmt.Errorf("failed to retrieve subscription: %w", err)
-	}
-
-	if subscription.UserID != userID {
-		return fmt.Errorf("unauthorized to cancel this subscription")
-	}
-
-	if !subscription.IsActive {
-		return fmt.Errorf("subscription is already inactive or cancelled")
+		return nil, err
 	}
-
-	subscription.IsActive = false
-	subscription.PaymentStatus = "cancelled"
-	subscription.EndDate = time.Now()
-
-	return s.customerSubscriptionRepo.UpdateSubscription(subscription)
+	return cancellation, nil
 }
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/internal/customer/repository/customerrepo"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCopyProfileNotFound = errors.New("copy profile not found")
+	ErrCopyProfileExists   = errors.New("a copy profile for this trader already exists")
+	ErrMasterNotTrader     = errors.New("master must be an existing trader")
+	ErrInvalidCopyProfile  = errors.New("invalid copy profile")
+)
+
+type ICopyProfileService interface {
+	CreateProfile(ctx context.Context, followerID uint, req models.CopyProfileRequest) (*models.CopyProfile, error)
+	UpdateProfile(ctx context.Context, followerID, profileID uint, req models.UpdateCopyProfileRequest) (*models.CopyProfile, error)
+	PauseProfile(ctx context.Context, followerID, profileID uint) (*models.CopyProfile, error)
+	ResumeProfile(ctx context.Context, followerID, profileID uint) (*models.CopyProfile, error)
+	GetProfile(ctx context.Context, followerID, profileID uint) (*models.CopyProfile, error)
+	ListProfiles(ctx context.Context, followerID uint) ([]models.CopyProfile, error)
+}
+
+type CopyProfileService struct {
+	repo customerrepo.ICopyProfileRepository
+	db   *gorm.DB
+}
+
+func NewCopyProfileService(repo customerrepo.ICopyProfileRepository, db *gorm.DB) ICopyProfileService {
+	return &CopyProfileService{repo: repo, db: db}
+}
+
+// CreateProfile stores the follower's settings for a master and starts copying straight away.
+func (s *CopyProfileService) CreateProfile(ctx context.Context, followerID uint, req models.CopyProfileRequest) (*models.CopyProfile, error) {
+	isTrader, err := s.repo.IsTrader(ctx, req.MasterID)
+	if err != nil {
+		return nil, err
+	}
+	if !isTrader {
+		return nil, ErrMasterNotTrader
+	}
+
+	existing, err := s.repo.GetByPair(ctx, followerID, req.MasterID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrCopyProfileExists
+	}
+
+	profile := &models.CopyProfile{
+		FollowerID:            followerID,
+		MasterID:              req.MasterID,
+		SizingMode:            req.SizingMode,
+		FixedAmount:           req.FixedAmount,
+		EquityPercent:         req.EquityPercent,
+		Multiplier:            req.Multiplier,
+		MaxAllocationPerTrade: req.MaxAllocationPerTrade,
+		MaxOpenTrades:         req.MaxOpenTrades,
+		AllowedSymbols:        models.JoinSymbols(req.AllowedSymbols),
+		DeniedSymbols:         models.JoinSymbols(req.DeniedSymbols),
+		DailyLossCap:          req.DailyLossCap,
+		Status:                models.CopyProfileActive,
+	}
+	if profile.SizingMode == models.CopySizingMultiplier && profile.Multiplier == 0 {
+		profile.Multiplier = 1
+	}
+	if err := profile.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCopyProfile, err)
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.Create(tx, profile); err != nil {
+			return err
+		}
+		return s.repo.SetSessionActive(tx, followerID, req.MasterID, true)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+func (s *CopyProfileService) UpdateProfile(ctx context.Context, followerID, profileID uint, req models.UpdateCopyProfileRequest) (*models.CopyProfile, error) {
+	profile, err := s.GetProfile(ctx, followerID, profileID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.SizingMode != nil {
+		profile.SizingMode = *req.SizingMode
+	}
+	if req.FixedAmount != nil {
+		profile.FixedAmount = *req.FixedAmount
+	}
+	if req.EquityPercent != nil {
+		profile.EquityPercent = *req.EquityPercent
+	}
+	if req.Multiplier != nil {
+		profile.Multiplier = *req.Multiplier
+	}
+	if req.MaxAllocationPerTrade != nil {
+		profile.MaxAllocationPerTrade = *req.MaxAllocationPerTrade
+	}
+	if req.MaxOpenTrades != nil {
+		profile.MaxOpenTrades = *req.MaxOpenTrades
+	}
+	if req.AllowedSymbols != nil {
+		profile.AllowedSymbols = models.JoinSymbols(*req.AllowedSymbols)
+	}
+	if req.DeniedSymbols != nil {
+		profile.DeniedSymbols = models.JoinSymbols(*req.DeniedSymbols)
+	}
+	if req.DailyLossCap != nil {
+		profile.DailyLossCap = *req.DailyLossCap
+	}
+	if err := profile.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCopyProfile, err)
+	}
+
+	if err := s.repo.Save(s.db.WithContext(ctx), profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+func (s *CopyProfileService) PauseProfile(ctx context.Context, followerID, profileID uint) (*models.CopyProfile, error) {
+	profile, err := s.GetProfile(ctx, followerID, profileID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	profile.Status = models.CopyProfilePaused
+	profile.PausedReason = "paused by customer"
+	profile.PausedAt = &now
+	return profile, s.saveWithSession(ctx, profile, false)
+}
+
+func (s *CopyProfileService) ResumeProfile(ctx context.Context, followerID, profileID uint) (*models.CopyProfile, error) {
+	profile, err := s.GetProfile(ctx, followerID, profileID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile.Status = models.CopyProfileActive
+	profile.PausedReason = ""
+	profile.PausedAt = nil
+	return profile, s.saveWithSession(ctx, profile, true)
+}
+
+func (s *CopyProfileService) GetProfile(ctx context.Context, followerID, profileID uint) (*models.CopyProfile, error) {
+	profile, err := s.repo.GetByID(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil || profile.FollowerID != followerID {
+		return nil, ErrCopyProfileNotFound
+	}
+	return profile, nil
+}
+
+func (s *CopyProfileService) ListProfiles(ctx context.Context, followerID uint) ([]models.CopyProfile, error) {
+	return s.repo.ListByFollower(ctx, followerID)
+}
+
+func (s *CopyProfileService) saveWithSession(ctx context.Context, profile *models.CopyProfile, active bool) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.Save(tx, profile); err != nil {
+			return err
+		}
+		return s.repo.SetSessionActive(tx, profile.FollowerID, profile.MasterID, active)
+	})
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/internal/customer/repository/walletrepo"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	paymentgateway "github.com/fathimasithara01/tradeverse/pkg/payment_gateway.go"
+)
+
+var (
+	ErrWebhookNotConfigured = errors.New("payment gateway webhook secret not configured")
+	ErrWebhookReplayed      = errors.New("webhook delivery already received")
+)
+
+// WebhookDelivery is a raw gateway callback as it came off the wire.
+type WebhookDelivery struct {
+	Signature string
+	Timestamp string
+	Nonce     string
+	Body      []byte
+}
+
+type IPaymentWebhookService interface {
+	HandleDelivery(ctx context.Context, delivery WebhookDelivery) (*models.PaymentWebhookEvent, error)
+}
+
+type paymentWebhookService struct {
+	events    walletrepo.WebhookEventRepository
+	wallet    IWalletService
+	secret    string
+	tolerance time.Duration
+}
+
+func NewPaymentWebhookService(events walletrepo.WebhookEventRepository, wallet IWalletService, secret string, tolerance time.Duration) IPaymentWebhookService {
+	return &paymentWebhookService{
+		events:    events,
+		wallet:    wallet,
+		secret:    secret,
+		tolerance: tolerance,
+	}
+}
+
+// HandleDelivery verifies the signature and timestamp, rejects a nonce that
+// has been seen before, records the event and applies it. The returned event
+// carries the outcome; an error is returned only when the delivery was
+// rejected or could not be applied, in which case the gateway should retry.
+func (s *paymentWebhookService) HandleDelivery(ctx context.Context, delivery WebhookDelivery) (*models.PaymentWebhookEvent, error) {
+	if s.secret == "" {
+		return nil, ErrWebhookNotConfigured
+	}
+	if err := paymentgateway.VerifySignature(s.secret, delivery.Signature, delivery.Timestamp, delivery.Nonce, delivery.Body, time.Now(), s.tolerance); err != nil {
+		return nil, err
+	}
+
+	var event paymentgateway.WebhookEvent
+	if err := json.Unmarshal(delivery.Body, &event); err != nil {
+		return nil, fmt.Errorf("%w: %v", paymentgateway.ErrInvalidPayload, err)
+	}
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+
+	record := &models.PaymentWebhookEvent{
+		EventID:            event.ID,
+		Nonce:              delivery.Nonce,
+		EventType:          string(event.Type),
+		PaymentGatewayTxID: event.PaymentGatewayTxID,
+		Status:             models.WebhookEventReceived,
+		Payload:            string(delivery.Body),
+		ReceivedAt:         time.Now(),
+	}
+	if err := s.events.CreateEvent(record); err != nil {
+		if errors.Is(err, walletrepo.ErrWebhookNonceSeen) {
+			return nil, ErrWebhookReplayed
+		}
+		return nil, err
+	}
+
+	status, applyErr := s.wallet.HandleGatewayEvent(ctx, event)
+	now := time.Now()
+	record.Status = status
+	record.ProcessedAt = &now
+	if applyErr != nil {
+		record.Error = applyErr.Error()
+	}
+	if err := s.events.UpdateEvent(record); err != nil {
+		log.Printf("Failed to record outcome of webhook event %s: %v", event.ID, err)
+	}
+
+	if applyErr != nil {
+		log.Printf("Webhook event %s (%s for %s) failed: %v", event.ID, event.Type, event.PaymentGatewayTxID, applyErr)
+		return record, applyErr
+	}
+	log.Printf("Webhook event %s (%s for %s) %s", event.ID, event.Type, event.PaymentGatewayTxID, status)
+	return record, nil
+}
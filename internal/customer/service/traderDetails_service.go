@@ -1,6 +1,9 @@
 package service
 
 import (
+	"errors"
+	"time"
+
 	"github.com/fathimasithara01/tradeverse/internal/customer/repository/customerrepo"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
 	"gorm.io/gorm"
@@ -34,7 +37,33 @@ func (s *TraderService) GetTraderDetails(traderID uint) (*models.TraderProfile,
 	return traderProfile, nil
 }
 
-func (s *TraderService) GetTraderPerformance(traderID uint) (interface{}, error) {
+// GetTraderPerformance computes the trader's metrics and equity curve over the
+// period from their closed trades and resolved signals. Copier counts and the
+// last refresh time come from the snapshot kept by the admin performance cron.
+func (s *TraderService) GetTraderPerformance(traderUserID uint, period models.PerformancePeriod) (*models.PerformanceReport, error) {
+	now := time.Now()
+	since := period.Since(now)
+
+	trades, err := s.traderRepo.FindClosedTrades(traderUserID, since)
+	if err != nil {
+		return nil, err
+	}
+	signals, err := s.traderRepo.FindResolvedSignals(traderUserID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	report := models.CalculatePerformance(trades, signals, since, now)
+	report.TraderID = traderUserID
+	report.Period = period
 
-	return map[string]string{"message": "Trader performance history not yet implemented"}, nil
+	snapshot, err := s.traderRepo.FindPerformance(traderUserID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if snapshot != nil {
+		report.ActiveCopiers = snapshot.ActiveCopiers
+		report.LastUpdated = snapshot.LastUpdated
+	}
+	return &report, nil
 }
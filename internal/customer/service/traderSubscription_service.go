@@ -9,7 +9,10 @@ import (
 	"time"
 
 	"github.com/fathimasithara01/tradeverse/internal/customer/repository/customerrepo"
+	"github.com/fathimasithara01/tradeverse/pkg/fx"
+	"github.com/fathimasithara01/tradeverse/pkg/ledger"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/refund"
 	"gorm.io/gorm"
 )
 
@@ -19,6 +22,7 @@ type ICustomerTraderSignalSubscriptionService interface {
 	GetSubscribedTradersSignals(ctx context.Context, customerID uint) ([]models.Signal, error)
 	GetActiveSubscriptions(ctx context.Context, customerID uint) ([]models.CustomerTraderSignalSubscription, error)
 	IsCustomerSubscribedToTrader(ctx context.Context, customerID, traderID uint) (bool, error)
+	CancelSubscription(ctx context.Context, customerID, subscriptionID uint) (*models.SubscriptionCancellation, error)
 }
 
 type CustomerTraderSignalSubscriptionService struct {
@@ -69,107 +73,60 @@ func (s *CustomerTraderSignalSubscriptionService) SubscribeToTrader(ctx context.
 		}
 	}()
 
-	customerWallet, err := s.repo.GetTraderWallet(ctx, customerID)
-
+	charge, err := fx.NewCharge(tx, customerID, plan.Price, plan.Currency)
 	if err != nil {
 		tx.Rollback()
-		return fmt.Errorf("failed to get customer wallet: %w", err)
-	}
-
-	if customerWallet.Balance < plan.Price {
-		tx.Rollback()
-		return fmt.Errorf("insufficient funds in wallet. current balance: %.2f, required: %.2f", customerWallet.Balance, plan.Price)
+		return fmt.Errorf("failed to price subscription: %w", err)
 	}
 
-	if err := s.repo.UpdateWalletBalance(ctx, customerID, -plan.Price, tx); err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to deduct funds from customer wallet: %w", err)
-	}
-	customerBalanceAfter := customerWallet.Balance - plan.Price
-
-	adminCommissionAmount := plan.Price * (plan.AdminCommission / 100.0)
-	traderRevenueAmount := plan.Price - adminCommissionAmount
-	adminWallet, err := s.repo.GetAdminWallet(ctx)
-	if err != nil {
+	if !charge.Covered() {
 		tx.Rollback()
-		return fmt.Errorf("failed to get admin wallet: %w", err)
+		return fmt.Errorf("insufficient funds in wallet. current balance: %s %s, required: %s", charge.Available.Format(charge.Currency), charge.Currency, charge)
 	}
-	if err := s.repo.UpdateWalletBalance(ctx, adminWallet.UserID, adminCommissionAmount, tx); err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to credit admin commission: %w", err)
-	}
-	adminBalanceAfter := adminWallet.Balance + adminCommissionAmount
 
-	traderWallet, err := s.repo.GetTraderWallet(ctx, plan.TraderID)
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to get trader wallet: %w", err)
-	}
-	if err := s.repo.UpdateWalletBalance(ctx, plan.TraderID, traderRevenueAmount, tx); err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to credit trader revenue: %w", err)
-	}
-	traderBalanceAfter := traderWallet.Balance + traderRevenueAmount
+	adminCommissionAmount, traderRevenueAmount := plan.Price.Split(plan.AdminCommission, plan.Currency)
 
 	customerTx := models.WalletTransaction{
-		WalletID:        customerWallet.ID,
-		UserID:          customerID,
 		Type:            models.TxTypeSubscription,
 		TransactionType: models.TxTypeDebit,
 		Name:            "Trader Subscription Debit",
-		Amount:          plan.Price,
-		Currency:        plan.Currency,
-		Status:          models.TxStatusSuccess,
 		Description:     fmt.Sprintf("Subscription to trader %d's plan '%s'", plan.TraderID, plan.Name),
-		BalanceBefore:   customerWallet.Balance,
-		BalanceAfter:    customerBalanceAfter,
 		ReferenceID:     fmt.Sprintf("TRADER_SUB_%d_PLAN_%d", customerID, plan.ID),
 		TransactionID:   fmt.Sprintf("TRADER_SUB_%d_%d_%d", customerID, plan.TraderID, time.Now().UnixNano()),
 	}
-
-	if err := s.repo.CreateWalletTransaction(ctx, &customerTx, tx); err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to record customer transaction: %w", err)
-	}
-
 	adminTx := models.WalletTransaction{
-		WalletID:        adminWallet.ID,
-		UserID:          adminWallet.UserID,
 		Type:            models.TxTypeAdminCommission,
 		TransactionType: models.TxTypeCredit,
 		Name:            "Credit from Trader Subscription Commission",
-		Amount:          adminCommissionAmount,
 		Currency:        plan.Currency,
-		Status:          models.TxStatusSuccess,
 		Description:     fmt.Sprintf("Commission from customer %d subscribing to trader %d's plan '%s'", customerID, plan.TraderID, plan.Name),
-		BalanceBefore:   adminWallet.Balance,
-		BalanceAfter:    adminBalanceAfter,
 		ReferenceID:     fmt.Sprintf("TRADER_SUB_COMMISSION_%d_PLAN_%d", customerID, plan.ID),
 		TransactionID:   fmt.Sprintf("ADMIN_COMM_%d_%d_%d", customerID, plan.TraderID, time.Now().UnixNano()),
 	}
-	if err := s.repo.CreateWalletTransaction(ctx, &adminTx, tx); err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to record admin transaction: %w", err)
-	}
-
 	traderTx := models.WalletTransaction{
-		WalletID:        traderWallet.ID,
-		UserID:          plan.TraderID,
 		Type:            models.TxTypeTraderRevenue,
 		TransactionType: models.TxTypeCredit,
 		Name:            "Credit from Customer Subscription",
-		Amount:          traderRevenueAmount,
 		Currency:        plan.Currency,
-		Status:          models.TxStatusSuccess,
 		Description:     fmt.Sprintf("Revenue from customer %d subscribing to plan '%s'", customerID, plan.Name),
-		BalanceBefore:   traderWallet.Balance,
-		BalanceAfter:    traderBalanceAfter,
 		ReferenceID:     fmt.Sprintf("TRADER_REVENUE_%d_PLAN_%d", customerID, plan.ID),
 		TransactionID:   fmt.Sprintf("TRADER_REV_%d_%d_%d", customerID, plan.TraderID, time.Now().UnixNano()),
 	}
-	if err := s.repo.CreateWalletTransaction(ctx, &traderTx, tx); err != nil {
+
+	if _, err := ledger.Post(tx, ledger.Entry{
+		Type:      models.TxTypeSubscription,
+		Reference: customerTx.ReferenceID,
+		Currency:  plan.Currency,
+		Legs: append(charge.Legs(customerID, &customerTx),
+			ledger.Credit(ledger.PlatformCommissionIn(plan.Currency), adminCommissionAmount, &adminTx),
+			ledger.Credit(ledger.WalletIn(plan.TraderID, plan.Currency), traderRevenueAmount, &traderTx),
+		),
+	}); err != nil {
 		tx.Rollback()
-		return fmt.Errorf("failed to record trader transaction: %w", err)
+		if errors.Is(err, ledger.ErrInsufficientFunds) {
+			return fmt.Errorf("insufficient funds in wallet. current balance: %s %s, required: %s", charge.Available.Format(charge.Currency), charge.Currency, charge)
+		}
+		return fmt.Errorf("failed to post subscription payment: %w", err)
 	}
 
 	startDate := time.Now()
@@ -183,18 +140,31 @@ func (s *CustomerTraderSignalSubscriptionService) SubscribeToTrader(ctx context.
 		EndDate:                  endDate,
 		IsActive:                 true,
 		WalletTransactionID:      &customerTx.ID,
+		PaymentStatus:            "paid",
+		AmountPaid:               plan.Price,
+		TraderShare:              traderRevenueAmount,
+		AdminCommission:          adminCommissionAmount,
+		TransactionReferenceID:   customerTx.TransactionID,
+		PaidCurrency:             charge.Currency,
+		PaidFXRate:               charge.Rate(),
 	}
 
-	if _, err := s.repo.CreateCustomerTraderSubscription(ctx, newSubscription); err != nil {
+	if err := tx.Create(newSubscription).Error; err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to create customer-trader subscription record: %w", err)
 	}
+	if err := tx.Model(&models.WalletTransaction{}).
+		Where("id IN ?", []uint{customerTx.ID, adminTx.ID, traderTx.ID}).
+		Update("trader_subscription_id", newSubscription.ID).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to link subscription payment: %w", err)
+	}
 
 	if err := tx.Commit().Error; err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	log.Printf("Customer %d successfully subscribed to trader %d's plan %d. Admin got %.2f, Trader got %.2f",
+	log.Printf("Customer %d successfully subscribed to trader %d's plan %d. Admin got %s, Trader got %s",
 		customerID, plan.TraderID, plan.ID, adminCommissionAmount, traderRevenueAmount)
 
 	return nil
@@ -219,3 +189,18 @@ func (s *CustomerTraderSignalSubscriptionService) GetActiveSubscriptions(ctx con
 func (s *CustomerTraderSignalSubscriptionService) IsCustomerSubscribedToTrader(ctx context.Context, customerID, traderID uint) (bool, error) {
 	return s.repo.IsCustomerSubscribedToTrader(ctx, customerID, traderID)
 }
+
+// CancelSubscription ends one of the customer's trader subscriptions and
+// refunds it under the plan's refund policy.
+func (s *CustomerTraderSignalSubscriptionService) CancelSubscription(ctx context.Context, customerID, subscriptionID uint) (*models.SubscriptionCancellation, error) {
+	var cancellation *models.SubscriptionCancellation
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		cancellation, err = refund.CancelTraderSubscription(tx, subscriptionID, customerID, time.Now())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cancellation, nil
+}
@@ -5,11 +5,16 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	walletrepo "github.com/fathimasithara01/tradeverse/internal/customer/repository/walletrepo"
+	"github.com/fathimasithara01/tradeverse/pkg/ledger"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
 	paymentgateway "github.com/fathimasithara01/tradeverse/pkg/payment_gateway.go"
+	"github.com/fathimasithara01/tradeverse/pkg/transfer"
+	"github.com/fathimasithara01/tradeverse/pkg/withdrawal"
 	"gorm.io/gorm"
 )
 
@@ -21,38 +26,46 @@ var (
 	ErrInvalidDepositStatus           = errors.New("invalid deposit status")
 	ErrUserWalletNotFound             = errors.New("user wallet not found")
 	ErrWithdrawalRequestNotFound      = errors.New("withdrawal request not found")
+	ErrGatewayEventMismatch           = errors.New("gateway event does not match the payment")
 )
 
 type IWalletService interface {
 	GetUserWallet(ctx context.Context, userID uint) (*models.Wallet, error)
-	DepositFunds(ctx context.Context, userID uint, amount float64, referenceID, description string) (*models.WalletTransaction, error)
-	WithdrawFunds(ctx context.Context, userID uint, amount float64, referenceID, description string) (*models.WalletTransaction, error)
+	DepositFunds(ctx context.Context, userID uint, amount money.Amount, referenceID, description string) (*models.WalletTransaction, error)
+	WithdrawFunds(ctx context.Context, userID uint, amount money.Amount, referenceID, description string) (*models.WalletTransaction, error)
 	GetWalletTransactions(ctx context.Context, userID uint, pagination models.PaginationParams) ([]models.WalletTransaction, int64, error)
 	GetWalletSummary(userID uint) (*models.WalletSummaryResponse, error)
 	InitiateDeposit(userID uint, input models.DepositRequestInput) (*models.DepositResponse, error)
 	VerifyDeposit(depositID uint, input models.DepositVerifyInput) (*models.DepositVerifyResponse, error)
 	RequestWithdrawal(userID uint, input models.WithdrawalRequestInput) (*models.WithdrawalResponse, error)
 	GetTransactions(userID uint, pagination models.PaginationParams) ([]models.WalletTransaction, int64, error)
-	DebitUserWallet(userID uint, amount float64, currency, description, transactionID string) error
+	DebitUserWallet(userID uint, amount money.Amount, currency, description, transactionID string) error
+	HandleGatewayEvent(ctx context.Context, event paymentgateway.WebhookEvent) (models.WebhookEventStatus, error)
+	Transfer(ctx context.Context, userID uint, input models.TransferInput) (*models.WalletTransfer, error)
+	GetTransfers(ctx context.Context, userID uint, pagination models.PaginationParams) ([]models.WalletTransfer, int64, error)
 }
 
 type walletService struct {
 	db             *gorm.DB
 	walletRepo     walletrepo.WalletRepository
 	paymentGateway paymentgateway.SimulatedPaymentClient
+	withdrawals    *withdrawal.Pipeline
+	transfers      *transfer.Service
 }
 
-func NewWalletService(db *gorm.DB, repo walletrepo.WalletRepository, pgClient paymentgateway.SimulatedPaymentClient) IWalletService {
+func NewWalletService(db *gorm.DB, repo walletrepo.WalletRepository, pgClient paymentgateway.SimulatedPaymentClient, withdrawals *withdrawal.Pipeline, transfers *transfer.Service) IWalletService {
 	return &walletService{
 		db:             db,
 		walletRepo:     repo,
 		paymentGateway: pgClient,
+		withdrawals:    withdrawals,
+		transfers:      transfers,
 	}
 }
-func (s *walletService) DebitUserWallet(userID uint, amount float64, currency, description, transactionID string) error {
+func (s *walletService) DebitUserWallet(userID uint, amount money.Amount, currency, description, transactionID string) error {
 	var wallet models.Wallet
 
-	err := s.db.Where("user_id = ?", userID).First(&wallet).Error
+	err := s.db.Scopes(models.PrimaryWallet).Where("user_id = ?", userID).First(&wallet).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		wallet = models.Wallet{
 			UserID:   userID,
@@ -66,36 +79,31 @@ func (s *walletService) DebitUserWallet(userID uint, amount float64, currency, d
 		return fmt.Errorf("failed to fetch wallet: %w", err)
 	}
 
-	if wallet.Balance < amount {
-		return fmt.Errorf("insufficient balance")
-	}
-
-	balanceBefore := wallet.Balance
-	balanceAfter := wallet.Balance - amount
-
-	tx := models.WalletTransaction{
-		WalletID:      wallet.ID,
-		UserID:        userID,
-		Type:          models.TxTypeDebit,
-		Amount:        amount,
-		Currency:      currency,
-		Status:        models.TxStatusSuccess,
-		Description:   description,
-		TransactionID: transactionID,
-		BalanceBefore: balanceBefore,
-		BalanceAfter:  balanceAfter,
-	}
-
 	err = s.db.Transaction(func(txn *gorm.DB) error {
-		if err := txn.Create(&tx).Error; err != nil {
-			return fmt.Errorf("failed to record transaction: %w", err)
-		}
-
-		if err := txn.Model(&wallet).Update("balance", balanceAfter).Error; err != nil {
-			return fmt.Errorf("failed to update wallet balance: %w", err)
+		_, err := ledger.Post(txn, ledger.Entry{
+			Type:        models.TxTypeDebit,
+			Reference:   transactionID,
+			Description: description,
+			Currency:    currency,
+			Legs: []ledger.Leg{
+				ledger.Debit(ledger.WalletIn(userID, currency), amount, &models.WalletTransaction{
+					Type:          models.TxTypeDebit,
+					Currency:      currency,
+					Description:   description,
+					TransactionID: transactionID,
+				}),
+				ledger.Credit(ledger.PlatformCommissionIn(currency), amount, &models.WalletTransaction{
+					Type:          models.TxTypeCredit,
+					Currency:      currency,
+					Description:   fmt.Sprintf("Received payment from user %d: %s", userID, description),
+					TransactionID: transactionID,
+				}),
+			},
+		})
+		if errors.Is(err, ledger.ErrInsufficientFunds) {
+			return fmt.Errorf("insufficient balance")
 		}
-
-		return nil
+		return err
 	})
 
 	if err != nil {
@@ -124,17 +132,31 @@ func (s *walletService) GetWalletSummary(userID uint) (*models.WalletSummaryResp
 		}
 		return nil, err
 	}
+	wallets, err := s.walletRepo.ListUserWallets(userID)
+	if err != nil {
+		return nil, err
+	}
+	balances := make([]models.CurrencyBalance, 0, len(wallets))
+	for _, w := range wallets {
+		balances = append(balances, models.CurrencyBalance{
+			WalletID: w.ID,
+			Currency: w.Currency,
+			Balance:  w.Balance,
+			Primary:  !w.SubBalance,
+		})
+	}
 	return &models.WalletSummaryResponse{
 		UserID:      wallet.UserID,
 		WalletID:    wallet.ID,
 		Balance:     wallet.Balance,
 		Currency:    wallet.Currency,
 		LastUpdated: wallet.LastUpdated,
+		Balances:    balances,
 	}, nil
 }
 
 func (s *walletService) InitiateDeposit(userID uint, input models.DepositRequestInput) (*models.DepositResponse, error) {
-	log.Printf("Initiating deposit for user %d, amount %.2f, method %s", userID, input.Amount, input.PaymentMethod)
+	log.Printf("Initiating deposit for user %d, amount %s, method %s", userID, input.Amount, input.PaymentMethod)
 
 	pgTxID, redirectURL, err := s.paymentGateway.CreateDepositInitiation(input.Amount, input.Currency, fmt.Sprint(userID))
 	if err != nil {
@@ -189,27 +211,20 @@ func (s *walletService) VerifyDeposit(depositID uint, input models.DepositVerify
 	var createdTransaction *models.WalletTransaction
 
 	err = s.db.Transaction(func(tx *gorm.DB) error {
-		wallet, err := s.walletRepo.GetUserWallet(depositRequest.UserID)
-		if err != nil {
-			return fmt.Errorf("%w for user %d: %v", ErrUserWalletNotFound, depositRequest.UserID, err) // Use service-level error
-		}
-
-		createdTransaction, err = s.walletRepo.CreditWallet(tx, wallet.ID, depositRequest.Amount, models.TxTypeDeposit,
-			fmt.Sprintf("DEPOSIT_%d", depositRequest.ID), "Funds added via deposit verification")
+		locked, err := s.walletRepo.LockDepositRequest(tx, depositID)
 		if err != nil {
-			return fmt.Errorf("failed to credit user wallet during deposit verification: %w", err)
+			return err
 		}
-
-		depositRequest.Status = models.TxStatusSuccess
-		now := time.Now()
-		depositRequest.CompletionTime = &now
-		if err := s.walletRepo.UpdateDepositRequestTx(tx, depositRequest); err != nil {
-			return fmt.Errorf("failed to update deposit request status: %w", err)
+		if locked.Status != models.TxStatusPending {
+			return ErrDepositAlreadyProcessed
 		}
-
-		return nil
+		createdTransaction, err = s.completeDeposit(tx, locked, "Funds added via deposit verification")
+		return err
 	})
 
+	if errors.Is(err, ErrDepositAlreadyProcessed) {
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrWalletServiceTransactionFailed, err)
 	}
@@ -226,70 +241,191 @@ func (s *walletService) VerifyDeposit(depositID uint, input models.DepositVerify
 		Message:       "Deposit verified and funds added.",
 	}, nil
 }
-func (s *walletService) RequestWithdrawal(userID uint, input models.WithdrawalRequestInput) (*models.WithdrawalResponse, error) {
-	if input.Amount <= 0 {
-		return nil, errors.New("withdrawal amount must be positive")
+
+// completeDeposit credits a pending deposit to its owner's wallet and marks it
+// successful. The caller must hold the deposit's row lock, so the verify
+// endpoint and a gateway webhook racing on the same deposit credit it once.
+func (s *walletService) completeDeposit(tx *gorm.DB, depositRequest *models.DepositRequest, description string) (*models.WalletTransaction, error) {
+	wallet, err := s.walletRepo.GetCurrencyWallet(tx, depositRequest.UserID, depositRequest.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w for user %d: %v", ErrUserWalletNotFound, depositRequest.UserID, err)
 	}
 
-	var withdrawalRequest *models.WithdrawalRequest
+	transaction, err := s.walletRepo.CreditWallet(tx, wallet.ID, depositRequest.Amount, models.TxTypeDeposit,
+		fmt.Sprintf("DEPOSIT_%d", depositRequest.ID), description, ledger.GatewayClearing(depositRequest.Currency))
+	if err != nil {
+		return nil, fmt.Errorf("failed to credit user wallet for deposit %d: %w", depositRequest.ID, err)
+	}
 
-	err := s.db.Transaction(func(tx *gorm.DB) error {
-		wallet, err := s.walletRepo.GetUserWallet(userID)
-		if err != nil {
-			return fmt.Errorf("%w for user %d: %v", ErrUserWalletNotFound, userID, err)
-		}
+	now := time.Now()
+	depositRequest.Status = models.TxStatusSuccess
+	depositRequest.CompletionTime = &now
+	depositRequest.WalletTransactionID = &transaction.ID
+	if err := s.walletRepo.UpdateDepositRequestTx(tx, depositRequest); err != nil {
+		return nil, fmt.Errorf("failed to update deposit request status: %w", err)
+	}
+	return transaction, nil
+}
 
-		if wallet.Balance < input.Amount {
-			return ErrWalletServiceInsufficientFunds
+// HandleGatewayEvent applies a verified gateway callback to the deposit or
+// withdrawal it names. Each payment is locked while the event is applied and
+// an event that would repeat a transition already made is reported as
+// WebhookEventIgnored, so redeliveries are harmless.
+func (s *walletService) HandleGatewayEvent(ctx context.Context, event paymentgateway.WebhookEvent) (models.WebhookEventStatus, error) {
+	status := models.WebhookEventProcessed
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var applied bool
+		var err error
+		switch event.Type {
+		case paymentgateway.EventDepositSucceeded, paymentgateway.EventDepositFailed,
+			paymentgateway.EventRefundSucceeded, paymentgateway.EventChargeback:
+			applied, err = s.applyDepositEvent(tx, event)
+		case paymentgateway.EventWithdrawalSucceeded, paymentgateway.EventWithdrawalFailed:
+			applied, err = s.applyWithdrawalEvent(tx, event)
+		default:
+			return fmt.Errorf("%w: unsupported event type %q", ErrGatewayEventMismatch, event.Type)
 		}
-
-		pgTxID, pgErr := s.paymentGateway.ProcessWithdrawal(input.Amount, input.Currency, input.BankAccountNumber)
-		if pgErr != nil {
-			return fmt.Errorf("payment gateway withdrawal failed: %w", pgErr)
+		if !applied {
+			status = models.WebhookEventIgnored
 		}
+		return err
+	})
+	if err != nil {
+		return models.WebhookEventFailed, err
+	}
+	return status, nil
+}
 
-		refID := fmt.Sprintf("WITHDRAW_REQ_%s", time.Now().Format("20060102150405"))
-		err = s.walletRepo.DebitWallet(tx, wallet.ID, input.Amount, models.TxTypeWithdrawal, refID, "Withdrawal request debit")
-		if err != nil {
-			return fmt.Errorf("failed to debit wallet: %w", err)
-		}
+func (s *walletService) applyDepositEvent(tx *gorm.DB, event paymentgateway.WebhookEvent) (bool, error) {
+	deposit, err := s.walletRepo.LockDepositRequestByGatewayTxID(tx, event.PaymentGatewayTxID)
+	if err != nil {
+		return false, err
+	}
+	if event.Type == paymentgateway.EventRefundSucceeded || event.Type == paymentgateway.EventChargeback {
+		return s.reverseDeposit(tx, deposit, event)
+	}
 
-		withdrawalRequest = &models.WithdrawalRequest{
-			UserID:             userID,
-			Amount:             input.Amount,
-			Currency:           input.Currency,
-			BankAccountNumber:  input.BankAccountNumber,
-			BankAccountHolder:  input.BankAccountHolder,
-			IFSCCode:           input.IFSCCode,
-			Status:             models.TxStatusSuccess,
-			RequestTime:        time.Now(),
-			PaymentGatewayTxID: pgTxID,
-		}
-		if err := s.walletRepo.CreateWithdrawalRequest(withdrawalRequest); err != nil {
-			return fmt.Errorf("failed to create withdrawal request: %w", err)
+	if !event.Amount.IsZero() && (event.Amount != deposit.Amount || event.Currency != deposit.Currency) {
+		return false, fmt.Errorf("%w: deposit %d is for %s %s, event reports %s %s", ErrGatewayEventMismatch,
+			deposit.ID, deposit.Amount, deposit.Currency, event.Amount, event.Currency)
+	}
+	if deposit.Status != models.TxStatusPending {
+		return false, nil
+	}
+
+	if event.Type == paymentgateway.EventDepositSucceeded {
+		_, err := s.completeDeposit(tx, deposit, "Funds added via payment gateway confirmation")
+		return err == nil, err
+	}
+	deposit.Status = models.TxStatusFailed
+	deposit.AdminNotes = event.Reason
+	return true, s.walletRepo.UpdateDepositRequestTx(tx, deposit)
+}
+
+// reverseDeposit takes a refund or chargeback back out of the wallet. Each
+// reversal is recorded under its own gateway ID, so a deposit can be partly
+// refunded several times and a redelivered reversal is only applied once. The
+// deposit becomes REVERSED once nothing of it is left.
+func (s *walletService) reverseDeposit(tx *gorm.DB, deposit *models.DepositRequest, event paymentgateway.WebhookEvent) (bool, error) {
+	kind, txDescription := "REFUND", "Deposit refunded by payment gateway"
+	if event.Type == paymentgateway.EventChargeback {
+		kind, txDescription = "CHARGEBACK", "Deposit charged back"
+	}
+	referenceID := fmt.Sprintf("DEPOSIT_%s_%d_%s", kind, deposit.ID, event.ReversalID)
+	if seen, err := s.walletRepo.HasTransactionReference(tx, referenceID); err != nil || seen {
+		return false, err
+	}
+
+	remaining := deposit.Amount - deposit.RefundedAmount
+	amount := event.Amount
+	if amount.IsZero() {
+		amount = remaining
+	}
+	if deposit.Status != models.TxStatusSuccess || !remaining.IsPositive() {
+		return false, fmt.Errorf("%w: deposit %d cannot be reversed from status %s with %s left", ErrGatewayEventMismatch,
+			deposit.ID, deposit.Status, remaining)
+	}
+	if event.Currency != "" && event.Currency != deposit.Currency {
+		return false, fmt.Errorf("%w: deposit %d is in %s, %s reported in %s", ErrGatewayEventMismatch,
+			deposit.ID, deposit.Currency, event.Type, event.Currency)
+	}
+	if amount > remaining {
+		return false, fmt.Errorf("%w: %s of %s exceeds the %s left on deposit %d", ErrGatewayEventMismatch,
+			event.Type, amount, remaining, deposit.ID)
+	}
+
+	wallet, err := s.walletRepo.GetCurrencyWallet(tx, deposit.UserID, deposit.Currency)
+	if err != nil {
+		return false, fmt.Errorf("%w for user %d: %v", ErrUserWalletNotFound, deposit.UserID, err)
+	}
+	if _, err := s.walletRepo.DebitWallet(tx, wallet.ID, amount, models.TxTypeReversal, referenceID, txDescription,
+		ledger.GatewayClearing(deposit.Currency)); err != nil {
+		if errors.Is(err, walletrepo.ErrInsufficientFunds) {
+			return false, fmt.Errorf("%w: %w: wallet cannot cover %s of %s on deposit %d", ErrGatewayEventMismatch,
+				ErrWalletServiceInsufficientFunds, event.Type, amount, deposit.ID)
 		}
+		return false, fmt.Errorf("failed to reverse deposit %d: %w", deposit.ID, err)
+	}
 
-		return nil
-	})
+	deposit.RefundedAmount += amount
+	if deposit.RefundedAmount == deposit.Amount {
+		deposit.Status = models.TxStatusReversed
+	}
+	if event.Reason != "" {
+		deposit.AdminNotes = strings.TrimSpace(deposit.AdminNotes + "\n" + fmt.Sprintf("%s %s: %s", event.Type, amount, event.Reason))
+	}
+	return true, s.walletRepo.UpdateDepositRequestTx(tx, deposit)
+}
 
+func (s *walletService) applyWithdrawalEvent(tx *gorm.DB, event paymentgateway.WebhookEvent) (bool, error) {
+	withdrawal, err := s.walletRepo.LockWithdrawalRequestByGatewayTxID(tx, event.PaymentGatewayTxID)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrWalletServiceTransactionFailed, err)
+		return false, err
+	}
+	if !event.Amount.IsZero() && (event.Amount != withdrawal.Amount || event.Currency != withdrawal.Currency) {
+		return false, fmt.Errorf("%w: withdrawal %d is for %s %s, event reports %s %s", ErrGatewayEventMismatch,
+			withdrawal.ID, withdrawal.Amount, withdrawal.Currency, event.Amount, event.Currency)
+	}
+	return s.withdrawals.ApplyGatewayOutcome(tx, withdrawal, event.Type == paymentgateway.EventWithdrawalSucceeded, event.Reason)
+}
+
+// RequestWithdrawal files the withdrawal with the withdrawal pipeline. The
+// funds are held in gateway clearing until an admin approves the payout or
+// rejects the request.
+func (s *walletService) RequestWithdrawal(userID uint, input models.WithdrawalRequestInput) (*models.WithdrawalResponse, error) {
+	withdrawalRequest, err := s.withdrawals.Request(context.Background(), userID, userID, input)
+	if err != nil {
+		return nil, err
 	}
 
+	message := "Withdrawal submitted for review."
+	if withdrawalRequest.HoldUntil != nil {
+		message = fmt.Sprintf("Withdrawal submitted for review; it will not be paid out before %s.", withdrawalRequest.HoldUntil.UTC().Format(time.RFC3339))
+	}
 	return &models.WithdrawalResponse{
-		WithdrawalID: withdrawalRequest.ID,
-		Amount:       withdrawalRequest.Amount,
-		Currency:     withdrawalRequest.Currency,
-		Status:       withdrawalRequest.Status,
-		Message:      "Withdrawal request submitted successfully.",
+		WithdrawalID:       withdrawalRequest.ID,
+		Amount:             withdrawalRequest.Amount,
+		Currency:           withdrawalRequest.Currency,
+		Status:             withdrawalRequest.Status,
+		PaymentGatewayTxID: withdrawalRequest.PaymentGatewayTxID,
+		Message:            message,
 	}, nil
 }
 
+// Transfer sends money from the user's wallet to another user's.
+func (s *walletService) Transfer(ctx context.Context, userID uint, input models.TransferInput) (*models.WalletTransfer, error) {
+	return s.transfers.Send(ctx, userID, input)
+}
+
+func (s *walletService) GetTransfers(ctx context.Context, userID uint, pagination models.PaginationParams) ([]models.WalletTransfer, int64, error) {
+	return s.transfers.List(ctx, userID, pagination)
+}
+
 func (s *walletService) GetTransactions(userID uint, pagination models.PaginationParams) ([]models.WalletTransaction, int64, error) {
 	return s.GetWalletTransactions(context.Background(), userID, pagination)
 }
 
-func (s *walletService) DepositFunds(ctx context.Context, userID uint, amount float64, referenceID, description string) (*models.WalletTransaction, error) {
+func (s *walletService) DepositFunds(ctx context.Context, userID uint, amount money.Amount, referenceID, description string) (*models.WalletTransaction, error) {
 	if amount <= 0 {
 		return nil, errors.New("deposit amount must be positive")
 	}
@@ -301,27 +437,9 @@ func (s *walletService) DepositFunds(ctx context.Context, userID uint, amount fl
 			return fmt.Errorf("failed to get or create wallet: %w", err)
 		}
 
-		balanceBefore := currentWallet.Balance
-		currentWallet.Balance += amount
-		currentWallet.LastUpdated = time.Now()
-		if err := s.walletRepo.UpdateWalletTx(tx, currentWallet); err != nil {
-			return fmt.Errorf("failed to update wallet balance: %w", err)
-		}
-
-		newTx := &models.WalletTransaction{
-			WalletID:        currentWallet.ID,
-			UserID:          userID,
-			TransactionType: models.TxTypeDeposit,
-			Amount:          amount,
-			Currency:        currentWallet.Currency,
-			Status:          models.TxStatusSuccess,
-			ReferenceID:     referenceID,
-			Description:     description,
-			BalanceBefore:   balanceBefore,
-			BalanceAfter:    currentWallet.Balance,
-		}
-		if err := s.walletRepo.CreateWalletTransaction(tx, newTx); err != nil {
-			return fmt.Errorf("failed to create wallet transaction: %w", err)
+		newTx, err := s.walletRepo.CreditWallet(tx, currentWallet.ID, amount, models.TxTypeDeposit, referenceID, description, ledger.GatewayClearing(currentWallet.Currency))
+		if err != nil {
+			return fmt.Errorf("failed to credit wallet: %w", err)
 		}
 		transaction = newTx
 		return nil
@@ -333,7 +451,7 @@ func (s *walletService) DepositFunds(ctx context.Context, userID uint, amount fl
 	return transaction, nil
 }
 
-func (s *walletService) WithdrawFunds(ctx context.Context, userID uint, amount float64, referenceID, description string) (*models.WalletTransaction, error) {
+func (s *walletService) WithdrawFunds(ctx context.Context, userID uint, amount money.Amount, referenceID, description string) (*models.WalletTransaction, error) {
 	if amount <= 0 {
 		return nil, errors.New("withdrawal amount must be positive")
 	}
@@ -348,31 +466,12 @@ func (s *walletService) WithdrawFunds(ctx context.Context, userID uint, amount f
 			return fmt.Errorf("failed to get user wallet: %w", err)
 		}
 
-		if currentWallet.Balance < amount {
+		newTx, err := s.walletRepo.DebitWallet(tx, currentWallet.ID, amount, models.TxTypeWithdrawal, referenceID, description, ledger.GatewayClearing(currentWallet.Currency))
+		if errors.Is(err, walletrepo.ErrInsufficientFunds) {
 			return ErrWalletServiceInsufficientFunds
 		}
-
-		balanceBefore := currentWallet.Balance
-		currentWallet.Balance -= amount
-		currentWallet.LastUpdated = time.Now()
-		if err := s.walletRepo.UpdateWalletTx(tx, currentWallet); err != nil {
-			return fmt.Errorf("failed to update wallet balance: %w", err)
-		}
-
-		newTx := &models.WalletTransaction{
-			WalletID:        currentWallet.ID,
-			UserID:          userID,
-			TransactionType: models.TxTypeWithdrawal,
-			Amount:          amount,
-			Currency:        currentWallet.Currency,
-			Status:          models.TxStatusSuccess,
-			ReferenceID:     referenceID,
-			Description:     description,
-			BalanceBefore:   balanceBefore,
-			BalanceAfter:    currentWallet.Balance,
-		}
-		if err := s.walletRepo.CreateWalletTransaction(tx, newTx); err != nil {
-			return fmt.Errorf("failed to create wallet transaction: %w", err)
+		if err != nil {
+			return fmt.Errorf("failed to debit wallet: %w", err)
 		}
 		transaction = newTx
 		return nil
@@ -5,9 +5,9 @@ import (
 	"gorm.io/gorm"
 )
 
-func RunMigrations(db *gorm.DB) error {
-
-	err := db.AutoMigrate(
+// Models lists every model whose table RunMigrations creates.
+func Models() []interface{} {
+	return []interface{}{
 		&models.User{},
 		&models.Role{},
 		&models.Permission{},
@@ -20,6 +20,26 @@ func RunMigrations(db *gorm.DB) error {
 		&models.DepositRequest{},
 		&models.WithdrawRequest{},
 		&models.WithdrawalRequest{},
+		&models.WithdrawalEvent{},
+		&models.WithdrawalApproval{},
+		&models.WalletTransfer{},
+		&models.TraderPayoutAccount{},
+		&models.TraderPayout{},
+		&models.PaymentWebhookEvent{},
+		&models.IdempotencyRecord{},
+		&models.RefreshToken{},
+		&models.RevokedSession{},
+		&models.UserTwoFactor{},
+		&models.TwoFactorRecoveryCode{},
+		&models.TwoFactorChallenge{},
+		&models.AccountToken{},
+		&models.APIKey{},
+		&models.FXRate{},
+		&models.LedgerAccount{},
+		&models.JournalEntry{},
+		&models.JournalPosting{},
+		&models.ReconciliationRun{},
+		&models.WalletDiscrepancy{},
 
 		&models.TraderSignalSubscriptionPlan{},
 		&models.CustomerTraderSignalSubscription{},
@@ -29,11 +49,16 @@ func RunMigrations(db *gorm.DB) error {
 
 		&models.MarketData{},
 		&models.MarketDataAPIResponse{},
+		&models.Candle{},
 		&models.Signal{},
+		&models.SignalStatusHistory{},
+		&models.SignalTarget{},
 		&models.Trade{},
 		&models.LiveTrade{},
 		&models.TradeLog{},
 		&models.CopySession{},
+		&models.CopyProfile{},
+		&models.CopySyncCursor{},
 
 		&models.KYCDocument{},
 		&models.UserKYCStatus{},
@@ -42,11 +67,120 @@ func RunMigrations(db *gorm.DB) error {
 		&models.CommissionSetting{},
 
 		&models.WebConfiguration{},
-	)
+	}
+}
 
-	if err != nil {
+func RunMigrations(db *gorm.DB) error {
+	if err := dedupeCopySessions(db); err != nil {
+		return err
+	}
+	if err := renameCandleVolume(db); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(Models()...); err != nil {
 		return err
 	}
 
-	return nil
+	if err := migrateWalletCurrencies(db); err != nil {
+		return err
+	}
+	if err := migrateTransactionDirections(db); err != nil {
+		return err
+	}
+	return migrateWithdrawals(db)
+}
+
+// dedupeCopySessions leaves one copy session per follower and master so that
+// AutoMigrate can add the unique index on the pair. The live, active and then
+// newest session is kept, and trade logs of the removed ones are moved to it.
+func dedupeCopySessions(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.CopySession{}) || migrator.HasIndex(&models.CopySession{}, "idx_copy_session_pair") {
+		return nil
+	}
+
+	const ranked = `SELECT id, FIRST_VALUE(id) OVER (PARTITION BY follower_id, master_id
+		ORDER BY (deleted_at IS NULL) DESC, is_active DESC, id DESC) AS keep_id
+		FROM copy_sessions`
+	return db.Transaction(func(tx *gorm.DB) error {
+		if migrator.HasTable(&models.TradeLog{}) {
+			if err := tx.Exec(`UPDATE trade_logs SET copy_session_id = d.keep_id
+				FROM (` + ranked + `) d
+				WHERE trade_logs.copy_session_id = d.id AND d.id <> d.keep_id`).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Exec(`DELETE FROM copy_sessions
+			WHERE id IN (SELECT id FROM (` + ranked + `) d WHERE d.id <> d.keep_id)`).Error
+	})
+}
+
+// renameCandleVolume renames the candle volume column to volume_24h: it has
+// always held the exchange's rolling 24h volume, not the volume of the bar.
+func renameCandleVolume(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.Candle{}) || !migrator.HasColumn(&models.Candle{}, "volume") || migrator.HasColumn(&models.Candle{}, "volume_24h") {
+		return nil
+	}
+	return migrator.RenameColumn(&models.Candle{}, "volume", "volume_24h")
+}
+
+// migrateWalletCurrencies lets a user hold one wallet per currency. The
+// single-wallet unique index on user_id is replaced by one on (user_id,
+// currency), and a partial index keeps one primary wallet per user.
+func migrateWalletCurrencies(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if migrator.HasIndex(&models.Wallet{}, "idx_wallets_user_id") {
+		if err := migrator.DropIndex(&models.Wallet{}, "idx_wallets_user_id"); err != nil {
+			return err
+		}
+	}
+	return db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_wallets_primary
+		ON wallets (user_id) WHERE sub_balance = false AND deleted_at IS NULL`).Error
+}
+
+// migrateTransactionDirections repairs wallet transactions whose
+// transaction_type holds the kind of movement, such as DEPOSIT, instead of
+// credit or debit. The kind is kept in type and the direction is read from the
+// balance change.
+func migrateTransactionDirections(db *gorm.DB) error {
+	if err := db.Exec(`UPDATE wallet_transactions SET type = transaction_type
+		WHERE (type IS NULL OR type = '') AND transaction_type NOT IN (?, ?)`,
+		models.TxTypeCredit, models.TxTypeDebit).Error; err != nil {
+		return err
+	}
+	return db.Exec(`UPDATE wallet_transactions
+		SET transaction_type = CASE WHEN balance_after < balance_before THEN ? ELSE ? END
+		WHERE transaction_type NOT IN (?, ?) AND balance_after <> balance_before`,
+		models.TxTypeDebit, models.TxTypeCredit, models.TxTypeCredit, models.TxTypeDebit).Error
+}
+
+// migrateWithdrawals moves every withdrawal onto withdrawal_requests. Admin
+// withdrawals filed before the pipeline existed are copied over from
+// withdraw_requests, and customer withdrawals are linked to the wallet
+// transaction that took their funds.
+func migrateWithdrawals(db *gorm.DB) error {
+	if err := db.Exec(`INSERT INTO withdrawal_requests (user_id, requested_by, amount, currency,
+			bank_account_number, bank_account_holder, ifsc_code, status, request_time, processing_time,
+			completion_time, admin_notes, payment_gateway_tx_id, wallet_transaction_id, limit_amount,
+			approvals_required, approvals, legacy_withdraw_request_id, created_at, updated_at)
+		SELECT w.user_id, w.user_id, w.amount, w.currency,
+			w.bank_account_number, w.bank_account_holder, w.ifsc_code, w.status, w.request_time, w.processing_time,
+			w.completion_time, w.admin_notes, w.payment_gateway_tx_id, w.wallet_transaction_id, 0,
+			1, 0, w.id, w.created_at, w.updated_at
+		FROM withdraw_requests w
+		WHERE w.deleted_at IS NULL
+		ON CONFLICT (legacy_withdraw_request_id) DO NOTHING`).Error; err != nil {
+		return err
+	}
+	// Withdrawals filed through the pipeline always have a REQUESTED event;
+	// one with requested_by 0 was filed by the platform.
+	if err := db.Exec(`UPDATE withdrawal_requests AS r SET requested_by = r.user_id
+		WHERE r.requested_by = 0 AND NOT EXISTS (SELECT 1 FROM withdrawal_events e WHERE e.withdrawal_id = r.id)`).Error; err != nil {
+		return err
+	}
+	return db.Exec(`UPDATE withdrawal_requests AS r SET wallet_transaction_id = wt.id
+		FROM wallet_transactions wt
+		WHERE r.wallet_transaction_id IS NULL AND wt.type = ? AND wt.reference_id = 'WITHDRAW_REQ_' || r.id`,
+		models.TxTypeWithdrawal).Error
 }
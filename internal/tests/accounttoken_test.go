@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/accounttoken"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+)
+
+func TestAccountTokenParse(t *testing.T) {
+	secret := []byte("test-secret")
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	token := accounttoken.Sign(secret, accounttoken.Claims{
+		UserID:    42,
+		Purpose:   models.AccountTokenPasswordReset,
+		ExpiresAt: now.Add(30 * time.Minute),
+		Nonce:     "abc",
+	})
+
+	claims, err := accounttoken.Parse(secret, token, models.AccountTokenPasswordReset, now)
+	if err != nil {
+		t.Fatalf("expected a valid token, got %v", err)
+	}
+	if claims.UserID != 42 {
+		t.Errorf("expected user 42, got %d", claims.UserID)
+	}
+
+	if _, err := accounttoken.Parse(secret, token, models.AccountTokenVerifyEmail, now); !errors.Is(err, accounttoken.ErrInvalid) {
+		t.Errorf("expected a reset token to be refused for email verification, got %v", err)
+	}
+	if _, err := accounttoken.Parse([]byte("other-secret"), token, models.AccountTokenPasswordReset, now); !errors.Is(err, accounttoken.ErrInvalid) {
+		t.Errorf("expected a token signed with another secret to be refused, got %v", err)
+	}
+	if _, err := accounttoken.Parse(secret, "x"+token, models.AccountTokenPasswordReset, now); !errors.Is(err, accounttoken.ErrInvalid) {
+		t.Errorf("expected a tampered token to be refused, got %v", err)
+	}
+	if _, err := accounttoken.Parse(secret, token, models.AccountTokenPasswordReset, now.Add(time.Hour)); !errors.Is(err, accounttoken.ErrExpired) {
+		t.Errorf("expected an expired token to be refused, got %v", err)
+	}
+}
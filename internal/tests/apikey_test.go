@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/apikey"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+)
+
+func TestAPIKeySignature(t *testing.T) {
+	secret := "signing-secret"
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	ts := now.Unix()
+	body := []byte(`{"symbol":"BTCUSDT","side":"buy"}`)
+	signature := apikey.Sign(secret, ts, http.MethodPost, "/api/v1/signals", body)
+	timestamp := strconv.FormatInt(ts, 10)
+	tolerance := 5 * time.Minute
+
+	if err := apikey.VerifySignature(secret, signature, timestamp, "post", "/api/v1/signals", body, now.Add(time.Minute), tolerance); err != nil {
+		t.Fatalf("expected a valid signature, got %v", err)
+	}
+	if err := apikey.VerifySignature(secret, signature, timestamp, http.MethodPost, "/api/v1/signals", []byte(`{"symbol":"ETHUSDT","side":"buy"}`), now, tolerance); !errors.Is(err, apikey.ErrInvalidSignature) {
+		t.Errorf("expected an altered body to be refused, got %v", err)
+	}
+	if err := apikey.VerifySignature(secret, signature, timestamp, http.MethodPut, "/api/v1/signals", body, now, tolerance); !errors.Is(err, apikey.ErrInvalidSignature) {
+		t.Errorf("expected another method to be refused, got %v", err)
+	}
+	if err := apikey.VerifySignature(secret, signature, timestamp, http.MethodPost, "/api/v1/signals", body, now.Add(10*time.Minute), tolerance); !errors.Is(err, apikey.ErrSignatureExpired) {
+		t.Errorf("expected a stale request to be refused, got %v", err)
+	}
+	if err := apikey.VerifySignature(secret, "", timestamp, http.MethodPost, "/api/v1/signals", body, now, tolerance); !errors.Is(err, apikey.ErrMissingSignature) {
+		t.Errorf("expected an unsigned request to be refused, got %v", err)
+	}
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	scopes, err := apikey.ParseScopes([]string{"signals:write", "trades:write", "signals:write"}, models.RoleTrader)
+	if err != nil {
+		t.Fatalf("expected trader scopes to be accepted, got %v", err)
+	}
+	if len(scopes) != 2 {
+		t.Errorf("expected duplicates to be dropped, got %v", scopes)
+	}
+
+	if _, err := apikey.ParseScopes([]string{"signals:write"}, models.RoleCustomer); !errors.Is(err, apikey.ErrScopeNotAllowed) {
+		t.Errorf("expected customers to be refused signals:write, got %v", err)
+	}
+	if _, err := apikey.ParseScopes([]string{"wallet:write"}, models.RoleTrader); !errors.Is(err, apikey.ErrUnknownScope) {
+		t.Errorf("expected an unknown scope to be refused, got %v", err)
+	}
+	if _, err := apikey.ParseScopes(nil, models.RoleTrader); !errors.Is(err, apikey.ErrNoScopes) {
+		t.Errorf("expected an empty scope list to be refused, got %v", err)
+	}
+
+	routes := apikey.Routes{"GET /api/v1/signals/:id": apikey.ScopeSignalsRead}
+	if scope, ok := routes.ScopeFor(http.MethodGet, "/api/v1/signals/:id"); !ok || scope != apikey.ScopeSignalsRead {
+		t.Errorf("expected signals:read for GET /api/v1/signals/:id, got %q %v", scope, ok)
+	}
+	if _, ok := routes.ScopeFor(http.MethodDelete, "/api/v1/signals/:id"); ok {
+		t.Error("expected an unlisted route to be closed to API keys")
+	}
+}
@@ -1,7 +1,9 @@
 package tests
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/fathimasithara01/tradeverse/pkg/auth"
 )
@@ -13,7 +15,9 @@ func TestGenerateAndValidateToken(t *testing.T) {
 	roleID := uint(10)
 	secret := "test-secret-key"
 
-	token, err := auth.GenerateJWT(userID, email, role, roleID, secret)
+	sessionID := "session-1"
+
+	token, err := auth.GenerateJWT(userID, email, role, roleID, sessionID, secret, time.Hour)
 	if err != nil {
 		t.Fatalf("failed to generate JWT: %v", err)
 	}
@@ -35,4 +39,34 @@ func TestGenerateAndValidateToken(t *testing.T) {
 	if claims.RoleID != roleID {
 		t.Errorf("expected RoleID %d, got %d", roleID, claims.RoleID)
 	}
+	if claims.SessionID != sessionID {
+		t.Errorf("expected SessionID %s, got %s", sessionID, claims.SessionID)
+	}
+	if claims.ID == "" {
+		t.Error("expected the token to carry an ID")
+	}
+}
+
+func TestExpiredAccessToken(t *testing.T) {
+	secret := "test-secret-key"
+	token, err := auth.GenerateJWT(1, "test@example.com", "customer", 2, "session-1", secret, -time.Minute)
+	if err != nil {
+		t.Fatalf("failed to generate JWT: %v", err)
+	}
+	if _, err := auth.ValidateJWT(token, secret); !errors.Is(err, auth.ErrTokenExpired) {
+		t.Errorf("expected ErrTokenExpired, got %v", err)
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	token, err := auth.RandomToken(32)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	if auth.HashToken(token) != auth.HashToken(token) {
+		t.Error("expected hashing to be deterministic")
+	}
+	if auth.HashToken(token) == token || len(auth.HashToken(token)) != 64 {
+		t.Errorf("expected a 64 character hex digest, got %q", auth.HashToken(token))
+	}
 }
@@ -0,0 +1,28 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+)
+
+func TestCandleBuckets(t *testing.T) {
+	at := time.Date(2024, 3, 10, 14, 37, 42, 0, time.UTC)
+
+	cases := map[models.CandleInterval]time.Time{
+		models.CandleInterval1m: time.Date(2024, 3, 10, 14, 37, 0, 0, time.UTC),
+		models.CandleInterval5m: time.Date(2024, 3, 10, 14, 35, 0, 0, time.UTC),
+		models.CandleInterval1h: time.Date(2024, 3, 10, 14, 0, 0, 0, time.UTC),
+		models.CandleInterval1d: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+	}
+	for interval, want := range cases {
+		if got := interval.BucketStart(at); !got.Equal(want) {
+			t.Errorf("%s bucket: expected %s, got %s", interval, want, got)
+		}
+	}
+
+	if _, err := models.ParseCandleInterval("15m"); err == nil {
+		t.Error("expected 15m to be rejected")
+	}
+}
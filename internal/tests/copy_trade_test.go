@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/fathimasithara01/tradeverse/internal/customer/repository/customerrepo"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+)
+
+func TestTradePnLBuy(t *testing.T) {
+	trade := models.Trade{Side: models.TradeSideBuy, EntryPrice: 100, Quantity: 2, Leverage: 1}
+
+	if pnl := trade.CalculatePnL(110); pnl != 20 {
+		t.Errorf("expected pnl 20, got %f", pnl)
+	}
+}
+
+func TestTradePnLSell(t *testing.T) {
+	executed := 200.0
+	trade := models.Trade{Side: models.TradeSideSell, EntryPrice: 190, ExecutedPrice: &executed, Quantity: 1, Leverage: 4}
+
+	if pnl := trade.CalculatePnL(180); pnl != 20 {
+		t.Errorf("expected pnl 20, got %f", pnl)
+	}
+	if margin := trade.MarginRequired(); margin != 50 {
+		t.Errorf("expected margin 50, got %f", margin)
+	}
+}
+
+func TestCopyProfileSizing(t *testing.T) {
+	master := &models.Trade{Side: models.TradeSideBuy, EntryPrice: 100, Quantity: 3, Leverage: 2}
+
+	fixed := models.CopyProfile{SizingMode: models.CopySizingFixed, FixedAmount: money.FromFloat(50)}
+	if qty := fixed.CopyQuantity(master, 1000); qty != 1 {
+		t.Errorf("expected fixed quantity 1, got %f", qty)
+	}
+
+	proportional := models.CopyProfile{SizingMode: models.CopySizingProportional, EquityPercent: 10, MaxAllocationPerTrade: money.FromFloat(25)}
+	if qty := proportional.CopyQuantity(master, 1000); qty != 0.5 {
+		t.Errorf("expected capped proportional quantity 0.5, got %f", qty)
+	}
+
+	multiplier := models.CopyProfile{SizingMode: models.CopySizingMultiplier, Multiplier: 0.5}
+	if qty := multiplier.CopyQuantity(master, 1000); qty != 1.5 {
+		t.Errorf("expected multiplier quantity 1.5, got %f", qty)
+	}
+}
+
+func TestCopyProfileSymbols(t *testing.T) {
+	profile := models.CopyProfile{
+		AllowedSymbols: models.JoinSymbols([]string{"btcusdt", " ETHUSDT "}),
+		DeniedSymbols:  "ETHUSDT",
+	}
+
+	if !profile.AllowsSymbol("BTCUSDT") {
+		t.Error("expected BTCUSDT to be allowed")
+	}
+	if profile.AllowsSymbol("ETHUSDT") {
+		t.Error("expected ETHUSDT to be denied")
+	}
+	if profile.AllowsSymbol("SOLUSDT") {
+		t.Error("expected SOLUSDT to be outside the allow list")
+	}
+}
+
+func TestResumeRestoresDeletedCopySession(t *testing.T) {
+	db := newTestDB(t, &models.CopySession{})
+	repo := customerrepo.NewCopyProfileRepository(db)
+
+	if err := repo.SetSessionActive(db, 1, 2, true); err != nil {
+		t.Fatalf("failed to start session: %v", err)
+	}
+	if err := db.Where("follower_id = ? AND master_id = ?", 1, 2).Delete(&models.CopySession{}).Error; err != nil {
+		t.Fatalf("failed to delete session: %v", err)
+	}
+	if err := repo.SetSessionActive(db, 1, 2, true); err != nil {
+		t.Fatalf("failed to resume session: %v", err)
+	}
+
+	var session models.CopySession
+	if err := db.Where("follower_id = ? AND master_id = ?", 1, 2).First(&session).Error; err != nil {
+		t.Fatalf("expected the resumed session to be visible: %v", err)
+	}
+	if !session.IsActive {
+		t.Error("expected the resumed session to be active")
+	}
+}
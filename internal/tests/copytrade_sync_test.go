@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	adminRepository "github.com/fathimasithara01/tradeverse/internal/admin/repository"
+	adminService "github.com/fathimasithara01/tradeverse/internal/admin/service"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"gorm.io/gorm"
+)
+
+func countFollowerTrades(t *testing.T, db *gorm.DB, masterTradeID uint) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(&models.Trade{}).Where("original_trade_id = ?", masterTradeID).Count(&count).Error; err != nil {
+		t.Fatalf("failed to count follower trades: %v", err)
+	}
+	return count
+}
+
+type copySyncFixture struct {
+	db                 *gorm.DB
+	masterID, followID uint
+}
+
+// newCopySyncFixture sets up a master trader and a follower with 1000 USD
+// who has copied them for the last two hours.
+func newCopySyncFixture(t *testing.T) *copySyncFixture {
+	t.Helper()
+	db := newTestDB(t,
+		&models.User{}, &models.Wallet{}, &models.WalletTransaction{}, &models.Trade{}, &models.TradeLog{},
+		&models.LedgerAccount{}, &models.JournalEntry{}, &models.JournalPosting{},
+		&models.CopySession{}, &models.CopyProfile{}, &models.CopySyncCursor{},
+	)
+	master := models.User{Name: "Master", Email: "master@example.com", Password: "x", Phone: "1", Role: models.RoleTrader}
+	follower := models.User{Name: "Follower", Email: "follower@example.com", Password: "x", Phone: "2", Role: models.RoleCustomer}
+	for _, u := range []*models.User{&master, &follower} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+	}
+	if err := db.Model(&models.Wallet{}).Where("user_id = ?", follower.ID).Update("balance", money.FromFloat(1000)).Error; err != nil {
+		t.Fatalf("failed to fund wallet: %v", err)
+	}
+	session := models.CopySession{FollowerID: follower.ID, MasterID: master.ID, IsActive: true, StartedAt: time.Now().Add(-2 * time.Hour)}
+	if err := db.Create(&session).Error; err != nil {
+		t.Fatalf("failed to create copy session: %v", err)
+	}
+	return &copySyncFixture{db: db, masterID: master.ID, followID: follower.ID}
+}
+
+func (f *copySyncFixture) service() *adminService.CopyTradeService {
+	return adminService.NewCopyTradeService(adminRepository.NewCopyTradeRepository(f.db), f.db)
+}
+
+func (f *copySyncFixture) openMaster(t *testing.T, openedAt time.Time) *models.Trade {
+	t.Helper()
+	price := 100.0
+	trade := &models.Trade{
+		TraderID: f.masterID, Symbol: "BTCUSDT", TradeType: models.TradeTypeMarket, Side: models.TradeSideBuy,
+		EntryPrice: price, ExecutedPrice: &price, Quantity: 1, Leverage: 1,
+		Status: models.TradeStatusOpen, OpenedAt: models.TimePtr(openedAt),
+	}
+	if err := f.db.Create(trade).Error; err != nil {
+		t.Fatalf("failed to create master trade: %v", err)
+	}
+	return trade
+}
+
+func TestSyncMasterTradesMirrorsOnlyNewOpens(t *testing.T) {
+	f := newCopySyncFixture(t)
+	ctx := context.Background()
+
+	old := f.openMaster(t, time.Now().Add(-time.Hour))
+
+	// The first run only records where to start from.
+	if err := f.service().SyncMasterTrades(ctx); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if n := countFollowerTrades(t, f.db, old.ID); n != 0 {
+		t.Fatalf("expected history not to be replayed, got %d follower trades", n)
+	}
+
+	// A restarted engine resumes from the stored cursor, and editing a trade
+	// opened before it does not mirror the open.
+	stop := 90.0
+	if err := f.db.Model(old).Update("stop_loss_price", stop).Error; err != nil {
+		t.Fatalf("failed to update master trade: %v", err)
+	}
+	if err := f.service().SyncMasterTrades(ctx); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if n := countFollowerTrades(t, f.db, old.ID); n != 0 {
+		t.Errorf("expected no follower trade for a trade opened before the cursor, got %d", n)
+	}
+
+	fresh := f.openMaster(t, time.Now())
+	svc := f.service()
+	if err := svc.SyncMasterTrades(ctx); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if n := countFollowerTrades(t, f.db, fresh.ID); n != 1 {
+		t.Fatalf("expected the new master trade to be mirrored once, got %d", n)
+	}
+
+	// Later updates only carry the stops over.
+	if err := f.db.Model(fresh).Update("stop_loss_price", stop).Error; err != nil {
+		t.Fatalf("failed to update master trade: %v", err)
+	}
+	if err := svc.SyncMasterTrades(ctx); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	var copies []models.Trade
+	if err := f.db.Where("original_trade_id = ?", fresh.ID).Find(&copies).Error; err != nil {
+		t.Fatalf("failed to load follower trades: %v", err)
+	}
+	if len(copies) != 1 || copies[0].StopLossPrice == nil || *copies[0].StopLossPrice != stop {
+		t.Errorf("expected one follower trade with stop loss %v, got %+v", stop, copies)
+	}
+
+	var logs int64
+	if err := f.db.Model(&models.TradeLog{}).Where("action = ?", models.CopyActionOpen).Count(&logs).Error; err != nil {
+		t.Fatalf("failed to count trade logs: %v", err)
+	}
+	if logs != 1 {
+		t.Errorf("expected a single open to be logged, got %d", logs)
+	}
+}
+
+func TestSyncMasterTradesRetriesFailedClose(t *testing.T) {
+	f := newCopySyncFixture(t)
+	ctx := context.Background()
+	svc := f.service()
+
+	if err := svc.SyncMasterTrades(ctx); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	master := f.openMaster(t, time.Now())
+	if err := svc.SyncMasterTrades(ctx); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+
+	followerStatus := func() models.TradeStatus {
+		t.Helper()
+		var follower models.Trade
+		if err := f.db.Where("original_trade_id = ?", master.ID).First(&follower).Error; err != nil {
+			t.Fatalf("failed to load follower trade: %v", err)
+		}
+		return follower.Status
+	}
+
+	// Without a wallet to pay the margin back into, the close fails.
+	var wallet models.Wallet
+	if err := f.db.Where("user_id = ?", f.followID).First(&wallet).Error; err != nil {
+		t.Fatalf("failed to load wallet: %v", err)
+	}
+	if err := f.db.Delete(&wallet).Error; err != nil {
+		t.Fatalf("failed to delete wallet: %v", err)
+	}
+	closePrice := 110.0
+	if err := f.db.Model(master).Updates(map[string]interface{}{
+		"status": models.TradeStatusClosed, "close_price": closePrice, "closed_at": time.Now(),
+	}).Error; err != nil {
+		t.Fatalf("failed to close master trade: %v", err)
+	}
+	if err := svc.SyncMasterTrades(ctx); err == nil {
+		t.Error("expected the failed close to be reported")
+	}
+	if status := followerStatus(); status != models.TradeStatusOpen {
+		t.Fatalf("expected the follower trade to stay open after a failed close, got %s", status)
+	}
+
+	// The cursor was held back, so a later run closes it.
+	if err := f.db.Unscoped().Model(&wallet).Update("deleted_at", nil).Error; err != nil {
+		t.Fatalf("failed to restore wallet: %v", err)
+	}
+	if err := svc.SyncMasterTrades(ctx); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if status := followerStatus(); status != models.TradeStatusClosed {
+		t.Errorf("expected the retried close to settle the follower trade, got %s", status)
+	}
+}
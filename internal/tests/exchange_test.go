@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fathimasithara01/tradeverse/pkg/exchange"
+)
+
+func TestMockExchangeOrders(t *testing.T) {
+	mock := exchange.NewMockExchange()
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	adapter := exchange.NewMockAdapter(server.URL, server.Client())
+	ctx := context.Background()
+
+	ticker, err := adapter.GetTicker(ctx, "BTCUSDT")
+	if err != nil {
+		t.Fatalf("get ticker failed: %v", err)
+	}
+	if ticker.Price != 43000.50 {
+		t.Errorf("expected deterministic BTC price 43000.50, got %f", ticker.Price)
+	}
+
+	market, err := adapter.PlaceOrder(ctx, exchange.OrderRequest{Symbol: "BTC-USDT", Side: exchange.OrderSideBuy, Type: exchange.OrderTypeMarket, Quantity: 1})
+	if err != nil {
+		t.Fatalf("place market order failed: %v", err)
+	}
+	if market.Status != exchange.OrderStatusFilled || market.AveragePrice != 43000.50 {
+		t.Errorf("expected market order filled at 43000.50, got %s at %f", market.Status, market.AveragePrice)
+	}
+
+	limit, err := adapter.PlaceOrder(ctx, exchange.OrderRequest{Symbol: "BTC", Side: exchange.OrderSideSell, Type: exchange.OrderTypeLimit, Quantity: 1, Price: 45000})
+	if err != nil {
+		t.Fatalf("place limit order failed: %v", err)
+	}
+	if limit.Status != exchange.OrderStatusNew {
+		t.Errorf("expected limit order to rest, got %s", limit.Status)
+	}
+
+	mock.SetPrice("BTC", 45500)
+	limit, err = adapter.GetOrder(ctx, limit.ID)
+	if err != nil {
+		t.Fatalf("get order failed: %v", err)
+	}
+	if limit.Status != exchange.OrderStatusFilled || limit.AveragePrice != 45000 {
+		t.Errorf("expected limit order filled at 45000, got %s at %f", limit.Status, limit.AveragePrice)
+	}
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fathimasithara01/tradeverse/pkg/fx"
+	"github.com/fathimasithara01/tradeverse/pkg/ledger"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+)
+
+func TestRateParseAndInverse(t *testing.T) {
+	r, err := money.ParseRate("83.125")
+	if err != nil {
+		t.Fatalf("ParseRate: %v", err)
+	}
+	if r.String() != "83.125" {
+		t.Errorf("expected 83.125, got %s", r)
+	}
+	if got := money.MustParseRate("4").Inverse().String(); got != "0.25" {
+		t.Errorf("expected inverse of 4 to be 0.25, got %s", got)
+	}
+	if got := money.MustParseRate("1.123456785").String(); got != "1.12345679" {
+		t.Errorf("expected digits beyond the scale to round half up, got %s", got)
+	}
+	if _, err := money.ParseRate("-1"); err == nil {
+		t.Error("expected a negative rate to be rejected")
+	}
+}
+
+func TestAmountConvertRoundsToTargetCurrency(t *testing.T) {
+	price := money.MustParse("999")
+	got := price.Convert(money.MustParseRate("0.012"), "USD", money.RoundHalfUp)
+	if got != money.MustParse("11.99") {
+		t.Errorf("expected 11.99, got %s", got)
+	}
+
+	got = money.MustParse("10").Convert(money.MustParseRate("0.33333333"), "USD", money.RoundHalfUp)
+	if got != money.MustParse("3.33") {
+		t.Errorf("expected 3.33, got %s", got)
+	}
+}
+
+func TestParseRatesDocument(t *testing.T) {
+	doc := `{"base": "usd", "rates": {"INR": 83.12, "aed": "3.6725", "USD": 1, "XXX": 0}}`
+	rates, err := fx.ParseRatesDocument(strings.NewReader(doc), "feed")
+	if err != nil {
+		t.Fatalf("ParseRatesDocument: %v", err)
+	}
+	if len(rates) != 2 {
+		t.Fatalf("expected 2 rates, got %d: %+v", len(rates), rates)
+	}
+	byQuote := map[string]string{}
+	for _, r := range rates {
+		if r.BaseCurrency != "USD" || r.Source != "feed" {
+			t.Errorf("unexpected rate %+v", r)
+		}
+		byQuote[r.QuoteCurrency] = r.Rate.String()
+	}
+	if byQuote["INR"] != "83.12" || byQuote["AED"] != "3.6725" {
+		t.Errorf("unexpected rates %v", byQuote)
+	}
+
+	if _, err := fx.ParseRatesDocument(strings.NewReader(`{"rates": {"INR": 83}}`), "feed"); err == nil {
+		t.Error("expected a document without a base currency to be rejected")
+	}
+}
+
+func TestLedgerExchangeLegsBalancePerCurrency(t *testing.T) {
+	legs := ledger.Exchange("USD", money.MustParse("12"), "INR", money.MustParse("999"))
+	if len(legs) != 2 {
+		t.Fatalf("expected 2 legs, got %d", len(legs))
+	}
+
+	// Without an exchange the legs must sum to zero across the entry, which is
+	// checked before the database is touched.
+	if _, err := ledger.Post(nil, ledger.Entry{Legs: []ledger.Leg{
+		ledger.Debit(ledger.WalletIn(1, "USD"), money.MustParse("12"), nil),
+		ledger.Credit(ledger.PlatformCommissionIn("INR"), money.MustParse("999"), nil),
+	}}); !errors.Is(err, ledger.ErrUnbalancedEntry) {
+		t.Errorf("expected ErrUnbalancedEntry, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fathimasithara01/tradeverse/pkg/idempotency"
+	"github.com/gin-gonic/gin"
+)
+
+func newIdempotentRouter(status int, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/wallet/withdraw",
+		func(c *gin.Context) { c.Set("userID", uint(7)); c.Next() },
+		idempotency.Middleware(idempotency.NewMemoryStore(), idempotency.Options{}),
+		func(c *gin.Context) {
+			*calls++
+			c.JSON(status, gin.H{"call": *calls})
+		},
+	)
+	return r
+}
+
+func sendIdempotent(r *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/wallet/withdraw", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(idempotency.HeaderKey, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	calls := 0
+	r := newIdempotentRouter(http.StatusCreated, &calls)
+
+	first := sendIdempotent(r, "key-1", `{"amount":100}`)
+	retry := sendIdempotent(r, "key-1", `{"amount":100}`)
+
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Fatalf("retry got %d %q, want %d %q", retry.Code, retry.Body.String(), first.Code, first.Body.String())
+	}
+	if retry.Header().Get(idempotency.HeaderReplayed) != "true" {
+		t.Fatalf("retry missing %s header", idempotency.HeaderReplayed)
+	}
+
+	if w := sendIdempotent(r, "key-1", `{"amount":200}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("reused key with different body got %d, want 422", w.Code)
+	}
+
+	sendIdempotent(r, "", `{"amount":100}`)
+	sendIdempotent(r, "", `{"amount":100}`)
+	if calls != 3 {
+		t.Fatalf("requests without a key ran %d times in total, want 3", calls)
+	}
+}
+
+func TestIdempotencyDoesNotStoreServerErrors(t *testing.T) {
+	calls := 0
+	r := newIdempotentRouter(http.StatusInternalServerError, &calls)
+
+	sendIdempotent(r, "key-1", `{"amount":100}`)
+	w := sendIdempotent(r, "key-1", `{"amount":100}`)
+
+	if calls != 2 {
+		t.Fatalf("handler ran %d times, want 2 after a 5xx", calls)
+	}
+	if w.Header().Get(idempotency.HeaderReplayed) != "" {
+		t.Fatal("5xx response was replayed")
+	}
+}
+
+func TestIdempotencyFingerprint(t *testing.T) {
+	a := idempotency.Fingerprint(http.MethodPost, "/wallet/withdraw", []byte(`{"amount":100}`))
+	if a != idempotency.Fingerprint(http.MethodPost, "/wallet/withdraw", []byte(`{"amount":100}`)) {
+		t.Fatal("fingerprint is not deterministic")
+	}
+	if a == idempotency.Fingerprint(http.MethodPost, "/wallet/deposit", []byte(`{"amount":100}`)) {
+		t.Fatal("fingerprint ignores the path")
+	}
+}
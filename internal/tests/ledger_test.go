@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fathimasithara01/tradeverse/pkg/ledger"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"gorm.io/gorm"
+)
+
+func TestLedgerPostRejectsBadEntries(t *testing.T) {
+	// Entries are validated before the database is touched, so a nil handle is enough.
+	if _, err := ledger.Post(nil, ledger.Entry{Legs: []ledger.Leg{
+		ledger.Debit(ledger.Wallet(1), money.MustParse("10"), nil),
+		ledger.Credit(ledger.PlatformCommission(), money.MustParse("9.99"), nil),
+	}}); !errors.Is(err, ledger.ErrUnbalancedEntry) {
+		t.Errorf("expected ErrUnbalancedEntry, got %v", err)
+	}
+
+	if _, err := ledger.Post(nil, ledger.Entry{Legs: []ledger.Leg{
+		ledger.Debit(ledger.Wallet(1), money.MustParse("10"), nil),
+		ledger.Credit(ledger.PlatformCommission(), 0, nil),
+	}}); !errors.Is(err, ledger.ErrEmptyEntry) {
+		t.Errorf("expected ErrEmptyEntry, got %v", err)
+	}
+
+	entry, err := ledger.Post(nil, ledger.Entry{Legs: []ledger.Leg{
+		ledger.Debit(ledger.Wallet(1), 0, nil),
+		ledger.Credit(ledger.MarketSettlement(), 0, nil),
+	}})
+	if entry != nil || err != nil {
+		t.Errorf("expected an all-zero entry to be a no-op, got %v, %v", entry, err)
+	}
+}
+
+func TestLedgerWalletLegDirection(t *testing.T) {
+	db := newTestDB(t, &models.User{}, &models.Wallet{}, &models.WalletTransaction{},
+		&models.LedgerAccount{}, &models.JournalEntry{}, &models.JournalPosting{})
+	user := models.User{Name: "Customer", Email: "customer@example.com", Password: "x", Phone: "1"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	post := func(entryType models.TransactionType, legs ...ledger.Leg) {
+		t.Helper()
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			_, err := ledger.Post(tx, ledger.Entry{Type: entryType, Reference: string(entryType), Legs: legs})
+			return err
+		}); err != nil {
+			t.Fatalf("failed to post %s: %v", entryType, err)
+		}
+	}
+	amount := money.MustParse("25")
+	post(models.TxTypeDeposit,
+		ledger.Credit(ledger.Wallet(user.ID), amount, nil),
+		ledger.Debit(ledger.GatewayClearing("USD"), amount, nil))
+	post(models.TxTypeWithdrawal,
+		ledger.Debit(ledger.Wallet(user.ID), amount, &models.WalletTransaction{Status: models.TxStatusPending}),
+		ledger.Credit(ledger.GatewayClearing("USD"), amount, nil))
+
+	var txs []models.WalletTransaction
+	if err := db.Order("id").Find(&txs).Error; err != nil {
+		t.Fatalf("failed to load wallet transactions: %v", err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("expected 2 wallet transactions, got %d", len(txs))
+	}
+	for i, want := range []struct {
+		kind, direction models.TransactionType
+	}{{models.TxTypeDeposit, models.TxTypeCredit}, {models.TxTypeWithdrawal, models.TxTypeDebit}} {
+		if txs[i].Type != want.kind || txs[i].TransactionType != want.direction {
+			t.Errorf("expected %s %s, got %s %s", want.kind, want.direction, txs[i].Type, txs[i].TransactionType)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/internal/database"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// A struct tag gorm cannot parse, such as a decimal default on an integer
+// column, breaks every query on the model, not just the migration.
+func TestMigratedModelsParse(t *testing.T) {
+	cache := &sync.Map{}
+	for _, model := range database.Models() {
+		if _, err := schema.Parse(model, cache, schema.NamingStrategy{}); err != nil {
+			t.Errorf("%T: %v", model, err)
+		}
+	}
+}
+
+// legacyCopySession is the copy_sessions table as it was before the pair
+// became unique.
+type legacyCopySession struct {
+	gorm.Model
+	FollowerID    uint
+	MasterID      uint
+	CurrentProfit float64
+	IsActive      bool
+	StartedAt     time.Time
+}
+
+func (legacyCopySession) TableName() string { return "copy_sessions" }
+
+func TestMigrationsDedupeCopySessions(t *testing.T) {
+	db := newTestDB(t, &legacyCopySession{}, &models.TradeLog{})
+	sessions := []legacyCopySession{
+		{FollowerID: 1, MasterID: 2, IsActive: false},
+		{FollowerID: 1, MasterID: 2, IsActive: true},
+		{FollowerID: 1, MasterID: 2, IsActive: false},
+		{FollowerID: 3, MasterID: 2, IsActive: true},
+	}
+	if err := db.Create(&sessions).Error; err != nil {
+		t.Fatalf("failed to create sessions: %v", err)
+	}
+	if err := db.Create(&models.TradeLog{CopySessionID: sessions[0].ID}).Error; err != nil {
+		t.Fatalf("failed to create trade log: %v", err)
+	}
+
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatalf("migrations failed: %v", err)
+	}
+
+	var kept []models.CopySession
+	if err := db.Unscoped().Order("id").Find(&kept).Error; err != nil {
+		t.Fatalf("failed to load sessions: %v", err)
+	}
+	if len(kept) != 2 || kept[0].ID != sessions[1].ID || kept[1].ID != sessions[3].ID {
+		t.Fatalf("expected the active session of each pair to be kept, got %+v", kept)
+	}
+	var log models.TradeLog
+	if err := db.First(&log).Error; err != nil {
+		t.Fatalf("failed to load trade log: %v", err)
+	}
+	if log.CopySessionID != sessions[1].ID {
+		t.Errorf("expected the trade log to move to session %d, got %d", sessions[1].ID, log.CopySessionID)
+	}
+	if !db.Migrator().HasIndex(&models.CopySession{}, "idx_copy_session_pair") {
+		t.Error("expected the unique index on the copy session pair")
+	}
+}
+
+// legacyCandle is the candles table as it was when the 24h volume snapshot
+// was stored in a column named volume.
+type legacyCandle struct {
+	ID        uint `gorm:"primarykey"`
+	Symbol    string
+	Interval  string `gorm:"column:candle_interval"`
+	OpenTime  time.Time
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    float64
+	TickCount int
+	UpdatedAt time.Time
+}
+
+func (legacyCandle) TableName() string { return "candles" }
+
+func TestMigrationsRenameCandleVolume(t *testing.T) {
+	db := newTestDB(t, &legacyCandle{})
+	if err := db.Create(&legacyCandle{Symbol: "BTC", Interval: "1m", OpenTime: time.Now(), Volume: 42}).Error; err != nil {
+		t.Fatalf("failed to create candle: %v", err)
+	}
+
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatalf("migrations failed: %v", err)
+	}
+
+	if db.Migrator().HasColumn(&models.Candle{}, "volume") {
+		t.Error("expected the volume column to be renamed")
+	}
+	var candle models.Candle
+	if err := db.First(&candle).Error; err != nil {
+		t.Fatalf("failed to load candle: %v", err)
+	}
+	if candle.Volume24H != 42 {
+		t.Errorf("expected the 24h volume to be kept, got %v", candle.Volume24H)
+	}
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+)
+
+func TestMoneyParseAndFormat(t *testing.T) {
+	cases := map[string]string{
+		"10":        "10.0000",
+		"0.1":       "0.1000",
+		"-3.00005":  "-3.0001",
+		"10.12344":  "10.1234",
+		".5":        "0.5000",
+		"123456.78": "123456.7800",
+	}
+	for in, want := range cases {
+		a, err := money.Parse(in)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", in, err)
+		}
+		if got := a.String(); got != want {
+			t.Errorf("Parse(%q) = %s, want %s", in, got, want)
+		}
+	}
+	for _, bad := range []string{"", "abc", "1.2.3", "-"} {
+		if _, err := money.Parse(bad); err == nil {
+			t.Errorf("expected an error parsing %q", bad)
+		}
+	}
+
+	if got := money.MustParse("0.1").Add(money.MustParse("0.2")); got != money.MustParse("0.3") {
+		t.Errorf("0.1 + 0.2 = %s", got)
+	}
+	if got := money.MustParse("12.345").Format("USD"); got != "12.35" {
+		t.Errorf("expected USD formatting to round half up, got %s", got)
+	}
+	if got := money.MustParse("1234.5").Format("JPY"); got != "1235" {
+		t.Errorf("expected JPY to have no decimals, got %s", got)
+	}
+}
+
+func TestMoneyRoundingModes(t *testing.T) {
+	cases := []struct {
+		in   string
+		mode money.RoundingMode
+		want string
+	}{
+		{"1.005", money.RoundHalfUp, "1.01"},
+		{"-1.005", money.RoundHalfUp, "-1.01"},
+		{"1.005", money.RoundHalfEven, "1.00"},
+		{"1.015", money.RoundHalfEven, "1.02"},
+		{"1.0099", money.RoundDown, "1.00"},
+		{"-1.0099", money.RoundDown, "-1.00"},
+	}
+	for _, c := range cases {
+		got := money.MustParse(c.in).Round("USD", c.mode)
+		if got != money.MustParse(c.want) {
+			t.Errorf("Round(%s, %d) = %s, want %s", c.in, c.mode, got, c.want)
+		}
+	}
+}
+
+func TestMoneySplitAddsUp(t *testing.T) {
+	for _, price := range []string{"9.99", "0.01", "33.33", "100", "7.77"} {
+		for _, pct := range []float64{0, 10, 12.5, 33.33, 100} {
+			total := money.MustParse(price)
+			share, rest := total.Split(pct, "USD")
+			if share+rest != total {
+				t.Errorf("split of %s at %.2f%% does not add up: %s + %s", price, pct, share, rest)
+			}
+			if share != share.Round("USD", money.RoundDown) {
+				t.Errorf("share %s is not at USD precision", share)
+			}
+		}
+	}
+
+	share, rest := money.MustParse("9.99").Split(10, "USD")
+	if share != money.MustParse("1.00") || rest != money.MustParse("8.99") {
+		t.Errorf("expected 1.00 / 8.99, got %s / %s", share, rest)
+	}
+}
+
+func TestMoneyJSONAndScan(t *testing.T) {
+	var payload struct {
+		Amount money.Amount `json:"amount"`
+		Text   money.Amount `json:"text"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount": 19.99, "text": "0.0001"}`), &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if payload.Amount != money.MustParse("19.99") || payload.Text != 1 {
+		t.Errorf("unexpected amounts %s, %s", payload.Amount, payload.Text)
+	}
+	out, _ := json.Marshal(payload)
+	if string(out) != `{"amount":19.99,"text":0.0001}` {
+		t.Errorf("unexpected JSON %s", out)
+	}
+
+	var a money.Amount
+	if err := a.Scan([]byte("1500.2500")); err != nil || a != money.MustParse("1500.25") {
+		t.Errorf("scan of numeric text gave %s, %v", a, err)
+	}
+	if v, _ := a.Value(); v != "1500.2500" {
+		t.Errorf("expected exact decimal value, got %v", v)
+	}
+}
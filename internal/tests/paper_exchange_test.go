@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+)
+
+func TestPendingOrderFill(t *testing.T) {
+	limitBuy := models.Trade{TradeType: models.TradeTypeLimit, Side: models.TradeSideBuy, EntryPrice: 100, Status: models.TradeStatusPending}
+	if limitBuy.FillTriggered(101) {
+		t.Error("limit buy should not fill above its price")
+	}
+	if !limitBuy.FillTriggered(99) {
+		t.Error("limit buy should fill below its price")
+	}
+
+	stopSell := models.Trade{TradeType: models.TradeTypeStop, Side: models.TradeSideSell, EntryPrice: 100, Status: models.TradeStatusPending}
+	if stopSell.FillTriggered(101) {
+		t.Error("stop sell should not fill above its price")
+	}
+	if !stopSell.FillTriggered(100) {
+		t.Error("stop sell should fill once price trades through it")
+	}
+}
+
+func TestOpenTradeExitTriggers(t *testing.T) {
+	sl, tp := 90.0, 120.0
+	trade := models.Trade{Side: models.TradeSideBuy, EntryPrice: 100, Quantity: 1, Leverage: 5, Status: models.TradeStatusOpen, StopLossPrice: &sl, TakeProfitPrice: &tp}
+
+	if _, _, hit := trade.ExitTriggered(105); hit {
+		t.Error("expected no exit at 105")
+	}
+	if _, reason, hit := trade.ExitTriggered(121); !hit || reason != models.CloseReasonTakeProfit {
+		t.Errorf("expected take profit, got %s", reason)
+	}
+	if _, reason, hit := trade.ExitTriggered(89); !hit || reason != models.CloseReasonStopLoss {
+		t.Errorf("expected stop loss, got %s", reason)
+	}
+
+	trade.StopLossPrice = nil
+	price, reason, hit := trade.ExitTriggered(75)
+	if !hit || reason != models.CloseReasonLiquidation {
+		t.Fatalf("expected liquidation, got %s", reason)
+	}
+	if price != trade.LiquidationPrice() {
+		t.Errorf("expected close at liquidation price %f, got %f", trade.LiquidationPrice(), price)
+	}
+}
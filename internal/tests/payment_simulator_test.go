@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	paymentgateway "github.com/fathimasithara01/tradeverse/pkg/payment_gateway.go"
+)
+
+func TestSimulatorScenarioSelection(t *testing.T) {
+	cfg := paymentgateway.Config{MagicAmounts: true, DeclineRate: 0.1, TimeoutRate: 0.1, RefundRate: 0.2}
+
+	magic := map[string]paymentgateway.Scenario{
+		"100.91": paymentgateway.ScenarioDecline,
+		"100.92": paymentgateway.ScenarioTimeout,
+		"7.93":   paymentgateway.ScenarioSettlementFailure,
+		"50.94":  paymentgateway.ScenarioPartialRefund,
+		"50.95":  paymentgateway.ScenarioChargeback,
+	}
+	for amount, want := range magic {
+		if got := cfg.ScenarioFor(money.MustParse(amount), 0.99, true); got != want {
+			t.Errorf("deposit of %s: expected %s, got %s", amount, want, got)
+		}
+	}
+
+	if got := cfg.ScenarioFor(money.MustParse("50.94"), 0.99, false); got != paymentgateway.ScenarioSuccess {
+		t.Errorf("withdrawals cannot be refunded, expected success, got %s", got)
+	}
+
+	rolls := []struct {
+		roll    float64
+		deposit bool
+		want    paymentgateway.Scenario
+	}{
+		{0.05, true, paymentgateway.ScenarioDecline},
+		{0.15, true, paymentgateway.ScenarioTimeout},
+		{0.25, true, paymentgateway.ScenarioPartialRefund},
+		{0.25, false, paymentgateway.ScenarioSuccess},
+		{0.95, true, paymentgateway.ScenarioSuccess},
+	}
+	for _, r := range rolls {
+		if got := cfg.ScenarioFor(money.MustParse("10"), r.roll, r.deposit); got != r.want {
+			t.Errorf("roll %.2f (deposit %t): expected %s, got %s", r.roll, r.deposit, r.want, got)
+		}
+	}
+
+	cfg.MagicAmounts = false
+	if got := cfg.ScenarioFor(money.MustParse("100.91"), 0.99, true); got != paymentgateway.ScenarioSuccess {
+		t.Errorf("magic amounts disabled, expected success, got %s", got)
+	}
+}
+
+func TestSimulatorWithdrawalSettlement(t *testing.T) {
+	never := func() float64 { return 0.99 }
+
+	sync := paymentgateway.NewSimulatedPaymentClient(paymentgateway.Config{MagicAmounts: true, Rand: never, Timeout: time.Millisecond})
+	if _, settled, err := sync.ProcessWithdrawal(money.MustParse("25"), "USD", "ACC1"); err != nil || !settled {
+		t.Errorf("expected a synchronous payout to settle, got settled=%t err=%v", settled, err)
+	}
+	if _, _, err := sync.ProcessWithdrawal(money.MustParse("25.91"), "USD", "ACC1"); !errors.Is(err, paymentgateway.ErrPaymentDeclined) {
+		t.Errorf("expected decline, got %v", err)
+	}
+	if _, _, err := sync.ProcessWithdrawal(money.MustParse("25.92"), "USD", "ACC1"); !errors.Is(err, paymentgateway.ErrGatewayTimeout) {
+		t.Errorf("expected timeout, got %v", err)
+	}
+
+	const secret = "whsec_test"
+	events := make(chan paymentgateway.WebhookEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := paymentgateway.VerifySignature(secret, r.Header.Get(paymentgateway.HeaderSignature),
+			r.Header.Get(paymentgateway.HeaderTimestamp), r.Header.Get(paymentgateway.HeaderNonce),
+			body, time.Now(), 0); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var event paymentgateway.WebhookEvent
+		_ = json.Unmarshal(body, &event)
+		events <- event
+	}))
+	defer server.Close()
+
+	async := paymentgateway.NewSimulatedPaymentClient(paymentgateway.Config{
+		WebhookURL:      server.URL,
+		WebhookSecret:   secret,
+		AsyncSettlement: true,
+		MagicAmounts:    true,
+		Rand:            never,
+	})
+	pgTxID, settled, err := async.ProcessWithdrawal(money.MustParse("40.93"), "USD", "ACC2")
+	if err != nil || settled || pgTxID == "" {
+		t.Fatalf("expected an accepted, unsettled payout, got id=%q settled=%t err=%v", pgTxID, settled, err)
+	}
+
+	select {
+	case event := <-events:
+		if event.Type != paymentgateway.EventWithdrawalFailed || event.PaymentGatewayTxID != pgTxID {
+			t.Errorf("expected withdrawal.failed for %s, got %+v", pgTxID, event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no settlement webhook received")
+	}
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	paymentgateway "github.com/fathimasithara01/tradeverse/pkg/payment_gateway.go"
+)
+
+func TestWebhookSignatureVerification(t *testing.T) {
+	const secret = "whsec_test"
+	body := []byte(`{"id":"evt_1","type":"deposit.succeeded","payment_gateway_tx_id":"PG_1","amount":100}`)
+	now := time.Unix(1_800_000_000, 0)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	sig := paymentgateway.Sign(secret, now.Unix(), "n1", body)
+
+	if err := paymentgateway.VerifySignature(secret, sig, ts, "n1", body, now.Add(time.Minute), time.Minute*5); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+
+	cases := []struct {
+		name      string
+		secret    string
+		sig, ts   string
+		nonce     string
+		body      []byte
+		now       time.Time
+		wantError error
+	}{
+		{"tampered body", secret, sig, ts, "n1", []byte(`{"id":"evt_1","amount":1000}`), now, paymentgateway.ErrInvalidSignature},
+		{"wrong secret", "other", sig, ts, "n1", body, now, paymentgateway.ErrInvalidSignature},
+		{"different nonce", secret, sig, ts, "n2", body, now, paymentgateway.ErrInvalidSignature},
+		{"stale", secret, sig, ts, "n1", body, now.Add(10 * time.Minute), paymentgateway.ErrWebhookExpired},
+		{"from the future", secret, sig, ts, "n1", body, now.Add(-10 * time.Minute), paymentgateway.ErrWebhookExpired},
+		{"missing nonce", secret, sig, ts, "", body, now, paymentgateway.ErrMissingSignature},
+	}
+	for _, tc := range cases {
+		err := paymentgateway.VerifySignature(tc.secret, tc.sig, tc.ts, tc.nonce, tc.body, tc.now, 5*time.Minute)
+		if !errors.Is(err, tc.wantError) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.wantError, err)
+		}
+	}
+}
+
+func TestWebhookSenderDeliversVerifiableEvents(t *testing.T) {
+	const secret = "whsec_test"
+	var received paymentgateway.WebhookEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		err := paymentgateway.VerifySignature(secret,
+			r.Header.Get(paymentgateway.HeaderSignature),
+			r.Header.Get(paymentgateway.HeaderTimestamp),
+			r.Header.Get(paymentgateway.HeaderNonce),
+			body, time.Now(), paymentgateway.DefaultWebhookTolerance)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.Unmarshal(body, &received)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	event := paymentgateway.WebhookEvent{
+		ID:                 "evt_42",
+		Type:               paymentgateway.EventWithdrawalFailed,
+		PaymentGatewayTxID: "PG_WITHDRAW_42",
+		Amount:             money.MustParse("12.50"),
+		Currency:           "USD",
+		Reason:             "account closed",
+	}
+	if err := event.Validate(); err != nil {
+		t.Fatalf("expected event to validate, got %v", err)
+	}
+
+	sender := &paymentgateway.WebhookSender{URL: server.URL, Secret: secret}
+	if err := sender.Send(context.Background(), event); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if received.ID != event.ID || received.Amount != event.Amount || received.Type != event.Type {
+		t.Errorf("received %+v, want %+v", received, event)
+	}
+
+	wrong := &paymentgateway.WebhookSender{URL: server.URL, Secret: "other"}
+	if err := wrong.Send(context.Background(), event); err == nil {
+		t.Error("expected a delivery signed with the wrong secret to be rejected")
+	}
+
+	if err := (paymentgateway.WebhookEvent{ID: "evt", Type: "bogus", PaymentGatewayTxID: "PG"}).Validate(); !errors.Is(err, paymentgateway.ErrInvalidPayload) {
+		t.Errorf("expected unknown event type to be invalid, got %v", err)
+	}
+}
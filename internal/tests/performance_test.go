@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+)
+
+func closedTrade(pnl float64, closedAt time.Time) models.Trade {
+	return models.Trade{
+		Status:     models.TradeStatusClosed,
+		Side:       models.TradeSideBuy,
+		EntryPrice: 100,
+		Quantity:   10,
+		Leverage:   1,
+		Pnl:        &pnl,
+		ClosedAt:   &closedAt,
+	}
+}
+
+func TestCalculatePerformanceTrades(t *testing.T) {
+	day := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	trades := []models.Trade{
+		closedTrade(200, day),
+		closedTrade(-300, day.AddDate(0, 0, 1)),
+		closedTrade(400, day.AddDate(0, 0, 3)),
+	}
+	copied := closedTrade(1000, day)
+	copied.IsCopyTrade = true
+	trades = append(trades, copied)
+
+	report := models.CalculatePerformance(trades, nil, time.Time{}, day.AddDate(0, 0, 3))
+
+	if report.TotalTrades != 3 || report.WinningTrades != 2 || report.LosingTrades != 1 {
+		t.Fatalf("expected 3 trades (2 wins, 1 loss) excluding the copy trade, got %+v", report)
+	}
+	// 3 trades x 1000 margin, net +300.
+	if report.CapitalDeployed != 3000 || report.ROI != 10 {
+		t.Errorf("expected 10%% ROI on 3000 deployed, got %f on %f", report.ROI, report.CapitalDeployed)
+	}
+	if report.AverageProfit != 300 || report.AverageLoss != -300 {
+		t.Errorf("unexpected averages: profit %f, loss %f", report.AverageProfit, report.AverageLoss)
+	}
+	// Equity peaks at 3200 then falls to 2900.
+	if want := 300.0 / 3200 * 100; math.Abs(report.MaxDrawdown-want) > 1e-9 {
+		t.Errorf("expected drawdown %f, got %f", want, report.MaxDrawdown)
+	}
+	if len(report.EquityCurve) != 4 {
+		t.Fatalf("expected one equity point per day including flat days, got %d", len(report.EquityCurve))
+	}
+	if last := report.EquityCurve[3]; last.CumulativePnL != 300 || last.Equity != 3300 {
+		t.Errorf("unexpected final equity point %+v", last)
+	}
+	if report.SharpeRatio == 0 || report.SortinoRatio <= report.SharpeRatio {
+		t.Errorf("expected positive Sharpe with higher Sortino, got %f / %f", report.SharpeRatio, report.SortinoRatio)
+	}
+}
+
+func TestCalculatePerformanceSignalsAndPeriod(t *testing.T) {
+	now := time.Now()
+	signals := []models.Signal{
+		{Status: models.SignalStatusTargetHit, ExitPrice: 120, RealizedR: 2},
+		{Status: models.SignalStatusStopLoss, ExitPrice: 90, RealizedR: -1},
+		{Status: models.SignalStatusExpired},
+		{Status: models.SignalStatusActive, RealizedR: 0.5},
+	}
+	trades := []models.Trade{closedTrade(100, now.AddDate(0, 0, -10)), closedTrade(50, now.Add(-time.Hour))}
+
+	report := models.CalculatePerformance(trades, signals, models.PerformancePeriodWeekly.Since(now), now)
+	if report.TotalTrades != 1 || report.TotalPnL != 50 {
+		t.Errorf("expected only the trade inside the week, got %d trades, pnl %f", report.TotalTrades, report.TotalPnL)
+	}
+	if report.ResolvedSignals != 2 || report.SignalWinRate != 50 || report.AverageSignalR != 0.5 {
+		t.Errorf("unexpected signal stats %d / %f / %f", report.ResolvedSignals, report.SignalWinRate, report.AverageSignalR)
+	}
+
+	if _, err := models.ParsePerformancePeriod("yearly"); err == nil {
+		t.Error("expected an error for an unknown period")
+	}
+	if p, err := models.ParsePerformancePeriod(""); err != nil || p != models.PerformancePeriodAll {
+		t.Errorf("expected empty period to mean all, got %q, %v", p, err)
+	}
+}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/fathimasithara01/tradeverse/pkg/lockout"
+	"github.com/fathimasithara01/tradeverse/pkg/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestMemoryStoreTokenBucket(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.PerMinute(60, 3)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if res, _ := store.Take(ctx, "ip:1", limit, now); !res.Allowed {
+			t.Fatalf("expected request %d of the burst to be allowed", i+1)
+		}
+	}
+	res, _ := store.Take(ctx, "ip:1", limit, now)
+	if res.Allowed {
+		t.Fatal("expected the request after the burst to be refused")
+	}
+	if res.RetryAfter != time.Second {
+		t.Errorf("expected to retry after 1s, got %s", res.RetryAfter)
+	}
+	if res, _ := store.Take(ctx, "ip:2", limit, now); !res.Allowed {
+		t.Error("expected another key to have its own bucket")
+	}
+	if res, _ := store.Take(ctx, "ip:1", limit, now.Add(time.Second)); !res.Allowed {
+		t.Error("expected a token to have refilled after 1s")
+	}
+}
+
+func TestLockoutPolicy(t *testing.T) {
+	policy := lockout.Policy{Threshold: 5, Base: time.Minute, Max: 10 * time.Minute}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{4, 0},
+		{5, time.Minute},
+		{6, 2 * time.Minute},
+		{8, 8 * time.Minute},
+		{9, 10 * time.Minute},
+		{50, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := policy.LockFor(tt.failures); got != tt.want {
+			t.Errorf("LockFor(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestRedisStoreTokenBucket(t *testing.T) {
+	server := miniredis.RunT(t)
+	store := ratelimit.NewRedisStore(server.Addr(), "", 0)
+	defer store.Close()
+	limit := ratelimit.PerMinute(60, 2)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		res, err := store.Take(ctx, "ip:1", limit, now)
+		if err != nil {
+			t.Fatalf("take failed: %v", err)
+		}
+		if !res.Allowed {
+			t.Fatalf("expected request %d of the burst to be allowed", i+1)
+		}
+	}
+	res, err := store.Take(ctx, "ip:1", limit, now)
+	if err != nil {
+		t.Fatalf("take failed: %v", err)
+	}
+	if res.Allowed {
+		t.Fatal("expected the request after the burst to be refused")
+	}
+
+	// After a script flush the store has to fall back from EVALSHA to EVAL.
+	admin := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer admin.Close()
+	if err := admin.ScriptFlush(ctx).Err(); err != nil {
+		t.Fatalf("script flush failed: %v", err)
+	}
+	if res, err := store.Take(ctx, "ip:1", limit, now.Add(time.Second)); err != nil || !res.Allowed {
+		t.Errorf("expected a request to be allowed after a flush, got %+v, %v", res, err)
+	}
+}
+
+func TestByIPIgnoresForwardedForFromUntrustedClients(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies(nil); err != nil {
+		t.Fatalf("failed to clear trusted proxies: %v", err)
+	}
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{"auth": ratelimit.PerMinute(60, 1)})
+	r.POST("/login", limiter.Group("auth", ratelimit.ByIP), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	codes := make([]int, 2)
+	for i, forwarded := range []string{"198.51.100.1", "198.51.100.2"} {
+		req := httptest.NewRequest(http.MethodPost, "/login", nil)
+		req.RemoteAddr = "203.0.113.7:4000"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		codes[i] = w.Code
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("expected a rotated X-Forwarded-For to share one bucket, got %v", codes)
+	}
+}
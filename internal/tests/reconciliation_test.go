@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+)
+
+func reconTx(id uint, at time.Time, amount, before, after string) models.WalletTransaction {
+	tx := models.WalletTransaction{
+		Amount:        money.MustParse(amount),
+		BalanceBefore: money.MustParse(before),
+		BalanceAfter:  money.MustParse(after),
+	}
+	tx.ID = id
+	tx.CreatedAt = at
+	return tx
+}
+
+func TestReconcileWalletCleanChain(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	wallet := models.Wallet{UserID: 7, Balance: money.MustParse("75")}
+	wallet.ID = 3
+
+	// Given out of order; a debit stores a positive Amount and a falling balance.
+	txs := []models.WalletTransaction{
+		reconTx(2, base.Add(time.Minute), "25", "100", "75"),
+		reconTx(1, base, "100", "0", "100"),
+	}
+	if found := models.ReconcileWallet(wallet, txs); len(found) != 0 {
+		t.Fatalf("expected no discrepancies, got %+v", found)
+	}
+
+	empty := models.Wallet{}
+	if found := models.ReconcileWallet(empty, nil); len(found) != 0 {
+		t.Fatalf("expected an empty wallet with no transactions to reconcile, got %+v", found)
+	}
+}
+
+func TestReconcileWalletFindsDiscrepancies(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	wallet := models.Wallet{UserID: 7, Balance: money.MustParse("60")}
+	wallet.ID = 3
+
+	txs := []models.WalletTransaction{
+		reconTx(1, base, "100", "0", "100"),
+		reconTx(2, base.Add(time.Minute), "20", "90", "70"),
+		reconTx(3, base.Add(2*time.Minute), "5", "70", "50"),
+	}
+	found := models.ReconcileWallet(wallet, txs)
+
+	want := map[string]models.DiscrepancyKind{
+		"CHAIN_GAP:tx:2":            models.DiscrepancyChainGap,
+		"AMOUNT_MISMATCH:tx:3":      models.DiscrepancyAmountMismatch,
+		"BALANCE_MISMATCH:wallet:3": models.DiscrepancyBalanceMismatch,
+	}
+	if len(found) != len(want) {
+		t.Fatalf("expected %d discrepancies, got %+v", len(want), found)
+	}
+	for _, d := range found {
+		kind, ok := want[d.Fingerprint]
+		if !ok || kind != d.Kind {
+			t.Errorf("unexpected discrepancy %s (%s)", d.Fingerprint, d.Kind)
+		}
+		if d.WalletID == nil || *d.WalletID != 3 || d.UserID == nil || *d.UserID != 7 {
+			t.Errorf("discrepancy %s not linked to wallet 3 / user 7", d.Fingerprint)
+		}
+		if d.Kind == models.DiscrepancyBalanceMismatch &&
+			(d.Expected != money.MustParse("50") || d.Actual != money.MustParse("60")) {
+			t.Errorf("balance mismatch expected 50/60, got %s/%s", d.Expected, d.Actual)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/fx"
+	"github.com/fathimasithara01/tradeverse/pkg/ledger"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"github.com/fathimasithara01/tradeverse/pkg/refund"
+	"gorm.io/gorm"
+)
+
+func TestRefundAmount(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 30)
+	paid := money.FromFloat(30)
+
+	tests := []struct {
+		name   string
+		policy models.RefundPolicy
+		window uint
+		now    time.Time
+		want   money.Amount
+	}{
+		{"no refund policy", models.RefundPolicyNone, 0, start.AddDate(0, 0, 1), 0},
+		{"full refund inside the window", models.RefundPolicyFullWithinWindow, 7, start.AddDate(0, 0, 6), paid},
+		{"nothing once the window has passed", models.RefundPolicyFullWithinWindow, 7, start.AddDate(0, 0, 7), 0},
+		{"prorated unused days", models.RefundPolicyProrated, 0, start.AddDate(0, 0, 10), money.FromFloat(20)},
+		{"prorated rounds down", models.RefundPolicyProrated, 0, start.Add(time.Hour), money.MustParse("29.95")},
+		{"nothing after the end", models.RefundPolicyProrated, 0, end, 0},
+	}
+	for _, tt := range tests {
+		if got := refund.Amount(tt.policy, tt.window, paid, "USD", start, end, tt.now); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRefundSplit(t *testing.T) {
+	paid := money.FromFloat(100)
+	platformShare := money.FromFloat(15)
+
+	fromPlatform, fromTrader := refund.Split(paid, paid, platformShare, "USD")
+	if fromPlatform != platformShare || fromTrader != money.FromFloat(85) {
+		t.Errorf("expected a full refund to return each share, got %s and %s", fromPlatform, fromTrader)
+	}
+
+	refunded := money.MustParse("33.33")
+	fromPlatform, fromTrader = refund.Split(refunded, paid, platformShare, "USD")
+	if fromPlatform != money.MustParse("5.00") {
+		t.Errorf("expected the platform to return 5.00, got %s", fromPlatform)
+	}
+	if fromPlatform+fromTrader != refunded {
+		t.Errorf("expected the shares to add up to %s, got %s", refunded, fromPlatform+fromTrader)
+	}
+}
+
+func TestCancelSubscriptionRefundsInPaidCurrency(t *testing.T) {
+	db := newTestDB(t,
+		&models.User{}, &models.Wallet{}, &models.WalletTransaction{}, &models.FXRate{},
+		&models.LedgerAccount{}, &models.JournalEntry{}, &models.JournalPosting{},
+		&models.AdminTraderSubscriptionPlan{}, &models.CustomerToTraderSub{},
+	)
+	admin := &models.User{Name: "Admin", Email: "admin@example.com", Password: "x", Phone: "1", Role: models.RoleAdmin}
+	customer := &models.User{Name: "Customer", Email: "customer@example.com", Password: "x", Phone: "2", Role: models.RoleCustomer}
+	for _, u := range []*models.User{admin, customer} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+	}
+	if err := db.Model(&models.Wallet{}).Where("user_id = ?", customer.ID).Update("balance", money.FromFloat(100)).Error; err != nil {
+		t.Fatalf("failed to fund wallet: %v", err)
+	}
+	if err := db.Create(&models.FXRate{BaseCurrency: "INR", QuoteCurrency: "USD", Rate: money.MustParseRate("0.012"), Source: models.FXSourceManual}).Error; err != nil {
+		t.Fatalf("failed to set rate: %v", err)
+	}
+	plan := &models.AdminTraderSubscriptionPlan{
+		Name: "Pro", Price: money.FromFloat(999), Currency: "INR", Duration: 1, Interval: "monthly",
+		RefundPolicy: models.RefundPolicyFullWithinWindow, RefundWindowDays: 7,
+	}
+	if err := db.Create(plan).Error; err != nil {
+		t.Fatalf("failed to create plan: %v", err)
+	}
+
+	start := time.Now()
+	var sub models.CustomerToTraderSub
+	err := db.Transaction(func(tx *gorm.DB) error {
+		charge, err := fx.NewCharge(tx, customer.ID, plan.Price, plan.Currency)
+		if err != nil {
+			return err
+		}
+		if _, err := ledger.Post(tx, ledger.Entry{
+			Type:      models.TxTypeSubscription,
+			Reference: "SUB_1",
+			Currency:  plan.Currency,
+			Legs: append(charge.Legs(customer.ID, &models.WalletTransaction{Name: "Subscription"}),
+				ledger.Credit(ledger.PlatformCommissionIn(plan.Currency), plan.Price, nil),
+			),
+		}); err != nil {
+			return err
+		}
+		sub = models.CustomerToTraderSub{
+			UserID: customer.ID, SubscriptionPlanID: plan.ID, StartDate: start, EndDate: start.AddDate(0, 1, 0),
+			IsActive: true, PaymentStatus: "paid", AmountPaid: plan.Price,
+			PaidCurrency: charge.Currency, PaidFXRate: charge.Rate(),
+		}
+		return tx.Create(&sub).Error
+	})
+	if err != nil {
+		t.Fatalf("failed to pay for subscription: %v", err)
+	}
+
+	var result *models.SubscriptionCancellation
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		result, err = refund.CancelSubscription(tx, sub.ID, customer.ID, start.Add(time.Hour))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to cancel subscription: %v", err)
+	}
+	if result.Refunded != plan.Price || result.Credited != money.MustParse("11.99") || result.CreditedCurrency != "USD" {
+		t.Errorf("expected 999 INR refunded as 11.99 USD, got %+v", result)
+	}
+
+	var wallet models.Wallet
+	if err := db.Where("user_id = ? AND currency = ?", customer.ID, "USD").First(&wallet).Error; err != nil {
+		t.Fatalf("failed to load wallet: %v", err)
+	}
+	if wallet.Balance != money.FromFloat(100) {
+		t.Errorf("expected the USD wallet to be made whole, got %s", wallet.Balance)
+	}
+	var inr int64
+	db.Model(&models.Wallet{}).Where("user_id = ? AND currency = ?", customer.ID, "INR").Count(&inr)
+	if inr != 0 {
+		t.Error("expected no INR wallet to be opened for the customer")
+	}
+}
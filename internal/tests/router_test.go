@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/fathimasithara01/tradeverse/config"
+	adminControllers "github.com/fathimasithara01/tradeverse/internal/admin/controllers"
+	adminRouter "github.com/fathimasithara01/tradeverse/internal/admin/router"
+	adminService "github.com/fathimasithara01/tradeverse/internal/admin/service"
+	customerControllers "github.com/fathimasithara01/tradeverse/internal/customer/controllers"
+	customerRouter "github.com/fathimasithara01/tradeverse/internal/customer/router"
+	traderControllers "github.com/fathimasithara01/tradeverse/internal/trader/controllers"
+	traderRouter "github.com/fathimasithara01/tradeverse/internal/trader/router"
+	"github.com/fathimasithara01/tradeverse/pkg/lockout"
+	"github.com/fathimasithara01/tradeverse/pkg/ratelimit"
+	"github.com/fathimasithara01/tradeverse/pkg/twofactor"
+	"github.com/gin-gonic/gin"
+)
+
+// The routers are built with unconnected services: registering routes must
+// not touch them, and gin panics on a method and path registered twice.
+
+func routerTestServices() (adminService.IUserService, adminService.IAccountService, *twofactor.Service, *ratelimit.Limiter) {
+	gin.SetMode(gin.TestMode)
+	userSvc := adminService.NewUserService(nil, nil, nil, nil, lockout.Policy{})
+	accountSvc := adminService.NewAccountService(nil, nil, nil, nil, nil, "", 0, 0)
+	return userSvc, accountSvc, twofactor.NewService(nil, twofactor.Config{}), ratelimit.NewLimiter(ratelimit.NewMemoryStore(), nil)
+}
+
+func TestCustomerRouterBuilds(t *testing.T) {
+	userSvc, accountSvc, twoFactor, limiter := routerTestServices()
+	r := customerRouter.SetupRouter(
+		&config.Config{},
+		customerControllers.NewAuthController(userSvc, accountSvc),
+		&customerControllers.ProfileController{},
+		&customerControllers.KYCController{},
+		&customerControllers.WalletController{},
+		&customerControllers.TraderController{},
+		&customerControllers.CustomerTraderSignalSubscriptionController{},
+		&customerControllers.SubscriptionPlanController{},
+		&customerControllers.CopyProfileController{},
+		&customerControllers.CandleController{},
+		&customerControllers.StreamController{},
+		&customerControllers.PaymentWebhookController{},
+		&customerControllers.APIKeyController{},
+		nil,
+		nil,
+		twoFactor,
+		limiter,
+	)
+	if len(r.Routes()) == 0 {
+		t.Fatal("expected routes to be registered")
+	}
+}
+
+func TestTraderRouterBuilds(t *testing.T) {
+	userSvc, accountSvc, twoFactor, limiter := routerTestServices()
+	r := traderRouter.SetupRouter(
+		&config.Config{},
+		traderControllers.NewAuthController(userSvc, accountSvc),
+		&traderControllers.TraderProfileController{},
+		&traderControllers.WalletController{},
+		&traderControllers.SubscriberController{},
+		&traderControllers.LiveTradeController{},
+		&traderControllers.SignalController{},
+		&traderControllers.MarketDataHandler{},
+		&traderControllers.TraderSubscriptionController{},
+		&traderControllers.TradeController{},
+		&traderControllers.CandleController{},
+		&traderControllers.StreamController{},
+		&traderControllers.APIKeyController{},
+		nil,
+		nil,
+		twoFactor,
+		limiter,
+	)
+	if len(r.Routes()) == 0 {
+		t.Fatal("expected routes to be registered")
+	}
+}
+
+func TestAdminRouterBuilds(t *testing.T) {
+	userSvc, _, twoFactor, limiter := routerTestServices()
+	r := gin.New()
+	adminRouter.WireAdminRoutes(
+		r,
+		&config.Config{},
+		adminControllers.NewAuthController(userSvc),
+		&adminControllers.DashboardController{},
+		&adminControllers.UserController{},
+		&adminControllers.RoleController{},
+		&adminControllers.PermissionController{},
+		&adminControllers.ActivityController{},
+		nil,
+		&adminControllers.AdminWalletController{},
+		&adminControllers.SubscriptionController{},
+		&adminControllers.TransactionController{},
+		nil,
+		&adminControllers.SignalController{},
+		&adminControllers.CommissionController{},
+		&adminControllers.WebConfigurationController{},
+		&adminControllers.StreamController{},
+		&adminControllers.ReconciliationController{},
+		&adminControllers.FXController{},
+		&adminControllers.TraderPayoutController{},
+		nil,
+		twoFactor,
+		limiter,
+	)
+	if len(r.Routes()) == 0 {
+		t.Fatal("expected routes to be registered")
+	}
+}
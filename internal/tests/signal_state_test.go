@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+)
+
+func TestSignalStateMachineShort(t *testing.T) {
+	now := time.Now()
+	signal := models.Signal{
+		Direction:      models.SignalDirectionShort,
+		EntryPrice:     100,
+		StopLoss:       110,
+		TargetPrice:    80,
+		Status:         models.SignalStatusPending,
+		TradeStartDate: now.Add(-time.Hour),
+	}
+	if err := signal.ValidateLevels(); err != nil {
+		t.Fatalf("expected valid short levels, got %v", err)
+	}
+
+	if _, ok := signal.NextStatus(105, now); ok {
+		t.Fatal("short signal should not activate above its entry")
+	}
+	next, ok := signal.NextStatus(99, now)
+	if !ok || next != models.SignalStatusActive {
+		t.Fatalf("expected Active, got %q", next)
+	}
+
+	signal.Status = models.SignalStatusActive
+	if next, _ := signal.NextStatus(111, now); next != models.SignalStatusStopLoss {
+		t.Errorf("expected Stop Loss above the stop for a short, got %q", next)
+	}
+	if next, _ := signal.NextStatus(79, now); next != models.SignalStatusTargetHit {
+		t.Errorf("expected Target Hit below the target for a short, got %q", next)
+	}
+
+	signal.Direction = models.SignalDirectionLong
+	if err := signal.ValidateLevels(); err == nil {
+		t.Error("expected short levels to be rejected for a long signal")
+	}
+}
+
+func TestSignalStateMachineExpiryAndTerminalStates(t *testing.T) {
+	now := time.Now()
+	signal := models.Signal{
+		Direction:    models.SignalDirectionLong,
+		EntryPrice:   100,
+		StopLoss:     90,
+		TargetPrice:  120,
+		Status:       models.SignalStatusActive,
+		TradeEndDate: now.Add(-time.Minute),
+	}
+
+	if next, ok := signal.NextStatus(105, now); !ok || next != models.SignalStatusExpired {
+		t.Fatalf("expected Expired after the end date, got %q", next)
+	}
+	if next, _ := signal.NextStatus(125, now); next != models.SignalStatusTargetHit {
+		t.Errorf("a target hit should win over expiry, got %q", next)
+	}
+
+	signal.Status = models.SignalStatusStopLoss
+	if _, ok := signal.NextStatus(125, now); ok {
+		t.Error("terminal signals must not transition")
+	}
+	if models.SignalStatusTargetHit.CanTransitionTo(models.SignalStatusActive) {
+		t.Error("Target Hit must be terminal")
+	}
+	if !models.SignalStatusPending.CanTransitionTo(models.SignalStatusCancelled) {
+		t.Error("Pending signals should be cancellable")
+	}
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+)
+
+func TestSignalTargetLadderWithBreakeven(t *testing.T) {
+	now := time.Now()
+	signal := models.Signal{
+		Direction:  models.SignalDirectionLong,
+		EntryPrice: 100,
+		StopLoss:   90,
+		Targets: []models.SignalTarget{
+			{Price: 130, AllocationPercent: 25},
+			{Price: 110, AllocationPercent: 50},
+			{Price: 120, AllocationPercent: 25},
+		},
+		BreakevenAfterTarget: 1,
+		Status:               models.SignalStatusActive,
+	}
+	if err := signal.PrepareTargets(); err != nil {
+		t.Fatalf("prepare targets: %v", err)
+	}
+	if signal.Targets[0].Price != 110 || signal.TargetPrice != 130 {
+		t.Fatalf("expected targets ordered from entry with final target 130, got %+v", signal.Targets)
+	}
+
+	signal.Advance(111, now)
+	if signal.Targets[0].HitAt == nil || signal.Targets[1].HitAt != nil {
+		t.Fatal("expected only TP1 to be hit")
+	}
+	if signal.StopLoss != 100 {
+		t.Errorf("expected stop moved to breakeven 100, got %f", signal.StopLoss)
+	}
+	if signal.RealizedR != 0.5 {
+		t.Errorf("expected 0.5R realised after TP1, got %f", signal.RealizedR)
+	}
+
+	next, ok := signal.NextStatus(100, now)
+	if !ok || next != models.SignalStatusStopLoss {
+		t.Fatalf("expected breakeven stop to trigger, got %q", next)
+	}
+	signal.Close(next, 100)
+	if signal.RealizedR != 0.5 {
+		t.Errorf("expected remainder closed at breakeven to keep 0.5R, got %f", signal.RealizedR)
+	}
+}
+
+func TestSignalPercentTrailingStopShort(t *testing.T) {
+	now := time.Now()
+	signal := models.Signal{
+		Direction:     models.SignalDirectionShort,
+		EntryPrice:    100,
+		StopLoss:      110,
+		TargetPrice:   50,
+		TrailingType:  models.TrailingStopPercent,
+		TrailingValue: 10,
+		Status:        models.SignalStatusActive,
+	}
+	if err := signal.PrepareTargets(); err != nil {
+		t.Fatalf("prepare targets: %v", err)
+	}
+
+	signal.Advance(100, now)
+	if signal.StopLoss != 110 {
+		t.Errorf("trail at entry should equal the original stop, got %f", signal.StopLoss)
+	}
+	signal.Advance(80, now)
+	if math.Abs(signal.StopLoss-88) > 1e-9 {
+		t.Errorf("expected stop trailed to 88, got %f", signal.StopLoss)
+	}
+	signal.Advance(85, now)
+	if math.Abs(signal.StopLoss-88) > 1e-9 {
+		t.Errorf("stop must not move back, got %f", signal.StopLoss)
+	}
+
+	next, _ := signal.NextStatus(88.5, now)
+	if next != models.SignalStatusStopLoss {
+		t.Fatalf("expected trailing stop to trigger, got %q", next)
+	}
+	signal.Close(next, 88.5)
+	if signal.RealizedR != 1.2 {
+		t.Errorf("expected 1.2R from a short stopped at 88, got %f", signal.RealizedR)
+	}
+}
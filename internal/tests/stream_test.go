@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/fathimasithara01/tradeverse/pkg/stream"
+)
+
+func TestStreamHubFiltersSignalsByTrader(t *testing.T) {
+	hub := stream.NewHub()
+	sub := hub.Subscribe(func(e stream.Event) bool {
+		return e.Type == stream.EventMarketTick || e.TraderID == 7
+	})
+	defer sub.Close()
+
+	hub.PublishTick(stream.MarketTick{Symbol: "BTC", Price: 43000})
+	hub.PublishSignalStatus(8, stream.SignalStatusChange{SignalID: 1, From: "Pending", To: "Active"})
+	hub.PublishSignalStatus(7, stream.SignalStatusChange{SignalID: 2, From: "Active", To: "Target Hit"})
+
+	first := <-sub.C
+	if first.Type != stream.EventMarketTick {
+		t.Fatalf("expected market tick first, got %s", first.Type)
+	}
+	second := <-sub.C
+	change, ok := second.Data.(stream.SignalStatusChange)
+	if !ok || second.TraderID != 7 || change.SignalID != 2 {
+		t.Fatalf("expected signal 2 from trader 7, got %+v", second)
+	}
+	select {
+	case e := <-sub.C:
+		t.Fatalf("unexpected extra event %+v", e)
+	default:
+	}
+}
+
+func TestStreamHubDoesNotBlockOnSlowSubscriber(t *testing.T) {
+	hub := stream.NewHub()
+	sub := hub.Subscribe(nil)
+
+	for i := 0; i < 1000; i++ {
+		hub.PublishTick(stream.MarketTick{Symbol: "ETH", Price: float64(i)})
+	}
+
+	sub.Close()
+	if hub.SubscriberCount() != 0 {
+		t.Errorf("expected no subscribers after close, got %d", hub.SubscriberCount())
+	}
+	hub.PublishTick(stream.MarketTick{Symbol: "ETH", Price: 1})
+
+	var nilHub *stream.Hub
+	nilHub.PublishTick(stream.MarketTick{Symbol: "ETH", Price: 1})
+}
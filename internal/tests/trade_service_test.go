@@ -0,0 +1,251 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fathimasithara01/tradeverse/internal/trader/repository"
+	"github.com/fathimasithara01/tradeverse/internal/trader/service"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"gorm.io/gorm"
+)
+
+type tradeFixture struct {
+	db       *gorm.DB
+	svc      service.TradeService
+	traderID uint
+}
+
+// newTradeFixture sets up a trader with 1000 USD in their wallet and BTC
+// quoted at 100.
+func newTradeFixture(t *testing.T) *tradeFixture {
+	t.Helper()
+	db := newTestDB(t,
+		&models.User{}, &models.Wallet{}, &models.WalletTransaction{}, &models.Trade{},
+		&models.LedgerAccount{}, &models.JournalEntry{}, &models.JournalPosting{}, &models.MarketData{},
+	)
+	trader := models.User{Name: "Trader", Email: "trader@example.com", Password: "x", Phone: "1", Role: models.RoleTrader}
+	if err := db.Create(&trader).Error; err != nil {
+		t.Fatalf("failed to create trader: %v", err)
+	}
+	if err := db.Model(&models.Wallet{}).Where("user_id = ?", trader.ID).Update("balance", money.FromFloat(1000)).Error; err != nil {
+		t.Fatalf("failed to fund wallet: %v", err)
+	}
+	f := &tradeFixture{db: db, svc: service.NewTradeService(repository.NewTradeRepository(db), db), traderID: trader.ID}
+	f.setPrice(t, "BTC", 100)
+	return f
+}
+
+func (f *tradeFixture) setPrice(t *testing.T, symbol string, price float64) {
+	t.Helper()
+	var md models.MarketData
+	if err := f.db.Where(models.MarketData{Symbol: symbol}).Assign(models.MarketData{CurrentPrice: price}).FirstOrCreate(&md).Error; err != nil {
+		t.Fatalf("failed to set %s price: %v", symbol, err)
+	}
+}
+
+func (f *tradeFixture) balance(t *testing.T) money.Amount {
+	t.Helper()
+	var wallet models.Wallet
+	if err := f.db.Where("user_id = ?", f.traderID).First(&wallet).Error; err != nil {
+		t.Fatalf("failed to load wallet: %v", err)
+	}
+	return wallet.Balance
+}
+
+func (f *tradeFixture) openMarketBuy(t *testing.T) *models.Trade {
+	t.Helper()
+	trade, err := f.svc.OpenTrade(context.Background(), f.traderID, models.TradeInput{
+		Symbol: "BTCUSDT", TradeType: models.TradeTypeMarket, Side: models.TradeSideBuy, Quantity: 2,
+	})
+	if err != nil {
+		t.Fatalf("failed to open trade: %v", err)
+	}
+	return trade
+}
+
+func TestOpenMarketTradeUsesMarketPrice(t *testing.T) {
+	f := newTradeFixture(t)
+	ctx := context.Background()
+
+	trade, err := f.svc.OpenTrade(ctx, f.traderID, models.TradeInput{
+		Symbol: "BTCUSDT", TradeType: models.TradeTypeMarket, Side: models.TradeSideBuy, Quantity: 2, EntryPrice: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to open trade: %v", err)
+	}
+	if trade.Status != models.TradeStatusOpen || trade.FillPrice() != 100 {
+		t.Errorf("expected an OPEN trade filled at the market price 100, got %s at %v", trade.Status, trade.FillPrice())
+	}
+	if got := f.balance(t); got != money.FromFloat(800) {
+		t.Errorf("expected 200 margin to be taken, balance is %s", got)
+	}
+
+	_, err = f.svc.OpenTrade(ctx, f.traderID, models.TradeInput{
+		Symbol: "ETHUSDT", TradeType: models.TradeTypeMarket, Side: models.TradeSideBuy, Quantity: 1, EntryPrice: 1,
+	})
+	if !errors.Is(err, service.ErrNoMarketPrice) {
+		t.Errorf("expected a market order without a quote to be refused, got %v", err)
+	}
+}
+
+func TestUpdateTradeStops(t *testing.T) {
+	f := newTradeFixture(t)
+	ctx := context.Background()
+	trade := f.openMarketBuy(t)
+
+	sl, tp := 90.0, 120.0
+	updated, err := f.svc.UpdateTrade(ctx, f.traderID, trade.ID, models.TradeUpdateInput{StopLossPrice: &sl, TakeProfitPrice: &tp})
+	if err != nil {
+		t.Fatalf("failed to set stops: %v", err)
+	}
+	if *updated.StopLossPrice != sl || *updated.TakeProfitPrice != tp {
+		t.Errorf("expected SL %v and TP %v, got %v and %v", sl, tp, *updated.StopLossPrice, *updated.TakeProfitPrice)
+	}
+
+	badSL := 110.0
+	if _, err := f.svc.UpdateTrade(ctx, f.traderID, trade.ID, models.TradeUpdateInput{StopLossPrice: &badSL}); !errors.Is(err, service.ErrInvalidTradeInput) {
+		t.Errorf("expected a stop loss above a BUY entry to be refused, got %v", err)
+	}
+}
+
+func TestCancelPendingTrade(t *testing.T) {
+	f := newTradeFixture(t)
+	ctx := context.Background()
+
+	trade, err := f.svc.OpenTrade(ctx, f.traderID, models.TradeInput{
+		Symbol: "BTCUSDT", TradeType: models.TradeTypeLimit, Side: models.TradeSideBuy, Quantity: 2, EntryPrice: 95,
+	})
+	if err != nil {
+		t.Fatalf("failed to place limit order: %v", err)
+	}
+	if trade.Status != models.TradeStatusPending {
+		t.Fatalf("expected a PENDING order, got %s", trade.Status)
+	}
+	if got := f.balance(t); got != money.FromFloat(810) {
+		t.Errorf("expected 190 margin to be held, balance is %s", got)
+	}
+
+	cancelled, err := f.svc.UpdateTrade(ctx, f.traderID, trade.ID, models.TradeUpdateInput{Action: "CANCEL"})
+	if err != nil {
+		t.Fatalf("failed to cancel order: %v", err)
+	}
+	if cancelled.Status != models.TradeStatusCancelled {
+		t.Errorf("expected CANCELLED, got %s", cancelled.Status)
+	}
+	if got := f.balance(t); got != money.FromFloat(1000) {
+		t.Errorf("expected the margin to be refunded, balance is %s", got)
+	}
+}
+
+func TestCloseTradeAtMarketPrice(t *testing.T) {
+	cases := []struct {
+		name        string
+		closeAt     float64
+		wantPnl     float64
+		wantBalance float64
+	}{
+		// Fees are 0.1% of entry plus exit notional.
+		{name: "profit", closeAt: 110, wantPnl: 20 - 0.42, wantBalance: 1019.58},
+		{name: "loss", closeAt: 90, wantPnl: -20 - 0.38, wantBalance: 979.62},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newTradeFixture(t)
+			trade := f.openMarketBuy(t)
+			f.setPrice(t, "BTC", tc.closeAt)
+
+			closed, err := f.svc.UpdateTrade(context.Background(), f.traderID, trade.ID, models.TradeUpdateInput{Action: "CLOSE"})
+			if err != nil {
+				t.Fatalf("failed to close trade: %v", err)
+			}
+			if closed.Status != models.TradeStatusClosed || *closed.ClosePrice != tc.closeAt {
+				t.Errorf("expected CLOSED at %v, got %s at %v", tc.closeAt, closed.Status, *closed.ClosePrice)
+			}
+			if money.FromFloat(*closed.Pnl) != money.FromFloat(tc.wantPnl) {
+				t.Errorf("expected pnl %v, got %v", tc.wantPnl, *closed.Pnl)
+			}
+			if got := f.balance(t); got != money.FromFloat(tc.wantBalance) {
+				t.Errorf("expected balance %v, got %s", tc.wantBalance, got)
+			}
+		})
+	}
+}
+
+func TestCloseTradeWithoutQuote(t *testing.T) {
+	f := newTradeFixture(t)
+	trade := f.openMarketBuy(t)
+	if err := f.db.Where("symbol = ?", "BTC").Delete(&models.MarketData{}).Error; err != nil {
+		t.Fatalf("failed to remove quote: %v", err)
+	}
+
+	_, err := f.svc.UpdateTrade(context.Background(), f.traderID, trade.ID, models.TradeUpdateInput{Action: "CLOSE"})
+	if !errors.Is(err, service.ErrClosePriceUnavailable) {
+		t.Errorf("expected closing without a quote to be refused, got %v", err)
+	}
+	if got := f.balance(t); got != money.FromFloat(800) {
+		t.Errorf("expected the margin to stay held, balance is %s", got)
+	}
+}
+
+func TestPaperExchangeFillsStopAtMarketPrice(t *testing.T) {
+	f := newTradeFixture(t)
+	ctx := context.Background()
+	exchange := service.NewPaperExchangeService(repository.NewTradeRepository(f.db), f.db)
+
+	stop, err := f.svc.OpenTrade(ctx, f.traderID, models.TradeInput{
+		Symbol: "BTCUSDT", TradeType: models.TradeTypeStop, Side: models.TradeSideBuy, Quantity: 2, EntryPrice: 110,
+	})
+	if err != nil {
+		t.Fatalf("failed to place stop order: %v", err)
+	}
+	if got := f.balance(t); got != money.FromFloat(780) {
+		t.Fatalf("expected 220 margin reserved at the stop price, balance %s", got)
+	}
+
+	// The market gaps through the stop.
+	f.setPrice(t, "BTC", 120)
+	if err := exchange.MatchTrades(ctx); err != nil {
+		t.Fatalf("matching failed: %v", err)
+	}
+	filled, err := f.svc.GetTrade(ctx, f.traderID, stop.ID)
+	if err != nil {
+		t.Fatalf("failed to load trade: %v", err)
+	}
+	if filled.Status != models.TradeStatusOpen || filled.FillPrice() != 120 {
+		t.Errorf("expected the stop to fill at the market price 120, got %s at %v", filled.Status, filled.FillPrice())
+	}
+	if got := f.balance(t); got != money.FromFloat(760) {
+		t.Errorf("expected the margin to be topped up to 240, balance %s", got)
+	}
+}
+
+func TestPaperExchangeCancelsStopWithoutMargin(t *testing.T) {
+	f := newTradeFixture(t)
+	ctx := context.Background()
+	exchange := service.NewPaperExchangeService(repository.NewTradeRepository(f.db), f.db)
+
+	stop, err := f.svc.OpenTrade(ctx, f.traderID, models.TradeInput{
+		Symbol: "BTCUSDT", TradeType: models.TradeTypeStop, Side: models.TradeSideBuy, Quantity: 9, EntryPrice: 110,
+	})
+	if err != nil {
+		t.Fatalf("failed to place stop order: %v", err)
+	}
+
+	f.setPrice(t, "BTC", 120)
+	if err := exchange.MatchTrades(ctx); err != nil {
+		t.Fatalf("matching failed: %v", err)
+	}
+	cancelled, err := f.svc.GetTrade(ctx, f.traderID, stop.ID)
+	if err != nil {
+		t.Fatalf("failed to load trade: %v", err)
+	}
+	if cancelled.Status != models.TradeStatusCancelled {
+		t.Errorf("expected a stop that cannot be margined to be cancelled, got %s", cancelled.Status)
+	}
+	if got := f.balance(t); got != money.FromFloat(1000) {
+		t.Errorf("expected the reserved margin to be refunded, balance %s", got)
+	}
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	adminRepository "github.com/fathimasithara01/tradeverse/internal/admin/repository"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+)
+
+func TestTraderPayoutUnpaidRevenue(t *testing.T) {
+	db := newTestDB(t, &models.WalletTransaction{}, &models.WithdrawalRequest{}, &models.TraderPayout{})
+	repo := adminRepository.NewTraderPayoutRepository(db)
+	ctx := context.Background()
+	const traderID = 7
+
+	post := func(txType, direction models.TransactionType, amount float64) uint {
+		t.Helper()
+		tx := models.WalletTransaction{
+			WalletID: 1, UserID: traderID, Name: string(txType), Type: txType, TransactionType: direction,
+			Amount: money.FromFloat(amount), Currency: "USD", Status: models.TxStatusSuccess,
+		}
+		if err := db.Create(&tx).Error; err != nil {
+			t.Fatalf("failed to create wallet transaction: %v", err)
+		}
+		return tx.ID
+	}
+	payOut := func(amount float64, throughID uint) *models.WithdrawalRequest {
+		t.Helper()
+		w := &models.WithdrawalRequest{
+			UserID: traderID, Amount: money.FromFloat(amount), Currency: "USD", BankAccountNumber: "1",
+			BankAccountHolder: "Trader", IFSCCode: "X", Status: models.TxStatusPending, RequestTime: time.Now(),
+		}
+		if err := db.Create(w).Error; err != nil {
+			t.Fatalf("failed to create withdrawal: %v", err)
+		}
+		payout := models.TraderPayout{TraderID: traderID, Currency: "USD", Amount: w.Amount, RevenueThroughID: throughID, WithdrawalID: w.ID}
+		if err := db.Create(&payout).Error; err != nil {
+			t.Fatalf("failed to create payout: %v", err)
+		}
+		return w
+	}
+	unpaid := func() money.Amount {
+		t.Helper()
+		revenue, err := repo.UnpaidRevenue(ctx, traderID)
+		if err != nil {
+			t.Fatalf("failed to sum unpaid revenue: %v", err)
+		}
+		if len(revenue) == 0 {
+			return 0
+		}
+		return revenue[0].Amount
+	}
+	setStatus := func(w *models.WithdrawalRequest, status models.TransactionStatus) {
+		t.Helper()
+		if err := db.Model(w).Update("status", status).Error; err != nil {
+			t.Fatalf("failed to update withdrawal: %v", err)
+		}
+	}
+
+	post(models.TxTypeTraderRevenue, models.TxTypeCredit, 100)
+	post(models.TxTypeReversal, models.TxTypeDebit, 30)
+	through := post(models.TxTypeTraderRevenue, models.TxTypeCredit, 50)
+	if got := unpaid(); got != money.FromFloat(120) {
+		t.Fatalf("expected refunds to be taken off unpaid revenue, got %s", got)
+	}
+
+	first := payOut(120, through)
+	if pending, err := repo.HasPendingPayout(ctx, traderID, "USD"); err != nil || !pending {
+		t.Errorf("expected a pending payout, got %v, %v", pending, err)
+	}
+	if got := unpaid(); got != 0 {
+		t.Errorf("expected a pending payout to cover its revenue, got %s", got)
+	}
+
+	setStatus(first, models.TxStatusRejected)
+	if got := unpaid(); got != money.FromFloat(120) {
+		t.Errorf("expected a rejected payout's revenue to be unpaid again, got %s", got)
+	}
+	if pending, err := repo.HasPendingPayout(ctx, traderID, "USD"); err != nil || pending {
+		t.Errorf("expected no pending payout after rejection, got %v, %v", pending, err)
+	}
+
+	second := payOut(120, through)
+	setStatus(second, models.TxStatusSuccess)
+	post(models.TxTypeTraderRevenue, models.TxTypeCredit, 40)
+	if got := unpaid(); got != money.FromFloat(40) {
+		t.Errorf("expected only revenue after the completed payout, got %s", got)
+	}
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"github.com/fathimasithara01/tradeverse/pkg/transfer"
+)
+
+func TestTransferLimits(t *testing.T) {
+	p := transfer.Policy{
+		Currency:   "USD",
+		MaxAmount:  money.FromFloat(500),
+		DailyLimit: money.FromFloat(1000),
+	}
+
+	if err := p.CheckLimit(money.FromFloat(500), money.FromFloat(500)); err != nil {
+		t.Errorf("expected a transfer reaching both limits to pass, got %v", err)
+	}
+	if err := p.CheckLimit(money.FromFloat(500.01), 0); !errors.Is(err, transfer.ErrLimitExceeded) {
+		t.Errorf("expected the per-transfer limit to be enforced, got %v", err)
+	}
+	if err := p.CheckLimit(money.FromFloat(100), money.FromFloat(950)); !errors.Is(err, transfer.ErrLimitExceeded) {
+		t.Errorf("expected the daily limit to be enforced, got %v", err)
+	}
+
+	unlimited := transfer.Policy{Currency: "USD"}
+	if err := unlimited.CheckLimit(money.FromFloat(1e6), money.FromFloat(1e6)); err != nil {
+		t.Errorf("expected zero limits not to be enforced, got %v", err)
+	}
+}
+
+func TestTransferHoldsAndMissingWallet(t *testing.T) {
+	db := newTestDB(t,
+		&models.User{}, &models.Wallet{}, &models.WalletTransaction{}, &models.WalletTransfer{}, &models.UserKYCStatus{},
+		&models.LedgerAccount{}, &models.JournalEntry{}, &models.JournalPosting{},
+	)
+	users := []*models.User{
+		{Name: "Admin", Email: "admin@example.com", Password: "x", Phone: "1", Role: models.RoleAdmin},
+		{Name: "Sender", Email: "sender@example.com", Password: "x", Phone: "2", Role: models.RoleCustomer},
+		{Name: "Recipient", Email: "recipient@example.com", Password: "x", Phone: "3", Role: models.RoleCustomer},
+	}
+	for _, u := range users {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+	}
+	sender, recipient := users[1], users[2]
+	if err := db.Create(&models.UserKYCStatus{UserID: sender.ID, Status: models.KYCStatusApproved}).Error; err != nil {
+		t.Fatalf("failed to approve KYC: %v", err)
+	}
+	if err := db.Model(&models.Wallet{}).Where("user_id = ?", sender.ID).Update("balance", money.FromFloat(100)).Error; err != nil {
+		t.Fatalf("failed to fund wallet: %v", err)
+	}
+	deposit := models.WalletTransaction{
+		WalletID: 1, UserID: sender.ID, Name: "Deposit", Type: models.TxTypeDeposit, TransactionType: models.TxTypeCredit,
+		Amount: money.FromFloat(60), Currency: "USD", Status: models.TxStatusSuccess,
+	}
+	if err := db.Create(&deposit).Error; err != nil {
+		t.Fatalf("failed to record deposit: %v", err)
+	}
+
+	svc := transfer.NewService(db, transfer.Policy{Currency: "USD", DepositHold: 24 * time.Hour, PasswordHold: 24 * time.Hour})
+	ctx := context.Background()
+	send := func(amount float64) error {
+		_, err := svc.Send(ctx, sender.ID, models.TransferInput{RecipientID: recipient.ID, Amount: money.FromFloat(amount), Currency: "USD"})
+		return err
+	}
+
+	if err := send(50); !errors.Is(err, transfer.ErrFundsOnHold) {
+		t.Errorf("expected a recent deposit to be held, got %v", err)
+	}
+	if err := send(40); err != nil {
+		t.Errorf("expected funds outside the hold to be sent, got %v", err)
+	}
+
+	if err := db.Model(sender).Update("password_changed_at", time.Now()).Error; err != nil {
+		t.Fatalf("failed to change password: %v", err)
+	}
+	if err := send(1); !errors.Is(err, transfer.ErrFundsOnHold) {
+		t.Errorf("expected transfers to pause after a password change, got %v", err)
+	}
+	if err := db.Model(sender).Update("password_changed_at", nil).Error; err != nil {
+		t.Fatalf("failed to clear password change: %v", err)
+	}
+	if err := db.Model(&deposit).Update("created_at", time.Now().Add(-48*time.Hour)).Error; err != nil {
+		t.Fatalf("failed to age deposit: %v", err)
+	}
+
+	if err := db.Unscoped().Where("user_id = ?", recipient.ID).Delete(&models.Wallet{}).Error; err != nil {
+		t.Fatalf("failed to delete wallet: %v", err)
+	}
+	if err := send(1); !errors.Is(err, transfer.ErrWalletNotFound) {
+		t.Errorf("expected a missing wallet to be reported as such, got %v", err)
+	}
+}
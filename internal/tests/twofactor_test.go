@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/twofactor"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// The RFC lists 8-digit codes; these are their last six digits.
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range vectors {
+		got, err := twofactor.Code(rfc6238Secret, time.Unix(unix, 0))
+		if err != nil {
+			t.Fatalf("Code(%d): %v", unix, err)
+		}
+		if got != want {
+			t.Errorf("Code(%d) = %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestTOTPValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, _ := twofactor.Code(rfc6238Secret, now)
+	step := twofactor.Step(now)
+
+	if got, ok := twofactor.Validate(rfc6238Secret, code, now.Add(twofactor.Period), 0); !ok || got != step {
+		t.Errorf("expected a code from the previous step to be accepted, got step %d, %v", got, ok)
+	}
+	if _, ok := twofactor.Validate(rfc6238Secret, code, now.Add(2*twofactor.Period), 0); ok {
+		t.Error("expected a code two steps old to be rejected")
+	}
+	if _, ok := twofactor.Validate(rfc6238Secret, code, now, step); ok {
+		t.Error("expected a code of an already used step to be rejected")
+	}
+	if _, ok := twofactor.Validate(rfc6238Secret, code[:3]+" "+code[3:], now, 0); !ok {
+		t.Error("expected spaces in the code to be ignored")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := twofactor.ProvisioningURI("TradeVerse", "admin@example.com", rfc6238Secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/TradeVerse:admin@example.com?") {
+		t.Errorf("unexpected label in %s", uri)
+	}
+	if !strings.Contains(uri, "secret="+rfc6238Secret) || !strings.Contains(uri, "issuer=TradeVerse") {
+		t.Errorf("expected secret and issuer in %s", uri)
+	}
+}
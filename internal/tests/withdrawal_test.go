@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"github.com/fathimasithara01/tradeverse/pkg/withdrawal"
+)
+
+func testPolicy() withdrawal.Policy {
+	return withdrawal.Policy{
+		Currency: "USD",
+		Limits: map[string]withdrawal.Limit{
+			"APPROVED": {Daily: money.FromFloat(1000), Monthly: money.FromFloat(5000)},
+		},
+		SecondApprovalAbove: money.FromFloat(10000),
+		DepositHold:         24 * time.Hour,
+		PasswordHold:        48 * time.Hour,
+	}
+}
+
+func TestWithdrawalLimits(t *testing.T) {
+	p := testPolicy()
+
+	if err := p.CheckLimit("APPROVED", money.FromFloat(400), money.FromFloat(600), money.FromFloat(600)); err != nil {
+		t.Errorf("expected a withdrawal reaching the daily limit to pass, got %v", err)
+	}
+	if err := p.CheckLimit("APPROVED", money.FromFloat(400.01), money.FromFloat(600), money.FromFloat(600)); !errors.Is(err, withdrawal.ErrLimitExceeded) {
+		t.Errorf("expected the daily limit to be enforced, got %v", err)
+	}
+	if err := p.CheckLimit("APPROVED", money.FromFloat(500), 0, money.FromFloat(4600)); !errors.Is(err, withdrawal.ErrLimitExceeded) {
+		t.Errorf("expected the monthly limit to be enforced, got %v", err)
+	}
+	if err := p.CheckLimit("NOT_SUBMITTED", money.FromFloat(1), 0, 0); !errors.Is(err, withdrawal.ErrLimitExceeded) {
+		t.Errorf("expected a tier without limits to allow nothing, got %v", err)
+	}
+}
+
+func TestWithdrawalApprovalsRequired(t *testing.T) {
+	p := testPolicy()
+	if got := p.ApprovalsFor(money.FromFloat(10000)); got != 1 {
+		t.Errorf("expected one approval at the threshold, got %d", got)
+	}
+	if got := p.ApprovalsFor(money.FromFloat(10000.01)); got != 2 {
+		t.Errorf("expected two approvals above the threshold, got %d", got)
+	}
+}
+
+func TestWithdrawalHold(t *testing.T) {
+	p := testPolicy()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	deposit := now.Add(-2 * time.Hour)
+	passwordChanged := now.Add(-time.Hour)
+
+	if hold := p.HoldUntil(now, nil, nil); hold != nil {
+		t.Errorf("expected no hold without a deposit or password change, got %v", hold)
+	}
+	if hold := p.HoldUntil(now, &deposit, nil); hold == nil || !hold.Equal(deposit.Add(24*time.Hour)) {
+		t.Errorf("expected the deposit hold to end 24h after the deposit, got %v", hold)
+	}
+	if hold := p.HoldUntil(now, &deposit, &passwordChanged); hold == nil || !hold.Equal(passwordChanged.Add(48*time.Hour)) {
+		t.Errorf("expected the later of the two holds, got %v", hold)
+	}
+	old := now.Add(-72 * time.Hour)
+	if hold := p.HoldUntil(now, &old, &old); hold != nil {
+		t.Errorf("expected an expired hold to be ignored, got %v", hold)
+	}
+}
@@ -3,6 +3,7 @@ package bootstrap
 import (
 	"context"
 	"log"
+	"time"
 
 	"github.com/fathimasithara01/tradeverse/config"
 	adminRepo "github.com/fathimasithara01/tradeverse/internal/admin/repository"
@@ -14,9 +15,22 @@ import (
 	"github.com/fathimasithara01/tradeverse/internal/trader/repository"
 	"github.com/fathimasithara01/tradeverse/internal/trader/router"
 	"github.com/fathimasithara01/tradeverse/internal/trader/service"
+	"github.com/fathimasithara01/tradeverse/pkg/accounttoken"
+	"github.com/fathimasithara01/tradeverse/pkg/apikey"
+	"github.com/fathimasithara01/tradeverse/pkg/idempotency"
+	"github.com/fathimasithara01/tradeverse/pkg/lockout"
+	"github.com/fathimasithara01/tradeverse/pkg/mailer"
+	"github.com/fathimasithara01/tradeverse/pkg/ratelimit"
+	"github.com/fathimasithara01/tradeverse/pkg/session"
+	"github.com/fathimasithara01/tradeverse/pkg/stream"
+	"github.com/fathimasithara01/tradeverse/pkg/transfer"
+	"github.com/fathimasithara01/tradeverse/pkg/twofactor"
+	"github.com/fathimasithara01/tradeverse/pkg/withdrawal"
 	"github.com/gin-gonic/gin"
 )
 
+const streamRelayInterval = 5 * time.Second
+
 type App struct {
 	engine *gin.Engine
 	port   string
@@ -40,14 +54,38 @@ func InitializeApp() (*App, error) {
 		panic(err)
 	}
 
+	hub := stream.NewHub()
+
 	userRepo := adminRepo.NewUserRepository(db)
 	roleRepo := adminRepo.NewRoleRepository(db)
 	commissionRepo := adminRepo.NewCommissionRepository(db)
+	candleRepo := adminRepo.NewCandleRepository(db)
 
-	userService := adminService.NewUserService(userRepo, roleRepo, cfg.JWT.Secret)
+	sessions := session.NewService(db, session.ConfigFromAppConfig(cfg))
+	twoFactor := twofactor.NewService(db, twofactor.ConfigFromAppConfig(cfg))
+	userService := adminService.NewUserService(userRepo, roleRepo, sessions, twoFactor, lockout.PolicyFromAppConfig(cfg))
+	limiter, err := ratelimit.LimiterFromAppConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	accountService := adminService.NewAccountService(
+		db,
+		userRepo,
+		sessions,
+		accounttoken.NewService(db, accounttoken.SecretFromAppConfig(cfg)),
+		mail,
+		cfg.AccountTokens.LinkBaseURL,
+		time.Duration(cfg.AccountTokens.VerifyEmailHours)*time.Hour,
+		time.Duration(cfg.AccountTokens.PasswordResetMinutes)*time.Minute,
+	)
 	commissionService := adminService.NewCommissionService(commissionRepo, db)
+	candleService := adminService.NewCandleService(candleRepo)
 
-	authController := controllers.NewAuthController(userService)
+	authController := controllers.NewAuthController(userService, accountService)
 
 	tradeSignlRepo := repository.NewSignalRepository(db)
 	profileRepo := repository.NewTraderProfileRepository(db)
@@ -55,13 +93,20 @@ func InitializeApp() (*App, error) {
 	subRepo := repository.NewSubscriberRepository(db)
 	liveRepo := repository.NewLiveTradeRepository(db)
 	traderSubsRepo := repository.NewTraderSubscriptionRepository(db)
+	tradeRepo := repository.NewTradeRepository(db)
 
 	subService := service.NewSubscriberService(subRepo)
 	liveService := service.NewLiveTradeService(liveRepo)
 	profileService := service.NewTraderProfileService(profileRepo)
-	walletService := service.NewWalletService(walletrepo)
-	tradeSignlService := service.NewSignalService(tradeSignlRepo)
+	// Trader withdrawals are paid out from the admin service once approved.
+	walletService := service.NewWalletService(
+		walletrepo,
+		withdrawal.NewPipeline(db, withdrawal.PolicyFromConfig(cfg), nil),
+		transfer.NewService(db, transfer.PolicyFromConfig(cfg)),
+	)
+	tradeSignlService := service.NewSignalService(tradeSignlRepo, hub)
 	traderSubsService := service.NewTraderSubscriptionService(traderSubsRepo, db, commissionService)
+	tradeService := service.NewTradeService(tradeRepo, db)
 
 	subController := controllers.NewSubscriberController(subService)
 	liveController := controllers.NewLiveTradeController(liveService)
@@ -69,14 +114,25 @@ func InitializeApp() (*App, error) {
 	walletController := controllers.NewWalletController(walletService)
 	tradeSignlController := controllers.NewSignalController(tradeSignlService)
 	traderSubsController := controllers.NewTraderSubscriptionController(traderSubsService)
+	tradeController := controllers.NewTradeController(tradeService)
+	candleController := controllers.NewCandleController(candleService)
+	streamController := controllers.NewStreamController(hub)
+	apiKeyController := controllers.NewAPIKeyController(apikey.NewService(db, apikey.ConfigFromAppConfig(cfg)))
 
 	marketDataRepo := repository.NewMarketDataRepository(db)
 	marketDataService := service.NewMarketDataService(marketDataRepo)
 	marketDataHandler := controllers.NewMarketDataHandler(marketDataService)
 
-	r := router.SetupRouter(cfg, authController, profileController, walletController, subController, liveController, tradeSignlController, marketDataHandler, traderSubsController)
+	r := router.SetupRouter(cfg, authController, profileController, walletController, subController, liveController, tradeSignlController, marketDataHandler, traderSubsController, tradeController, candleController, streamController, apiKeyController, idempotency.NewGormStore(db), sessions, twoFactor, limiter)
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, err
+	}
 
-	cron.StartSignalCronJobs(service.NewSignalService(repository.NewSignalRepository(db)))
+	cron.StartSignalCronJobs(tradeSignlService)
+	cron.StartPaperExchangeCron(service.NewPaperExchangeService(tradeRepo, db))
+	if err := stream.NewRelay(db, hub).Start(ctx, streamRelayInterval); err != nil {
+		log.Printf("Stream relay not started: %v", err)
+	}
 
 	return &App{
 		engine: r,
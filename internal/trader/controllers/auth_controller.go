@@ -1,18 +1,23 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/fathimasithara01/tradeverse/internal/admin/service"
+	"github.com/fathimasithara01/tradeverse/pkg/accounttoken"
+	"github.com/fathimasithara01/tradeverse/pkg/session"
+	"github.com/fathimasithara01/tradeverse/pkg/twofactor"
 	"github.com/gin-gonic/gin"
 )
 
 type AuthController struct {
-	UserSvc service.IUserService
+	UserSvc    service.IUserService
+	AccountSvc service.IAccountService
 }
 
-func NewAuthController(userSvc service.IUserService) *AuthController {
-	return &AuthController{UserSvc: userSvc}
+func NewAuthController(userSvc service.IUserService, accountSvc service.IAccountService) *AuthController {
+	return &AuthController{UserSvc: userSvc, AccountSvc: accountSvc}
 }
 
 type LoginRequest struct {
@@ -27,15 +32,299 @@ func (ctrl *AuthController) Login(c *gin.Context) {
 		return
 	}
 
-	token, user, err := ctrl.UserSvc.Login(req.Email, req.Password)
+	result, err := ctrl.UserSvc.Login(req.Email, req.Password, session.MetaFrom(c))
+	if err != nil {
+		status := http.StatusUnauthorized
+		if errors.Is(err, service.ErrAccountLocked) {
+			status = http.StatusLocked
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if result.Challenge != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"message":             "Two-factor authentication required",
+			"two_factor_required": true,
+			"challenge_token":     result.Challenge.Token,
+			"expires_at":          result.Challenge.ExpiresAt,
+			"enrolment_required":  result.Challenge.EnrolmentRequired,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Login successful",
+		"token":              result.Tokens.AccessToken,
+		"refresh_token":      result.Tokens.RefreshToken,
+		"expires_in":         result.Tokens.ExpiresIn,
+		"refresh_expires_at": result.Tokens.RefreshExpiresAt,
+		"user":               result.User,
+	})
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// VerifyTwoFactor completes a login that returned a challenge. If the code
+// also confirmed a new authenticator, the recovery codes are included once.
+func (ctrl *AuthController) VerifyTwoFactor(c *gin.Context) {
+	var req TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := ctrl.UserSvc.CompleteTwoFactorLogin(req.ChallengeToken, req.Code, session.MetaFrom(c))
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{
+		"message":            "Login successful",
+		"token":              result.Tokens.AccessToken,
+		"refresh_token":      result.Tokens.RefreshToken,
+		"expires_in":         result.Tokens.ExpiresIn,
+		"refresh_expires_at": result.Tokens.RefreshExpiresAt,
+		"user":               result.User,
+	}
+	if len(result.RecoveryCodes) > 0 {
+		response["recovery_codes"] = result.RecoveryCodes
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+// EnrollTwoFactorAtLogin returns a new authenticator secret for a user whose
+// login challenge says enrolment is required.
+func (ctrl *AuthController) EnrollTwoFactorAtLogin(c *gin.Context) {
+	var req TwoFactorChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	enrolment, err := ctrl.UserSvc.BeginLoginEnrolment(req.ChallengeToken)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, enrolment)
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Refresh rotates the refresh token and issues a new access token.
+func (ctrl *AuthController) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := ctrl.UserSvc.RefreshSession(req.RefreshToken, session.MetaFrom(c))
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"token":   token,
-		"user":    user,
+		"message":            "Session refreshed",
+		"token":              tokens.AccessToken,
+		"refresh_token":      tokens.RefreshToken,
+		"expires_in":         tokens.ExpiresIn,
+		"refresh_expires_at": tokens.RefreshExpiresAt,
 	})
 }
+
+// Logout revokes the session the request was made with; ?all=true revokes
+// every session of the user.
+func (ctrl *AuthController) Logout(c *gin.Context) {
+	userID := c.GetUint("userID")
+	if err := ctrl.UserSvc.Logout(userID, c.GetString("sessionID"), c.Query("all") == "true"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+func twoFactorErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, twofactor.ErrInvalidCode), errors.Is(err, twofactor.ErrCodeRequired),
+		errors.Is(err, twofactor.ErrChallengeInvalid), errors.Is(err, twofactor.ErrChallengeExpired),
+		errors.Is(err, twofactor.ErrTooManyAttempts):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrTwoFactorMandatory), errors.Is(err, twofactor.ErrEnrolmentRequired):
+		return http.StatusForbidden
+	case errors.Is(err, twofactor.ErrAlreadyEnabled), errors.Is(err, twofactor.ErrNotEnrolled), errors.Is(err, twofactor.ErrNoPendingEnrolment):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (ctrl *AuthController) TwoFactorStatus(c *gin.Context) {
+	status, err := ctrl.UserSvc.TwoFactorStatus(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// EnrollTwoFactor starts setting up an authenticator app. The response holds
+// the secret and the otpauth:// URI to show as a QR code.
+func (ctrl *AuthController) EnrollTwoFactor(c *gin.Context) {
+	enrolment, err := ctrl.UserSvc.BeginTwoFactorEnrolment(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, enrolment)
+}
+
+// ConfirmTwoFactor enables 2FA with a code from the new authenticator and
+// returns the recovery codes. They are not shown again.
+func (ctrl *AuthController) ConfirmTwoFactor(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := ctrl.UserSvc.ConfirmTwoFactor(c.GetUint("userID"), req.Code)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication enabled", "recovery_codes": codes})
+}
+
+func (ctrl *AuthController) DisableTwoFactor(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.UserSvc.DisableTwoFactor(c.GetUint("userID"), req.Code); err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+func (ctrl *AuthController) RegenerateRecoveryCodes(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := ctrl.UserSvc.RegenerateRecoveryCodes(c.GetUint("userID"), req.Code)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+type EmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" form:"token" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+func accountErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, accounttoken.ErrInvalid), errors.Is(err, accounttoken.ErrExpired), errors.Is(err, accounttoken.ErrUsed),
+		errors.Is(err, service.ErrWeakPassword):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// VerifyEmail takes the token from the verification link, either as ?token=
+// or in a JSON body.
+func (ctrl *AuthController) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.AccountSvc.VerifyEmail(req.Token); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email address verified"})
+}
+
+// ResendVerificationEmail always answers the same way so that it cannot be
+// used to find out which addresses have accounts.
+func (ctrl *AuthController) ResendVerificationEmail(c *gin.Context) {
+	var req EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.AccountSvc.SendVerificationEmail(req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "If an unverified account exists for this address, a verification email has been sent"})
+}
+
+// ForgotPassword emails a password reset link. Like ResendVerificationEmail
+// it does not say whether the address has an account.
+func (ctrl *AuthController) ForgotPassword(c *gin.Context) {
+	var req EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.AccountSvc.RequestPasswordReset(req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send password reset email"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "If an account exists for this address, a password reset email has been sent"})
+}
+
+// ResetPassword sets a new password with the token from the reset email. All
+// sessions of the account are logged out.
+func (ctrl *AuthController) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.AccountSvc.ResetPassword(req.Token, req.Password); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset. Please log in again."})
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	adminSvc "github.com/fathimasithara01/tradeverse/internal/admin/service"
+	"github.com/gin-gonic/gin"
+)
+
+type CandleController struct {
+	candleService adminSvc.ICandleService
+}
+
+func NewCandleController(candleService adminSvc.ICandleService) *CandleController {
+	return &CandleController{candleService: candleService}
+}
+
+// GetCandles serves GET /market/candles?symbol=&interval=&from=&to=. from and
+// to accept RFC3339 timestamps or unix seconds.
+func (ctrl *CandleController) GetCandles(c *gin.Context) {
+	from, err := parseCandleTime(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from time"})
+		return
+	}
+	to, err := parseCandleTime(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to time"})
+		return
+	}
+
+	symbol := c.Query("symbol")
+	interval := c.DefaultQuery("interval", "1h")
+
+	candles, err := ctrl.candleService.GetCandles(c, symbol, interval, from, to)
+	if err != nil {
+		if errors.Is(err, adminSvc.ErrInvalidCandleQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch candles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"symbol": symbol, "interval": interval, "candles": candles})
+}
+
+func parseCandleTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
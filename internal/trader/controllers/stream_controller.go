@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"github.com/fathimasithara01/tradeverse/pkg/stream"
+	"github.com/gin-gonic/gin"
+)
+
+type StreamController struct {
+	hub *stream.Hub
+}
+
+func NewStreamController(hub *stream.Hub) *StreamController {
+	return &StreamController{hub: hub}
+}
+
+func (ctrl *StreamController) StreamSSE(c *gin.Context) {
+	stream.ServeSSE(c, ctrl.hub, traderStreamFilter(c.MustGet("userID").(uint)))
+}
+
+func (ctrl *StreamController) StreamWebSocket(c *gin.Context) {
+	stream.ServeWebSocket(c, ctrl.hub, traderStreamFilter(c.MustGet("userID").(uint)))
+}
+
+// traderStreamFilter passes every tick but only the trader's own signal events.
+func traderStreamFilter(traderID uint) stream.Filter {
+	return func(e stream.Event) bool {
+		return e.Type == stream.EventMarketTick || e.TraderID == traderID
+	}
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fathimasithara01/tradeverse/internal/trader/service"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+type TradeController struct {
+	tradeService service.TradeService
+}
+
+func NewTradeController(tradeService service.TradeService) *TradeController {
+	return &TradeController{tradeService: tradeService}
+}
+
+func (ctrl *TradeController) OpenTrade(c *gin.Context) {
+	var input models.TradeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trade, err := ctrl.tradeService.OpenTrade(c, c.GetUint("userID"), input)
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to open trade")
+		return
+	}
+
+	c.JSON(http.StatusCreated, trade)
+}
+
+func (ctrl *TradeController) ListTrades(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	trades, err := ctrl.tradeService.ListTrades(c, c.GetUint("userID"), c.Query("status"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trades"})
+		return
+	}
+
+	c.JSON(http.StatusOK, trades)
+}
+
+func (ctrl *TradeController) GetTrade(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid trade ID"})
+		return
+	}
+
+	trade, err := ctrl.tradeService.GetTrade(c, c.GetUint("userID"), uint(id))
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to fetch trade")
+		return
+	}
+
+	c.JSON(http.StatusOK, trade)
+}
+
+func (ctrl *TradeController) UpdateTrade(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid trade ID"})
+		return
+	}
+
+	var input models.TradeUpdateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trade, err := ctrl.tradeService.UpdateTrade(c, c.GetUint("userID"), uint(id), input)
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to update trade")
+		return
+	}
+
+	c.JSON(http.StatusOK, trade)
+}
+
+func (ctrl *TradeController) handleError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrTradeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidTradeState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidTradeInput),
+		errors.Is(err, service.ErrInsufficientMargin):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNoMarketPrice),
+		errors.Is(err, service.ErrClosePriceUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -24,8 +25,14 @@ func (ctrl *SignalController) CreateSignal(c *gin.Context) {
 		return
 	}
 
+	req.TraderID = c.MustGet("userID").(uint)
+
 	signal, err := ctrl.signalService.CreateSignal(c, &req)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidSignal) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create signal"})
 		return
 	}
@@ -78,6 +85,10 @@ func (ctrl *SignalController) UpdateSignal(c *gin.Context) {
 
 	signal, err := ctrl.signalService.UpdateSignal(c, &req)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidSignal) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update signal"})
 		return
 	}
@@ -100,3 +111,48 @@ func (ctrl *SignalController) DeleteSignal(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "signal deleted successfully"})
 }
+
+func (ctrl *SignalController) CancelSignal(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid signal ID"})
+		return
+	}
+
+	signal, err := ctrl.signalService.CancelSignal(c, c.MustGet("userID").(uint), uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrSignalNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrPermissionDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, models.ErrInvalidSignalTransition):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel signal"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, signal)
+}
+
+func (ctrl *SignalController) GetSignalHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid signal ID"})
+		return
+	}
+
+	history, err := ctrl.signalService.GetSignalHistory(c, uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrSignalNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch signal history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
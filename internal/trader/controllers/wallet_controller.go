@@ -1,10 +1,16 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/fathimasithara01/tradeverse/internal/trader/service"
+	"github.com/fathimasithara01/tradeverse/pkg/fx"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"github.com/fathimasithara01/tradeverse/pkg/transfer"
 	"github.com/fathimasithara01/tradeverse/pkg/utils/response"
+	"github.com/fathimasithara01/tradeverse/pkg/withdrawal"
 	"github.com/gin-gonic/gin"
 )
 
@@ -31,7 +37,7 @@ func (ctrl *WalletController) GetBalance(c *gin.Context) {
 func (ctrl *WalletController) Deposit(c *gin.Context) {
 	userID := c.GetUint("userID")
 	var req struct {
-		Amount float64 `json:"amount"`
+		Amount money.Amount `json:"amount"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, http.StatusBadRequest, err.Error())
@@ -49,21 +55,23 @@ func (ctrl *WalletController) Deposit(c *gin.Context) {
 
 func (ctrl *WalletController) Withdraw(c *gin.Context) {
 	userID := c.GetUint("userID")
-	var req struct {
-		Amount float64 `json:"amount"`
-	}
+	var req models.WithdrawalRequestInput
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	tx, err := ctrl.walletService.Withdraw(c.Request.Context(), userID, req.Amount)
+	w, err := ctrl.walletService.Withdraw(c.Request.Context(), userID, req)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, err.Error())
+		status := http.StatusBadRequest
+		if errors.Is(err, withdrawal.ErrLimitExceeded) || errors.Is(err, fx.ErrRateNotFound) {
+			status = http.StatusUnprocessableEntity
+		}
+		response.Error(c, status, err.Error())
 		return
 	}
 
-	response.Success(c, http.StatusOK, "Withdrawal successful", tx)
+	response.Success(c, http.StatusOK, "Withdrawal submitted for review", w)
 }
 
 func (ctrl *WalletController) TransactionHistory(c *gin.Context) {
@@ -77,3 +85,75 @@ func (ctrl *WalletController) TransactionHistory(c *gin.Context) {
 
 	response.Success(c, http.StatusOK, "Transaction history retrieved", txs)
 }
+
+func (ctrl *WalletController) Transfer(c *gin.Context) {
+	userID := c.GetUint("userID")
+	var req models.TransferInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sent, err := ctrl.walletService.Transfer(c.Request.Context(), userID, req)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, transfer.ErrRecipientNotFound), errors.Is(err, transfer.ErrWalletNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, transfer.ErrKYCRequired):
+			status = http.StatusForbidden
+		case errors.Is(err, transfer.ErrLimitExceeded), errors.Is(err, transfer.ErrFundsOnHold), errors.Is(err, fx.ErrRateNotFound):
+			status = http.StatusUnprocessableEntity
+		}
+		response.Error(c, status, err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Transfer sent", sent)
+}
+
+func (ctrl *WalletController) TransferHistory(c *gin.Context) {
+	userID := c.GetUint("userID")
+	var pagination models.PaginationParams
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	transfers, total, err := ctrl.walletService.GetTransfers(c.Request.Context(), userID, pagination)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Transfer history retrieved", gin.H{"transfers": transfers, "total": total})
+}
+
+func (ctrl *WalletController) GetPayoutAccount(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	account, err := ctrl.walletService.GetPayoutAccount(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Payout account retrieved", account)
+}
+
+func (ctrl *WalletController) SetPayoutAccount(c *gin.Context) {
+	userID := c.GetUint("userID")
+	var req models.TraderPayoutAccountInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	account, err := ctrl.walletService.SetPayoutAccount(c.Request.Context(), userID, req)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Payout account saved", account)
+}
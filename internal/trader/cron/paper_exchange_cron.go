@@ -0,0 +1,22 @@
+package cron
+
+import (
+	"context"
+	"log"
+
+	"github.com/fathimasithara01/tradeverse/internal/trader/service"
+	"github.com/robfig/cron/v3"
+)
+
+func StartPaperExchangeCron(exchange service.PaperExchangeService) {
+	c := cron.New()
+
+	c.AddFunc("@every 10s", func() {
+		if err := exchange.MatchTrades(context.Background()); err != nil {
+			log.Printf("Error matching paper trades: %v", err)
+		}
+	})
+
+	c.Start()
+	log.Println("Paper exchange cron started.")
+}
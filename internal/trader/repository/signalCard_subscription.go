@@ -29,8 +29,6 @@ type ITraderSubscriptionRepository interface {
 	CreateUserSubscription(ctx context.Context, sub *models.UserSubscription) error
 
 	GetUserWallet(ctx context.Context, userID uint) (*models.Wallet, error)
-	UpdateWalletBalance(ctx context.Context, userID uint, amount float64, tx *gorm.DB) error
-	CreateWalletTransaction(ctx context.Context, transaction *models.WalletTransaction, tx *gorm.DB) error
 	GetAdminWallet(ctx context.Context) (*models.Wallet, error)
 	GetUserByID(ctx context.Context, userID uint) (*models.User, error)
 }
@@ -175,31 +173,19 @@ func (r *TraderSubscriptionRepository) CreateUserSubscription(ctx context.Contex
 
 func (r *TraderSubscriptionRepository) GetUserWallet(ctx context.Context, userID uint) (*models.Wallet, error) {
 	var wallet models.Wallet
-	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&wallet).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(models.PrimaryWallet).Where("user_id = ?", userID).First(&wallet).Error; err != nil {
 		return nil, fmt.Errorf("wallet not found for user %d: %w", userID, err)
 	}
 	return &wallet, nil
 }
 
-func (r *TraderSubscriptionRepository) UpdateWalletBalance(ctx context.Context, userID uint, amount float64, tx *gorm.DB) error {
-	return tx.WithContext(ctx).Model(&models.Wallet{}).Where("user_id = ?", userID).
-		Update("balance", gorm.Expr("balance + ?", amount)).Error
-}
-
-func (r *TraderSubscriptionRepository) CreateWalletTransaction(ctx context.Context, transaction *models.WalletTransaction, tx *gorm.DB) error {
-	if err := tx.WithContext(ctx).Create(transaction).Error; err != nil {
-		return fmt.Errorf("failed to create wallet transaction: %w", err)
-	}
-	return nil
-}
-
 func (r *TraderSubscriptionRepository) GetAdminWallet(ctx context.Context) (*models.Wallet, error) {
 	var adminUser models.User
 	if err := r.db.WithContext(ctx).Where("role = ?", models.RoleAdmin).First(&adminUser).Error; err != nil {
 		return nil, fmt.Errorf("admin user not found: %w", err)
 	}
 	var adminWallet models.Wallet
-	if err := r.db.WithContext(ctx).Where("user_id = ?", adminUser.ID).First(&adminWallet).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(models.PrimaryWallet).Where("user_id = ?", adminUser.ID).First(&adminWallet).Error; err != nil {
 		return nil, fmt.Errorf("admin wallet not found for admin user %d: %w", adminUser.ID, err)
 	}
 	return &adminWallet, nil
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TradeRepository interface {
+	CreateTrade(tx *gorm.DB, trade *models.Trade) error
+	UpdateTrade(tx *gorm.DB, trade *models.Trade) error
+	GetTradeByID(ctx context.Context, traderID, tradeID uint) (*models.Trade, error)
+	GetTradeForUpdate(tx *gorm.DB, traderID, tradeID uint) (*models.Trade, error)
+	ListTrades(ctx context.Context, traderID uint, status string, page, limit int) ([]models.Trade, int64, error)
+	ListWorkingTrades(ctx context.Context) ([]models.Trade, error)
+
+	GetWalletForUpdate(tx *gorm.DB, userID uint) (*models.Wallet, error)
+
+	GetMarketPrice(ctx context.Context, symbol string) (float64, error)
+}
+
+type tradeRepository struct {
+	db *gorm.DB
+}
+
+func NewTradeRepository(db *gorm.DB) TradeRepository {
+	return &tradeRepository{db: db}
+}
+
+func (r *tradeRepository) CreateTrade(tx *gorm.DB, trade *models.Trade) error {
+	return tx.Create(trade).Error
+}
+
+func (r *tradeRepository) UpdateTrade(tx *gorm.DB, trade *models.Trade) error {
+	return tx.Save(trade).Error
+}
+
+func (r *tradeRepository) GetTradeByID(ctx context.Context, traderID, tradeID uint) (*models.Trade, error) {
+	var trade models.Trade
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND trader_id = ? AND is_copy_trade = ?", tradeID, traderID, false).
+		First(&trade).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &trade, nil
+}
+
+func (r *tradeRepository) GetTradeForUpdate(tx *gorm.DB, traderID, tradeID uint) (*models.Trade, error) {
+	var trade models.Trade
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND trader_id = ? AND is_copy_trade = ?", tradeID, traderID, false).
+		First(&trade).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &trade, nil
+}
+
+func (r *tradeRepository) ListTrades(ctx context.Context, traderID uint, status string, page, limit int) ([]models.Trade, int64, error) {
+	var (
+		trades []models.Trade
+		total  int64
+	)
+
+	query := r.db.WithContext(ctx).Model(&models.Trade{}).
+		Where("trader_id = ? AND is_copy_trade = ?", traderID, false)
+	if status != "" {
+		query = query.Where("status = ?", strings.ToUpper(status))
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at desc").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&trades).Error
+	return trades, total, err
+}
+
+// ListWorkingTrades returns every PENDING or OPEN master trade the paper exchange has to watch.
+// Copy trades are left out; they close when the copy engine mirrors their master's close.
+func (r *tradeRepository) ListWorkingTrades(ctx context.Context) ([]models.Trade, error) {
+	var trades []models.Trade
+	err := r.db.WithContext(ctx).
+		Where("is_copy_trade = ? AND status IN ?", false, []models.TradeStatus{models.TradeStatusPending, models.TradeStatusOpen}).
+		Order("id asc").
+		Find(&trades).Error
+	return trades, err
+}
+
+func (r *tradeRepository) GetWalletForUpdate(tx *gorm.DB, userID uint) (*models.Wallet, error) {
+	var wallet models.Wallet
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Scopes(models.PrimaryWallet).
+		Where("user_id = ?", userID).
+		First(&wallet).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("wallet not found")
+		}
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// GetMarketPrice looks the symbol up in market_data, which stores base assets
+// ("BTC"), so quote suffixes such as USDT are stripped before a second attempt.
+func (r *tradeRepository) GetMarketPrice(ctx context.Context, symbol string) (float64, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	candidates := []string{symbol}
+	for _, quote := range []string{"USDT", "USD"} {
+		if base := strings.TrimSuffix(symbol, quote); base != symbol && base != "" {
+			candidates = append(candidates, strings.TrimRight(base, "-/"))
+		}
+	}
+
+	var md models.MarketData
+	err := r.db.WithContext(ctx).Where("symbol IN ?", candidates).Order("updated_at desc").First(&md).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, fmt.Errorf("no market price available for %s", symbol)
+		}
+		return 0, err
+	}
+	return md.CurrentPrice, nil
+}
@@ -3,7 +3,10 @@ package repository
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/fathimasithara01/tradeverse/pkg/exchange"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
 	"gorm.io/gorm"
 )
@@ -13,7 +16,10 @@ type ISignalRepository interface {
 	GetAllSignals(ctx context.Context) ([]models.Signal, error)
 	GetMarketDataBySymbol(ctx context.Context, symbol string) (*models.MarketData, error)
 	UpdateSignalCurrentPrice(ctx context.Context, signalID uint, price float64) error
-	UpdateSignalStatus(ctx context.Context, signalID uint, status string) error
+	TransitionSignalStatus(ctx context.Context, signal *models.Signal, next models.SignalStatus, price float64, reason string) (bool, error)
+	SaveSignalProgress(ctx context.Context, signal *models.Signal) error
+	ReplaceSignalTargets(ctx context.Context, signalID uint, targets []models.SignalTarget) error
+	GetSignalHistory(ctx context.Context, signalID uint) ([]models.SignalStatusHistory, error)
 	GetPendingSignals(ctx context.Context) ([]models.Signal, error)
 	GetActiveSignals(ctx context.Context) ([]models.Signal, error)
 	GetSignalByID(ctx context.Context, id uint) (*models.Signal, error)
@@ -31,9 +37,14 @@ func NewSignalRepository(db *gorm.DB) ISignalRepository {
 	return &SignalRepository{db: db}
 }
 
+// withTargets loads a signal's target ladder in order.
+func withTargets(db *gorm.DB) *gorm.DB {
+	return db.Preload("Targets", func(db *gorm.DB) *gorm.DB { return db.Order("sequence ASC") })
+}
+
 func (r *SignalRepository) GetSignalsByTraderID(ctx context.Context, traderID uint) ([]models.Signal, error) {
 	var signals []models.Signal
-	if err := r.db.WithContext(ctx).Where("trader_id = ?", traderID).Find(&signals).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(withTargets).Where("trader_id = ?", traderID).Find(&signals).Error; err != nil {
 		return nil, fmt.Errorf("failed to retrieve signals for trader %d: %w", traderID, err)
 	}
 	return signals, nil
@@ -48,7 +59,7 @@ func (r *SignalRepository) CreateSignal(ctx context.Context, signal *models.Sign
 
 func (r *SignalRepository) GetAllSignals(ctx context.Context) ([]models.Signal, error) {
 	var signals []models.Signal
-	if err := r.db.WithContext(ctx).Find(&signals).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(withTargets).Find(&signals).Error; err != nil {
 		return nil, err
 	}
 	return signals, nil
@@ -56,7 +67,9 @@ func (r *SignalRepository) GetAllSignals(ctx context.Context) ([]models.Signal,
 
 func (r *SignalRepository) GetMarketDataBySymbol(ctx context.Context, symbol string) (*models.MarketData, error) {
 	var md models.MarketData
-	if err := r.db.WithContext(ctx).Where("symbol = ?", symbol).First(&md).Error; err != nil {
+	// Signals are quoted as "BTCUSDT" while market data is stored by base asset.
+	symbols := []string{strings.ToUpper(symbol), exchange.BaseSymbol(symbol)}
+	if err := r.db.WithContext(ctx).Where("UPPER(symbol) IN ?", symbols).First(&md).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
@@ -69,32 +82,124 @@ func (r *SignalRepository) UpdateSignalCurrentPrice(ctx context.Context, signalI
 	return r.db.WithContext(ctx).Model(&models.Signal{}).Where("id = ?", signalID).Update("current_price", price).Error
 }
 
-func (r *SignalRepository) UpdateSignalStatus(ctx context.Context, signalID uint, status string) error {
-	return r.db.WithContext(ctx).Model(&models.Signal{}).Where("id = ?", signalID).Update("status", status).Error
+// TransitionSignalStatus moves the signal from its loaded status to next and
+// records the change. It returns false when another worker already moved it.
+func (r *SignalRepository) TransitionSignalStatus(ctx context.Context, signal *models.Signal, next models.SignalStatus, price float64, reason string) (bool, error) {
+	if !signal.Status.CanTransitionTo(next) {
+		return false, fmt.Errorf("%w: %s to %s", models.ErrInvalidSignalTransition, signal.Status, next)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": next}
+	if next.IsTerminal() {
+		updates["deactivated_at"] = now
+		updates["exit_price"] = signal.ExitPrice
+		updates["realized_r"] = signal.RealizedR
+	}
+
+	moved := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Signal{}).Where("id = ? AND status = ?", signal.ID, signal.Status).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		moved = true
+		return tx.Create(&models.SignalStatusHistory{
+			SignalID:   signal.ID,
+			FromStatus: signal.Status,
+			ToStatus:   next,
+			Price:      price,
+			Reason:     reason,
+			ChangedAt:  now,
+		}).Error
+	})
+	return moved, err
+}
+
+// SaveSignalProgress persists the stop, best price, realised R and newly hit
+// targets of an Active signal. The stop and watermark never move backwards, so
+// this is safe to race with the admin cron.
+func (r *SignalRepository) SaveSignalProgress(ctx context.Context, signal *models.Signal) error {
+	best := "GREATEST"
+	if signal.Direction == models.SignalDirectionShort {
+		best = "LEAST"
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Signal{}).
+			Where("id = ? AND status = ?", signal.ID, models.SignalStatusActive).
+			Updates(map[string]interface{}{
+				"stop_loss":      gorm.Expr("CASE WHEN stop_loss = 0 THEN ? ELSE "+best+"(stop_loss, ?) END", signal.StopLoss, signal.StopLoss),
+				"high_watermark": gorm.Expr("CASE WHEN high_watermark = 0 THEN ? ELSE "+best+"(high_watermark, ?) END", signal.HighWatermark, signal.HighWatermark),
+				"realized_r":     signal.RealizedR,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		for _, t := range signal.Targets {
+			if t.HitAt == nil {
+				continue
+			}
+			if err := tx.Model(&models.SignalTarget{}).
+				Where("id = ? AND hit_at IS NULL", t.ID).
+				Updates(map[string]interface{}{"hit_at": t.HitAt, "hit_price": t.HitPrice}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+func (r *SignalRepository) ReplaceSignalTargets(ctx context.Context, signalID uint, targets []models.SignalTarget) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("signal_id = ?", signalID).Delete(&models.SignalTarget{}).Error; err != nil {
+			return err
+		}
+		for i := range targets {
+			targets[i].ID = 0
+			targets[i].SignalID = signalID
+		}
+		if len(targets) == 0 {
+			return nil
+		}
+		return tx.Create(&targets).Error
+	})
+}
+
+func (r *SignalRepository) GetSignalHistory(ctx context.Context, signalID uint) ([]models.SignalStatusHistory, error) {
+	var history []models.SignalStatusHistory
+	err := r.db.WithContext(ctx).Where("signal_id = ?", signalID).Order("changed_at ASC, id ASC").Find(&history).Error
+	return history, err
 }
 
 func (r *SignalRepository) GetPendingSignals(ctx context.Context) ([]models.Signal, error) {
 	var signals []models.Signal
-	err := r.db.WithContext(ctx).Where("status = ?", "Pending").Find(&signals).Error
+	err := r.db.WithContext(ctx).Scopes(withTargets).Where("status = ?", models.SignalStatusPending).Find(&signals).Error
 	return signals, err
 }
 
 func (r *SignalRepository) GetActiveSignals(ctx context.Context) ([]models.Signal, error) {
 	var signals []models.Signal
-	err := r.db.WithContext(ctx).Where("status = ?", "Active").Find(&signals).Error
+	err := r.db.WithContext(ctx).Scopes(withTargets).Where("status = ?", models.SignalStatusActive).Find(&signals).Error
 	return signals, err
 }
 
 func (r *SignalRepository) GetSignalByID(ctx context.Context, id uint) (*models.Signal, error) {
 	var signal models.Signal
-	if err := r.db.WithContext(ctx).First(&signal, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(withTargets).First(&signal, id).Error; err != nil {
 		return nil, err
 	}
 	return &signal, nil
 }
 
+// UpdateSignal saves the editable fields. Status is left alone so an edit
+// cannot undo a transition made by the cron in the meantime.
 func (r *SignalRepository) UpdateSignal(ctx context.Context, signal *models.Signal) error {
-	return r.db.WithContext(ctx).Save(signal).Error
+	return r.db.WithContext(ctx).Omit("status", "deactivated_at", "Targets").Save(signal).Error
 }
 
 func (r *SignalRepository) DeleteSignal(ctx context.Context, id uint) error {
@@ -4,15 +4,18 @@ import (
 	"context"
 	"errors"
 
+	"github.com/fathimasithara01/tradeverse/pkg/ledger"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type WalletRepository interface {
 	GetWalletByUserID(ctx context.Context, userID uint) (*models.Wallet, error)
-	UpdateWallet(ctx context.Context, wallet *models.Wallet) error
-	CreateTransaction(ctx context.Context, tx *models.WalletTransaction) error
+	Post(ctx context.Context, entry ledger.Entry) error
 	GetTransactionsByWalletID(ctx context.Context, walletID uint) ([]models.WalletTransaction, error)
+	GetPayoutAccount(ctx context.Context, traderID uint) (*models.TraderPayoutAccount, error)
+	SavePayoutAccount(ctx context.Context, account *models.TraderPayoutAccount) error
 }
 
 type gormWalletRepository struct {
@@ -25,7 +28,7 @@ func NewGormWalletRepository(db *gorm.DB) WalletRepository {
 
 func (r *gormWalletRepository) GetWalletByUserID(ctx context.Context, userID uint) (*models.Wallet, error) {
 	var wallet models.Wallet
-	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&wallet).Error
+	err := r.db.WithContext(ctx).Scopes(models.PrimaryWallet).Where("user_id = ?", userID).First(&wallet).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -35,12 +38,11 @@ func (r *gormWalletRepository) GetWalletByUserID(ctx context.Context, userID uin
 	return &wallet, nil
 }
 
-func (r *gormWalletRepository) UpdateWallet(ctx context.Context, wallet *models.Wallet) error {
-	return r.db.WithContext(ctx).Save(wallet).Error
-}
-
-func (r *gormWalletRepository) CreateTransaction(ctx context.Context, tx *models.WalletTransaction) error {
-	return r.db.WithContext(ctx).Create(tx).Error
+func (r *gormWalletRepository) Post(ctx context.Context, entry ledger.Entry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		_, err := ledger.Post(tx, entry)
+		return err
+	})
 }
 
 func (r *gormWalletRepository) GetTransactionsByWalletID(ctx context.Context, walletID uint) ([]models.WalletTransaction, error) {
@@ -48,3 +50,22 @@ func (r *gormWalletRepository) GetTransactionsByWalletID(ctx context.Context, wa
 	err := r.db.WithContext(ctx).Where("wallet_id = ?", walletID).Order("created_at desc").Find(&txs).Error
 	return txs, err
 }
+
+func (r *gormWalletRepository) GetPayoutAccount(ctx context.Context, traderID uint) (*models.TraderPayoutAccount, error) {
+	var account models.TraderPayoutAccount
+	err := r.db.WithContext(ctx).Where("trader_id = ?", traderID).First(&account).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *gormWalletRepository) SavePayoutAccount(ctx context.Context, account *models.TraderPayoutAccount) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "trader_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"bank_account_number", "bank_account_holder", "ifsc_code", "auto_payout", "updated_at"}),
+	}).Create(account).Error
+}
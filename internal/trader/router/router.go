@@ -1,12 +1,43 @@
 package router
 
 import (
+	"net/http"
+
 	"github.com/fathimasithara01/tradeverse/config"
 	"github.com/fathimasithara01/tradeverse/internal/customer/middleware"
 	"github.com/fathimasithara01/tradeverse/internal/trader/controllers"
+	"github.com/fathimasithara01/tradeverse/pkg/apikey"
+	"github.com/fathimasithara01/tradeverse/pkg/idempotency"
+	"github.com/fathimasithara01/tradeverse/pkg/ratelimit"
+	"github.com/fathimasithara01/tradeverse/pkg/session"
+	"github.com/fathimasithara01/tradeverse/pkg/twofactor"
 	"github.com/gin-gonic/gin"
 )
 
+// apiKeyRoutes lists the routes that accept personal API keys and the scope
+// each needs. Reading trades is allowed to keys that may open them. Everything
+// else, including managing the keys themselves, needs a logged-in session.
+var apiKeyRoutes = apikey.Routes{
+	http.MethodPost + " /api/v1/signals":               apikey.ScopeSignalsWrite,
+	http.MethodPut + " /api/v1/signals/:id":            apikey.ScopeSignalsWrite,
+	http.MethodDelete + " /api/v1/signals/:id":         apikey.ScopeSignalsWrite,
+	http.MethodPost + " /api/v1/signals/:id/cancel":    apikey.ScopeSignalsWrite,
+	http.MethodGet + " /api/v1/signals":                apikey.ScopeSignalsRead,
+	http.MethodGet + " /api/v1/signals/:id":            apikey.ScopeSignalsRead,
+	http.MethodGet + " /api/v1/signals/:id/history":    apikey.ScopeSignalsRead,
+	http.MethodGet + " /api/v1/trader/subscribers":     apikey.ScopeSignalsRead,
+	http.MethodGet + " /api/v1/trader/subscribers/:id": apikey.ScopeSignalsRead,
+	http.MethodGet + " /api/v1/wallet":                 apikey.ScopeWalletRead,
+	http.MethodGet + " /api/v1/wallet/transactions":    apikey.ScopeWalletRead,
+	http.MethodGet + " /api/v1/wallet/transfers":       apikey.ScopeWalletRead,
+	http.MethodPost + " /api/v1/trader/trades":         apikey.ScopeTradesWrite,
+	http.MethodPut + " /api/v1/trader/trades/:id":      apikey.ScopeTradesWrite,
+	http.MethodGet + " /api/v1/trader/trades":          apikey.ScopeTradesWrite,
+	http.MethodGet + " /api/v1/trader/trades/:id":      apikey.ScopeTradesWrite,
+	http.MethodPost + " /api/v1/trader/live":           apikey.ScopeTradesWrite,
+	http.MethodGet + " /api/v1/trader/live":            apikey.ScopeTradesWrite,
+}
+
 func SetupRouter(
 	cfg *config.Config,
 	authController *controllers.AuthController,
@@ -17,18 +48,57 @@ func SetupRouter(
 	tradeSignlCntrl *controllers.SignalController,
 	marketDataCnttl *controllers.MarketDataHandler,
 	subsController *controllers.TraderSubscriptionController,
+	tradeCtrl *controllers.TradeController,
+	candleCtrl *controllers.CandleController,
+	streamCtrl *controllers.StreamController,
+	apiKeyCtrl *controllers.APIKeyController,
+	idempotencyStore idempotency.Store,
+	sessions *session.Service,
+	twoFactor *twofactor.Service,
+	limiter *ratelimit.Limiter,
 ) *gin.Engine {
 	r := gin.Default()
+	idempotent := idempotency.Middleware(idempotencyStore, idempotency.Options{})
+	stepUp := twoFactor.RequireCode(authController.UserSvc.TwoFactorRequired)
+	verified := middleware.RequireVerifiedEmail(authController.AccountSvc.IsVerified)
+	authLimit := limiter.Group("auth", ratelimit.ByIP)
 
 	public := r.Group("/api/v1")
 	{
-		public.POST("/login", authController.Login)
+		public.POST("/login", authLimit, authController.Login)
+		public.POST("/auth/refresh", authLimit, authController.Refresh)
+		public.POST("/auth/2fa/verify", authLimit, authController.VerifyTwoFactor)
+		public.POST("/auth/2fa/login-enroll", authLimit, authController.EnrollTwoFactorAtLogin)
+		public.GET("/auth/verify-email", authLimit, authController.VerifyEmail)
+		public.POST("/auth/verify-email", authLimit, authController.VerifyEmail)
+		public.POST("/auth/verify-email/resend", authLimit, authController.ResendVerificationEmail)
+		public.POST("/auth/password/forgot", authLimit, authController.ForgotPassword)
+		public.POST("/auth/password/reset", authLimit, authController.ResetPassword)
+		public.GET("/market/candles", candleCtrl.GetCandles)
 
 	}
 
+	streamRoutes := r.Group("/api/v1/stream")
+	streamRoutes.Use(middleware.StreamAuthMiddleware(sessions))
+	{
+		streamRoutes.GET("/sse", streamCtrl.StreamSSE)
+		streamRoutes.GET("/ws", streamCtrl.StreamWebSocket)
+	}
+
 	protected := r.Group("/api/v1")
-	protected.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+	protected.Use(middleware.AuthMiddleware(sessions, apiKeyCtrl.Keys, apiKeyRoutes), limiter.Group("api", ratelimit.ByUser))
 	{
+		protected.POST("/auth/logout", authController.Logout)
+
+		protected.GET("/auth/2fa", authController.TwoFactorStatus)
+		protected.POST("/auth/2fa/enroll", authController.EnrollTwoFactor)
+		protected.POST("/auth/2fa/confirm", authController.ConfirmTwoFactor)
+		protected.POST("/auth/2fa/disable", authController.DisableTwoFactor)
+		protected.POST("/auth/2fa/recovery-codes", authController.RegenerateRecoveryCodes)
+
+		protected.GET("/api-keys", apiKeyCtrl.ListKeys)
+		protected.POST("/api-keys", stepUp, apiKeyCtrl.CreateKey)
+		protected.DELETE("/api-keys/:id", apiKeyCtrl.RevokeKey)
 
 		protected.POST("/market-", marketDataCnttl.CreateMarketData)
 
@@ -38,9 +108,13 @@ func SetupRouter(
 		protected.DELETE("/trader/profile", profileController.DeleteTraderProfile)
 
 		protected.GET("/wallet", walletCntrl.GetBalance)
-		protected.POST("/wallet/deposit", walletCntrl.Deposit)
-		protected.POST("/wallet/withdraw", walletCntrl.Withdraw)
+		protected.POST("/wallet/deposit", verified, idempotent, walletCntrl.Deposit)
+		protected.POST("/wallet/withdraw", stepUp, idempotent, walletCntrl.Withdraw)
 		protected.GET("/wallet/transactions", walletCntrl.TransactionHistory)
+		protected.POST("/wallet/transfers", stepUp, idempotent, walletCntrl.Transfer)
+		protected.GET("/wallet/transfers", walletCntrl.TransferHistory)
+		protected.GET("/wallet/payout-account", walletCntrl.GetPayoutAccount)
+		protected.PUT("/wallet/payout-account", stepUp, walletCntrl.SetPayoutAccount)
 
 		protected.GET("/trader/subscribers", subscriberController.ListSubscribers)
 		protected.GET("/trader/subscribers/:id", subscriberController.GetSubscriber)
@@ -48,11 +122,18 @@ func SetupRouter(
 		protected.POST("/trader/live", liveCtrl.PublishLiveTrade)
 		protected.GET("/trader/live", liveCtrl.GetActiveTrades)
 
+		protected.POST("/trader/trades", idempotent, tradeCtrl.OpenTrade)
+		protected.GET("/trader/trades", tradeCtrl.ListTrades)
+		protected.GET("/trader/trades/:id", tradeCtrl.GetTrade)
+		protected.PUT("/trader/trades/:id", tradeCtrl.UpdateTrade)
+
 		protected.POST("/signals", tradeSignlCntrl.CreateSignal)
 		protected.GET("/signals", tradeSignlCntrl.GetAllSignals)
 		protected.GET("/signals/:id", tradeSignlCntrl.GetSignalByID)
 		protected.PUT("/signals/:id", tradeSignlCntrl.UpdateSignal)
 		protected.DELETE("/signals/:id", tradeSignlCntrl.DeleteSignal)
+		protected.POST("/signals/:id/cancel", tradeSignlCntrl.CancelSignal)
+		protected.GET("/signals/:id/history", tradeSignlCntrl.GetSignalHistory)
 
 		protected.POST("/plans", subsController.CreateTraderSubscriptionPlan)
 		protected.GET("/plans", subsController.GetMyTraderSubscriptionPlans)
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/fathimasithara01/tradeverse/internal/trader/repository"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"gorm.io/gorm"
+)
+
+// PaperExchangeService is an in-process matching engine that works trades
+// against the prices in the market_data table instead of a live broker.
+type PaperExchangeService interface {
+	MatchTrades(ctx context.Context) error
+}
+
+type paperExchangeService struct {
+	repo   repository.TradeRepository
+	db     *gorm.DB
+	trades *tradeService
+}
+
+func NewPaperExchangeService(repo repository.TradeRepository, db *gorm.DB) PaperExchangeService {
+	return &paperExchangeService{
+		repo:   repo,
+		db:     db,
+		trades: &tradeService{repo: repo, db: db},
+	}
+}
+
+// MatchTrades fills PENDING LIMIT/STOP orders whose trigger has been reached and
+// closes OPEN trades that hit liquidation, stop loss or take profit. Each trade
+// is handled in its own transaction so one failure does not block the rest.
+// Copy trades are not matched here: a follower position only exits when the
+// copy engine mirrors the close of its master trade.
+func (s *paperExchangeService) MatchTrades(ctx context.Context) error {
+	trades, err := s.repo.ListWorkingTrades(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load working trades: %w", err)
+	}
+
+	prices := make(map[string]float64)
+	for i := range trades {
+		trade := &trades[i]
+
+		price, ok := prices[trade.Symbol]
+		if !ok {
+			price, err = s.repo.GetMarketPrice(ctx, trade.Symbol)
+			if err != nil {
+				log.Printf("Paper exchange: no price for %s: %v", trade.Symbol, err)
+			}
+			prices[trade.Symbol] = price
+		}
+		if price <= 0 {
+			continue
+		}
+
+		if err := s.matchTrade(ctx, trade.TraderID, trade.ID, price); err != nil {
+			log.Printf("Paper exchange: failed to process trade %d: %v", trade.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *paperExchangeService) matchTrade(ctx context.Context, traderID, tradeID uint, price float64) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		trade, err := s.repo.GetTradeForUpdate(tx, traderID, tradeID)
+		if err != nil || trade == nil {
+			return err
+		}
+
+		switch trade.Status {
+		case models.TradeStatusPending:
+			if !trade.FillTriggered(price) {
+				return nil
+			}
+			fill := trade.TriggeredFillPrice(price)
+			err := s.trades.fillOrder(tx, trade, fill)
+			if errors.Is(err, ErrInsufficientMargin) {
+				// A stop that gapped past what the wallet can margin is cancelled.
+				log.Printf("Paper exchange: cancelling %s %s order %d, no margin to fill at %.4f", trade.TradeType, trade.Side, trade.ID, fill)
+				return s.trades.cancelTrade(tx, trade)
+			}
+			if err != nil {
+				return err
+			}
+			log.Printf("Paper exchange: filled %s %s order %d at %.4f", trade.TradeType, trade.Side, trade.ID, fill)
+
+		case models.TradeStatusOpen:
+			closePrice, reason, hit := trade.ExitTriggered(price)
+			if !hit {
+				return nil
+			}
+			if err := s.trades.settleClose(tx, trade, closePrice, reason); err != nil {
+				return err
+			}
+			log.Printf("Paper exchange: closed trade %d at %.4f (%s)", trade.ID, closePrice, reason)
+		}
+		return nil
+	})
+}
@@ -2,12 +2,16 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	adminService "github.com/fathimasithara01/tradeverse/internal/admin/service"
 	"github.com/fathimasithara01/tradeverse/internal/trader/repository"
+	"github.com/fathimasithara01/tradeverse/pkg/fx"
+	"github.com/fathimasithara01/tradeverse/pkg/ledger"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
 	"gorm.io/gorm"
 )
 
@@ -54,21 +58,21 @@ func (s *TraderSubscriptionService) CreateTraderSubscriptionPlan(ctx context.Con
 	}
 	adminCommissionPercentage := commissionSetting.CommissionPercentage
 
-	traderShareAmount := input.Price * (1 - (adminCommissionPercentage / 100.0))
-	if traderShareAmount < 0 {
-		traderShareAmount = 0
-	}
+	_, traderShareAmount := input.Price.Split(adminCommissionPercentage, input.Currency)
+	traderShareAmount = money.Max(traderShareAmount, 0)
 
 	plan := &models.TraderSignalSubscriptionPlan{
-		TraderID:        traderID,
-		Name:            input.Name,
-		Description:     input.Description,
-		Price:           input.Price,
-		Currency:        input.Currency,
-		DurationDays:    input.DurationDays,
-		IsActive:        true,
-		AdminCommission: adminCommissionPercentage,
-		TraderShare:     traderShareAmount,
+		TraderID:         traderID,
+		Name:             input.Name,
+		Description:      input.Description,
+		Price:            input.Price,
+		Currency:         input.Currency,
+		DurationDays:     input.DurationDays,
+		IsActive:         true,
+		AdminCommission:  adminCommissionPercentage,
+		TraderShare:      traderShareAmount,
+		RefundPolicy:     input.RefundPolicy.OrNone(),
+		RefundWindowDays: input.RefundWindowDays,
 	}
 
 	return s.repo.CreateTraderSubscriptionPlan(ctx, plan)
@@ -102,10 +106,8 @@ func (s *TraderSubscriptionService) UpdateTraderSubscriptionPlan(ctx context.Con
 	}
 	adminCommissionPercentage := commissionSetting.CommissionPercentage
 
-	traderShareAmount := input.Price * (1 - (adminCommissionPercentage / 100.0))
-	if traderShareAmount < 0 {
-		traderShareAmount = 0
-	}
+	_, traderShareAmount := input.Price.Split(adminCommissionPercentage, input.Currency)
+	traderShareAmount = money.Max(traderShareAmount, 0)
 
 	existingPlan.Name = input.Name
 	existingPlan.Description = input.Description
@@ -114,6 +116,8 @@ func (s *TraderSubscriptionService) UpdateTraderSubscriptionPlan(ctx context.Con
 	existingPlan.DurationDays = input.DurationDays
 	existingPlan.AdminCommission = adminCommissionPercentage
 	existingPlan.TraderShare = traderShareAmount
+	existingPlan.RefundPolicy = input.RefundPolicy.OrNone()
+	existingPlan.RefundWindowDays = input.RefundWindowDays
 
 	if err := s.repo.UpdateTraderSubscriptionPlan(ctx, existingPlan); err != nil {
 		return nil, err
@@ -158,107 +162,60 @@ func (s *TraderSubscriptionService) SubscribeToTraderPlan(ctx context.Context, c
 		}
 	}()
 
-	customerWallet, err := s.repo.GetUserWallet(ctx, customerID)
+	charge, err := fx.NewCharge(tx, customerID, plan.Price, plan.Currency)
 	if err != nil {
 		tx.Rollback()
-		return fmt.Errorf("failed to get customer wallet: %w", err)
-	}
-
-	if customerWallet.Balance < plan.Price {
-		tx.Rollback()
-		return fmt.Errorf("insufficient funds in wallet. current balance: %.2f, required: %.2f", customerWallet.Balance, plan.Price)
+		return fmt.Errorf("failed to price subscription: %w", err)
 	}
 
-	if err := s.repo.UpdateWalletBalance(ctx, customerID, -plan.Price, tx); err != nil {
+	if !charge.Covered() {
 		tx.Rollback()
-		return fmt.Errorf("failed to deduct funds from customer wallet: %w", err)
+		return fmt.Errorf("insufficient funds in wallet. current balance: %s %s, required: %s", charge.Available.Format(charge.Currency), charge.Currency, charge)
 	}
-	customerBalanceAfter := customerWallet.Balance - plan.Price
-
-	adminCommissionAmount := plan.Price * (plan.AdminCommission / 100.0)
-	traderReceiveAmount := plan.Price - adminCommissionAmount
 
-	adminWallet, err := s.repo.GetAdminWallet(ctx)
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to get admin wallet: %w", err)
-	}
-	if err := s.repo.UpdateWalletBalance(ctx, adminWallet.UserID, adminCommissionAmount, tx); err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to credit admin commission: %w", err)
-	}
-	adminBalanceAfter := adminWallet.Balance + adminCommissionAmount
-
-	traderWallet, err := s.repo.GetUserWallet(ctx, traderID)
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to get trader wallet: %w", err)
-	}
-	if err := s.repo.UpdateWalletBalance(ctx, traderID, traderReceiveAmount, tx); err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to credit trader wallet: %w", err)
-	}
-	traderBalanceAfter := traderWallet.Balance + traderReceiveAmount
+	adminCommissionAmount, traderReceiveAmount := plan.Price.Split(plan.AdminCommission, plan.Currency)
 
 	customerTx := models.WalletTransaction{
-		WalletID:        customerWallet.ID,
-		UserID:          customerID,
 		Type:            models.TxTypeSignalPayment,
 		TransactionType: models.TxTypeDebit,
 		Name:            "Debit for Trader Subscription",
-		Amount:          plan.Price,
-		Currency:        plan.Currency,
-		Status:          models.TxStatusSuccess,
 		Description:     fmt.Sprintf("Subscription to trader %d's plan '%s'", traderID, plan.Name),
-		BalanceBefore:   customerWallet.Balance,
-		BalanceAfter:    customerBalanceAfter,
 		ReferenceID:     fmt.Sprintf("TRADER_SUB_%d_PLAN_%d", customerID, plan.ID),
 		TransactionID:   fmt.Sprintf("TRADER_SUB_CUST_%d_%d_%d", customerID, plan.ID, time.Now().UnixNano()),
 	}
-
-	if err := s.repo.CreateWalletTransaction(ctx, &customerTx, tx); err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to record customer transaction for trader subscription: %w", err)
-	}
-
 	adminCommissionTx := models.WalletTransaction{
-		WalletID:        adminWallet.ID,
-		UserID:          adminWallet.UserID,
 		Type:            models.TxTypeCommission,
 		TransactionType: models.TxTypeCredit,
 		Name:            "Credit from Trader Plan Commission",
-		Amount:          adminCommissionAmount,
 		Currency:        plan.Currency,
-		Status:          models.TxStatusSuccess,
 		Description:     fmt.Sprintf("Commission from customer %d subscribing to trader %d's plan '%s'", customerID, traderID, plan.Name),
-		BalanceBefore:   adminWallet.Balance,
-		BalanceAfter:    adminBalanceAfter,
 		ReferenceID:     fmt.Sprintf("TRADER_SUB_ADMIN_COMM_%d_PLAN_%d", customerID, plan.ID),
 		TransactionID:   fmt.Sprintf("TRADER_SUB_ADM_%d_%d_%d", customerID, plan.ID, time.Now().UnixNano()),
 	}
-	if err := s.repo.CreateWalletTransaction(ctx, &adminCommissionTx, tx); err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to record admin commission transaction: %w", err)
-	}
-
 	traderCreditTx := models.WalletTransaction{
-		WalletID:        traderWallet.ID,
-		UserID:          traderID,
 		Type:            models.TxTypeSignalPayment,
 		TransactionType: models.TxTypeCredit,
 		Name:            "Credit from Customer Subscription",
-		Amount:          traderReceiveAmount,
 		Currency:        plan.Currency,
-		Status:          models.TxStatusSuccess,
 		Description:     fmt.Sprintf("Credit from customer %d subscribing to plan '%s'", customerID, plan.Name),
-		BalanceBefore:   traderWallet.Balance,
-		BalanceAfter:    traderBalanceAfter,
 		ReferenceID:     fmt.Sprintf("TRADER_SUB_TRADER_CREDIT_%d_PLAN_%d", customerID, plan.ID),
 		TransactionID:   fmt.Sprintf("TRADER_SUB_TRD_%d_%d_%d", customerID, plan.ID, time.Now().UnixNano()),
 	}
-	if err := s.repo.CreateWalletTransaction(ctx, &traderCreditTx, tx); err != nil {
+
+	if _, err := ledger.Post(tx, ledger.Entry{
+		Type:      models.TxTypeSignalPayment,
+		Reference: customerTx.ReferenceID,
+		Currency:  plan.Currency,
+		Legs: append(charge.Legs(customerID, &customerTx),
+			ledger.Credit(ledger.PlatformCommissionIn(plan.Currency), adminCommissionAmount, &adminCommissionTx),
+			ledger.Credit(ledger.WalletIn(traderID, plan.Currency), traderReceiveAmount, &traderCreditTx),
+		),
+	}); err != nil {
 		tx.Rollback()
-		return fmt.Errorf("failed to record trader credit transaction: %w", err)
+		if errors.Is(err, ledger.ErrInsufficientFunds) {
+			return fmt.Errorf("insufficient funds in wallet. current balance: %s %s, required: %s", charge.Available.Format(charge.Currency), charge.Currency, charge)
+		}
+		return fmt.Errorf("failed to post trader subscription payment: %w", err)
 	}
 
 	startDate := time.Now()
@@ -275,8 +232,10 @@ func (s *TraderSubscriptionService) SubscribeToTraderPlan(ctx context.Context, c
 		// PaymentStatus:            models.TxStatusSuccess,
 		AmountPaid:             plan.Price,
 		TraderShare:            traderReceiveAmount,
-		AdminCommission:        plan.AdminCommission,
+		AdminCommission:        adminCommissionAmount,
 		TransactionReferenceID: customerTx.TransactionID,
+		PaidCurrency:           charge.Currency,
+		PaidFXRate:             charge.Rate(),
 	}
 
 	if err := s.repo.CreateCustomerTraderSubscription(ctx, customerTraderSubscription); err != nil {
@@ -331,72 +290,48 @@ func (s *TraderSubscriptionService) SubscribeToTraderUpgradePlan(ctx context.Con
 		}
 	}()
 
-	userWallet, err := s.repo.GetUserWallet(ctx, userID)
+	charge, err := fx.NewCharge(tx, userID, plan.Price, plan.Currency)
 	if err != nil {
 		tx.Rollback()
-		return fmt.Errorf("failed to get user wallet: %w", err)
+		return fmt.Errorf("failed to price subscription: %w", err)
 	}
 
-	if userWallet.Balance < plan.Price {
+	if !charge.Covered() {
 		tx.Rollback()
-		return fmt.Errorf("insufficient funds in wallet. current balance: %.2f, required: %.2f", userWallet.Balance, plan.Price)
+		return fmt.Errorf("insufficient funds in wallet. current balance: %s %s, required: %s", charge.Available.Format(charge.Currency), charge.Currency, charge)
 	}
 
-	if err := s.repo.UpdateWalletBalance(ctx, userID, -plan.Price, tx); err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to deduct funds from user wallet: %w", err)
-	}
-	userBalanceAfter := userWallet.Balance - plan.Price
-
-	adminWallet, err := s.repo.GetAdminWallet(ctx)
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to get admin wallet: %w", err)
-	}
-	if err := s.repo.UpdateWalletBalance(ctx, adminWallet.UserID, plan.Price, tx); err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to credit admin wallet: %w", err)
-	}
-	adminBalanceAfter := adminWallet.Balance + plan.Price
-
 	userTx := models.WalletTransaction{
-		WalletID:        userWallet.ID,
-		UserID:          userID,
 		Type:            models.TxTypeSubscription,
 		TransactionType: models.TxTypeDebit,
 		Name:            "Debit for Trader Upgrade Subscription",
-		Amount:          plan.Price,
-		Currency:        plan.Currency,
-		Status:          models.TxStatusSuccess,
 		Description:     fmt.Sprintf("Subscription to admin plan '%s' to become a trader", plan.Name),
-		BalanceBefore:   userWallet.Balance,
-		BalanceAfter:    userBalanceAfter,
 		ReferenceID:     fmt.Sprintf("ADMIN_SUB_%d_PLAN_%d", userID, plan.ID),
 		TransactionID:   fmt.Sprintf("ADMIN_SUB_USER_%d_%d_%d", userID, plan.ID, time.Now().UnixNano()),
 	}
-	if err := s.repo.CreateWalletTransaction(ctx, &userTx, tx); err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to record user transaction for admin subscription: %w", err)
-	}
-
 	adminTx := models.WalletTransaction{
-		WalletID:        adminWallet.ID,
-		UserID:          adminWallet.UserID,
 		Type:            models.TxTypeSubscription,
 		TransactionType: models.TxTypeCredit,
 		Name:            "Credit from Trader Upgrade Subscription",
-		Amount:          plan.Price,
 		Currency:        plan.Currency,
-		Status:          models.TxStatusSuccess,
 		Description:     fmt.Sprintf("Credit from user %d subscribing to admin plan '%s'", userID, plan.Name),
-		BalanceBefore:   adminWallet.Balance,
-		BalanceAfter:    adminBalanceAfter,
 		ReferenceID:     fmt.Sprintf("ADMIN_SUB_CREDIT_%d_PLAN_%d", userID, plan.ID),
 		TransactionID:   fmt.Sprintf("ADMIN_SUB_ADMIN_%d_%d_%d", userID, plan.ID, time.Now().UnixNano()),
 	}
-	if err := s.repo.CreateWalletTransaction(ctx, &adminTx, tx); err != nil {
+
+	if _, err := ledger.Post(tx, ledger.Entry{
+		Type:      models.TxTypeSubscription,
+		Reference: userTx.ReferenceID,
+		Currency:  plan.Currency,
+		Legs: append(charge.Legs(userID, &userTx),
+			ledger.Credit(ledger.PlatformCommissionIn(plan.Currency), plan.Price, &adminTx),
+		),
+	}); err != nil {
 		tx.Rollback()
-		return fmt.Errorf("failed to record admin transaction for admin subscription: %w", err)
+		if errors.Is(err, ledger.ErrInsufficientFunds) {
+			return fmt.Errorf("insufficient funds in wallet. current balance: %s %s, required: %s", charge.Available.Format(charge.Currency), charge.Currency, charge)
+		}
+		return fmt.Errorf("failed to post upgrade subscription payment: %w", err)
 	}
 
 	startDate := time.Now()
@@ -0,0 +1,399 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/internal/trader/repository"
+	"github.com/fathimasithara01/tradeverse/pkg/ledger"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"gorm.io/gorm"
+)
+
+const (
+	tradeFeeRate = 0.001
+	maxLeverage  = 125
+)
+
+var (
+	ErrTradeNotFound         = errors.New("trade not found")
+	ErrInvalidTradeInput     = errors.New("invalid trade input")
+	ErrInvalidTradeState     = errors.New("trade cannot be changed in its current status")
+	ErrInsufficientMargin    = errors.New("insufficient wallet balance for trade margin")
+	ErrNoMarketPrice         = errors.New("no market price is available for this symbol")
+	ErrClosePriceUnavailable = errors.New("no market price is available to close the trade at")
+)
+
+var tradeTxNames = map[models.TransactionType]string{
+	models.TxTypeTradeOpeningFunds: "Trade Opening Funds",
+	models.TxTypeTradeClosingFunds: "Trade Closing Funds",
+	models.TxTypeTradeProfit:       "Trade Profit",
+	models.TxTypeTradeLoss:         "Trade Loss",
+}
+
+type TradeService interface {
+	OpenTrade(ctx context.Context, traderID uint, input models.TradeInput) (*models.Trade, error)
+	UpdateTrade(ctx context.Context, traderID, tradeID uint, input models.TradeUpdateInput) (*models.Trade, error)
+	GetTrade(ctx context.Context, traderID, tradeID uint) (*models.Trade, error)
+	ListTrades(ctx context.Context, traderID uint, status string, page, limit int) (*models.TradeListResponse, error)
+}
+
+type tradeService struct {
+	repo repository.TradeRepository
+	db   *gorm.DB
+}
+
+func NewTradeService(repo repository.TradeRepository, db *gorm.DB) TradeService {
+	return &tradeService{repo: repo, db: db}
+}
+
+// OpenTrade fills MARKET orders immediately at the current market price; any
+// entry price sent with them is ignored, and they are refused when there is no
+// quote. LIMIT and STOP orders are stored as PENDING. In both cases the margin
+// is taken from the wallet up front.
+func (s *tradeService) OpenTrade(ctx context.Context, traderID uint, input models.TradeInput) (*models.Trade, error) {
+	trade := &models.Trade{
+		TraderID:        traderID,
+		Symbol:          strings.ToUpper(strings.TrimSpace(input.Symbol)),
+		TradeType:       models.TradeType(strings.ToUpper(string(input.TradeType))),
+		Side:            models.TradeSide(strings.ToUpper(string(input.Side))),
+		EntryPrice:      input.EntryPrice,
+		Quantity:        input.Quantity,
+		Leverage:        input.Leverage,
+		StopLossPrice:   input.StopLossPrice,
+		TakeProfitPrice: input.TakeProfitPrice,
+		Status:          models.TradeStatusPending,
+	}
+	if trade.Leverage == 0 {
+		trade.Leverage = 1
+	}
+
+	if err := validateTradeInput(trade); err != nil {
+		return nil, err
+	}
+
+	if trade.TradeType == models.TradeTypeMarket {
+		price, err := s.repo.GetMarketPrice(ctx, trade.Symbol)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNoMarketPrice, err)
+		}
+		trade.EntryPrice = price
+		trade.ExecutedPrice = &price
+		trade.Status = models.TradeStatusOpen
+		trade.OpenedAt = models.TimePtr(time.Now())
+	}
+
+	if err := validateStops(trade.Side, trade.FillPrice(), trade.StopLossPrice, trade.TakeProfitPrice); err != nil {
+		return nil, err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		wallet, err := s.repo.GetWalletForUpdate(tx, traderID)
+		if err != nil {
+			return err
+		}
+
+		margin := money.FromFloat(trade.MarginRequired())
+		if wallet.Balance < margin {
+			return ErrInsufficientMargin
+		}
+
+		if err := s.repo.CreateTrade(tx, trade); err != nil {
+			return fmt.Errorf("failed to create trade: %w", err)
+		}
+
+		return s.moveFunds(tx, wallet, trade, models.TxTypeTradeOpeningFunds, models.TxTypeDebit, margin,
+			fmt.Sprintf("Margin for %s %s %s trade", trade.TradeType, trade.Side, trade.Symbol))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return trade, nil
+}
+
+// UpdateTrade applies SL/TP changes and, when an Action is given, closes an OPEN
+// trade or cancels a PENDING one.
+func (s *tradeService) UpdateTrade(ctx context.Context, traderID, tradeID uint, input models.TradeUpdateInput) (*models.Trade, error) {
+	var trade *models.Trade
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		trade, err = s.repo.GetTradeForUpdate(tx, traderID, tradeID)
+		if err != nil {
+			return err
+		}
+		if trade == nil {
+			return ErrTradeNotFound
+		}
+
+		switch strings.ToUpper(input.Action) {
+		case "CLOSE":
+			return s.closeTrade(ctx, tx, trade)
+		case "CANCEL":
+			return s.cancelTrade(tx, trade)
+		}
+
+		if input.StopLossPrice == nil && input.TakeProfitPrice == nil {
+			return fmt.Errorf("%w: nothing to update", ErrInvalidTradeInput)
+		}
+		if trade.Status != models.TradeStatusOpen && trade.Status != models.TradeStatusPending {
+			return ErrInvalidTradeState
+		}
+
+		stopLoss, takeProfit := trade.StopLossPrice, trade.TakeProfitPrice
+		if input.StopLossPrice != nil {
+			stopLoss = input.StopLossPrice
+		}
+		if input.TakeProfitPrice != nil {
+			takeProfit = input.TakeProfitPrice
+		}
+		if err := validateStops(trade.Side, trade.FillPrice(), stopLoss, takeProfit); err != nil {
+			return err
+		}
+
+		trade.StopLossPrice = stopLoss
+		trade.TakeProfitPrice = takeProfit
+		return s.repo.UpdateTrade(tx, trade)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return trade, nil
+}
+
+func (s *tradeService) GetTrade(ctx context.Context, traderID, tradeID uint) (*models.Trade, error) {
+	trade, err := s.repo.GetTradeByID(ctx, traderID, tradeID)
+	if err != nil {
+		return nil, err
+	}
+	if trade == nil {
+		return nil, ErrTradeNotFound
+	}
+	return trade, nil
+}
+
+func (s *tradeService) ListTrades(ctx context.Context, traderID uint, status string, page, limit int) (*models.TradeListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	trades, total, err := s.repo.ListTrades(ctx, traderID, status, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TradeListResponse{Trades: trades, Total: total, Page: page, Limit: limit}, nil
+}
+
+// closeTrade closes an OPEN trade at the current market price. Clients cannot
+// name the price, since the profit is paid out of the market.
+func (s *tradeService) closeTrade(ctx context.Context, tx *gorm.DB, trade *models.Trade) error {
+	if trade.Status != models.TradeStatusOpen {
+		return ErrInvalidTradeState
+	}
+
+	price, err := s.repo.GetMarketPrice(ctx, trade.Symbol)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrClosePriceUnavailable, err)
+	}
+
+	return s.settleClose(tx, trade, price, models.CloseReasonManual)
+}
+
+// settleClose marks the trade CLOSED at price. The posted margin is returned
+// first and the net result (PnL minus fees) is then booked as a profit or a
+// loss. A position can never lose more than the margin posted for it.
+func (s *tradeService) settleClose(tx *gorm.DB, trade *models.Trade, price float64, reason models.CloseReason) error {
+	margin := money.FromFloat(trade.MarginRequired())
+	fees := money.FromFloat(tradeFeeRate * (trade.FillPrice() + price) * trade.Quantity)
+	net := money.FromFloat(trade.CalculatePnL(price)) - fees
+
+	// The stored figures are exactly the amounts settled below.
+	pnl := net.Float64()
+	trade.ClosePrice = &price
+	trade.ClosedAt = models.TimePtr(time.Now())
+	trade.Fees = fees.Float64()
+	trade.Pnl = &pnl
+	trade.Status = models.TradeStatusClosed
+	trade.CloseReason = reason
+	if err := s.repo.UpdateTrade(tx, trade); err != nil {
+		return fmt.Errorf("failed to close trade: %w", err)
+	}
+
+	wallet, err := s.repo.GetWalletForUpdate(tx, trade.TraderID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.moveFunds(tx, wallet, trade, models.TxTypeTradeClosingFunds, models.TxTypeCredit, margin,
+		fmt.Sprintf("Margin released for %s trade #%d", trade.Symbol, trade.ID)); err != nil {
+		return err
+	}
+
+	switch {
+	case net.IsPositive():
+		return s.moveFunds(tx, wallet, trade, models.TxTypeTradeProfit, models.TxTypeCredit, net,
+			fmt.Sprintf("Profit on %s trade #%d (fees %s)", trade.Symbol, trade.ID, fees))
+	case net.IsNegative():
+		return s.moveFunds(tx, wallet, trade, models.TxTypeTradeLoss, models.TxTypeDebit, money.Min(net.Neg(), margin),
+			fmt.Sprintf("Loss on %s trade #%d (fees %s)", trade.Symbol, trade.ID, fees))
+	}
+	return nil
+}
+
+// fillOrder opens a PENDING order at fill. The margin reserved when the order
+// was placed was worked out at its own price, so the difference to the margin
+// at the fill price is taken from or returned to the wallet.
+func (s *tradeService) fillOrder(tx *gorm.DB, trade *models.Trade, fill float64) error {
+	reserved := money.FromFloat(trade.MarginRequired())
+	trade.ExecutedPrice = &fill
+	required := money.FromFloat(trade.MarginRequired())
+
+	if required != reserved {
+		wallet, err := s.repo.GetWalletForUpdate(tx, trade.TraderID)
+		if err != nil {
+			return err
+		}
+		if required > reserved {
+			if wallet.Balance < required-reserved {
+				trade.ExecutedPrice = nil
+				return ErrInsufficientMargin
+			}
+			err = s.moveFunds(tx, wallet, trade, models.TxTypeTradeOpeningFunds, models.TxTypeDebit, required-reserved,
+				fmt.Sprintf("Additional margin for %s order #%d filled at %.4f", trade.Symbol, trade.ID, fill))
+		} else {
+			err = s.moveFunds(tx, wallet, trade, models.TxTypeTradeClosingFunds, models.TxTypeCredit, reserved-required,
+				fmt.Sprintf("Margin released for %s order #%d filled at %.4f", trade.Symbol, trade.ID, fill))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	trade.Status = models.TradeStatusOpen
+	trade.OpenedAt = models.TimePtr(time.Now())
+	if err := s.repo.UpdateTrade(tx, trade); err != nil {
+		return fmt.Errorf("failed to fill trade: %w", err)
+	}
+	return nil
+}
+
+func (s *tradeService) cancelTrade(tx *gorm.DB, trade *models.Trade) error {
+	if trade.Status != models.TradeStatusPending {
+		return ErrInvalidTradeState
+	}
+
+	trade.Status = models.TradeStatusCancelled
+	trade.ClosedAt = models.TimePtr(time.Now())
+	if err := s.repo.UpdateTrade(tx, trade); err != nil {
+		return fmt.Errorf("failed to cancel trade: %w", err)
+	}
+
+	wallet, err := s.repo.GetWalletForUpdate(tx, trade.TraderID)
+	if err != nil {
+		return err
+	}
+	return s.moveFunds(tx, wallet, trade, models.TxTypeTradeClosingFunds, models.TxTypeCredit, money.FromFloat(trade.MarginRequired()),
+		fmt.Sprintf("Margin refunded for cancelled %s order #%d", trade.Symbol, trade.ID))
+}
+
+// moveFunds posts one leg of a trade against the wallet. Margin moves between
+// the wallet and the user's trade margin account; realised profit and loss
+// settle against the market.
+func (s *tradeService) moveFunds(tx *gorm.DB, wallet *models.Wallet, trade *models.Trade, txType, direction models.TransactionType, amount money.Amount, description string) error {
+	if !amount.IsPositive() {
+		return nil
+	}
+
+	counterparty := ledger.MarketSettlement()
+	if txType == models.TxTypeTradeOpeningFunds || txType == models.TxTypeTradeClosingFunds {
+		counterparty = ledger.TradeMargin(wallet.UserID)
+	}
+
+	walletTx := &models.WalletTransaction{
+		Type:            txType,
+		TransactionType: direction,
+		Name:            tradeTxNames[txType],
+		TransactionID:   fmt.Sprintf("%s_%d_%d", txType, trade.ID, time.Now().UnixNano()),
+		TradeID:         &trade.ID,
+	}
+	legs := []ledger.Leg{
+		ledger.Credit(ledger.Wallet(wallet.UserID), amount, walletTx),
+		ledger.Debit(counterparty, amount, nil),
+	}
+	if direction == models.TxTypeDebit {
+		legs = []ledger.Leg{
+			ledger.Debit(ledger.Wallet(wallet.UserID), amount, walletTx),
+			ledger.Credit(counterparty, amount, nil),
+		}
+	}
+
+	if _, err := ledger.Post(tx, ledger.Entry{
+		Type:        txType,
+		Reference:   fmt.Sprintf("TRADE_%d", trade.ID),
+		Description: description,
+		Currency:    wallet.Currency,
+		Legs:        legs,
+	}); err != nil {
+		if errors.Is(err, ledger.ErrInsufficientFunds) {
+			return ErrInsufficientMargin
+		}
+		return fmt.Errorf("failed to record %s transaction: %w", txType, err)
+	}
+	return nil
+}
+
+func validateTradeInput(trade *models.Trade) error {
+	switch trade.TradeType {
+	case models.TradeTypeMarket:
+	case models.TradeTypeLimit, models.TradeTypeStop:
+		if trade.EntryPrice <= 0 {
+			return fmt.Errorf("%w: entry_price is required for %s orders", ErrInvalidTradeInput, trade.TradeType)
+		}
+	default:
+		return fmt.Errorf("%w: trade_type must be MARKET, LIMIT or STOP", ErrInvalidTradeInput)
+	}
+
+	if trade.Side != models.TradeSideBuy && trade.Side != models.TradeSideSell {
+		return fmt.Errorf("%w: side must be BUY or SELL", ErrInvalidTradeInput)
+	}
+	if trade.Symbol == "" {
+		return fmt.Errorf("%w: symbol is required", ErrInvalidTradeInput)
+	}
+	if trade.Quantity <= 0 {
+		return fmt.Errorf("%w: quantity must be greater than zero", ErrInvalidTradeInput)
+	}
+	if trade.Leverage > maxLeverage {
+		return fmt.Errorf("%w: leverage cannot exceed %d", ErrInvalidTradeInput, maxLeverage)
+	}
+	return nil
+}
+
+// validateStops checks that SL/TP sit on the correct side of the reference price.
+func validateStops(side models.TradeSide, price float64, stopLoss, takeProfit *float64) error {
+	if price <= 0 {
+		return nil
+	}
+	if side == models.TradeSideBuy {
+		if stopLoss != nil && *stopLoss >= price {
+			return fmt.Errorf("%w: stop loss must be below the entry price for BUY trades", ErrInvalidTradeInput)
+		}
+		if takeProfit != nil && *takeProfit <= price {
+			return fmt.Errorf("%w: take profit must be above the entry price for BUY trades", ErrInvalidTradeInput)
+		}
+		return nil
+	}
+	if stopLoss != nil && *stopLoss <= price {
+		return fmt.Errorf("%w: stop loss must be above the entry price for SELL trades", ErrInvalidTradeInput)
+	}
+	if takeProfit != nil && *takeProfit >= price {
+		return fmt.Errorf("%w: take profit must be below the entry price for SELL trades", ErrInvalidTradeInput)
+	}
+	return nil
+}
@@ -2,12 +2,16 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/fathimasithara01/tradeverse/internal/trader/repository"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/stream"
+	"gorm.io/gorm"
 )
 
 type ISignalService interface {
@@ -18,14 +22,22 @@ type ISignalService interface {
 	GetSignalByID(ctx context.Context, id uint) (*models.Signal, error)
 	UpdateSignal(ctx context.Context, updated *models.Signal) (*models.Signal, error)
 	DeleteSignal(ctx context.Context, id uint) error
+	CancelSignal(ctx context.Context, traderID, signalID uint) (*models.Signal, error)
+	GetSignalHistory(ctx context.Context, signalID uint) ([]models.SignalStatusHistory, error)
 }
 
+var (
+	ErrSignalNotFound = errors.New("signal not found")
+	ErrInvalidSignal  = errors.New("invalid signal")
+)
+
 type SignalService struct {
 	repo repository.ISignalRepository
+	hub  *stream.Hub
 }
 
-func NewSignalService(repo repository.ISignalRepository) ISignalService {
-	return &SignalService{repo: repo}
+func NewSignalService(repo repository.ISignalRepository, hub *stream.Hub) ISignalService {
+	return &SignalService{repo: repo, hub: hub}
 }
 
 func (s *SignalService) CreateSignal(ctx context.Context, signal *models.Signal) (*models.Signal, error) {
@@ -34,10 +46,24 @@ func (s *SignalService) CreateSignal(ctx context.Context, signal *models.Signal)
 	} else {
 		signal.Symbol = strings.ToUpper(signal.Symbol)
 	}
+	if err := signal.NormalizeDirection(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignal, err)
+	}
+	if err := signal.PrepareTargets(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignal, err)
+	}
+	if err := signal.ValidateLevels(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignal, err)
+	}
 
-	signal.Status = "Pending"
+	signal.Status = models.SignalStatusPending
 
-	return s.repo.CreateSignal(ctx, signal)
+	created, err := s.repo.CreateSignal(ctx, signal)
+	if err != nil {
+		return nil, err
+	}
+	s.hub.PublishSignalCreated(created.TraderID, *created)
+	return created, nil
 }
 
 func (s *SignalService) GetAllSignals(ctx context.Context) ([]models.Signal, error) {
@@ -49,29 +75,7 @@ func (s *SignalService) UpdatePendingSignalsCurrentPrice(ctx context.Context) er
 	if err != nil {
 		return err
 	}
-
-	for _, signal := range pendingSignals {
-		md, err := s.repo.GetMarketDataBySymbol(ctx, signal.Symbol)
-		if err != nil || md == nil {
-			log.Printf("No market data for %s", signal.Symbol)
-			continue
-		}
-
-		if md.CurrentPrice >= signal.EntryPrice {
-			err := s.repo.UpdateSignalStatus(ctx, signal.ID, "Active")
-			if err != nil {
-				log.Printf("Failed to activate signal %d: %v", signal.ID, err)
-			} else {
-				log.Printf("Signal %d is now Active", signal.ID)
-			}
-		}
-
-		err = s.repo.UpdateSignalCurrentPrice(ctx, signal.ID, md.CurrentPrice)
-		if err != nil {
-			log.Printf("Failed to update current price for signal %d: %v", signal.ID, err)
-		}
-	}
-
+	s.evaluateSignals(ctx, pendingSignals)
 	return nil
 }
 
@@ -80,47 +84,157 @@ func (s *SignalService) UpdateActiveSignalStatuses(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	s.evaluateSignals(ctx, activeSignals)
+	return nil
+}
 
-	for _, signal := range activeSignals {
+// evaluateSignals refreshes each signal's current price and applies whatever
+// transition the state machine calls for at that price.
+func (s *SignalService) evaluateSignals(ctx context.Context, signals []models.Signal) {
+	now := time.Now()
+	for _, signal := range signals {
+		var price float64
 		md, err := s.repo.GetMarketDataBySymbol(ctx, signal.Symbol)
 		if err != nil || md == nil {
-			continue
+			log.Printf("No market data for %s", signal.Symbol)
+		} else {
+			price = md.CurrentPrice
+			if err := s.repo.UpdateSignalCurrentPrice(ctx, signal.ID, price); err != nil {
+				log.Printf("Failed to update current price for signal %d: %v", signal.ID, err)
+			}
 		}
 
-		_ = s.repo.UpdateSignalCurrentPrice(ctx, signal.ID, md.CurrentPrice)
-
-		if md.CurrentPrice <= signal.StopLoss {
-			_ = s.repo.UpdateSignalStatus(ctx, signal.ID, "Stop Loss")
-			log.Printf("Signal %d hit Stop Loss", signal.ID)
+		progressed := signal.Advance(price, now)
+		next, ok := signal.NextStatus(price, now)
+		if ok && next.IsTerminal() {
+			signal.Close(next, price)
+			progressed = true
+		}
+		if progressed {
+			if err := s.repo.SaveSignalProgress(ctx, &signal); err != nil {
+				log.Printf("Failed to save progress for signal %d: %v", signal.ID, err)
+				continue
+			}
+		}
+		if !ok {
 			continue
 		}
+		if _, err := s.transition(ctx, signal, next, price, "trader signal cron"); err != nil {
+			log.Printf("Failed to move signal %d to %s: %v", signal.ID, next, err)
+		}
+	}
+}
 
-		if md.CurrentPrice >= signal.TargetPrice {
-			_ = s.repo.UpdateSignalStatus(ctx, signal.ID, "Target Hit")
-			log.Printf("Signal %d hit Target Price", signal.ID)
-			continue
+func (s *SignalService) transition(ctx context.Context, signal models.Signal, next models.SignalStatus, price float64, reason string) (bool, error) {
+	moved, err := s.repo.TransitionSignalStatus(ctx, &signal, next, price, reason)
+	if err != nil || !moved {
+		return false, err
+	}
+	log.Printf("Signal %d moved %s -> %s at %.4f (R: %.2f)", signal.ID, signal.Status, next, price, signal.RealizedR)
+	s.hub.PublishSignalStatus(signal.TraderID, stream.SignalStatusChange{
+		SignalID:     signal.ID,
+		Symbol:       signal.Symbol,
+		From:         signal.Status,
+		To:           next,
+		CurrentPrice: price,
+		RealizedR:    signal.RealizedR,
+	})
+	return true, nil
+}
+
+// CancelSignal withdraws a Pending or Active signal. Only the trader who
+// published it may cancel it.
+func (s *SignalService) CancelSignal(ctx context.Context, traderID, signalID uint) (*models.Signal, error) {
+	signal, err := s.repo.GetSignalByID(ctx, signalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSignalNotFound
 		}
+		return nil, err
+	}
+	if signal.TraderID != traderID {
+		return nil, ErrPermissionDenied
+	}
+	if !signal.Status.CanTransitionTo(models.SignalStatusCancelled) {
+		return nil, fmt.Errorf("%w: signal is already %s", models.ErrInvalidSignalTransition, signal.Status)
 	}
 
-	return nil
+	signal.Close(models.SignalStatusCancelled, signal.CurrentPrice)
+	moved, err := s.transition(ctx, *signal, models.SignalStatusCancelled, signal.CurrentPrice, "cancelled by trader")
+	if err != nil {
+		return nil, err
+	}
+	if !moved {
+		return nil, fmt.Errorf("%w: signal status changed, reload and retry", models.ErrInvalidSignalTransition)
+	}
+	return s.repo.GetSignalByID(ctx, signalID)
+}
+
+func (s *SignalService) GetSignalHistory(ctx context.Context, signalID uint) ([]models.SignalStatusHistory, error) {
+	if _, err := s.repo.GetSignalByID(ctx, signalID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSignalNotFound
+		}
+		return nil, err
+	}
+	return s.repo.GetSignalHistory(ctx, signalID)
 }
 
 func (s *SignalService) GetSignalByID(ctx context.Context, id uint) (*models.Signal, error) {
 	return s.repo.GetSignalByID(ctx, id)
 }
 
+// UpdateSignal edits a signal. Entry, direction, targets and trailing rules are
+// fixed once the signal leaves Pending, since hit targets and R-multiples are
+// measured against them; an Active signal's stop can still be moved by hand.
 func (s *SignalService) UpdateSignal(ctx context.Context, updated *models.Signal) (*models.Signal, error) {
 	existing, err := s.repo.GetSignalByID(ctx, updated.ID)
 	if err != nil {
 		return nil, fmt.Errorf("signal not found: %w", err)
 	}
 
-	existing.EntryPrice = updated.EntryPrice
-	existing.StopLoss = updated.StopLoss
-	existing.TargetPrice = updated.TargetPrice
 	existing.Strategy = updated.Strategy
 	existing.Risk = updated.Risk
-	existing.Symbol = strings.ToUpper(updated.Symbol)
+	if !updated.TradeEndDate.IsZero() {
+		existing.TradeEndDate = updated.TradeEndDate
+	}
+
+	switch existing.Status {
+	case models.SignalStatusPending:
+		existing.EntryPrice = updated.EntryPrice
+		existing.StopLoss = updated.StopLoss
+		existing.InitialStopLoss = updated.StopLoss
+		existing.Symbol = strings.ToUpper(updated.Symbol)
+		if updated.Direction != "" {
+			existing.Direction = updated.Direction
+		}
+		existing.TrailingType = updated.TrailingType
+		existing.TrailingValue = updated.TrailingValue
+		existing.BreakevenAfterTarget = updated.BreakevenAfterTarget
+		if len(updated.Targets) > 0 {
+			existing.Targets = updated.Targets
+		} else if updated.TargetPrice != existing.TargetPrice {
+			existing.Targets = nil
+			existing.TargetPrice = updated.TargetPrice
+		}
+
+		if err := existing.NormalizeDirection(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSignal, err)
+		}
+		if err := existing.PrepareTargets(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSignal, err)
+		}
+		if err := existing.ValidateLevels(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSignal, err)
+		}
+		if err := s.repo.ReplaceSignalTargets(ctx, existing.ID, existing.Targets); err != nil {
+			return nil, fmt.Errorf("failed to update signal targets: %w", err)
+		}
+	case models.SignalStatusActive:
+		if updated.StopLoss != 0 {
+			existing.StopLoss = updated.StopLoss
+		}
+	}
 
 	if err := s.repo.UpdateSignal(ctx, existing); err != nil {
 		return nil, fmt.Errorf("failed to update signal: %w", err)
@@ -5,22 +5,32 @@ import (
 	"errors"
 
 	"github.com/fathimasithara01/tradeverse/internal/trader/repository"
+	"github.com/fathimasithara01/tradeverse/pkg/ledger"
 	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"github.com/fathimasithara01/tradeverse/pkg/transfer"
+	"github.com/fathimasithara01/tradeverse/pkg/withdrawal"
 )
 
 type WalletService interface {
 	GetBalance(ctx context.Context, userID uint) (*models.Wallet, error)
-	Deposit(ctx context.Context, userID uint, amount float64) (*models.WalletTransaction, error)
-	Withdraw(ctx context.Context, userID uint, amount float64) (*models.WalletTransaction, error)
+	Deposit(ctx context.Context, userID uint, amount money.Amount) (*models.WalletTransaction, error)
+	Withdraw(ctx context.Context, userID uint, input models.WithdrawalRequestInput) (*models.WithdrawalRequest, error)
 	GetTransactionHistory(ctx context.Context, userID uint) ([]models.WalletTransaction, error)
+	Transfer(ctx context.Context, userID uint, input models.TransferInput) (*models.WalletTransfer, error)
+	GetTransfers(ctx context.Context, userID uint, pagination models.PaginationParams) ([]models.WalletTransfer, int64, error)
+	GetPayoutAccount(ctx context.Context, userID uint) (*models.TraderPayoutAccount, error)
+	SetPayoutAccount(ctx context.Context, userID uint, input models.TraderPayoutAccountInput) (*models.TraderPayoutAccount, error)
 }
 
 type walletService struct {
-	repo repository.WalletRepository
+	repo        repository.WalletRepository
+	withdrawals *withdrawal.Pipeline
+	transfers   *transfer.Service
 }
 
-func NewWalletService(repo repository.WalletRepository) WalletService {
-	return &walletService{repo: repo}
+func NewWalletService(repo repository.WalletRepository, withdrawals *withdrawal.Pipeline, transfers *transfer.Service) WalletService {
+	return &walletService{repo: repo, withdrawals: withdrawals, transfers: transfers}
 }
 
 func (s *walletService) GetBalance(ctx context.Context, userID uint) (*models.Wallet, error) {
@@ -34,7 +44,7 @@ func (s *walletService) GetBalance(ctx context.Context, userID uint) (*models.Wa
 	return wallet, nil
 }
 
-func (s *walletService) Deposit(ctx context.Context, userID uint, amount float64) (*models.WalletTransaction, error) {
+func (s *walletService) Deposit(ctx context.Context, userID uint, amount money.Amount) (*models.WalletTransaction, error) {
 	if amount <= 0 {
 		return nil, errors.New("amount must be greater than zero")
 	}
@@ -47,29 +57,32 @@ func (s *walletService) Deposit(ctx context.Context, userID uint, amount float64
 		return nil, errors.New("wallet not found")
 	}
 
-	wallet.Balance += amount
-	if err := s.repo.UpdateWallet(ctx, wallet); err != nil {
-		return nil, err
-	}
-
 	tx := &models.WalletTransaction{
-		WalletID: wallet.ID,
-		UserID:   userID,
-		// Type:     models.Deposit,
-		Amount: amount,
-	}
-	if err := s.repo.CreateTransaction(ctx, tx); err != nil {
+		Type:            models.TxTypeDeposit,
+		TransactionType: models.TxTypeCredit,
+		Name:            "Wallet Deposit",
+	}
+	if err := s.repo.Post(ctx, ledger.Entry{
+		Type:     models.TxTypeDeposit,
+		Currency: wallet.Currency,
+		Legs: []ledger.Leg{
+			ledger.Debit(ledger.GatewayClearing(wallet.Currency), amount, nil),
+			ledger.Credit(ledger.Wallet(userID), amount, tx),
+		},
+	}); err != nil {
 		return nil, err
 	}
 
 	return tx, nil
 }
 
-func (s *walletService) Withdraw(ctx context.Context, userID uint, amount float64) (*models.WalletTransaction, error) {
-	if amount <= 0 {
-		return nil, errors.New("amount must be greater than zero")
-	}
+// Withdraw files the withdrawal for admin review; the funds stay on hold
+// until it is paid out or rejected.
+func (s *walletService) Withdraw(ctx context.Context, userID uint, input models.WithdrawalRequestInput) (*models.WithdrawalRequest, error) {
+	return s.withdrawals.Request(ctx, userID, userID, input)
+}
 
+func (s *walletService) GetTransactionHistory(ctx context.Context, userID uint) ([]models.WalletTransaction, error) {
 	wallet, err := s.repo.GetWalletByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
@@ -77,35 +90,42 @@ func (s *walletService) Withdraw(ctx context.Context, userID uint, amount float6
 	if wallet == nil {
 		return nil, errors.New("wallet not found")
 	}
-	if wallet.Balance < amount {
-		return nil, errors.New("insufficient balance")
-	}
-
-	wallet.Balance -= amount
-	if err := s.repo.UpdateWallet(ctx, wallet); err != nil {
-		return nil, err
-	}
+	return s.repo.GetTransactionsByWalletID(ctx, wallet.ID)
+}
 
-	tx := &models.WalletTransaction{
-		WalletID: wallet.ID,
-		UserID:   userID,
-		// Type:     models.Withdrawal,
-		Amount: amount,
-	}
-	if err := s.repo.CreateTransaction(ctx, tx); err != nil {
-		return nil, err
-	}
+// Transfer sends money from the trader's wallet to another user's, such as a
+// team member.
+func (s *walletService) Transfer(ctx context.Context, userID uint, input models.TransferInput) (*models.WalletTransfer, error) {
+	return s.transfers.Send(ctx, userID, input)
+}
 
-	return tx, nil
+func (s *walletService) GetTransfers(ctx context.Context, userID uint, pagination models.PaginationParams) ([]models.WalletTransfer, int64, error) {
+	return s.transfers.List(ctx, userID, pagination)
 }
 
-func (s *walletService) GetTransactionHistory(ctx context.Context, userID uint) ([]models.WalletTransaction, error) {
-	wallet, err := s.repo.GetWalletByUserID(ctx, userID)
+func (s *walletService) GetPayoutAccount(ctx context.Context, userID uint) (*models.TraderPayoutAccount, error) {
+	account, err := s.repo.GetPayoutAccount(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
-	if wallet == nil {
-		return nil, errors.New("wallet not found")
+	if account == nil {
+		return nil, errors.New("payout account not set")
 	}
-	return s.repo.GetTransactionsByWalletID(ctx, wallet.ID)
+	return account, nil
+}
+
+// SetPayoutAccount saves where the trader's earnings are paid out. Automatic
+// payouts are on unless the trader turns them off.
+func (s *walletService) SetPayoutAccount(ctx context.Context, userID uint, input models.TraderPayoutAccountInput) (*models.TraderPayoutAccount, error) {
+	account := &models.TraderPayoutAccount{
+		TraderID:          userID,
+		BankAccountNumber: input.BankAccountNumber,
+		BankAccountHolder: input.BankAccountHolder,
+		IFSCCode:          input.IFSCCode,
+		AutoPayout:        input.AutoPayout == nil || *input.AutoPayout,
+	}
+	if err := s.repo.SavePayoutAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPayoutAccount(ctx, userID)
 }
@@ -0,0 +1,169 @@
+// Package accounttoken issues the tokens sent by email to verify an address
+// or reset a password. A token is HMAC-signed and names its user, purpose and
+// expiry, so forged or stale tokens are rejected without a database lookup;
+// a stored hash makes each token usable once.
+package accounttoken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/config"
+	"github.com/fathimasithara01/tradeverse/pkg/auth"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalid = errors.New("invalid or tampered token")
+	ErrExpired = errors.New("token has expired")
+	// ErrUsed is returned for a token that was already used or was replaced
+	// by a newer one.
+	ErrUsed = errors.New("token has already been used")
+)
+
+// Claims is what a token says about itself.
+type Claims struct {
+	UserID    uint
+	Purpose   models.AccountTokenPurpose
+	ExpiresAt time.Time
+	Nonce     string
+}
+
+// Sign encodes claims as a token signed with secret.
+func Sign(secret []byte, claims Claims) string {
+	payload := strings.Join([]string{
+		string(claims.Purpose),
+		strconv.FormatUint(uint64(claims.UserID), 10),
+		strconv.FormatInt(claims.ExpiresAt.Unix(), 10),
+		claims.Nonce,
+	}, ".")
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(signature(secret, encoded))
+}
+
+// Parse checks the signature, purpose and expiry of token at now.
+func Parse(secret []byte, token string, purpose models.AccountTokenPurpose, now time.Time) (*Claims, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalid
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, signature(secret, encoded)) {
+		return nil, ErrInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	parts := strings.Split(string(payload), ".")
+	if len(parts) != 4 || models.AccountTokenPurpose(parts[0]) != purpose {
+		return nil, ErrInvalid
+	}
+	userID, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	claims := &Claims{UserID: uint(userID), Purpose: purpose, ExpiresAt: time.Unix(expires, 0), Nonce: parts[3]}
+	if !now.Before(claims.ExpiresAt) {
+		return nil, ErrExpired
+	}
+	return claims, nil
+}
+
+func signature(secret []byte, encoded string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+type Service struct {
+	db     *gorm.DB
+	secret []byte
+}
+
+func NewService(db *gorm.DB, secret string) *Service {
+	return &Service{db: db, secret: []byte(secret)}
+}
+
+// SecretFromAppConfig returns the signing secret: app.secret, or jwt.secret
+// when that is not set.
+func SecretFromAppConfig(cfg *config.Config) string {
+	if cfg.App.Secret != "" {
+		return cfg.App.Secret
+	}
+	return cfg.JWT.Secret
+}
+
+// Issue creates a token for purpose that is valid for ttl. Earlier unused
+// tokens of the user for the same purpose stop working, so only the most
+// recent email's link can be followed.
+func (s *Service) Issue(userID uint, purpose models.AccountTokenPurpose, ttl time.Duration) (string, error) {
+	nonce, err := auth.RandomToken(18)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	token := Sign(s.secret, Claims{UserID: userID, Purpose: purpose, ExpiresAt: now.Add(ttl), Nonce: nonce})
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.AccountToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Update("used_at", now).Error; err != nil {
+			return fmt.Errorf("failed to retire earlier tokens: %w", err)
+		}
+		row := &models.AccountToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: auth.HashToken(token),
+			ExpiresAt: now.Add(ttl),
+		}
+		if err := tx.Create(row).Error; err != nil {
+			return fmt.Errorf("failed to store token: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Consume checks token and marks it used inside tx, returning the user it
+// was issued to. If tx is rolled back the token stays usable.
+func (s *Service) Consume(tx *gorm.DB, token string, purpose models.AccountTokenPurpose) (uint, error) {
+	now := time.Now()
+	claims, err := Parse(s.secret, token, purpose, now)
+	if err != nil {
+		return 0, err
+	}
+	result := tx.Model(&models.AccountToken{}).
+		Where("token_hash = ? AND user_id = ? AND purpose = ? AND used_at IS NULL", auth.HashToken(token), claims.UserID, purpose).
+		Update("used_at", now)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to use token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, ErrUsed
+	}
+	return claims.UserID, nil
+}
+
+// PruneExpired deletes tokens that can no longer be used.
+func (s *Service) PruneExpired(now time.Time) (int64, error) {
+	result := s.db.Where("expires_at < ?", now).Delete(&models.AccountToken{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune account tokens: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
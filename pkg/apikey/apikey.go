@@ -0,0 +1,171 @@
+// Package apikey implements personal API keys for scripts. A key carries a
+// fixed set of scopes and an expiry, only its hash is stored, and a key can
+// be made to require HMAC-signed requests so that a captured request cannot
+// be altered or replayed outside a short window.
+package apikey
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/auth"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+)
+
+// Scope is a permission granted to an API key.
+type Scope string
+
+const (
+	ScopeSignalsWrite Scope = "signals:write"
+	ScopeSignalsRead  Scope = "signals:read"
+	ScopeWalletRead   Scope = "wallet:read"
+	ScopeTradesWrite  Scope = "trades:write"
+)
+
+// KeyPrefix starts every API key, so leaked keys are easy to recognise.
+const KeyPrefix = "tvk_"
+
+var (
+	ErrUnknownScope    = errors.New("unknown scope")
+	ErrScopeNotAllowed = errors.New("scope is not available to this account")
+	ErrNoScopes        = errors.New("at least one scope is required")
+
+	ErrMissingSignature = errors.New("this API key requires signed requests")
+	ErrInvalidSignature = errors.New("invalid request signature")
+	ErrSignatureExpired = errors.New("request timestamp is outside the allowed window")
+)
+
+// roleScopes lists the scopes each role may grant its keys. Customers only
+// read; publishing signals and trades is for traders.
+var roleScopes = map[models.UserRole][]Scope{
+	models.RoleCustomer: {ScopeSignalsRead, ScopeWalletRead},
+	models.RoleTrader:   {ScopeSignalsWrite, ScopeSignalsRead, ScopeWalletRead, ScopeTradesWrite},
+}
+
+// ScopesForRole returns the scopes a user with role may grant.
+func ScopesForRole(role models.UserRole) []Scope {
+	return roleScopes[role]
+}
+
+// ParseScopes validates requested scope names against what role may grant
+// and returns them without duplicates.
+func ParseScopes(names []string, role models.UserRole) ([]Scope, error) {
+	allowed := ScopesForRole(role)
+	seen := make(map[Scope]bool, len(names))
+	var scopes []Scope
+	for _, name := range names {
+		scope := Scope(strings.TrimSpace(name))
+		if !knownScope(scope) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownScope, name)
+		}
+		if !containsScope(allowed, scope) {
+			return nil, fmt.Errorf("%w: %s", ErrScopeNotAllowed, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, ErrNoScopes
+	}
+	return scopes, nil
+}
+
+func knownScope(scope Scope) bool {
+	switch scope {
+	case ScopeSignalsWrite, ScopeSignalsRead, ScopeWalletRead, ScopeTradesWrite:
+		return true
+	}
+	return false
+}
+
+func containsScope(scopes []Scope, scope Scope) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func joinScopes(scopes []Scope) string {
+	names := make([]string, len(scopes))
+	for i, s := range scopes {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ",")
+}
+
+func splitScopes(stored string) []Scope {
+	var scopes []Scope
+	for _, name := range strings.Split(stored, ",") {
+		if name != "" {
+			scopes = append(scopes, Scope(name))
+		}
+	}
+	return scopes
+}
+
+// Routes maps "METHOD /full/path", in gin's route syntax, to the scope an API
+// key needs to call the route. Routes that are not listed cannot be called
+// with an API key at all.
+type Routes map[string]Scope
+
+// ScopeFor returns the scope required for a route.
+func (r Routes) ScopeFor(method, fullPath string) (Scope, bool) {
+	scope, ok := r[method+" "+fullPath]
+	return scope, ok
+}
+
+// generateKey returns a new key and its public prefix.
+func generateKey() (key, prefix string, err error) {
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", fmt.Errorf("failed to generate key id: %w", err)
+	}
+	secret, err := auth.RandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	prefix = KeyPrefix + hex.EncodeToString(id)
+	return prefix + "_" + secret, prefix, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of "timestamp.METHOD.requestURI.body"
+// under secret. requestURI is the path with its query string, as sent.
+func Sign(secret string, timestamp int64, method, requestURI string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.%s.%s.", timestamp, strings.ToUpper(method), requestURI)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks a request's signature and that its timestamp, in
+// Unix seconds, is within tolerance of now.
+func VerifySignature(secret, signature, timestamp, method, requestURI string, body []byte, now time.Time, tolerance time.Duration) error {
+	if signature == "" || timestamp == "" {
+		return ErrMissingSignature
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp %q", ErrInvalidSignature, timestamp)
+	}
+
+	expected := Sign(secret, ts, method, requestURI, body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return ErrInvalidSignature
+	}
+
+	if skew := now.Sub(time.Unix(ts, 0)); skew > tolerance || skew < -tolerance {
+		return ErrSignatureExpired
+	}
+	return nil
+}
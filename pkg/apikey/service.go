@@ -0,0 +1,285 @@
+package apikey
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/config"
+	"github.com/fathimasithara01/tradeverse/pkg/auth"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/secretbox"
+	"gorm.io/gorm"
+)
+
+// lastUsedInterval is how often last-use details are written for a key that
+// is in constant use.
+const lastUsedInterval = time.Minute
+
+var (
+	ErrInvalidKey     = errors.New("invalid API key")
+	ErrKeyExpired     = errors.New("API key has expired")
+	ErrKeyRevoked     = errors.New("API key has been revoked")
+	ErrAccountBlocked = errors.New("account is blocked")
+	ErrNotFound       = errors.New("API key not found")
+	ErrNameRequired   = errors.New("a name is required")
+	ErrInvalidExpiry  = errors.New("expiry is out of range")
+	ErrTooManyKeys    = errors.New("too many active API keys; revoke one first")
+)
+
+type Config struct {
+	EncryptionKey      string
+	DefaultTTL         time.Duration
+	MaxTTL             time.Duration
+	MaxPerUser         int
+	SignatureTolerance time.Duration
+}
+
+// ConfigFromAppConfig builds the settings from the api_keys section of the
+// application config.
+func ConfigFromAppConfig(cfg *config.Config) Config {
+	key := cfg.APIKeys.EncryptionKey
+	if key == "" {
+		key = cfg.JWT.Secret
+	}
+	return Config{
+		EncryptionKey:      key,
+		DefaultTTL:         time.Duration(cfg.APIKeys.DefaultExpiryDays) * 24 * time.Hour,
+		MaxTTL:             time.Duration(cfg.APIKeys.MaxExpiryDays) * 24 * time.Hour,
+		MaxPerUser:         cfg.APIKeys.MaxPerUser,
+		SignatureTolerance: time.Duration(cfg.APIKeys.SignatureToleranceSeconds) * time.Second,
+	}
+}
+
+// CreateInput describes a key to create. ExpiresInDays of zero uses the
+// configured default.
+type CreateInput struct {
+	Name             string   `json:"name" binding:"required"`
+	Scopes           []string `json:"scopes" binding:"required"`
+	ExpiresInDays    int      `json:"expires_in_days"`
+	RequireSignature bool     `json:"require_signature"`
+}
+
+// Info describes a key without any of its secrets.
+type Info struct {
+	ID               uint       `json:"id"`
+	Name             string     `json:"name"`
+	Prefix           string     `json:"prefix"`
+	Scopes           []Scope    `json:"scopes"`
+	RequireSignature bool       `json:"require_signature"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	LastUsedAt       *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP       string     `json:"last_used_ip,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// Created is handed back once when a key is created. Neither the key nor the
+// signing secret can be retrieved again.
+type Created struct {
+	Info
+	Key           string `json:"key"`
+	SigningSecret string `json:"signing_secret,omitempty"`
+}
+
+// Request is what Authenticate needs to know about the call being made.
+// Signature and Timestamp are only checked for keys that require signing.
+type Request struct {
+	Method     string
+	RequestURI string
+	Body       []byte
+	Signature  string
+	Timestamp  string
+	IPAddress  string
+}
+
+// Principal is the user and grant behind an authenticated key.
+type Principal struct {
+	KeyID  uint
+	UserID uint
+	Email  string
+	Role   models.UserRole
+	Scopes []Scope
+}
+
+// Has reports whether the key was granted scope.
+func (p *Principal) Has(scope Scope) bool {
+	return containsScope(p.Scopes, scope)
+}
+
+type Service struct {
+	db  *gorm.DB
+	cfg Config
+	box *secretbox.Box
+}
+
+func NewService(db *gorm.DB, cfg Config) *Service {
+	return &Service{db: db, cfg: cfg, box: secretbox.New(cfg.EncryptionKey)}
+}
+
+// Create issues a key for the user. The scopes must be ones the user's role
+// may grant.
+func (s *Service) Create(userID uint, role models.UserRole, in CreateInput) (*Created, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return nil, ErrNameRequired
+	}
+	scopes, err := ParseScopes(in.Scopes, role)
+	if err != nil {
+		return nil, err
+	}
+	ttl := s.cfg.DefaultTTL
+	if in.ExpiresInDays != 0 {
+		ttl = time.Duration(in.ExpiresInDays) * 24 * time.Hour
+	}
+	if ttl <= 0 || ttl > s.cfg.MaxTTL {
+		return nil, fmt.Errorf("%w: at most %d days", ErrInvalidExpiry, int(s.cfg.MaxTTL.Hours()/24))
+	}
+
+	now := time.Now()
+	var active int64
+	if err := s.db.Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Count(&active).Error; err != nil {
+		return nil, fmt.Errorf("failed to count API keys: %w", err)
+	}
+	if s.cfg.MaxPerUser > 0 && active >= int64(s.cfg.MaxPerUser) {
+		return nil, ErrTooManyKeys
+	}
+
+	key, prefix, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+	record := models.APIKey{
+		UserID:           userID,
+		Name:             name,
+		Prefix:           prefix,
+		KeyHash:          auth.HashToken(key),
+		Scopes:           joinScopes(scopes),
+		RequireSignature: in.RequireSignature,
+		ExpiresAt:        now.Add(ttl),
+	}
+	created := &Created{Key: key}
+	if in.RequireSignature {
+		secret, err := auth.RandomToken(32)
+		if err != nil {
+			return nil, err
+		}
+		if record.SigningSecret, err = s.box.Seal(secret); err != nil {
+			return nil, err
+		}
+		created.SigningSecret = secret
+	}
+	if err := s.db.Create(&record).Error; err != nil {
+		return nil, fmt.Errorf("failed to store API key: %w", err)
+	}
+	created.Info = infoFrom(record)
+	return created, nil
+}
+
+// List returns all of the user's keys, newest first, including expired and
+// revoked ones.
+func (s *Service) List(userID uint) ([]Info, error) {
+	var records []models.APIKey
+	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	infos := make([]Info, len(records))
+	for i, record := range records {
+		infos[i] = infoFrom(record)
+	}
+	return infos, nil
+}
+
+// Revoke disables one of the user's keys. Revoking a revoked key is a no-op.
+func (s *Service) Revoke(userID, keyID uint) error {
+	result := s.db.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke API key: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := s.db.Model(&models.APIKey{}).Where("id = ? AND user_id = ?", keyID, userID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to look up API key: %w", err)
+		}
+		if count == 0 {
+			return ErrNotFound
+		}
+	}
+	return nil
+}
+
+// Authenticate resolves a key to its user and scopes, verifying the request
+// signature when the key requires one, and records the use.
+func (s *Service) Authenticate(key string, req Request, now time.Time) (*Principal, error) {
+	if !strings.HasPrefix(key, KeyPrefix) {
+		return nil, ErrInvalidKey
+	}
+	var record models.APIKey
+	if err := s.db.Where("key_hash = ?", auth.HashToken(key)).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidKey
+		}
+		return nil, fmt.Errorf("failed to look up API key: %w", err)
+	}
+	if record.RevokedAt != nil {
+		return nil, ErrKeyRevoked
+	}
+	if !now.Before(record.ExpiresAt) {
+		return nil, ErrKeyExpired
+	}
+
+	if record.RequireSignature {
+		secret, err := s.box.Open(record.SigningSecret)
+		if err != nil {
+			return nil, err
+		}
+		if err := VerifySignature(secret, req.Signature, req.Timestamp, req.Method, req.RequestURI, req.Body, now, s.cfg.SignatureTolerance); err != nil {
+			return nil, err
+		}
+	}
+
+	var user models.User
+	if err := s.db.Select("id", "email", "role", "is_blocked").First(&user, record.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidKey
+		}
+		return nil, fmt.Errorf("failed to load API key owner: %w", err)
+	}
+	if user.IsBlocked {
+		return nil, ErrAccountBlocked
+	}
+
+	if err := s.db.Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", record.ID, now.Add(-lastUsedInterval)).
+		Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": req.IPAddress}).Error; err != nil {
+		return nil, fmt.Errorf("failed to record API key use: %w", err)
+	}
+
+	return &Principal{
+		KeyID:  record.ID,
+		UserID: user.ID,
+		Email:  user.Email,
+		Role:   user.Role,
+		Scopes: splitScopes(record.Scopes),
+	}, nil
+}
+
+func infoFrom(record models.APIKey) Info {
+	return Info{
+		ID:               record.ID,
+		Name:             record.Name,
+		Prefix:           record.Prefix,
+		Scopes:           splitScopes(record.Scopes),
+		RequireSignature: record.RequireSignature,
+		ExpiresAt:        record.ExpiresAt,
+		LastUsedAt:       record.LastUsedAt,
+		LastUsedIP:       record.LastUsedIP,
+		RevokedAt:        record.RevokedAt,
+		CreatedAt:        record.CreatedAt,
+	}
+}
@@ -8,26 +8,38 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+var ErrTokenExpired = errors.New("token is expired")
+
 type AuthClaims struct {
 	UserID uint   `json:"user_id"`
 	Email  string `json:"email"`
 	Role   string `json:"role"`
 	RoleID uint   `json:"role_id"`
+	// SessionID names the login session the token was issued for; revoking
+	// the session invalidates every access token carrying it.
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(userID uint, email, role string, roleID uint, jwtSecret string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) 
-	
+// GenerateJWT signs an access token for one session that expires after ttl.
+func GenerateJWT(userID uint, email, role string, roleID uint, sessionID, jwtSecret string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	tokenID, err := RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
 	claims := &AuthClaims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
-		RoleID: roleID,
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		RoleID:    roleID,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
@@ -51,7 +63,7 @@ func ValidateJWT(tokenString string, jwtSecret string) (*AuthClaims, error) {
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
-			return nil, errors.New("token is expired")
+			return nil, ErrTokenExpired
 		}
 		if errors.Is(err, jwt.ErrTokenNotValidYet) {
 			return nil, errors.New("token is not valid yet")
@@ -0,0 +1,153 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderMock      = "mock"
+	ProviderCoinGecko = "coingecko"
+	ProviderKuCoin    = "kucoin"
+)
+
+var (
+	ErrNotSupported   = errors.New("operation not supported by this exchange adapter")
+	ErrSymbolNotFound = errors.New("symbol not found")
+	ErrOrderNotFound  = errors.New("order not found")
+)
+
+type OrderSide string
+
+const (
+	OrderSideBuy  OrderSide = "BUY"
+	OrderSideSell OrderSide = "SELL"
+)
+
+type OrderType string
+
+const (
+	OrderTypeMarket OrderType = "MARKET"
+	OrderTypeLimit  OrderType = "LIMIT"
+)
+
+type OrderStatus string
+
+const (
+	OrderStatusNew      OrderStatus = "NEW"
+	OrderStatusFilled   OrderStatus = "FILLED"
+	OrderStatusRejected OrderStatus = "REJECTED"
+)
+
+// Ticker is a price snapshot. Symbol is always the base asset ("BTC"); Pair is
+// the exchange's own market name ("BTC-USDT") when it has one.
+type Ticker struct {
+	Symbol    string  `json:"symbol"`
+	Pair      string  `json:"pair,omitempty"`
+	Name      string  `json:"name"`
+	Price     float64 `json:"price"`
+	Change24H float64 `json:"change_24h"`
+	Volume24H float64 `json:"volume_24h"`
+	MarketCap float64 `json:"market_cap"`
+	LogoURL   string  `json:"logo_url"`
+}
+
+type OrderRequest struct {
+	Symbol   string    `json:"symbol"`
+	Side     OrderSide `json:"side"`
+	Type     OrderType `json:"type"`
+	Quantity float64   `json:"quantity"`
+	Price    float64   `json:"price,omitempty"`
+}
+
+type Order struct {
+	ID             string      `json:"id"`
+	Symbol         string      `json:"symbol"`
+	Side           OrderSide   `json:"side"`
+	Type           OrderType   `json:"type"`
+	Quantity       float64     `json:"quantity"`
+	Price          float64     `json:"price"`
+	FilledQuantity float64     `json:"filled_quantity"`
+	AveragePrice   float64     `json:"average_price"`
+	Status         OrderStatus `json:"status"`
+	Reason         string      `json:"reason,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+type Balance struct {
+	Asset  string  `json:"asset"`
+	Free   float64 `json:"free"`
+	Locked float64 `json:"locked"`
+}
+
+// ExchangeAdapter is the single entry point for market data and order routing.
+// Public data sources implement the order and balance methods by returning
+// ErrNotSupported.
+type ExchangeAdapter interface {
+	Name() string
+	GetTickers(ctx context.Context) ([]Ticker, error)
+	GetTicker(ctx context.Context, symbol string) (*Ticker, error)
+	PlaceOrder(ctx context.Context, req OrderRequest) (*Order, error)
+	GetOrder(ctx context.Context, orderID string) (*Order, error)
+	GetBalances(ctx context.Context) ([]Balance, error)
+}
+
+type Config struct {
+	Provider string
+	BaseURL  string
+	Timeout  time.Duration
+}
+
+// NewAdapter builds the adapter named by cfg.Provider. The mock provider starts
+// an in-process stand-in server when no BaseURL is configured.
+func NewAdapter(cfg Config) (ExchangeAdapter, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+
+	switch strings.ToLower(strings.TrimSpace(cfg.Provider)) {
+	case ProviderMock, "":
+		if cfg.BaseURL == "" {
+			return NewLocalMockAdapter(), nil
+		}
+		return NewMockAdapter(cfg.BaseURL, client), nil
+	case ProviderCoinGecko:
+		return NewCoinGeckoAdapter(cfg.BaseURL, client), nil
+	case ProviderKuCoin:
+		return NewKuCoinAdapter(cfg.BaseURL, client), nil
+	default:
+		return nil, fmt.Errorf("unknown exchange provider %q", cfg.Provider)
+	}
+}
+
+// BaseSymbol reduces "BTCUSDT", "BTC-USDT" or "btc/usdt" to "BTC".
+func BaseSymbol(symbol string) string {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	for _, sep := range []string{"-", "/", "_"} {
+		if i := strings.Index(symbol, sep); i > 0 {
+			return symbol[:i]
+		}
+	}
+	for _, quote := range []string{"USDT", "USDC", "USD"} {
+		if base := strings.TrimSuffix(symbol, quote); base != symbol && base != "" {
+			return base
+		}
+	}
+	return symbol
+}
+
+func findTicker(tickers []Ticker, symbol string) (*Ticker, error) {
+	base := BaseSymbol(symbol)
+	for i := range tickers {
+		if tickers[i].Symbol == base {
+			return &tickers[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrSymbolNotFound, symbol)
+}
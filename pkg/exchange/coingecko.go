@@ -0,0 +1,96 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const defaultCoinGeckoURL = "https://api.coingecko.com"
+
+// CoinGeckoAdapter reads public market data from CoinGecko. It has no trading API.
+type CoinGeckoAdapter struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewCoinGeckoAdapter(baseURL string, client *http.Client) *CoinGeckoAdapter {
+	if baseURL == "" {
+		baseURL = defaultCoinGeckoURL
+	}
+	return &CoinGeckoAdapter{baseURL: strings.TrimRight(baseURL, "/"), client: client}
+}
+
+func (a *CoinGeckoAdapter) Name() string { return ProviderCoinGecko }
+
+func (a *CoinGeckoAdapter) GetTickers(ctx context.Context) ([]Ticker, error) {
+	url := a.baseURL + "/api/v3/coins/markets?vs_currency=usd&order=market_cap_desc&per_page=100&page=1&sparkline=false&price_change_percentage=24h"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch market data from coingecko: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read coingecko response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("coingecko returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var coins []struct {
+		Symbol                   string  `json:"symbol"`
+		Name                     string  `json:"name"`
+		Image                    string  `json:"image"`
+		CurrentPrice             float64 `json:"current_price"`
+		PriceChangePercentage24h float64 `json:"price_change_percentage_24h"`
+		TotalVolume              float64 `json:"total_volume"`
+		MarketCap                float64 `json:"market_cap"`
+	}
+	if err := json.Unmarshal(body, &coins); err != nil {
+		return nil, fmt.Errorf("failed to parse coingecko response: %w", err)
+	}
+
+	tickers := make([]Ticker, 0, len(coins))
+	for _, coin := range coins {
+		tickers = append(tickers, Ticker{
+			Symbol:    strings.ToUpper(coin.Symbol),
+			Name:      coin.Name,
+			Price:     coin.CurrentPrice,
+			Change24H: coin.PriceChangePercentage24h,
+			Volume24H: coin.TotalVolume,
+			MarketCap: coin.MarketCap,
+			LogoURL:   coin.Image,
+		})
+	}
+	return tickers, nil
+}
+
+func (a *CoinGeckoAdapter) GetTicker(ctx context.Context, symbol string) (*Ticker, error) {
+	tickers, err := a.GetTickers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return findTicker(tickers, symbol)
+}
+
+func (a *CoinGeckoAdapter) PlaceOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	return nil, ErrNotSupported
+}
+
+func (a *CoinGeckoAdapter) GetOrder(ctx context.Context, orderID string) (*Order, error) {
+	return nil, ErrNotSupported
+}
+
+func (a *CoinGeckoAdapter) GetBalances(ctx context.Context) ([]Balance, error) {
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,121 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const defaultKuCoinURL = "https://api.kucoin.com"
+
+// kuCoinMarkets are the pairs shown on the admin dashboard.
+var kuCoinMarkets = map[string]string{
+	"BTC-USDT":  "Bitcoin",
+	"ETH-USDT":  "Ethereum",
+	"XRP-USDT":  "Ripple",
+	"LTC-USDT":  "Litecoin",
+	"ADA-USDT":  "Cardano",
+	"SOL-USDT":  "Solana",
+	"DOGE-USDT": "Dogecoin",
+}
+
+// KuCoinAdapter reads public tickers from KuCoin. Trading requires signed
+// requests, which are not configured, so order methods are unsupported.
+type KuCoinAdapter struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewKuCoinAdapter(baseURL string, client *http.Client) *KuCoinAdapter {
+	if baseURL == "" {
+		baseURL = defaultKuCoinURL
+	}
+	return &KuCoinAdapter{baseURL: strings.TrimRight(baseURL, "/"), client: client}
+}
+
+func (a *KuCoinAdapter) Name() string { return ProviderKuCoin }
+
+func (a *KuCoinAdapter) GetTickers(ctx context.Context) ([]Ticker, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL+"/api/v1/market/allTickers", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch market data from kucoin: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kucoin response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("kucoin returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var kucoinResponse struct {
+		Data struct {
+			Ticker []struct {
+				Symbol     string `json:"symbol"`
+				LastPrice  string `json:"last"`
+				ChangeRate string `json:"changeRate"`
+				VolValue   string `json:"volValue"`
+			} `json:"ticker"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &kucoinResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse kucoin response: %w", err)
+	}
+
+	var tickers []Ticker
+	for _, t := range kucoinResponse.Data.Ticker {
+		name, ok := kuCoinMarkets[t.Symbol]
+		if !ok {
+			continue
+		}
+
+		price, err := strconv.ParseFloat(t.LastPrice, 64)
+		if err != nil {
+			continue
+		}
+		changeRate, _ := strconv.ParseFloat(t.ChangeRate, 64)
+		volume, _ := strconv.ParseFloat(t.VolValue, 64)
+
+		base := BaseSymbol(t.Symbol)
+		tickers = append(tickers, Ticker{
+			Symbol:    base,
+			Pair:      t.Symbol,
+			Name:      name,
+			Price:     price,
+			Change24H: changeRate * 100,
+			Volume24H: volume,
+			LogoURL:   fmt.Sprintf("https://cryptoicons.org/api/icon/%s/24", strings.ToLower(base)),
+		})
+	}
+	return tickers, nil
+}
+
+func (a *KuCoinAdapter) GetTicker(ctx context.Context, symbol string) (*Ticker, error) {
+	tickers, err := a.GetTickers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return findTicker(tickers, symbol)
+}
+
+func (a *KuCoinAdapter) PlaceOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	return nil, ErrNotSupported
+}
+
+func (a *KuCoinAdapter) GetOrder(ctx context.Context, orderID string) (*Order, error) {
+	return nil, ErrNotSupported
+}
+
+func (a *KuCoinAdapter) GetBalances(ctx context.Context) ([]Balance, error) {
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,319 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMockTickers is the fixed price table served by the mock exchange.
+func DefaultMockTickers() []Ticker {
+	return []Ticker{
+		{Symbol: "BTC", Pair: "BTC-USDT", Name: "Bitcoin", Price: 43000.50, Change24H: 1.25, Volume24H: 25000000000, MarketCap: 845000000000},
+		{Symbol: "ETH", Pair: "ETH-USDT", Name: "Ethereum", Price: 2300.75, Change24H: -0.78, Volume24H: 12000000000, MarketCap: 276000000000},
+		{Symbol: "XRP", Pair: "XRP-USDT", Name: "Ripple", Price: 0.58, Change24H: 3.10, Volume24H: 1500000000, MarketCap: 31000000000},
+		{Symbol: "LTC", Pair: "LTC-USDT", Name: "Litecoin", Price: 70.15, Change24H: -1.50, Volume24H: 800000000, MarketCap: 5200000000},
+		{Symbol: "ADA", Pair: "ADA-USDT", Name: "Cardano", Price: 0.45, Change24H: 0.85, Volume24H: 600000000, MarketCap: 15800000000},
+		{Symbol: "SOL", Pair: "SOL-USDT", Name: "Solana", Price: 192.00, Change24H: 2.10, Volume24H: 3000000000, MarketCap: 84000000000},
+		{Symbol: "DOGE", Pair: "DOGE-USDT", Name: "Dogecoin", Price: 0.20, Change24H: 5.00, Volume24H: 2000000000, MarketCap: 28000000000},
+	}
+}
+
+// MockExchange is a deterministic, in-memory exchange exposed over HTTP. Prices
+// only change through SetPrice, MARKET orders fill at the current price and
+// LIMIT orders fill as soon as the price reaches their limit.
+type MockExchange struct {
+	mu       sync.Mutex
+	tickers  map[string]Ticker
+	orders   map[string]*Order
+	balances map[string]*Balance
+	seq      int
+}
+
+func NewMockExchange() *MockExchange {
+	m := &MockExchange{
+		tickers:  make(map[string]Ticker),
+		orders:   make(map[string]*Order),
+		balances: map[string]*Balance{"USDT": {Asset: "USDT", Free: 100000}},
+	}
+	for _, t := range DefaultMockTickers() {
+		t.LogoURL = fmt.Sprintf("https://cryptoicons.org/api/icon/%s/24", strings.ToLower(t.Symbol))
+		m.tickers[t.Symbol] = t
+	}
+	return m
+}
+
+func (m *MockExchange) SetPrice(symbol string, price float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	base := BaseSymbol(symbol)
+	t, ok := m.tickers[base]
+	if !ok {
+		t = Ticker{Symbol: base, Pair: base + "-USDT", Name: base}
+	}
+	t.Price = price
+	m.tickers[base] = t
+
+	for _, o := range m.orders {
+		if o.Status == OrderStatusNew && o.Symbol == base {
+			m.tryFill(o)
+		}
+	}
+}
+
+func (m *MockExchange) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+
+	switch {
+	case r.Method == http.MethodGet && path == "/api/v1/tickers":
+		m.mu.Lock()
+		tickers := make([]Ticker, 0, len(m.tickers))
+		for _, t := range m.tickers {
+			tickers = append(tickers, t)
+		}
+		m.mu.Unlock()
+		sort.Slice(tickers, func(i, j int) bool { return tickers[i].Symbol < tickers[j].Symbol })
+		writeMockJSON(w, http.StatusOK, tickers)
+
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/api/v1/tickers/"):
+		m.mu.Lock()
+		t, ok := m.tickers[BaseSymbol(strings.TrimPrefix(path, "/api/v1/tickers/"))]
+		m.mu.Unlock()
+		if !ok {
+			writeMockJSON(w, http.StatusNotFound, map[string]string{"error": ErrSymbolNotFound.Error()})
+			return
+		}
+		writeMockJSON(w, http.StatusOK, t)
+
+	case r.Method == http.MethodPost && path == "/api/v1/orders":
+		var req OrderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeMockJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		order, err := m.placeOrder(req)
+		if err != nil {
+			writeMockJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeMockJSON(w, http.StatusCreated, order)
+
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/api/v1/orders/"):
+		m.mu.Lock()
+		o, ok := m.orders[strings.TrimPrefix(path, "/api/v1/orders/")]
+		var order Order
+		if ok {
+			order = *o
+		}
+		m.mu.Unlock()
+		if !ok {
+			writeMockJSON(w, http.StatusNotFound, map[string]string{"error": ErrOrderNotFound.Error()})
+			return
+		}
+		writeMockJSON(w, http.StatusOK, order)
+
+	case r.Method == http.MethodGet && path == "/api/v1/balances":
+		m.mu.Lock()
+		balances := make([]Balance, 0, len(m.balances))
+		for _, b := range m.balances {
+			balances = append(balances, *b)
+		}
+		m.mu.Unlock()
+		sort.Slice(balances, func(i, j int) bool { return balances[i].Asset < balances[j].Asset })
+		writeMockJSON(w, http.StatusOK, balances)
+
+	default:
+		writeMockJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	}
+}
+
+func (m *MockExchange) placeOrder(req OrderRequest) (*Order, error) {
+	if req.Quantity <= 0 {
+		return nil, errors.New("quantity must be greater than zero")
+	}
+	if req.Side != OrderSideBuy && req.Side != OrderSideSell {
+		return nil, errors.New("side must be BUY or SELL")
+	}
+	if req.Type == OrderTypeLimit && req.Price <= 0 {
+		return nil, errors.New("price is required for LIMIT orders")
+	}
+	if req.Type == "" {
+		req.Type = OrderTypeMarket
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	base := BaseSymbol(req.Symbol)
+	if _, ok := m.tickers[base]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSymbolNotFound, req.Symbol)
+	}
+
+	m.seq++
+	order := &Order{
+		ID:        fmt.Sprintf("MOCK-%06d", m.seq),
+		Symbol:    base,
+		Side:      req.Side,
+		Type:      req.Type,
+		Quantity:  req.Quantity,
+		Price:     req.Price,
+		Status:    OrderStatusNew,
+		CreatedAt: time.Now(),
+	}
+	m.orders[order.ID] = order
+	m.tryFill(order)
+
+	copied := *order
+	return &copied, nil
+}
+
+// tryFill fills the order against the current price and settles balances. The
+// caller must hold m.mu.
+func (m *MockExchange) tryFill(o *Order) {
+	price := m.tickers[o.Symbol].Price
+	if o.Type == OrderTypeLimit {
+		if (o.Side == OrderSideBuy && price > o.Price) || (o.Side == OrderSideSell && price < o.Price) {
+			return
+		}
+		price = o.Price
+	}
+
+	quote := m.balance("USDT")
+	base := m.balance(o.Symbol)
+	cost := price * o.Quantity
+	if o.Side == OrderSideBuy {
+		if quote.Free < cost {
+			o.Status, o.Reason = OrderStatusRejected, "insufficient USDT balance"
+			return
+		}
+		quote.Free -= cost
+		base.Free += o.Quantity
+	} else {
+		if base.Free < o.Quantity {
+			o.Status, o.Reason = OrderStatusRejected, fmt.Sprintf("insufficient %s balance", o.Symbol)
+			return
+		}
+		base.Free -= o.Quantity
+		quote.Free += cost
+	}
+
+	o.FilledQuantity = o.Quantity
+	o.AveragePrice = price
+	o.Status = OrderStatusFilled
+}
+
+func (m *MockExchange) balance(asset string) *Balance {
+	b, ok := m.balances[asset]
+	if !ok {
+		b = &Balance{Asset: asset}
+		m.balances[asset] = b
+	}
+	return b
+}
+
+func writeMockJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// MockAdapter talks to a MockExchange over HTTP, exactly as a real adapter
+// would talk to a remote exchange.
+type MockAdapter struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewMockAdapter(baseURL string, client *http.Client) *MockAdapter {
+	return &MockAdapter{baseURL: strings.TrimRight(baseURL, "/"), client: client}
+}
+
+// NewLocalMockAdapter starts a MockExchange on a loopback listener and returns
+// an adapter pointed at it. The server lives for the rest of the process.
+func NewLocalMockAdapter() *MockAdapter {
+	server := httptest.NewServer(NewMockExchange())
+	return NewMockAdapter(server.URL, server.Client())
+}
+
+func (a *MockAdapter) Name() string { return ProviderMock }
+
+func (a *MockAdapter) GetTickers(ctx context.Context) ([]Ticker, error) {
+	var tickers []Ticker
+	return tickers, a.do(ctx, http.MethodGet, "/api/v1/tickers", nil, &tickers)
+}
+
+func (a *MockAdapter) GetTicker(ctx context.Context, symbol string) (*Ticker, error) {
+	var ticker Ticker
+	if err := a.do(ctx, http.MethodGet, "/api/v1/tickers/"+BaseSymbol(symbol), nil, &ticker); err != nil {
+		return nil, err
+	}
+	return &ticker, nil
+}
+
+func (a *MockAdapter) PlaceOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	var order Order
+	if err := a.do(ctx, http.MethodPost, "/api/v1/orders", req, &order); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (a *MockAdapter) GetOrder(ctx context.Context, orderID string) (*Order, error) {
+	var order Order
+	if err := a.do(ctx, http.MethodGet, "/api/v1/orders/"+orderID, nil, &order); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (a *MockAdapter) GetBalances(ctx context.Context) ([]Balance, error) {
+	var balances []Balance
+	return balances, a.do(ctx, http.MethodGet, "/api/v1/balances", nil, &balances)
+}
+
+func (a *MockAdapter) do(ctx context.Context, method, path string, body, out interface{}) error {
+	reader := bytes.NewReader(nil)
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("mock exchange request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		switch {
+		case resp.StatusCode == http.StatusNotFound && apiErr.Error == ErrOrderNotFound.Error():
+			return ErrOrderNotFound
+		case resp.StatusCode == http.StatusNotFound && apiErr.Error == ErrSymbolNotFound.Error():
+			return ErrSymbolNotFound
+		}
+		return fmt.Errorf("mock exchange returned status %d: %s", resp.StatusCode, apiErr.Error)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
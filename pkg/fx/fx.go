@@ -0,0 +1,201 @@
+// Package fx converts prices between currencies using the rates in the
+// fx_rates table, and builds the ledger legs for paying a price from a wallet
+// held in another currency.
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/ledger"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"gorm.io/gorm"
+)
+
+var ErrRateNotFound = errors.New("no exchange rate for currency pair")
+
+// Quote is the rate to convert From into To: one unit of From buys Rate units
+// of To. RateID is the fx_rates row it came from, nil for a currency
+// converted to itself.
+type Quote struct {
+	From   string     `json:"from"`
+	To     string     `json:"to"`
+	Rate   money.Rate `json:"rate"`
+	RateID *uint      `json:"rate_id,omitempty"`
+	AsOf   time.Time  `json:"as_of"`
+}
+
+// Lookup finds the rate from one currency to another. A stored rate for the
+// opposite pair is inverted when there is no direct one.
+func Lookup(db *gorm.DB, from, to string) (*Quote, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return &Quote{From: from, To: to, Rate: money.OneRate, AsOf: time.Now()}, nil
+	}
+
+	var rates []models.FXRate
+	if err := db.Where("(base_currency = ? AND quote_currency = ?) OR (base_currency = ? AND quote_currency = ?)",
+		from, to, to, from).Find(&rates).Error; err != nil {
+		return nil, fmt.Errorf("failed to look up %s/%s rate: %w", from, to, err)
+	}
+
+	var inverse *models.FXRate
+	for i := range rates {
+		rate := &rates[i]
+		if !rate.Rate.IsPositive() {
+			continue
+		}
+		if rate.BaseCurrency == from {
+			return &Quote{From: from, To: to, Rate: rate.Rate, RateID: &rate.ID, AsOf: rate.UpdatedAt}, nil
+		}
+		inverse = rate
+	}
+	if inverse != nil {
+		return &Quote{From: from, To: to, Rate: inverse.Rate.Inverse(), RateID: &inverse.ID, AsOf: inverse.UpdatedAt}, nil
+	}
+	return nil, fmt.Errorf("%w: %s/%s", ErrRateNotFound, from, to)
+}
+
+// Charge is a price and what paying it costs the payer. Amount in Currency is
+// taken from the payer's wallet; when Currency differs from PriceCurrency,
+// Quote is the rate the price was converted at.
+type Charge struct {
+	Price         money.Amount `json:"price"`
+	PriceCurrency string       `json:"price_currency"`
+	Amount        money.Amount `json:"amount"`
+	Currency      string       `json:"currency"`
+	Quote         *Quote       `json:"quote,omitempty"`
+	// Available is the balance of the wallet the charge is paid from, as it
+	// was when the charge was priced.
+	Available money.Amount `json:"available"`
+}
+
+// Covered reports whether the paying wallet held enough when the charge was
+// priced. The ledger checks again when the charge is posted.
+func (c *Charge) Covered() bool {
+	return c.Available >= c.Amount
+}
+
+// NewCharge decides how userID pays price. A sub-balance in the price's
+// currency that covers it is used as is; otherwise the price is converted
+// into the currency of the user's primary wallet at the current rate, rounded
+// half up.
+func NewCharge(db *gorm.DB, userID uint, price money.Amount, currency string) (*Charge, error) {
+	currency = strings.ToUpper(currency)
+	charge := &Charge{Price: price, PriceCurrency: currency, Amount: price, Currency: currency}
+
+	var wallets []models.Wallet
+	if err := db.Where("user_id = ?", userID).Find(&wallets).Error; err != nil {
+		return nil, fmt.Errorf("failed to load wallets for user %d: %w", userID, err)
+	}
+	var primary *models.Wallet
+	for i := range wallets {
+		wallet := &wallets[i]
+		if strings.EqualFold(wallet.Currency, currency) && wallet.Balance >= price {
+			charge.Available = wallet.Balance
+			return charge, nil
+		}
+		if !wallet.SubBalance {
+			primary = wallet
+		}
+	}
+	if primary == nil {
+		return nil, fmt.Errorf("%w for user %d", ledger.ErrWalletNotFound, userID)
+	}
+	charge.Available = primary.Balance
+	if strings.EqualFold(primary.Currency, currency) {
+		return charge, nil
+	}
+
+	quote, err := Lookup(db, currency, primary.Currency)
+	if err != nil {
+		return nil, err
+	}
+	charge.Currency = quote.To
+	charge.Amount = price.Convert(quote.Rate, quote.To, money.RoundHalfUp)
+	charge.Quote = quote
+	return charge, nil
+}
+
+// Recorded rebuilds the charge for price from what was recorded when an
+// earlier one was paid: the currency the payer's wallet was debited in and
+// the rate the price was converted at. An empty paidCurrency or a nil rate
+// means the price was paid as is.
+func Recorded(price money.Amount, priceCurrency, paidCurrency string, rate *money.Rate) *Charge {
+	priceCurrency = strings.ToUpper(priceCurrency)
+	charge := &Charge{Price: price, PriceCurrency: priceCurrency, Amount: price, Currency: priceCurrency}
+	if paidCurrency == "" || rate == nil || strings.EqualFold(paidCurrency, priceCurrency) {
+		return charge
+	}
+	charge.Currency = strings.ToUpper(paidCurrency)
+	charge.Amount = price.Convert(*rate, charge.Currency, money.RoundHalfUp)
+	charge.Quote = &Quote{From: priceCurrency, To: charge.Currency, Rate: *rate}
+	return charge
+}
+
+// Converted reports whether the payer pays in a different currency.
+func (c *Charge) Converted() bool {
+	return c.Quote != nil
+}
+
+// Rate returns the rate the price was converted at, nil if it was not.
+func (c *Charge) Rate() *money.Rate {
+	if !c.Converted() {
+		return nil
+	}
+	rate := c.Quote.Rate
+	return &rate
+}
+
+// Legs returns the ledger legs that take the charge from userID's wallet and
+// leave Price in PriceCurrency to be credited to the payees. walletTx, the
+// payer's side of the entry, is stamped with the currency it was paid in and,
+// for a converted charge, the original price and the rate.
+func (c *Charge) Legs(userID uint, walletTx *models.WalletTransaction) []ledger.Leg {
+	c.stamp(walletTx)
+	legs := []ledger.Leg{ledger.Debit(ledger.WalletIn(userID, c.Currency), c.Amount, walletTx)}
+	if c.Converted() {
+		legs = append(legs, ledger.Exchange(c.Currency, c.Amount, c.PriceCurrency, c.Price)...)
+	}
+	return legs
+}
+
+// RefundLegs returns the ledger legs that reverse the charge: Price in
+// PriceCurrency, taken back from the payees by the caller's own legs, is
+// credited to userID's wallet as Amount in Currency. walletTx is stamped as
+// for Legs.
+func (c *Charge) RefundLegs(userID uint, walletTx *models.WalletTransaction) []ledger.Leg {
+	c.stamp(walletTx)
+	legs := []ledger.Leg{ledger.Credit(ledger.WalletIn(userID, c.Currency), c.Amount, walletTx)}
+	if c.Converted() {
+		legs = append(legs, ledger.Exchange(c.PriceCurrency, c.Price, c.Currency, c.Amount)...)
+	}
+	return legs
+}
+
+func (c *Charge) stamp(walletTx *models.WalletTransaction) {
+	if walletTx == nil {
+		return
+	}
+	walletTx.Currency = c.Currency
+	if c.Converted() {
+		price, rate := c.Price, c.Quote.Rate
+		walletTx.OriginalAmount = &price
+		walletTx.OriginalCurrency = c.PriceCurrency
+		walletTx.FXRate = &rate
+		walletTx.FXRateID = c.Quote.RateID
+	}
+}
+
+// String describes the charge for error messages, e.g. "12.00 USD (999.00 INR
+// at 0.012)".
+func (c *Charge) String() string {
+	s := fmt.Sprintf("%s %s", c.Amount.Format(c.Currency), c.Currency)
+	if c.Converted() {
+		s += fmt.Sprintf(" (%s %s at %s)", c.Price.Format(c.PriceCurrency), c.PriceCurrency, c.Quote.Rate)
+	}
+	return s
+}
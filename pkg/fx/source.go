@@ -0,0 +1,81 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+)
+
+// Source feeds exchange rates from outside the platform.
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context) ([]models.FXRate, error)
+}
+
+// HTTPSource reads a rates document in the common
+// {"base": "USD", "rates": {"INR": 83.12, ...}} shape.
+type HTTPSource struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewHTTPSource(url string) *HTTPSource {
+	return &HTTPSource{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (s *HTTPSource) Name() string {
+	return "feed"
+}
+
+func (s *HTTPSource) Fetch(ctx context.Context) ([]models.FXRate, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build rates request: %w", err)
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch rates: feed returned %s", resp.Status)
+	}
+	return ParseRatesDocument(resp.Body, s.Name())
+}
+
+// ParseRatesDocument decodes a {"base", "rates"} document. Rates are read as
+// exact decimals; non-positive ones are skipped.
+func ParseRatesDocument(r io.Reader, source string) ([]models.FXRate, error) {
+	var doc struct {
+		Base  string                     `json:"base"`
+		Rates map[string]json.RawMessage `json:"rates"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode rates: %w", err)
+	}
+	base := strings.ToUpper(doc.Base)
+	if base == "" {
+		return nil, fmt.Errorf("failed to decode rates: document has no base currency")
+	}
+
+	rates := make([]models.FXRate, 0, len(doc.Rates))
+	for quote, raw := range doc.Rates {
+		var rate money.Rate
+		if err := rate.UnmarshalJSON(raw); err != nil {
+			return nil, fmt.Errorf("failed to decode %s/%s rate: %w", base, quote, err)
+		}
+		quote = strings.ToUpper(quote)
+		if quote == base || !rate.IsPositive() {
+			continue
+		}
+		rates = append(rates, models.FXRate{BaseCurrency: base, QuoteCurrency: quote, Rate: rate, Source: source})
+	}
+	return rates, nil
+}
@@ -0,0 +1,177 @@
+// Package idempotency lets clients retry money-moving requests safely. A
+// request carrying an Idempotency-Key header is executed once; retries with
+// the same key and body get the stored response back instead of running the
+// handler again.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+
+	DefaultTTL   = 24 * time.Hour
+	maxKeyLength = 255
+)
+
+type Options struct {
+	// TTL is how long a key is remembered. Zero uses DefaultTTL.
+	TTL time.Duration
+	// Required rejects requests that carry no key.
+	Required bool
+}
+
+// Fingerprint identifies a request by method, path and body.
+func Fingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", method, path)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// scope keeps keys of different callers apart. It must run after the auth
+// middleware that sets userID.
+func scope(c *gin.Context) string {
+	if userID, ok := c.Get("userID"); ok {
+		return fmt.Sprintf("user:%v", userID)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// Middleware returns a handler that makes the routes it guards idempotent.
+//
+// The first request with a key runs normally and its response is stored. A
+// retry with the same key and body replays that response with the
+// Idempotent-Replayed header set; the same key with a different body gets 422,
+// and a retry while the first request is still running gets 409. Responses
+// with a 5xx status are not stored, so the client may retry with the same key.
+func Middleware(store Store, opts Options) gin.HandlerFunc {
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderKey)
+		if key == "" {
+			if opts.Required {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key header is required"})
+				return
+			}
+			c.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Idempotency-Key must be at most %d characters", maxKeyLength)})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		record := &models.IdempotencyRecord{
+			Scope:       scope(c),
+			Key:         key,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			Fingerprint: Fingerprint(c.Request.Method, c.Request.URL.Path, body),
+			Status:      models.IdempotencyInProgress,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}
+
+		existing, claimed, err := store.Reserve(c.Request.Context(), record)
+		if err != nil {
+			log.Printf("Idempotency: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check idempotency key"})
+			return
+		}
+		if !claimed {
+			replay(c, existing, record.Fingerprint)
+			return
+		}
+
+		// The store calls after the handler must not be cut short by the
+		// client disconnecting.
+		ctx := context.WithoutCancel(c.Request.Context())
+		defer func() {
+			if r := recover(); r != nil {
+				if err := store.Release(ctx, record); err != nil {
+					log.Printf("Idempotency: %v", err)
+				}
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := store.Release(ctx, record); err != nil {
+				log.Printf("Idempotency: %v", err)
+			}
+			return
+		}
+		record.ResponseStatus = status
+		record.ContentType = recorder.Header().Get("Content-Type")
+		record.ResponseBody = recorder.body.Bytes()
+		if err := store.Complete(ctx, record); err != nil {
+			log.Printf("Idempotency: %v", err)
+		}
+	}
+}
+
+func replay(c *gin.Context, existing *models.IdempotencyRecord, fingerprint string) {
+	if existing.Fingerprint != fingerprint {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+		return
+	}
+	if existing.Status != models.IdempotencyCompleted {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+		return
+	}
+
+	c.Header(HeaderReplayed, "true")
+	contentType := existing.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Data(existing.ResponseStatus, contentType, existing.ResponseBody)
+	c.Abort()
+}
+
+// responseRecorder copies the response body while it is written to the client.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,166 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Store persists idempotency records.
+type Store interface {
+	// Reserve claims record.Key within record.Scope. When the key is already
+	// held by an unexpired record, that record is returned with claimed false.
+	Reserve(ctx context.Context, record *models.IdempotencyRecord) (existing *models.IdempotencyRecord, claimed bool, err error)
+	// Complete stores the response of a reserved record.
+	Complete(ctx context.Context, record *models.IdempotencyRecord) error
+	// Release drops a reservation so the key can be retried.
+	Release(ctx context.Context, record *models.IdempotencyRecord) error
+	// Purge deletes records that expired before the given time.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+type gormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) Store {
+	return &gormStore{db: db}
+}
+
+func (s *gormStore) Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error) {
+	var existing *models.IdempotencyRecord
+	claimed := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("scope = ? AND key = ? AND expires_at < ?", record.Scope, record.Key, time.Now()).
+			Delete(&models.IdempotencyRecord{}).Error; err != nil {
+			return fmt.Errorf("failed to clear expired idempotency key: %w", err)
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil {
+			return fmt.Errorf("failed to reserve idempotency key: %w", result.Error)
+		}
+		if result.RowsAffected > 0 {
+			claimed = true
+			return nil
+		}
+
+		var found models.IdempotencyRecord
+		if err := tx.Where("scope = ? AND key = ?", record.Scope, record.Key).First(&found).Error; err != nil {
+			return fmt.Errorf("failed to load idempotency key: %w", err)
+		}
+		existing = &found
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return existing, claimed, nil
+}
+
+func (s *gormStore) Complete(ctx context.Context, record *models.IdempotencyRecord) error {
+	if err := s.db.WithContext(ctx).Model(&models.IdempotencyRecord{}).
+		Where("id = ?", record.ID).
+		Updates(map[string]interface{}{
+			"status":          models.IdempotencyCompleted,
+			"response_status": record.ResponseStatus,
+			"content_type":    record.ContentType,
+			"response_body":   record.ResponseBody,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+func (s *gormStore) Release(ctx context.Context, record *models.IdempotencyRecord) error {
+	if err := s.db.WithContext(ctx).Delete(&models.IdempotencyRecord{}, record.ID).Error; err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (s *gormStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.IdempotencyRecord{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// memoryStore keeps records in process. It suits tests and single-instance
+// development setups; records are lost on restart.
+type memoryStore struct {
+	mu      sync.Mutex
+	nextID  uint
+	records map[string]*models.IdempotencyRecord
+}
+
+func NewMemoryStore() Store {
+	return &memoryStore{records: make(map[string]*models.IdempotencyRecord)}
+}
+
+func memoryKey(scope, key string) string {
+	return scope + "\x00" + key
+}
+
+func (s *memoryStore) Reserve(_ context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := memoryKey(record.Scope, record.Key)
+	if found, ok := s.records[k]; ok && found.ExpiresAt.After(time.Now()) {
+		copied := *found
+		return &copied, false, nil
+	}
+	s.nextID++
+	record.ID = s.nextID
+	stored := *record
+	s.records[k] = &stored
+	return nil, true, nil
+}
+
+func (s *memoryStore) Complete(_ context.Context, record *models.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found, ok := s.records[memoryKey(record.Scope, record.Key)]
+	if !ok || found.ID != record.ID {
+		return errors.New("idempotency key is not reserved")
+	}
+	found.Status = models.IdempotencyCompleted
+	found.ResponseStatus = record.ResponseStatus
+	found.ContentType = record.ContentType
+	found.ResponseBody = append([]byte(nil), record.ResponseBody...)
+	return nil
+}
+
+func (s *memoryStore) Release(_ context.Context, record *models.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := memoryKey(record.Scope, record.Key)
+	if found, ok := s.records[k]; ok && found.ID == record.ID {
+		delete(s.records, k)
+	}
+	return nil
+}
+
+func (s *memoryStore) Purge(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for k, record := range s.records {
+		if record.ExpiresAt.Before(before) {
+			delete(s.records, k)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,490 @@
+// Package ledger records every movement of money as a balanced double-entry
+// journal entry. Wallet balances are a projection of the ledger: they are only
+// changed by Post, in the same database transaction as the journal rows.
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultCurrency = "USD"
+
+	TxTypeOpeningBalance models.TransactionType = "OPENING_BALANCE"
+)
+
+var (
+	ErrEmptyEntry        = errors.New("journal entry needs at least two non-zero legs")
+	ErrUnbalancedEntry   = errors.New("journal entry does not balance")
+	ErrInsufficientFunds = errors.New("insufficient wallet balance")
+	ErrWalletNotFound    = errors.New("wallet not found")
+	ErrPlatformAccount   = errors.New("platform commission wallet not found")
+)
+
+// Account identifies a ledger account. Build one with the helpers below.
+type Account struct {
+	Kind     models.LedgerAccountKind
+	OwnerID  uint
+	Currency string
+}
+
+// Wallet is the user's primary wallet.
+func Wallet(userID uint) Account {
+	return Account{Kind: models.LedgerUserWallet, OwnerID: userID}
+}
+
+// WalletIn is the user's wallet in currency: the primary wallet if it is held
+// in that currency, otherwise a sub-balance, opened on first use.
+func WalletIn(userID uint, currency string) Account {
+	return Account{Kind: models.LedgerUserWallet, OwnerID: userID, Currency: currency}
+}
+
+// PlatformCommission is the platform's own wallet, held by the admin user.
+func PlatformCommission() Account {
+	return Account{Kind: models.LedgerPlatformCommission}
+}
+
+// PlatformCommissionIn is the platform's wallet in currency.
+func PlatformCommissionIn(currency string) Account {
+	return Account{Kind: models.LedgerPlatformCommission, Currency: currency}
+}
+
+// GatewayClearing is the counterparty for money entering or leaving the
+// platform through the payment gateway.
+func GatewayClearing(currency string) Account {
+	return Account{Kind: models.LedgerGatewayClearing, Currency: currency}
+}
+
+// TraderPayable holds money owed to a trader that has left their wallet but
+// has not been paid out yet.
+func TraderPayable(traderID uint) Account {
+	return Account{Kind: models.LedgerTraderPayable, OwnerID: traderID}
+}
+
+// TradeMargin holds the margin a user has locked in open trades.
+func TradeMargin(userID uint) Account {
+	return Account{Kind: models.LedgerTradeMargin, OwnerID: userID}
+}
+
+// MarketSettlement is the counterparty for realised trading profit and loss.
+func MarketSettlement() Account {
+	return Account{Kind: models.LedgerMarketSettlement}
+}
+
+// FXPosition is the platform's position in currency from conversions.
+func FXPosition(currency string) Account {
+	return Account{Kind: models.LedgerFXPosition, Currency: currency}
+}
+
+// Leg moves Amount into an account; a negative amount moves money out. For
+// wallet-backed accounts a WalletTransaction is written for the leg: Tx may
+// carry its descriptive fields, and the amounts, balances and owner are filled
+// in by Post. After Post returns, Tx holds the stored row.
+type Leg struct {
+	Account Account
+	Amount  money.Amount
+	Tx      *models.WalletTransaction
+}
+
+func Credit(account Account, amount money.Amount, walletTx *models.WalletTransaction) Leg {
+	return Leg{Account: account, Amount: amount, Tx: walletTx}
+}
+
+func Debit(account Account, amount money.Amount, walletTx *models.WalletTransaction) Leg {
+	return Leg{Account: account, Amount: -amount, Tx: walletTx}
+}
+
+// Exchange returns the legs that convert fromAmount of one currency into
+// toAmount of another through the platform's FX positions. An entry that
+// debits a wallet in from and credits wallets in to stays balanced in each
+// currency once these legs are added.
+func Exchange(from string, fromAmount money.Amount, to string, toAmount money.Amount) []Leg {
+	return []Leg{
+		Credit(FXPosition(from), fromAmount, nil),
+		Debit(FXPosition(to), toAmount, nil),
+	}
+}
+
+// Entry is a set of legs that must sum to zero in each currency.
+type Entry struct {
+	Type        models.TransactionType
+	Reference   string
+	Description string
+	// Currency is used for ledger-only accounts that do not name one. It
+	// defaults to the currency of the first wallet in the entry.
+	Currency string
+	Legs     []Leg
+}
+
+type posting struct {
+	leg     Leg
+	account *models.LedgerAccount
+	wallet  *models.Wallet
+}
+
+// Post writes the entry inside tx, which must be a database transaction.
+// Wallets are locked in user ID order, debits that would take a wallet below
+// zero are rejected, and an entry whose legs are all zero is a no-op.
+func Post(tx *gorm.DB, entry Entry) (*models.JournalEntry, error) {
+	var postings []*posting
+	for _, leg := range entry.Legs {
+		if leg.Amount.IsZero() {
+			continue
+		}
+		postings = append(postings, &posting{leg: leg})
+	}
+	if len(postings) == 0 {
+		return nil, nil
+	}
+	if len(postings) < 2 {
+		return nil, ErrEmptyEntry
+	}
+
+	// Without an exchange every leg is in one currency, so a bad entry can be
+	// turned away before any wallet is locked.
+	exchange := false
+	var total money.Amount
+	for _, p := range postings {
+		total += p.leg.Amount
+		exchange = exchange || p.leg.Account.Kind == models.LedgerFXPosition
+	}
+	if !exchange && total != 0 {
+		return nil, fmt.Errorf("%w: legs sum to %s", ErrUnbalancedEntry, total)
+	}
+
+	if err := lockWallets(tx, postings); err != nil {
+		return nil, err
+	}
+	if entry.Currency == "" {
+		entry.Currency = defaultCurrency
+		for _, p := range postings {
+			if p.wallet != nil {
+				entry.Currency = p.wallet.Currency
+				break
+			}
+		}
+	}
+
+	sums := make(map[string]money.Amount)
+	for _, p := range postings {
+		sums[p.currency(entry.Currency)] += p.leg.Amount
+	}
+	for currency, sum := range sums {
+		if sum != 0 {
+			return nil, fmt.Errorf("%w: %s legs sum to %s", ErrUnbalancedEntry, currency, sum)
+		}
+	}
+	var err error
+
+	now := time.Now()
+	journal := &models.JournalEntry{
+		Type:        entry.Type,
+		Reference:   entry.Reference,
+		Description: entry.Description,
+		CreatedAt:   now,
+	}
+
+	for _, p := range postings {
+		if p.wallet != nil {
+			p.account, err = walletAccount(tx, p.leg.Account.Kind, p.wallet)
+		} else {
+			p.account, err = systemAccount(tx, p.leg.Account.Kind, p.leg.Account.OwnerID, p.currency(entry.Currency))
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var walletTxID *uint
+		if p.wallet != nil {
+			walletTx, err := applyWalletLeg(tx, entry, p, now)
+			if err != nil {
+				return nil, err
+			}
+			walletTxID = &walletTx.ID
+		}
+
+		if err := tx.Model(p.account).UpdateColumns(map[string]interface{}{
+			"balance":    gorm.Expr("balance + ?", p.leg.Amount),
+			"updated_at": now,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("failed to update ledger account %d: %w", p.account.ID, err)
+		}
+		if err := tx.Select("balance").First(p.account, p.account.ID).Error; err != nil {
+			return nil, fmt.Errorf("failed to read ledger account %d: %w", p.account.ID, err)
+		}
+
+		journal.Postings = append(journal.Postings, models.JournalPosting{
+			AccountID:           p.account.ID,
+			Amount:              p.leg.Amount,
+			BalanceAfter:        p.account.Balance,
+			WalletTransactionID: walletTxID,
+			CreatedAt:           now,
+		})
+	}
+
+	if err := tx.Create(journal).Error; err != nil {
+		return nil, fmt.Errorf("failed to write journal entry: %w", err)
+	}
+	return journal, nil
+}
+
+// currency is the currency the posting moves: its wallet's, or for ledger-only
+// accounts the one the account names, falling back to the entry's.
+func (p *posting) currency(entryCurrency string) string {
+	if p.wallet != nil {
+		return p.wallet.Currency
+	}
+	if p.leg.Account.Currency != "" {
+		return p.leg.Account.Currency
+	}
+	return entryCurrency
+}
+
+// PlatformOwnerID returns the admin user whose wallet backs the platform
+// commission account.
+func PlatformOwnerID(tx *gorm.DB) (uint, error) {
+	var ids []uint
+	if err := tx.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Order("id ASC").Limit(1).Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("failed to find admin user: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, ErrPlatformAccount
+	}
+	return ids[0], nil
+}
+
+type walletKey struct {
+	userID   uint
+	currency string
+}
+
+// lockWallets resolves and locks the wallet behind every wallet-backed leg, in
+// ascending user ID and then currency so concurrent entries cannot deadlock on
+// each other. Legs without a currency resolve to the owner's primary wallet;
+// a sub-balance in a currency the owner does not hold yet is opened empty.
+func lockWallets(tx *gorm.DB, postings []*posting) error {
+	var adminID uint
+	primaryCurrencies := make(map[uint]string)
+	keys := make(map[walletKey]bool)
+	for _, p := range postings {
+		if !p.leg.Account.Kind.IsWalletBacked() {
+			continue
+		}
+		if p.leg.Account.Kind == models.LedgerPlatformCommission && p.leg.Account.OwnerID == 0 {
+			if adminID == 0 {
+				id, err := PlatformOwnerID(tx)
+				if err != nil {
+					return err
+				}
+				adminID = id
+			}
+			p.leg.Account.OwnerID = adminID
+		}
+
+		if p.leg.Account.Currency == "" {
+			ownerID := p.leg.Account.OwnerID
+			currency, ok := primaryCurrencies[ownerID]
+			if !ok {
+				var currencies []string
+				if err := tx.Model(&models.Wallet{}).Scopes(models.PrimaryWallet).
+					Where("user_id = ?", ownerID).Limit(1).Pluck("currency", &currencies).Error; err != nil {
+					return fmt.Errorf("failed to find wallet for user %d: %w", ownerID, err)
+				}
+				if len(currencies) == 0 {
+					return fmt.Errorf("%w for user %d", ErrWalletNotFound, ownerID)
+				}
+				currency = currencies[0]
+				primaryCurrencies[ownerID] = currency
+			}
+			p.leg.Account.Currency = currency
+		}
+		keys[walletKey{p.leg.Account.OwnerID, p.leg.Account.Currency}] = true
+	}
+
+	ordered := make([]walletKey, 0, len(keys))
+	for key := range keys {
+		ordered = append(ordered, key)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].userID != ordered[j].userID {
+			return ordered[i].userID < ordered[j].userID
+		}
+		return ordered[i].currency < ordered[j].currency
+	})
+
+	wallets := make(map[walletKey]*models.Wallet, len(ordered))
+	for _, key := range ordered {
+		wallet, err := lockWallet(tx, key)
+		if err != nil {
+			return err
+		}
+		wallets[key] = wallet
+	}
+
+	for _, p := range postings {
+		if p.leg.Account.Kind.IsWalletBacked() {
+			p.wallet = wallets[walletKey{p.leg.Account.OwnerID, p.leg.Account.Currency}]
+		}
+	}
+	return nil
+}
+
+func lockWallet(tx *gorm.DB, key walletKey) (*models.Wallet, error) {
+	var wallet models.Wallet
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND currency = ?", key.userID, key.currency).
+		First(&wallet).Error
+	if err == nil {
+		return &wallet, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to lock %s wallet for user %d: %w", key.currency, key.userID, err)
+	}
+
+	var primaries int64
+	if err := tx.Model(&models.Wallet{}).Scopes(models.PrimaryWallet).Where("user_id = ?", key.userID).Count(&primaries).Error; err != nil {
+		return nil, fmt.Errorf("failed to find wallet for user %d: %w", key.userID, err)
+	}
+	if primaries == 0 {
+		return nil, fmt.Errorf("%w for user %d", ErrWalletNotFound, key.userID)
+	}
+
+	sub := models.Wallet{UserID: key.userID, Currency: key.currency, SubBalance: true, LastUpdated: time.Now()}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&sub).Error; err != nil {
+		return nil, fmt.Errorf("failed to open %s sub-balance for user %d: %w", key.currency, key.userID, err)
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND currency = ?", key.userID, key.currency).
+		First(&wallet).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock %s wallet for user %d: %w", key.currency, key.userID, err)
+	}
+	return &wallet, nil
+}
+
+// walletAccount returns the ledger account behind a locked wallet. The first
+// time a wallet is seen its existing balance is brought into the ledger with an
+// opening balance entry, so the account and the wallet agree from then on.
+func walletAccount(tx *gorm.DB, kind models.LedgerAccountKind, wallet *models.Wallet) (*models.LedgerAccount, error) {
+	var account models.LedgerAccount
+	err := tx.Where("wallet_id = ?", wallet.ID).First(&account).Error
+	if err == nil {
+		return &account, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load ledger account for wallet %d: %w", wallet.ID, err)
+	}
+
+	account = models.LedgerAccount{
+		Kind:     kind,
+		OwnerID:  wallet.UserID,
+		Currency: wallet.Currency,
+		WalletID: &wallet.ID,
+		Balance:  wallet.Balance,
+	}
+	if err := tx.Create(&account).Error; err != nil {
+		return nil, fmt.Errorf("failed to open ledger account for wallet %d: %w", wallet.ID, err)
+	}
+	if account.Balance == 0 {
+		return &account, nil
+	}
+
+	opening, err := systemAccount(tx, models.LedgerOpeningBalance, 0, wallet.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Model(opening).UpdateColumn("balance", gorm.Expr("balance - ?", account.Balance)).Error; err != nil {
+		return nil, fmt.Errorf("failed to update opening balance account: %w", err)
+	}
+	if err := tx.Select("balance").First(opening, opening.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to read opening balance account: %w", err)
+	}
+
+	now := time.Now()
+	journal := &models.JournalEntry{
+		Type:        TxTypeOpeningBalance,
+		Reference:   fmt.Sprintf("OPENING_WALLET_%d", wallet.ID),
+		Description: fmt.Sprintf("Opening balance of wallet %d brought into the ledger", wallet.ID),
+		CreatedAt:   now,
+		Postings: []models.JournalPosting{
+			{AccountID: opening.ID, Amount: -account.Balance, BalanceAfter: opening.Balance, CreatedAt: now},
+			{AccountID: account.ID, Amount: account.Balance, BalanceAfter: account.Balance, CreatedAt: now},
+		},
+	}
+	if err := tx.Create(journal).Error; err != nil {
+		return nil, fmt.Errorf("failed to write opening balance entry: %w", err)
+	}
+	return &account, nil
+}
+
+func systemAccount(tx *gorm.DB, kind models.LedgerAccountKind, ownerID uint, currency string) (*models.LedgerAccount, error) {
+	account := models.LedgerAccount{Kind: kind, OwnerID: ownerID, Currency: currency}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&account).Error; err != nil {
+		return nil, fmt.Errorf("failed to open %s ledger account: %w", kind, err)
+	}
+	if err := tx.Where("kind = ? AND owner_id = ? AND currency = ?", kind, ownerID, currency).First(&account).Error; err != nil {
+		return nil, fmt.Errorf("failed to load %s ledger account: %w", kind, err)
+	}
+	return &account, nil
+}
+
+func applyWalletLeg(tx *gorm.DB, entry Entry, p *posting, now time.Time) (*models.WalletTransaction, error) {
+	wallet := p.wallet
+	before := wallet.Balance
+	after := before + p.leg.Amount
+	if after.IsNegative() {
+		return nil, fmt.Errorf("%w: wallet %d has %s, needs %s", ErrInsufficientFunds, wallet.ID, before, p.leg.Amount.Neg())
+	}
+
+	wallet.Balance = after
+	wallet.LastUpdated = now
+	if err := tx.Model(wallet).UpdateColumns(map[string]interface{}{
+		"balance":      after,
+		"last_updated": now,
+		"updated_at":   now,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update wallet %d: %w", wallet.ID, err)
+	}
+
+	walletTx := p.leg.Tx
+	if walletTx == nil {
+		walletTx = &models.WalletTransaction{}
+	}
+	walletTx.WalletID = wallet.ID
+	walletTx.UserID = wallet.UserID
+	walletTx.Amount = p.leg.Amount.Abs()
+	walletTx.BalanceBefore = before
+	walletTx.BalanceAfter = after
+	if walletTx.Type == "" {
+		walletTx.Type = entry.Type
+	}
+	if walletTx.TransactionType == "" {
+		walletTx.TransactionType = models.TxTypeCredit
+		if p.leg.Amount.IsNegative() {
+			walletTx.TransactionType = models.TxTypeDebit
+		}
+	}
+	if walletTx.Currency == "" {
+		walletTx.Currency = wallet.Currency
+	}
+	if walletTx.Status == "" {
+		walletTx.Status = models.TxStatusSuccess
+	}
+	if walletTx.ReferenceID == "" {
+		walletTx.ReferenceID = entry.Reference
+	}
+	if walletTx.Description == "" {
+		walletTx.Description = entry.Description
+	}
+	if err := tx.Create(walletTx).Error; err != nil {
+		return nil, fmt.Errorf("failed to record wallet transaction: %w", err)
+	}
+	return walletTx, nil
+}
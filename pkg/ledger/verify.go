@@ -0,0 +1,74 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"gorm.io/gorm"
+)
+
+// AccountMismatch is an account whose stored balance disagrees with the sum of
+// its postings or, for wallet-backed accounts, with the wallet.
+type AccountMismatch struct {
+	AccountID      uint                     `json:"account_id"`
+	Kind           models.LedgerAccountKind `json:"kind"`
+	OwnerID        uint                     `json:"owner_id"`
+	WalletID       *uint                    `json:"wallet_id,omitempty"`
+	AccountBalance money.Amount             `json:"account_balance"`
+	JournalBalance money.Amount             `json:"journal_balance"`
+	WalletBalance  *money.Amount            `json:"wallet_balance,omitempty"`
+}
+
+// UnbalancedEntry is a journal entry whose postings in one currency do not
+// sum to zero.
+type UnbalancedEntry struct {
+	EntryID  uint         `json:"entry_id"`
+	Currency string       `json:"currency"`
+	Sum      money.Amount `json:"sum"`
+}
+
+type Report struct {
+	Accounts []AccountMismatch `json:"accounts"`
+	Entries  []UnbalancedEntry `json:"entries"`
+}
+
+func (r *Report) OK() bool {
+	return len(r.Accounts) == 0 && len(r.Entries) == 0
+}
+
+// Verify checks the ledger against itself and against the wallets: every entry
+// must balance in each currency, every account must equal the sum of its postings, and every
+// wallet-backed account must equal its wallet.
+func Verify(ctx context.Context, db *gorm.DB) (*Report, error) {
+	report := &Report{}
+
+	if err := db.WithContext(ctx).Raw(`
+		SELECT p.entry_id, a.currency, SUM(p.amount) AS sum
+		FROM journal_postings p
+		JOIN ledger_accounts a ON a.id = p.account_id
+		GROUP BY p.entry_id, a.currency
+		HAVING SUM(p.amount) <> 0
+		ORDER BY p.entry_id`).Scan(&report.Entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to check journal entries: %w", err)
+	}
+
+	if err := db.WithContext(ctx).Raw(`
+		SELECT a.id AS account_id, a.kind, a.owner_id, a.wallet_id,
+			a.balance AS account_balance,
+			COALESCE(p.total, 0) AS journal_balance,
+			w.balance AS wallet_balance
+		FROM ledger_accounts a
+		LEFT JOIN (
+			SELECT account_id, SUM(amount) AS total FROM journal_postings GROUP BY account_id
+		) p ON p.account_id = a.id
+		LEFT JOIN wallets w ON w.id = a.wallet_id
+		WHERE a.balance <> COALESCE(p.total, 0)
+			OR (a.wallet_id IS NOT NULL AND (w.id IS NULL OR w.balance <> a.balance))
+		ORDER BY a.id`).Scan(&report.Accounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to check ledger accounts: %w", err)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,48 @@
+// Package lockout decides how long an account is locked after repeated failed
+// logins. Locks grow with every further failure, so guessing a password
+// slows down sharply while a user who mistypes a few times is barely held up.
+package lockout
+
+import (
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/config"
+)
+
+type Policy struct {
+	// Threshold is the number of consecutive failures that locks the
+	// account. Zero disables lockout.
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+}
+
+// PolicyFromAppConfig builds the policy from the login_lockout section of the
+// application config.
+func PolicyFromAppConfig(cfg *config.Config) Policy {
+	return Policy{
+		Threshold: cfg.LoginLockout.Threshold,
+		Base:      time.Duration(cfg.LoginLockout.BaseMinutes) * time.Minute,
+		Max:       time.Duration(cfg.LoginLockout.MaxMinutes) * time.Minute,
+	}
+}
+
+// LockFor returns how long to lock the account after its failures-th
+// consecutive failed login: nothing below the threshold, Base when it is
+// reached, then twice as long for each further failure up to Max.
+func (p Policy) LockFor(failures int) time.Duration {
+	if p.Threshold <= 0 || failures < p.Threshold {
+		return 0
+	}
+	d := p.Base
+	for i := p.Threshold; i < failures; i++ {
+		d *= 2
+		if p.Max > 0 && d >= p.Max {
+			return p.Max
+		}
+	}
+	if p.Max > 0 && d > p.Max {
+		return p.Max
+	}
+	return d
+}
@@ -0,0 +1,163 @@
+// Package mailer sends the platform's transactional email. Mail goes through
+// the Mailer interface; SMTP delivers it for real, while the file and log
+// drivers keep it on the local machine for development.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/config"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the mailer selected by the mail section of the config.
+func New(cfg *config.Config) (Mailer, error) {
+	switch strings.ToLower(cfg.Mail.Driver) {
+	case "", "log":
+		return &LogMailer{From: cfg.Mail.From}, nil
+	case "file":
+		return &FileMailer{From: cfg.Mail.From, Dir: cfg.Mail.FileDir}, nil
+	case "smtp":
+		if cfg.Mail.SMTP.Host == "" {
+			return nil, fmt.Errorf("mail.smtp.host is required for the smtp mail driver")
+		}
+		return NewSMTPMailer(cfg.Mail.SMTP.Host, cfg.Mail.SMTP.Port, cfg.Mail.SMTP.Username, cfg.Mail.SMTP.Password, cfg.Mail.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Mail.Driver)
+	}
+}
+
+// SMTPMailer delivers mail through an SMTP server, using STARTTLS when the
+// server offers it.
+type SMTPMailer struct {
+	Addr string
+	From string
+	Auth smtp.Auth
+}
+
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	m := &SMTPMailer{Addr: net.JoinHostPort(host, strconv.Itoa(port)), From: from}
+	if username != "" {
+		m.Auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := compose(m.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := smtp.SendMail(m.Addr, m.Auth, address(m.From), []string{msg.To}, data); err != nil {
+		return fmt.Errorf("failed to send mail to %s: %w", msg.To, err)
+	}
+	return nil
+}
+
+// FileMailer writes each message to Dir as an .eml file that mail clients can
+// open.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	now := time.Now()
+	data, err := compose(m.From, msg, now)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create mail directory '%s': %w", m.Dir, err)
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("failed to name mail file: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+	if err := os.WriteFile(filepath.Join(m.Dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	return nil
+}
+
+// LogMailer prints messages to the log instead of sending them.
+type LogMailer struct {
+	From string
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := checkHeaders(msg); err != nil {
+		return err
+	}
+	log.Printf("[MAIL] From: %s | To: %s | Subject: %s\n%s", m.From, msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// compose renders msg as an RFC 5322 message.
+func compose(from string, msg Message, date time.Time) ([]byte, error) {
+	if err := checkHeaders(msg); err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes(), nil
+}
+
+// checkHeaders refuses line breaks in header values, which would let them
+// inject extra headers or recipients.
+func checkHeaders(msg Message) error {
+	if msg.To == "" {
+		return fmt.Errorf("mail has no recipient")
+	}
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return fmt.Errorf("mail headers must not contain line breaks")
+	}
+	return nil
+}
+
+// address returns the bare address of "Name <addr>".
+func address(from string) string {
+	if i := strings.LastIndex(from, "<"); i >= 0 {
+		return strings.TrimSuffix(from[i+1:], ">")
+	}
+	return from
+}
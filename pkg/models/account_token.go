@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// AccountTokenPurpose says what an AccountToken may be used for.
+type AccountTokenPurpose string
+
+const (
+	AccountTokenVerifyEmail   AccountTokenPurpose = "verify_email"
+	AccountTokenPasswordReset AccountTokenPurpose = "password_reset"
+)
+
+// AccountToken is an emailed token that proves control of the account's
+// address, for verifying it or resetting the password. The token itself is
+// signed and carries its expiry; the row makes it single-use. Only its hash
+// is stored.
+type AccountToken struct {
+	ID        uint                `gorm:"primaryKey" json:"id"`
+	UserID    uint                `gorm:"not null;index" json:"user_id"`
+	Purpose   AccountTokenPurpose `gorm:"size:30;not null;index" json:"purpose"`
+	TokenHash string              `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time           `gorm:"not null;index" json:"expires_at"`
+	UsedAt    *time.Time          `json:"used_at,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+}
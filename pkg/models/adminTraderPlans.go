@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/fathimasithara01/tradeverse/pkg/money"
 	"gorm.io/gorm"
 )
 
@@ -10,7 +11,7 @@ type AdminTraderSubscriptionPlan struct {
 	gorm.Model
 	Name        string        `gorm:"size:100;not null;unique" json:"name"`
 	Description string        `gorm:"type:text" json:"description"`
-	Price       float64       `gorm:"type:numeric(18,4);not null" json:"price"`
+	Price       money.Amount  `gorm:"type:numeric(18,4);not null" json:"price"`
 	Currency    string        `gorm:"size:10;not null;default:'USD'" json:"currency"`
 	Duration    time.Duration `gorm:"type:integer;not null" json:"duration"`
 	Interval    string        `gorm:"size:20;not null" json:"interval"`
@@ -25,4 +26,7 @@ type AdminTraderSubscriptionPlan struct {
 	CreatedByAdminID uint    `json:"created_by_admin_id"` 
 
 	IsUpgradeToTrader bool `gorm:"default:false" json:"is_upgrade_to_trader"`
+
+	RefundPolicy     RefundPolicy `gorm:"size:30;not null;default:'NONE'" json:"refund_policy"`
+	RefundWindowDays uint         `gorm:"not null;default:0" json:"refund_window_days"`
 }
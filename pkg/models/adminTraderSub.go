@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/fathimasithara01/tradeverse/pkg/money"
 	"gorm.io/gorm"
 )
 
@@ -15,11 +16,20 @@ type CustomerToTraderSub struct {
 
 	TraderID *uint `gorm:"index" json:"trader_id,omitempty"`
 
-	StartDate     time.Time  `gorm:"not null" json:"start_date"`
-	EndDate       time.Time  `gorm:"not null" json:"end_date"`
-	IsActive      bool       `gorm:"default:true" json:"is_active"`
-	PaymentStatus string     `gorm:"size:50;not null" json:"payment_status"`
-	AmountPaid    float64    `gorm:"type:numeric(18,4);not null" json:"amount_paid"`
-	TransactionID string     `gorm:"size:255" json:"transaction_id"`
-	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"`
+	StartDate     time.Time    `gorm:"not null" json:"start_date"`
+	EndDate       time.Time    `gorm:"not null" json:"end_date"`
+	IsActive      bool         `gorm:"default:true" json:"is_active"`
+	PaymentStatus string       `gorm:"size:50;not null" json:"payment_status"`
+	AmountPaid    money.Amount `gorm:"type:numeric(18,4);not null" json:"amount_paid"`
+	TransactionID string       `gorm:"size:255" json:"transaction_id"`
+	DeactivatedAt *time.Time   `json:"deactivated_at,omitempty"`
+	// RefundedAmount is what was given back when the subscription was
+	// cancelled, in the plan's currency.
+	RefundedAmount money.Amount `gorm:"type:numeric(18,4);not null;default:0" json:"refunded_amount"`
+	// PaidCurrency is the currency the subscriber's wallet was debited in and
+	// PaidFXRate the rate the plan's price was converted at, if it was. A
+	// refund goes back the same way. Empty for subscriptions bought before
+	// they were recorded, which were paid in the plan's currency.
+	PaidCurrency string      `gorm:"size:10" json:"paid_currency,omitempty"`
+	PaidFXRate   *money.Rate `gorm:"type:numeric(20,8)" json:"paid_fx_rate,omitempty"`
 }
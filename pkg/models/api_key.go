@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// APIKey is a personal credential for scripts. The key is shown once when it
+// is created and only its hash is stored; Prefix is the public part that
+// identifies the key in listings. Scopes is a comma-separated list. Keys that
+// require signed requests keep their signing secret encrypted, since the
+// server has to recompute signatures.
+type APIKey struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	UserID           uint       `gorm:"not null;index" json:"user_id"`
+	Name             string     `gorm:"size:100;not null" json:"name"`
+	Prefix           string     `gorm:"size:20;not null;uniqueIndex" json:"prefix"`
+	KeyHash          string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	Scopes           string     `gorm:"size:255;not null" json:"-"`
+	RequireSignature bool       `gorm:"not null;default:false" json:"require_signature"`
+	SigningSecret    string     `gorm:"size:255" json:"-"`
+	ExpiresAt        time.Time  `gorm:"not null;index" json:"expires_at"`
+	LastUsedAt       *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP       string     `gorm:"size:64" json:"last_used_ip,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+type CandleInterval string
+
+const (
+	CandleInterval1m CandleInterval = "1m"
+	CandleInterval5m CandleInterval = "5m"
+	CandleInterval1h CandleInterval = "1h"
+	CandleInterval1d CandleInterval = "1d"
+)
+
+// CandleIntervals lists every stored interval from finest to coarsest.
+var CandleIntervals = []CandleInterval{CandleInterval1m, CandleInterval5m, CandleInterval1h, CandleInterval1d}
+
+// Candle is one OHLC bar built from market data ticks. The ticker feed carries
+// no per-trade volume, so there is no bar volume: Volume24H is a snapshot of
+// the exchange's rolling 24h volume at the last tick of the bar.
+type Candle struct {
+	ID        uint           `gorm:"primarykey" json:"-"`
+	Symbol    string         `gorm:"size:20;not null;uniqueIndex:idx_candle_bucket,priority:1" json:"symbol"`
+	Interval  CandleInterval `gorm:"column:candle_interval;size:5;not null;uniqueIndex:idx_candle_bucket,priority:2" json:"interval"`
+	OpenTime  time.Time      `gorm:"not null;uniqueIndex:idx_candle_bucket,priority:3" json:"open_time"`
+	Open      float64        `gorm:"type:numeric(20,8);not null" json:"open"`
+	High      float64        `gorm:"type:numeric(20,8);not null" json:"high"`
+	Low       float64        `gorm:"type:numeric(20,8);not null" json:"low"`
+	Close     float64        `gorm:"type:numeric(20,8);not null" json:"close"`
+	Volume24H float64        `gorm:"column:volume_24h;type:numeric(30,8);default:0" json:"volume_24h"`
+	TickCount int            `gorm:"default:0" json:"tick_count"`
+	UpdatedAt time.Time      `json:"-"`
+}
+
+func ParseCandleInterval(s string) (CandleInterval, error) {
+	for _, interval := range CandleIntervals {
+		if string(interval) == s {
+			return interval, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported interval %q, use 1m, 5m, 1h or 1d", s)
+}
+
+func (i CandleInterval) Duration() time.Duration {
+	switch i {
+	case CandleInterval1m:
+		return time.Minute
+	case CandleInterval5m:
+		return 5 * time.Minute
+	case CandleInterval1h:
+		return time.Hour
+	case CandleInterval1d:
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+// BucketStart returns the open time of the bar that t falls into, in UTC.
+func (i CandleInterval) BucketStart(t time.Time) time.Time {
+	return t.UTC().Truncate(i.Duration())
+}
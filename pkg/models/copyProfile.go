@@ -0,0 +1,188 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"gorm.io/gorm"
+)
+
+type CopySizingMode string
+
+const (
+	CopySizingFixed        CopySizingMode = "FIXED"
+	CopySizingProportional CopySizingMode = "PROPORTIONAL"
+	CopySizingMultiplier   CopySizingMode = "MULTIPLIER"
+)
+
+type CopyProfileStatus string
+
+const (
+	CopyProfileActive CopyProfileStatus = "ACTIVE"
+	CopyProfilePaused CopyProfileStatus = "PAUSED"
+)
+
+// CopyProfile holds a follower's sizing and risk limits for copying one master.
+type CopyProfile struct {
+	gorm.Model
+	FollowerID uint `gorm:"not null;uniqueIndex:idx_copy_profile_pair" json:"follower_id"`
+	MasterID   uint `gorm:"not null;uniqueIndex:idx_copy_profile_pair" json:"master_id"`
+
+	SizingMode    CopySizingMode `gorm:"type:varchar(20);not null;default:'MULTIPLIER'" json:"sizing_mode"`
+	FixedAmount   money.Amount   `gorm:"type:numeric(18,4);default:0" json:"fixed_amount"`
+	EquityPercent float64        `gorm:"type:decimal(5,2);default:0" json:"equity_percent"`
+	Multiplier    float64        `gorm:"type:decimal(10,4);default:1" json:"multiplier"`
+
+	MaxAllocationPerTrade money.Amount `gorm:"type:numeric(18,4);default:0" json:"max_allocation_per_trade"`
+	MaxOpenTrades         int          `gorm:"default:0" json:"max_open_trades"`
+	AllowedSymbols        string       `gorm:"type:text" json:"allowed_symbols"`
+	DeniedSymbols         string       `gorm:"type:text" json:"denied_symbols"`
+	DailyLossCap          money.Amount `gorm:"type:numeric(18,4);default:0" json:"daily_loss_cap"`
+
+	Status       CopyProfileStatus `gorm:"type:varchar(20);not null;default:'ACTIVE';index" json:"status"`
+	PausedReason string            `gorm:"size:255" json:"paused_reason,omitempty"`
+	PausedAt     *time.Time        `json:"paused_at,omitempty"`
+}
+
+type CopyProfileRequest struct {
+	MasterID              uint           `json:"master_id" binding:"required"`
+	SizingMode            CopySizingMode `json:"sizing_mode" binding:"required,oneof=FIXED PROPORTIONAL MULTIPLIER"`
+	FixedAmount           money.Amount   `json:"fixed_amount" binding:"gte=0"`
+	EquityPercent         float64        `json:"equity_percent" binding:"gte=0,lte=100"`
+	Multiplier            float64        `json:"multiplier" binding:"gte=0"`
+	MaxAllocationPerTrade money.Amount   `json:"max_allocation_per_trade" binding:"gte=0"`
+	MaxOpenTrades         int            `json:"max_open_trades" binding:"gte=0"`
+	AllowedSymbols        []string       `json:"allowed_symbols"`
+	DeniedSymbols         []string       `json:"denied_symbols"`
+	DailyLossCap          money.Amount   `json:"daily_loss_cap" binding:"gte=0"`
+}
+
+type UpdateCopyProfileRequest struct {
+	SizingMode            *CopySizingMode `json:"sizing_mode" binding:"omitempty,oneof=FIXED PROPORTIONAL MULTIPLIER"`
+	FixedAmount           *money.Amount   `json:"fixed_amount" binding:"omitempty,gte=0"`
+	EquityPercent         *float64        `json:"equity_percent" binding:"omitempty,gte=0,lte=100"`
+	Multiplier            *float64        `json:"multiplier" binding:"omitempty,gte=0"`
+	MaxAllocationPerTrade *money.Amount   `json:"max_allocation_per_trade" binding:"omitempty,gte=0"`
+	MaxOpenTrades         *int            `json:"max_open_trades" binding:"omitempty,gte=0"`
+	AllowedSymbols        *[]string       `json:"allowed_symbols"`
+	DeniedSymbols         *[]string       `json:"denied_symbols"`
+	DailyLossCap          *money.Amount   `json:"daily_loss_cap" binding:"omitempty,gte=0"`
+}
+
+// DefaultCopyProfile is applied to copy sessions that were started without a profile.
+func DefaultCopyProfile(followerID, masterID uint) *CopyProfile {
+	return &CopyProfile{
+		FollowerID: followerID,
+		MasterID:   masterID,
+		SizingMode: CopySizingMultiplier,
+		Multiplier: 1,
+		Status:     CopyProfileActive,
+	}
+}
+
+func (p *CopyProfile) Validate() error {
+	switch p.SizingMode {
+	case CopySizingFixed:
+		if !p.FixedAmount.IsPositive() {
+			return errors.New("fixed_amount must be greater than zero for FIXED sizing")
+		}
+	case CopySizingProportional:
+		if p.EquityPercent <= 0 || p.EquityPercent > 100 {
+			return errors.New("equity_percent must be between 0 and 100 for PROPORTIONAL sizing")
+		}
+	case CopySizingMultiplier:
+		if p.Multiplier <= 0 {
+			return errors.New("multiplier must be greater than zero for MULTIPLIER sizing")
+		}
+	default:
+		return errors.New("unknown sizing_mode")
+	}
+	if p.FollowerID == p.MasterID {
+		return errors.New("cannot copy your own trades")
+	}
+	return nil
+}
+
+func (p *CopyProfile) IsActive() bool { return p.Status == CopyProfileActive }
+
+// AllowsSymbol applies the deny list first, then the allow list. An empty allow list allows everything.
+func (p *CopyProfile) AllowsSymbol(symbol string) bool {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	for _, denied := range SplitSymbols(p.DeniedSymbols) {
+		if denied == symbol {
+			return false
+		}
+	}
+	allowed := SplitSymbols(p.AllowedSymbols)
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, s := range allowed {
+		if s == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// CopyQuantity sizes a follower position for the given master trade. FIXED and
+// PROPORTIONAL treat their amount as the margin to commit; the result is then
+// capped so the margin never exceeds MaxAllocationPerTrade.
+func (p *CopyProfile) CopyQuantity(master *Trade, equity float64) float64 {
+	price := master.FillPrice()
+	if price <= 0 {
+		return 0
+	}
+	leverage := float64(master.Leverage)
+	if leverage == 0 {
+		leverage = 1
+	}
+
+	var quantity float64
+	switch p.SizingMode {
+	case CopySizingFixed:
+		quantity = p.FixedAmount.Float64() * leverage / price
+	case CopySizingProportional:
+		quantity = equity * p.EquityPercent / 100 * leverage / price
+	default:
+		multiplier := p.Multiplier
+		if multiplier <= 0 {
+			multiplier = 1
+		}
+		quantity = master.Quantity * multiplier
+	}
+
+	if maxAllocation := p.MaxAllocationPerTrade.Float64(); maxAllocation > 0 && quantity*price/leverage > maxAllocation {
+		quantity = maxAllocation * leverage / price
+	}
+	return quantity
+}
+
+// JoinSymbols normalises a symbol list into the comma separated form stored on CopyProfile.
+func JoinSymbols(symbols []string) string {
+	cleaned := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if s != "" {
+			cleaned = append(cleaned, s)
+		}
+	}
+	return strings.Join(cleaned, ",")
+}
+
+func SplitSymbols(symbols string) []string {
+	if strings.TrimSpace(symbols) == "" {
+		return nil
+	}
+	parts := strings.Split(symbols, ",")
+	out := make([]string, 0, len(parts))
+	for _, s := range parts {
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -8,13 +8,24 @@ import (
 
 type CopySession struct {
 	gorm.Model
-	FollowerID uint `gorm:"index"`
+	FollowerID uint `gorm:"index;uniqueIndex:idx_copy_session_pair"`
 	Follower   User `gorm:"foreignKey:FollowerID"`
-	MasterID   uint `gorm:"index"`
+	MasterID   uint `gorm:"index;uniqueIndex:idx_copy_session_pair"`
 	Master     User `gorm:"foreignKey:MasterID"`
 
-	RiskSetting   string  `gorm:"size:100"`
 	CurrentProfit float64 `gorm:"type:decimal(10,2)"`
 	IsActive      bool    `gorm:"default:true;index"`
 	StartedAt     time.Time
 }
+
+// CopySyncCursorMasterTrades names the cursor of the master-trade sync job.
+const CopySyncCursorMasterTrades = "master_trades"
+
+// CopySyncCursor persists how far the copy engine has replayed master trades,
+// so a restart resumes where the previous process stopped.
+type CopySyncCursor struct {
+	ID        uint      `gorm:"primaryKey"`
+	Name      string    `gorm:"size:64;uniqueIndex;not null"`
+	SyncedAt  time.Time `gorm:"not null"`
+	UpdatedAt time.Time
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+)
+
+const (
+	FXSourceManual = "manual"
+)
+
+// FXRate is the current rate for one currency pair: one unit of
+// BaseCurrency buys Rate units of QuoteCurrency. Rates are either set by an
+// admin (Source "manual") or refreshed from a feed, named in Source.
+type FXRate struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	BaseCurrency  string     `gorm:"size:10;not null;uniqueIndex:idx_fx_pair" json:"base_currency"`
+	QuoteCurrency string     `gorm:"size:10;not null;uniqueIndex:idx_fx_pair" json:"quote_currency"`
+	Rate          money.Rate `gorm:"type:numeric(20,8);not null" json:"rate"`
+	Source        string     `gorm:"size:50;not null" json:"source"`
+	UpdatedBy     *uint      `json:"updated_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type FXRateInput struct {
+	BaseCurrency  string     `json:"base_currency" binding:"required,min=3,max=10"`
+	QuoteCurrency string     `json:"quote_currency" binding:"required,min=3,max=10"`
+	Rate          money.Rate `json:"rate" binding:"required"`
+}
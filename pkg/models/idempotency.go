@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type IdempotencyStatus string
+
+const (
+	IdempotencyInProgress IdempotencyStatus = "IN_PROGRESS"
+	IdempotencyCompleted  IdempotencyStatus = "COMPLETED"
+)
+
+// IdempotencyRecord remembers the response to a request sent with an
+// Idempotency-Key header. Keys are unique per scope (the caller), and the
+// fingerprint of the original request is kept so a key reused for a different
+// request can be rejected.
+type IdempotencyRecord struct {
+	ID             uint              `gorm:"primaryKey" json:"id"`
+	Scope          string            `gorm:"size:100;not null;uniqueIndex:idx_idempotency_scope_key" json:"scope"`
+	Key            string            `gorm:"size:255;not null;uniqueIndex:idx_idempotency_scope_key" json:"key"`
+	Method         string            `gorm:"size:10;not null" json:"method"`
+	Path           string            `gorm:"size:255;not null" json:"path"`
+	Fingerprint    string            `gorm:"size:64;not null" json:"fingerprint"`
+	Status         IdempotencyStatus `gorm:"type:varchar(20);not null" json:"status"`
+	ResponseStatus int               `json:"response_status"`
+	ContentType    string            `gorm:"size:100" json:"content_type"`
+	ResponseBody   []byte            `json:"-"`
+	CreatedAt      time.Time         `json:"created_at"`
+	ExpiresAt      time.Time         `gorm:"not null;index" json:"expires_at"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+)
+
+type LedgerAccountKind string
+
+const (
+	// LedgerUserWallet and LedgerPlatformCommission are backed by a Wallet row;
+	// the platform commission account is the admin user's wallet.
+	LedgerUserWallet         LedgerAccountKind = "USER_WALLET"
+	LedgerPlatformCommission LedgerAccountKind = "PLATFORM_COMMISSION"
+
+	// The remaining kinds only exist in the ledger.
+	LedgerGatewayClearing  LedgerAccountKind = "GATEWAY_CLEARING"
+	LedgerTraderPayable    LedgerAccountKind = "TRADER_PAYABLE"
+	LedgerTradeMargin      LedgerAccountKind = "TRADE_MARGIN"
+	LedgerMarketSettlement LedgerAccountKind = "MARKET_SETTLEMENT"
+	LedgerOpeningBalance   LedgerAccountKind = "OPENING_BALANCE"
+	// LedgerFXPosition is the platform's holding in one currency from
+	// converting customer payments; one account per currency.
+	LedgerFXPosition LedgerAccountKind = "FX_POSITION"
+)
+
+// IsWalletBacked reports whether postings to the account move a Wallet balance.
+func (k LedgerAccountKind) IsWalletBacked() bool {
+	return k == LedgerUserWallet || k == LedgerPlatformCommission
+}
+
+// LedgerAccount is one account in the double-entry ledger. Balance is the sum
+// of the account's postings; for wallet-backed accounts it always equals the
+// wallet's balance.
+type LedgerAccount struct {
+	ID        uint              `gorm:"primaryKey" json:"id"`
+	Kind      LedgerAccountKind `gorm:"type:varchar(30);not null;uniqueIndex:idx_ledger_account" json:"kind"`
+	OwnerID   uint              `gorm:"not null;default:0;uniqueIndex:idx_ledger_account" json:"owner_id"`
+	Currency  string            `gorm:"size:10;not null;uniqueIndex:idx_ledger_account" json:"currency"`
+	WalletID  *uint             `gorm:"uniqueIndex" json:"wallet_id,omitempty"`
+	Balance   money.Amount      `gorm:"type:numeric(18,4);not null;default:0" json:"balance"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// JournalEntry groups postings that were written together. The amounts of its
+// postings always sum to zero in each currency.
+type JournalEntry struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	Type        TransactionType `gorm:"type:varchar(30);not null;index" json:"type"`
+	Reference   string          `gorm:"size:100;index" json:"reference"`
+	Description string          `gorm:"type:text" json:"description"`
+	CreatedAt   time.Time       `json:"created_at"`
+
+	Postings []JournalPosting `gorm:"foreignKey:EntryID" json:"postings,omitempty"`
+}
+
+// JournalPosting moves Amount into an account; negative amounts move money out.
+type JournalPosting struct {
+	ID                  uint         `gorm:"primaryKey" json:"id"`
+	EntryID             uint         `gorm:"not null;index" json:"entry_id"`
+	AccountID           uint         `gorm:"not null;index" json:"account_id"`
+	Amount              money.Amount `gorm:"type:numeric(18,4);not null" json:"amount"`
+	BalanceAfter        money.Amount `gorm:"type:numeric(18,4);not null" json:"balance_after"`
+	WalletTransactionID *uint        `gorm:"index" json:"wallet_transaction_id,omitempty"`
+	CreatedAt           time.Time    `json:"created_at"`
+}
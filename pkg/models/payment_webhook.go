@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+type WebhookEventStatus string
+
+const (
+	WebhookEventReceived  WebhookEventStatus = "RECEIVED"
+	WebhookEventProcessed WebhookEventStatus = "PROCESSED"
+	// WebhookEventIgnored: the event was valid but its payment was already in
+	// the state the event reports, typically a redelivery.
+	WebhookEventIgnored WebhookEventStatus = "IGNORED"
+	WebhookEventFailed  WebhookEventStatus = "FAILED"
+)
+
+// PaymentWebhookEvent records every signed gateway callback that was accepted.
+// The unique nonce is what rejects a replayed delivery; EventID and
+// PaymentGatewayTxID are kept for tracing a payment's history.
+type PaymentWebhookEvent struct {
+	ID                 uint               `gorm:"primaryKey" json:"id"`
+	EventID            string             `gorm:"size:100;not null;index" json:"event_id"`
+	Nonce              string             `gorm:"size:100;not null;uniqueIndex" json:"nonce"`
+	EventType          string             `gorm:"size:50;not null" json:"event_type"`
+	PaymentGatewayTxID string             `gorm:"size:100;not null;index" json:"payment_gateway_tx_id"`
+	Status             WebhookEventStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	Error              string             `gorm:"type:text" json:"error,omitempty"`
+	Payload            string             `gorm:"type:text" json:"payload"`
+	ReceivedAt         time.Time          `gorm:"not null" json:"received_at"`
+	ProcessedAt        *time.Time         `json:"processed_at,omitempty"`
+}
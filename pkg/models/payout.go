@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+)
+
+// TraderPayoutAccount is where a trader's earnings are paid out. The payout
+// run only sweeps revenue for traders with AutoPayout on.
+type TraderPayoutAccount struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	TraderID          uint      `gorm:"not null;uniqueIndex" json:"trader_id"`
+	BankAccountNumber string    `gorm:"size:50;not null" json:"bank_account_number"`
+	BankAccountHolder string    `gorm:"size:100;not null" json:"bank_account_holder"`
+	IFSCCode          string    `gorm:"size:20;not null" json:"ifsc_code"`
+	AutoPayout        bool      `gorm:"not null;default:true" json:"auto_payout"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type TraderPayoutAccountInput struct {
+	BankAccountNumber string `json:"bank_account_number" binding:"required"`
+	BankAccountHolder string `json:"bank_account_holder" binding:"required"`
+	IFSCCode          string `json:"ifsc_code" binding:"required"`
+	AutoPayout        *bool  `json:"auto_payout"`
+}
+
+// TraderPayout is one sweep of a trader's earned revenue in one currency into
+// a withdrawal request. RevenueThroughID is the last trader revenue wallet
+// transaction it covers; the next sweep starts after it unless the withdrawal
+// is rejected or reversed.
+type TraderPayout struct {
+	ID               uint               `gorm:"primaryKey" json:"id"`
+	TraderID         uint               `gorm:"not null;index:idx_trader_payout_currency" json:"trader_id"`
+	Currency         string             `gorm:"size:10;not null;index:idx_trader_payout_currency" json:"currency"`
+	Amount           money.Amount       `gorm:"type:numeric(18,4);not null" json:"amount"`
+	RevenueThroughID uint               `gorm:"not null" json:"revenue_through_id"`
+	WithdrawalID     uint               `gorm:"not null;uniqueIndex" json:"withdrawal_id"`
+	Withdrawal       *WithdrawalRequest `gorm:"foreignKey:WithdrawalID" json:"withdrawal,omitempty"`
+	CreatedAt        time.Time          `json:"created_at"`
+}
+
+// UnpaidTraderRevenue is the trader revenue in one currency earned since the
+// trader's last payout in it, net of refunds taken back from the trader.
+type UnpaidTraderRevenue struct {
+	Currency  string
+	Amount    money.Amount
+	ThroughID uint
+}
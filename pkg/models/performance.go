@@ -27,4 +27,11 @@ type TraderPerformance struct {
 	TradingStyle    string    `gorm:"size:255" json:"trading_style,omitempty"` 
 	Bio             string    `gorm:"type:text" json:"bio,omitempty"`          
 	IsPublicProfile bool      `gorm:"default:true" json:"is_public_profile"`   
+
+	TotalPnL        float64 `gorm:"type:numeric(18,4);default:0.00" json:"total_pnl"`
+	SharpeRatio     float64 `gorm:"type:numeric(10,4);default:0.00" json:"sharpe_ratio"`
+	SortinoRatio    float64 `gorm:"type:numeric(10,4);default:0.00" json:"sortino_ratio"`
+	ResolvedSignals uint    `gorm:"default:0" json:"resolved_signals"`
+	SignalWinRate   float64 `gorm:"type:numeric(5,2);default:0.00" json:"signal_win_rate"`
+	AverageSignalR  float64 `gorm:"type:numeric(10,4);default:0.00" json:"average_signal_r"`
 }
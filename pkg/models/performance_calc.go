@@ -0,0 +1,290 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+type PerformancePeriod string
+
+const (
+	PerformancePeriodDaily   PerformancePeriod = "daily"
+	PerformancePeriodWeekly  PerformancePeriod = "weekly"
+	PerformancePeriodMonthly PerformancePeriod = "monthly"
+	PerformancePeriodAll     PerformancePeriod = "all"
+)
+
+// tradingDaysPerYear annualises Sharpe and Sortino. Crypto trades every day.
+const tradingDaysPerYear = 365
+
+var ErrInvalidPerformancePeriod = errors.New("period must be one of daily, weekly, monthly or all")
+
+// ParsePerformancePeriod accepts the period query parameter; empty means all.
+func ParsePerformancePeriod(raw string) (PerformancePeriod, error) {
+	switch p := PerformancePeriod(strings.ToLower(strings.TrimSpace(raw))); p {
+	case "":
+		return PerformancePeriodAll, nil
+	case PerformancePeriodDaily, PerformancePeriodWeekly, PerformancePeriodMonthly, PerformancePeriodAll:
+		return p, nil
+	}
+	return "", ErrInvalidPerformancePeriod
+}
+
+// Since is the start of the period ending at now. It is zero for all.
+func (p PerformancePeriod) Since(now time.Time) time.Time {
+	switch p {
+	case PerformancePeriodDaily:
+		return now.Add(-24 * time.Hour)
+	case PerformancePeriodWeekly:
+		return now.AddDate(0, 0, -7)
+	case PerformancePeriodMonthly:
+		return now.AddDate(0, -1, 0)
+	}
+	return time.Time{}
+}
+
+// EquityPoint is the trader's equity at the end of one UTC day. Equity starts
+// at the margin deployed over the window and moves by realised PnL.
+type EquityPoint struct {
+	Date          time.Time `json:"date"`
+	PnL           float64   `json:"pnl"`
+	CumulativePnL float64   `json:"cumulative_pnl"`
+	Equity        float64   `json:"equity"`
+	ROI           float64   `json:"roi"`
+}
+
+// PerformanceReport holds the metrics for one trader over one window.
+type PerformanceReport struct {
+	TraderID        uint              `json:"trader_id"`
+	Period          PerformancePeriod `json:"period"`
+	From            *time.Time        `json:"from,omitempty"`
+	To              time.Time         `json:"to"`
+	TotalPnL        float64           `json:"total_pnl"`
+	CapitalDeployed float64           `json:"capital_deployed"`
+	ROI             float64           `json:"roi"`
+	MaxDrawdown     float64           `json:"max_drawdown"`
+	WinRate         float64           `json:"win_rate"`
+	LossRate        float64           `json:"loss_rate"`
+	AverageProfit   float64           `json:"average_profit"`
+	AverageLoss     float64           `json:"average_loss"`
+	TotalTrades     uint              `json:"total_trades"`
+	WinningTrades   uint              `json:"winning_trades"`
+	LosingTrades    uint              `json:"losing_trades"`
+	SharpeRatio     float64           `json:"sharpe_ratio"`
+	SortinoRatio    float64           `json:"sortino_ratio"`
+	ResolvedSignals uint              `json:"resolved_signals"`
+	SignalWinRate   float64           `json:"signal_win_rate"`
+	AverageSignalR  float64           `json:"average_signal_r"`
+	ActiveCopiers   uint              `json:"active_copiers"`
+	LastUpdated     time.Time         `json:"last_updated"`
+	EquityCurve     []EquityPoint     `json:"equity_curve"`
+}
+
+// CalculatePerformance derives a report from the trader's own closed trades and
+// resolved signals in [from, to]. Copy trades are ignored so followers' results
+// never count towards the master. A zero from means since the first trade.
+//
+// ROI and drawdown are measured against the margin the trades locked, which is
+// the only capital figure that is known historically. Sharpe and Sortino use
+// daily returns on that equity and are annualised over 365 days.
+func CalculatePerformance(trades []Trade, signals []Signal, from, to time.Time) PerformanceReport {
+	report := PerformanceReport{To: to, EquityCurve: []EquityPoint{}}
+	if !from.IsZero() {
+		report.From = &from
+	}
+
+	closed := make([]Trade, 0, len(trades))
+	for _, t := range trades {
+		if t.IsCopyTrade || t.Status != TradeStatusClosed || t.ClosedAt == nil || t.Pnl == nil {
+			continue
+		}
+		if t.ClosedAt.Before(from) || t.ClosedAt.After(to) {
+			continue
+		}
+		closed = append(closed, t)
+	}
+	sort.SliceStable(closed, func(i, j int) bool { return closed[i].ClosedAt.Before(*closed[j].ClosedAt) })
+
+	var grossProfit, grossLoss float64
+	for i := range closed {
+		pnl := *closed[i].Pnl
+		report.TotalPnL += pnl
+		report.CapitalDeployed += closed[i].MarginRequired()
+		switch {
+		case pnl > 0:
+			report.WinningTrades++
+			grossProfit += pnl
+		case pnl < 0:
+			report.LosingTrades++
+			grossLoss += pnl
+		}
+	}
+	report.TotalTrades = uint(len(closed))
+	if report.TotalTrades > 0 {
+		report.WinRate = float64(report.WinningTrades) / float64(report.TotalTrades) * 100
+		report.LossRate = float64(report.LosingTrades) / float64(report.TotalTrades) * 100
+	}
+	if report.WinningTrades > 0 {
+		report.AverageProfit = grossProfit / float64(report.WinningTrades)
+	}
+	if report.LosingTrades > 0 {
+		report.AverageLoss = grossLoss / float64(report.LosingTrades)
+	}
+	if report.CapitalDeployed > 0 {
+		report.ROI = report.TotalPnL / report.CapitalDeployed * 100
+	}
+
+	report.MaxDrawdown = maxDrawdown(closed, report.CapitalDeployed)
+	report.EquityCurve = equityCurve(closed, report.CapitalDeployed, from, to)
+	report.SharpeRatio, report.SortinoRatio = riskAdjustedReturns(report.EquityCurve, report.CapitalDeployed)
+
+	var totalR float64
+	for _, s := range signals {
+		if !s.Status.IsTerminal() || s.ExitPrice == 0 {
+			continue
+		}
+		if s.DeactivatedAt != nil && (s.DeactivatedAt.Before(from) || s.DeactivatedAt.After(to)) {
+			continue
+		}
+		report.ResolvedSignals++
+		totalR += s.RealizedR
+		if s.RealizedR > 0 {
+			report.SignalWinRate++
+		}
+	}
+	if report.ResolvedSignals > 0 {
+		report.SignalWinRate = report.SignalWinRate / float64(report.ResolvedSignals) * 100
+		report.AverageSignalR = totalR / float64(report.ResolvedSignals)
+	}
+
+	return report
+}
+
+// maxDrawdown is the largest peak-to-trough fall in equity, as a percentage of
+// the peak, walking trades in the order they closed.
+func maxDrawdown(closed []Trade, capital float64) float64 {
+	if capital <= 0 {
+		return 0
+	}
+	equity, peak, worst := capital, capital, 0.0
+	for i := range closed {
+		equity += *closed[i].Pnl
+		if equity > peak {
+			peak = equity
+		}
+		if dd := (peak - equity) / peak * 100; dd > worst {
+			worst = dd
+		}
+	}
+	return worst
+}
+
+// equityCurve buckets realised PnL into UTC days, including flat days, from the
+// start of the window (or the first close) to its end.
+func equityCurve(closed []Trade, capital float64, from, to time.Time) []EquityPoint {
+	start := from
+	if start.IsZero() {
+		if len(closed) == 0 {
+			return []EquityPoint{}
+		}
+		start = *closed[0].ClosedAt
+	}
+	day := truncateDay(start)
+	last := truncateDay(to)
+
+	curve := []EquityPoint{}
+	var cumulative float64
+	next := 0
+	for ; !day.After(last); day = day.AddDate(0, 0, 1) {
+		point := EquityPoint{Date: day}
+		end := day.AddDate(0, 0, 1)
+		for next < len(closed) && closed[next].ClosedAt.Before(end) {
+			point.PnL += *closed[next].Pnl
+			next++
+		}
+		cumulative += point.PnL
+		point.CumulativePnL = cumulative
+		point.Equity = capital + cumulative
+		if capital > 0 {
+			point.ROI = cumulative / capital * 100
+		}
+		curve = append(curve, point)
+	}
+	return curve
+}
+
+func riskAdjustedReturns(curve []EquityPoint, capital float64) (sharpe, sortino float64) {
+	if capital <= 0 || len(curve) < 2 {
+		return 0, 0
+	}
+
+	returns := make([]float64, 0, len(curve))
+	prev := capital
+	for _, p := range curve {
+		if prev > 0 {
+			returns = append(returns, p.PnL/prev)
+		}
+		prev = p.Equity
+	}
+	if len(returns) < 2 {
+		return 0, 0
+	}
+
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance, downside float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+		if r < 0 {
+			downside += r * r
+		}
+	}
+	stdDev := math.Sqrt(variance / float64(len(returns)-1))
+	downsideDev := math.Sqrt(downside / float64(len(returns)))
+
+	annualise := math.Sqrt(tradingDaysPerYear)
+	if stdDev > 0 {
+		sharpe = mean / stdDev * annualise
+	}
+	if downsideDev > 0 {
+		sortino = mean / downsideDev * annualise
+	}
+	return sharpe, sortino
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ApplyReport copies an all-time report and the rolling period ROIs onto the
+// stored snapshot.
+func (p *TraderPerformance) ApplyReport(all PerformanceReport, daily, weekly, monthly float64) {
+	p.TotalROI = all.ROI
+	p.DailyROI = daily
+	p.WeeklyROI = weekly
+	p.MonthlyROI = monthly
+	p.MaxDrawdown = all.MaxDrawdown
+	p.WinRate = all.WinRate
+	p.LossRate = all.LossRate
+	p.AverageProfit = all.AverageProfit
+	p.AverageLoss = all.AverageLoss
+	p.TotalTrades = all.TotalTrades
+	p.WinningTrades = all.WinningTrades
+	p.LosingTrades = all.LosingTrades
+	p.TotalPnL = all.TotalPnL
+	p.SharpeRatio = all.SharpeRatio
+	p.SortinoRatio = all.SortinoRatio
+	p.ResolvedSignals = all.ResolvedSignals
+	p.SignalWinRate = all.SignalWinRate
+	p.AverageSignalR = all.AverageSignalR
+	p.ActiveCopiers = all.ActiveCopiers
+	p.LastUpdated = all.To
+}
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+)
+
+type DiscrepancyKind string
+
+const (
+	// DiscrepancyChainGap: a transaction's BalanceBefore is not the previous
+	// transaction's BalanceAfter.
+	DiscrepancyChainGap DiscrepancyKind = "CHAIN_GAP"
+	// DiscrepancyAmountMismatch: BalanceAfter - BalanceBefore does not equal the
+	// transaction's Amount.
+	DiscrepancyAmountMismatch DiscrepancyKind = "AMOUNT_MISMATCH"
+	// DiscrepancyBalanceMismatch: the wallet balance is not the BalanceAfter of
+	// its last transaction.
+	DiscrepancyBalanceMismatch DiscrepancyKind = "BALANCE_MISMATCH"
+	// DiscrepancyOrphanDeposit: a successful DepositRequest has no wallet transaction.
+	DiscrepancyOrphanDeposit DiscrepancyKind = "ORPHAN_DEPOSIT"
+	// DiscrepancyOrphanWithdrawal: a WithdrawRequest has no wallet transaction.
+	DiscrepancyOrphanWithdrawal DiscrepancyKind = "ORPHAN_WITHDRAWAL"
+	// DiscrepancyLedgerMismatch: the double-entry ledger disagrees with itself
+	// or with a wallet.
+	DiscrepancyLedgerMismatch DiscrepancyKind = "LEDGER_MISMATCH"
+)
+
+type DiscrepancyStatus string
+
+const (
+	DiscrepancyStatusOpen     DiscrepancyStatus = "OPEN"
+	DiscrepancyStatusResolved DiscrepancyStatus = "RESOLVED"
+)
+
+type ReconciliationRunStatus string
+
+const (
+	ReconciliationRunning   ReconciliationRunStatus = "RUNNING"
+	ReconciliationCompleted ReconciliationRunStatus = "COMPLETED"
+	ReconciliationFailed    ReconciliationRunStatus = "FAILED"
+)
+
+// ReconciliationRun is one pass of the wallet reconciliation job.
+type ReconciliationRun struct {
+	ID                  uint                    `gorm:"primaryKey" json:"id"`
+	Status              ReconciliationRunStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	TriggeredBy         *uint                   `json:"triggered_by,omitempty"`
+	WalletsChecked      int                     `json:"wallets_checked"`
+	TransactionsChecked int                     `json:"transactions_checked"`
+	DiscrepanciesFound  int                     `json:"discrepancies_found"`
+	Error               string                  `gorm:"type:text" json:"error,omitempty"`
+	StartedAt           time.Time               `gorm:"not null" json:"started_at"`
+	FinishedAt          *time.Time              `json:"finished_at,omitempty"`
+}
+
+// WalletDiscrepancy is a problem found by reconciliation. Fingerprint
+// identifies the problem across runs, so a discrepancy that is still present
+// on the next run is updated rather than recorded twice.
+type WalletDiscrepancy struct {
+	ID          uint              `gorm:"primaryKey" json:"id"`
+	Fingerprint string            `gorm:"size:150;not null;uniqueIndex" json:"fingerprint"`
+	Kind        DiscrepancyKind   `gorm:"type:varchar(30);not null;index" json:"kind"`
+	Status      DiscrepancyStatus `gorm:"type:varchar(20);not null;default:'OPEN';index" json:"status"`
+	RunID       uint              `gorm:"index" json:"run_id"`
+
+	WalletID            *uint `gorm:"index" json:"wallet_id,omitempty"`
+	UserID              *uint `gorm:"index" json:"user_id,omitempty"`
+	WalletTransactionID *uint `json:"wallet_transaction_id,omitempty"`
+	DepositRequestID    *uint `json:"deposit_request_id,omitempty"`
+	WithdrawRequestID   *uint `json:"withdraw_request_id,omitempty"`
+
+	Expected money.Amount `gorm:"type:numeric(18,4);not null;default:0" json:"expected"`
+	Actual   money.Amount `gorm:"type:numeric(18,4);not null;default:0" json:"actual"`
+	Details  string       `gorm:"type:text" json:"details"`
+
+	Notes          string     `gorm:"type:text" json:"notes"`
+	ResolvedBy     *uint      `json:"resolved_by,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	ResolutionNote string     `gorm:"type:text" json:"resolution_note,omitempty"`
+
+	FirstSeenAt time.Time `gorm:"not null" json:"first_seen_at"`
+	LastSeenAt  time.Time `gorm:"not null" json:"last_seen_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type DiscrepancyFilter struct {
+	Status DiscrepancyStatus `form:"status"`
+	Kind   DiscrepancyKind   `form:"kind"`
+	UserID uint              `form:"user_id"`
+	Page   int               `form:"page"`
+	Limit  int               `form:"limit"`
+}
+
+type DiscrepancyNoteInput struct {
+	Note string `json:"note" binding:"required"`
+}
+
+// ReconcileWallet walks a wallet's transactions in the order they were written
+// and reports every break in the BalanceBefore/BalanceAfter chain, every
+// transaction whose balances do not move by its Amount, and a final balance
+// that does not match the wallet.
+func ReconcileWallet(wallet Wallet, txs []WalletTransaction) []WalletDiscrepancy {
+	sorted := make([]WalletTransaction, len(txs))
+	copy(sorted, txs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].CreatedAt.Equal(sorted[j].CreatedAt) {
+			return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	var found []WalletDiscrepancy
+	add := func(kind DiscrepancyKind, tx *WalletTransaction, expected, actual money.Amount, details string) {
+		walletID, userID := wallet.ID, wallet.UserID
+		d := WalletDiscrepancy{
+			Kind:     kind,
+			WalletID: &walletID,
+			UserID:   &userID,
+			Expected: expected,
+			Actual:   actual,
+			Details:  details,
+		}
+		if tx != nil {
+			txID := tx.ID
+			d.WalletTransactionID = &txID
+			d.Fingerprint = fmt.Sprintf("%s:tx:%d", kind, tx.ID)
+		} else {
+			d.Fingerprint = fmt.Sprintf("%s:wallet:%d", kind, wallet.ID)
+		}
+		found = append(found, d)
+	}
+
+	var previous *WalletTransaction
+	for i := range sorted {
+		tx := &sorted[i]
+		if previous != nil && tx.BalanceBefore != previous.BalanceAfter {
+			add(DiscrepancyChainGap, tx, previous.BalanceAfter, tx.BalanceBefore,
+				fmt.Sprintf("transaction %d starts at %s but transaction %d ended at %s", tx.ID, tx.BalanceBefore, previous.ID, previous.BalanceAfter))
+		}
+		if moved := (tx.BalanceAfter - tx.BalanceBefore).Abs(); moved != tx.Amount.Abs() {
+			add(DiscrepancyAmountMismatch, tx, tx.Amount.Abs(), moved,
+				fmt.Sprintf("transaction %d is for %s but moved the balance by %s", tx.ID, tx.Amount, moved))
+		}
+		previous = tx
+	}
+
+	var expected money.Amount
+	if previous != nil {
+		expected = previous.BalanceAfter
+	}
+	if expected != wallet.Balance {
+		add(DiscrepancyBalanceMismatch, nil, expected, wallet.Balance,
+			fmt.Sprintf("wallet %d holds %s but its transactions end at %s", wallet.ID, wallet.Balance, expected))
+	}
+	return found
+}
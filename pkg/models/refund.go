@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+)
+
+// RefundPolicy is what a plan gives back when a subscription to it is
+// cancelled before it ends.
+type RefundPolicy string
+
+const (
+	RefundPolicyNone RefundPolicy = "NONE"
+	// RefundPolicyFullWithinWindow refunds everything paid when the
+	// subscription is cancelled within the plan's RefundWindowDays.
+	RefundPolicyFullWithinWindow RefundPolicy = "FULL_WITHIN_WINDOW"
+	// RefundPolicyProrated refunds the unused part of the subscription period.
+	RefundPolicyProrated RefundPolicy = "PRORATED"
+)
+
+// OrNone returns p, or RefundPolicyNone when no policy was given.
+func (p RefundPolicy) OrNone() RefundPolicy {
+	if p == "" {
+		return RefundPolicyNone
+	}
+	return p
+}
+
+// SubscriptionCancellation is the outcome of cancelling a subscription: the
+// refund credited to the subscriber and where it was taken from. Amounts are
+// in the plan's Currency except Credited, which is the refund as it reached
+// the subscriber's wallet in CreditedCurrency.
+type SubscriptionCancellation struct {
+	SubscriptionID   uint         `json:"subscription_id"`
+	CustomerID       uint         `json:"customer_id"`
+	Policy           RefundPolicy `json:"refund_policy"`
+	Currency         string       `json:"currency"`
+	AmountPaid       money.Amount `json:"amount_paid"`
+	Refunded         money.Amount `json:"refunded"`
+	FromTrader       money.Amount `json:"from_trader"`
+	FromPlatform     money.Amount `json:"from_platform"`
+	Credited         money.Amount `json:"credited"`
+	CreditedCurrency string       `json:"credited_currency"`
+	CancelledAt      time.Time    `json:"cancelled_at"`
+}
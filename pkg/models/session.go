@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// RefreshToken is one refresh token of a login session. Only its hash is
+// stored. Refreshing rotates the token: the old row is marked used and a new
+// one is issued for the same SessionID.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	SessionID string     `gorm:"size:64;not null;index" json:"session_id"`
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	UserAgent string     `gorm:"size:255" json:"user_agent,omitempty"`
+	IPAddress string     `gorm:"size:64" json:"ip_address,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// RevokedSession is the revocation list checked on every authenticated
+// request. A row can be pruned once ExpiresAt has passed, since no access
+// token of the session is still valid by then.
+type RevokedSession struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	SessionID string    `gorm:"size:64;not null;uniqueIndex" json:"session_id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	Reason    string    `gorm:"size:50;not null" json:"reason"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Reasons recorded on RevokedSession.
+const (
+	RevokeReasonLogout         = "logout"
+	RevokeReasonTokenReuse     = "refresh_token_reuse"
+	RevokeReasonPasswordChange = "password_change"
+	RevokeReasonBlocked        = "blocked"
+	RevokeReasonDeleted        = "deleted"
+)
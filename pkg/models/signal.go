@@ -12,18 +12,28 @@ type Signal struct {
 	TraderName    string `json:"traderName"`
 	TotalDuration string `json:"totalDuration"`
 
-	Symbol         string  `gorm:"size:20;not null" json:"symbol"`
-	EntryPrice     float64 `gorm:"type:numeric(18,4);not null" json:"entry_price"`
-	CurrentPrice   float64 `gorm:"type:numeric(18,4)" json:"current_price"`
-	TargetPrice    float64 `gorm:"type:numeric(18,4);not null" json:"target_price"`
-	StopLoss       float64 `gorm:"type:numeric(18,4);not null" json:"stop_loss"`
-	Strategy       string  `gorm:"type:text" json:"strategy"`
-	Risk           string  `gorm:"size:20" json:"risk"`
-	Status         string  `gorm:"size:20;default:'Pending'" json:"status"`
+	Symbol         string          `gorm:"size:20;not null" json:"symbol"`
+	Direction      SignalDirection `gorm:"size:5;not null;default:'LONG'" json:"direction"`
+	EntryPrice     float64         `gorm:"type:numeric(18,4);not null" json:"entry_price"`
+	CurrentPrice   float64         `gorm:"type:numeric(18,4)" json:"current_price"`
+	TargetPrice    float64         `gorm:"type:numeric(18,4);not null" json:"target_price"`
+	StopLoss       float64         `gorm:"type:numeric(18,4);not null" json:"stop_loss"`
+	Strategy       string          `gorm:"type:text" json:"strategy"`
+	Risk           string          `gorm:"size:20" json:"risk"`
+	Status         SignalStatus    `gorm:"size:20;default:'Pending'" json:"status"`
 	PublishedAt    time.Time
 	DeactivatedAt  *time.Time `json:"deactivated_at"`
 	TradeStartDate time.Time  `json:"tradeStartDate"`
 	TradeEndDate   time.Time  `json:"tradeEndDate"`
 	CreatedBy      string     `json:"createdBy"`
 	CreatorID      uint       `json:"creatorId"`
+
+	Targets              []SignalTarget   `gorm:"foreignKey:SignalID" json:"targets"`
+	InitialStopLoss      float64          `gorm:"type:numeric(18,4)" json:"initial_stop_loss"`
+	TrailingType         TrailingStopType `gorm:"size:10;not null;default:'NONE'" json:"trailing_type"`
+	TrailingValue        float64          `gorm:"type:numeric(18,4)" json:"trailing_value"`
+	BreakevenAfterTarget int              `json:"breakeven_after_target"`
+	HighWatermark        float64          `gorm:"type:numeric(18,4)" json:"high_watermark"`
+	ExitPrice            float64          `gorm:"type:numeric(18,4)" json:"exit_price"`
+	RealizedR            float64          `gorm:"type:numeric(10,4)" json:"realized_r"`
 }
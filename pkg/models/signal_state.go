@@ -0,0 +1,133 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type SignalDirection string
+
+const (
+	SignalDirectionLong  SignalDirection = "LONG"
+	SignalDirectionShort SignalDirection = "SHORT"
+)
+
+type SignalStatus string
+
+const (
+	SignalStatusPending   SignalStatus = "Pending"
+	SignalStatusActive    SignalStatus = "Active"
+	SignalStatusTargetHit SignalStatus = "Target Hit"
+	SignalStatusStopLoss  SignalStatus = "Stop Loss"
+	SignalStatusExpired   SignalStatus = "Expired"
+	SignalStatusCancelled SignalStatus = "Cancelled"
+)
+
+var ErrInvalidSignalTransition = errors.New("invalid signal status transition")
+
+// signalTransitions lists every status a signal may move to from its current
+// one. Target Hit, Stop Loss, Expired and Cancelled are terminal.
+var signalTransitions = map[SignalStatus][]SignalStatus{
+	SignalStatusPending: {SignalStatusActive, SignalStatusExpired, SignalStatusCancelled},
+	SignalStatusActive:  {SignalStatusTargetHit, SignalStatusStopLoss, SignalStatusExpired, SignalStatusCancelled},
+}
+
+func (s SignalStatus) CanTransitionTo(next SignalStatus) bool {
+	for _, allowed := range signalTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+func (s SignalStatus) IsTerminal() bool {
+	return len(signalTransitions[s]) == 0
+}
+
+// SignalStatusHistory records one status transition of a signal.
+type SignalStatusHistory struct {
+	ID         uint         `gorm:"primaryKey" json:"id"`
+	SignalID   uint         `gorm:"index;not null" json:"signal_id"`
+	FromStatus SignalStatus `gorm:"size:20;not null" json:"from_status"`
+	ToStatus   SignalStatus `gorm:"size:20;not null" json:"to_status"`
+	Price      float64      `gorm:"type:numeric(18,4)" json:"price"`
+	Reason     string       `gorm:"size:255" json:"reason"`
+	ChangedAt  time.Time    `gorm:"index;not null" json:"changed_at"`
+}
+
+// NormalizeDirection defaults an empty direction to LONG and rejects anything
+// other than LONG or SHORT.
+func (s *Signal) NormalizeDirection() error {
+	dir := SignalDirection(strings.ToUpper(strings.TrimSpace(string(s.Direction))))
+	if dir == "" {
+		dir = SignalDirectionLong
+	}
+	if dir != SignalDirectionLong && dir != SignalDirectionShort {
+		return fmt.Errorf("direction must be LONG or SHORT, got %q", s.Direction)
+	}
+	s.Direction = dir
+	return nil
+}
+
+// ValidateLevels checks that the original stop loss and the final target sit
+// on the correct side of the entry for the signal's direction. A zero stop or
+// target is allowed and simply never triggers.
+func (s *Signal) ValidateLevels() error {
+	if s.EntryPrice <= 0 {
+		return errors.New("entry price must be greater than zero")
+	}
+	short := s.Direction == SignalDirectionShort
+	stop := s.InitialStopLoss
+	if stop == 0 {
+		stop = s.StopLoss
+	}
+	if stop != 0 && ((!short && stop >= s.EntryPrice) || (short && stop <= s.EntryPrice)) {
+		return fmt.Errorf("stop loss %.4f is on the wrong side of entry %.4f for a %s signal", stop, s.EntryPrice, s.Direction)
+	}
+	if s.TargetPrice != 0 && ((!short && s.TargetPrice <= s.EntryPrice) || (short && s.TargetPrice >= s.EntryPrice)) {
+		return fmt.Errorf("target %.4f is on the wrong side of entry %.4f for a %s signal", s.TargetPrice, s.EntryPrice, s.Direction)
+	}
+	if !s.TradeEndDate.IsZero() && !s.TradeStartDate.IsZero() && !s.TradeEndDate.After(s.TradeStartDate) {
+		return errors.New("trade end date must be after the start date")
+	}
+	return nil
+}
+
+// NextStatus evaluates the signal against the market price at time now and
+// returns the status it should move to, if any.
+//
+// A Pending signal activates once TradeStartDate has passed and the price has
+// reached the entry (at or above it for LONG, at or below for SHORT). An
+// Active signal resolves on its stop loss before its target. A signal that is
+// still open after TradeEndDate expires.
+func (s *Signal) NextStatus(price float64, now time.Time) (SignalStatus, bool) {
+	if s.Status.IsTerminal() {
+		return "", false
+	}
+
+	short := s.Direction == SignalDirectionShort
+	if price > 0 {
+		switch s.Status {
+		case SignalStatusPending:
+			started := s.TradeStartDate.IsZero() || !s.TradeStartDate.After(now)
+			if started && ((!short && price >= s.EntryPrice) || (short && price <= s.EntryPrice)) {
+				return SignalStatusActive, true
+			}
+		case SignalStatusActive:
+			if s.StopLoss != 0 && ((!short && price <= s.StopLoss) || (short && price >= s.StopLoss)) {
+				return SignalStatusStopLoss, true
+			}
+			if s.TargetPrice != 0 && ((!short && price >= s.TargetPrice) || (short && price <= s.TargetPrice)) {
+				return SignalStatusTargetHit, true
+			}
+		}
+	}
+
+	if !s.TradeEndDate.IsZero() && now.After(s.TradeEndDate) {
+		return SignalStatusExpired, true
+	}
+	return "", false
+}
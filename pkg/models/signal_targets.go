@@ -0,0 +1,233 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+type TrailingStopType string
+
+const (
+	TrailingStopNone     TrailingStopType = "NONE"
+	TrailingStopPercent  TrailingStopType = "PERCENT"
+	TrailingStopAbsolute TrailingStopType = "ABSOLUTE"
+)
+
+// SignalTarget is one take-profit level of a signal. AllocationPercent is the
+// share of the position closed when the level is hit.
+type SignalTarget struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	SignalID          uint       `gorm:"index;not null" json:"signal_id"`
+	Sequence          int        `gorm:"not null" json:"sequence"`
+	Price             float64    `gorm:"type:numeric(18,4);not null" json:"price"`
+	AllocationPercent float64    `gorm:"type:numeric(5,2);not null" json:"allocation_percent"`
+	HitAt             *time.Time `json:"hit_at"`
+	HitPrice          float64    `gorm:"type:numeric(18,4)" json:"hit_price"`
+}
+
+// PrepareTargets normalises the target ladder before a signal is stored. A
+// signal posted with only TargetPrice gets a single 100% target. Targets are
+// ordered from nearest to furthest from the entry, allocations must add up to
+// 100, and TargetPrice is set to the final target. InitialStopLoss keeps the
+// original stop so R-multiples stay meaningful once the stop starts moving.
+func (s *Signal) PrepareTargets() error {
+	if len(s.Targets) == 0 {
+		if s.TargetPrice <= 0 {
+			return errors.New("at least one target is required")
+		}
+		s.Targets = []SignalTarget{{Price: s.TargetPrice, AllocationPercent: 100}}
+	}
+
+	short := s.Direction == SignalDirectionShort
+	sort.SliceStable(s.Targets, func(i, j int) bool {
+		if short {
+			return s.Targets[i].Price > s.Targets[j].Price
+		}
+		return s.Targets[i].Price < s.Targets[j].Price
+	})
+
+	var total float64
+	for i := range s.Targets {
+		t := &s.Targets[i]
+		if t.Price <= 0 {
+			return fmt.Errorf("target %d must have a price greater than zero", i+1)
+		}
+		if s.EntryPrice > 0 && ((!short && t.Price <= s.EntryPrice) || (short && t.Price >= s.EntryPrice)) {
+			return fmt.Errorf("target %.4f is on the wrong side of entry %.4f for a %s signal", t.Price, s.EntryPrice, s.Direction)
+		}
+		if t.AllocationPercent <= 0 {
+			return fmt.Errorf("target %d must have a positive allocation", i+1)
+		}
+		t.Sequence = i + 1
+		t.HitAt = nil
+		t.HitPrice = 0
+		total += t.AllocationPercent
+	}
+	if math.Abs(total-100) > 0.01 {
+		return fmt.Errorf("target allocations must add up to 100%%, got %.2f%%", total)
+	}
+	s.TargetPrice = s.Targets[len(s.Targets)-1].Price
+
+	if s.InitialStopLoss == 0 {
+		s.InitialStopLoss = s.StopLoss
+	}
+
+	s.TrailingType = TrailingStopType(strings.ToUpper(strings.TrimSpace(string(s.TrailingType))))
+	switch s.TrailingType {
+	case "", TrailingStopNone:
+		s.TrailingType = TrailingStopNone
+		s.TrailingValue = 0
+	case TrailingStopPercent:
+		if s.TrailingValue <= 0 || s.TrailingValue >= 100 {
+			return errors.New("percent trailing stop must be between 0 and 100")
+		}
+	case TrailingStopAbsolute:
+		if s.TrailingValue <= 0 {
+			return errors.New("absolute trailing stop must be greater than zero")
+		}
+	default:
+		return fmt.Errorf("trailing type must be NONE, PERCENT or ABSOLUTE, got %q", s.TrailingType)
+	}
+	if s.BreakevenAfterTarget < 0 || s.BreakevenAfterTarget > len(s.Targets) {
+		return fmt.Errorf("breakeven_after_target must be between 0 and %d", len(s.Targets))
+	}
+	return nil
+}
+
+// Advance applies a price tick to an Active signal: it tracks the best price
+// seen, marks the targets the price has reached, moves the stop to breakeven
+// once the configured target is hit, and trails the stop behind the best
+// price. The stop only ever moves in the signal's favour. It reports whether
+// anything changed.
+func (s *Signal) Advance(price float64, now time.Time) bool {
+	if s.Status != SignalStatusActive || price <= 0 {
+		return false
+	}
+
+	short := s.Direction == SignalDirectionShort
+	better := func(a, b float64) bool {
+		if short {
+			return a < b
+		}
+		return a > b
+	}
+	changed := false
+
+	if s.HighWatermark == 0 || better(price, s.HighWatermark) {
+		s.HighWatermark = price
+		changed = true
+	}
+
+	for i := range s.Targets {
+		t := &s.Targets[i]
+		if t.HitAt != nil || better(t.Price, price) {
+			continue
+		}
+		hitAt := now
+		t.HitAt = &hitAt
+		t.HitPrice = price
+		changed = true
+	}
+
+	moveStop := func(stop float64) {
+		if s.StopLoss == 0 || better(stop, s.StopLoss) {
+			s.StopLoss = stop
+			changed = true
+		}
+	}
+	if n := s.BreakevenAfterTarget; n > 0 && n <= len(s.Targets) && s.Targets[n-1].HitAt != nil {
+		moveStop(s.EntryPrice)
+	}
+
+	var trail float64
+	switch s.TrailingType {
+	case TrailingStopPercent:
+		trail = s.HighWatermark * s.TrailingValue / 100
+	case TrailingStopAbsolute:
+		trail = s.TrailingValue
+	}
+	if trail > 0 {
+		if short {
+			moveStop(s.HighWatermark + trail)
+		} else {
+			moveStop(s.HighWatermark - trail)
+		}
+	}
+
+	s.RealizedR = s.RealizedRMultiple()
+	return changed
+}
+
+// Close records how the signal's remaining position was exited when it moves
+// to a terminal status. Stops and targets exit at their level, anything else
+// at the given market price. A signal that never became Active has no exit.
+func (s *Signal) Close(next SignalStatus, price float64) {
+	if s.Status != SignalStatusActive {
+		s.ExitPrice = 0
+		s.RealizedR = 0
+		return
+	}
+
+	switch next {
+	case SignalStatusStopLoss:
+		s.ExitPrice = s.StopLoss
+	case SignalStatusTargetHit:
+		s.ExitPrice = s.TargetPrice
+		now := time.Now()
+		for i := range s.Targets {
+			if s.Targets[i].HitAt == nil {
+				s.Targets[i].HitAt = &now
+				s.Targets[i].HitPrice = s.Targets[i].Price
+			}
+		}
+	default:
+		s.ExitPrice = price
+	}
+	s.RealizedR = s.RealizedRMultiple()
+}
+
+// RiskPerUnit is the distance between the entry and the original stop, i.e.
+// one R.
+func (s *Signal) RiskPerUnit() float64 {
+	stop := s.InitialStopLoss
+	if stop == 0 {
+		stop = s.StopLoss
+	}
+	if stop == 0 {
+		return 0
+	}
+	return math.Abs(s.EntryPrice - stop)
+}
+
+// RealizedRMultiple weighs each hit target by its allocation and, once the
+// signal has an exit price, closes the unfilled remainder there.
+func (s *Signal) RealizedRMultiple() float64 {
+	risk := s.RiskPerUnit()
+	if risk == 0 {
+		return 0
+	}
+	rOf := func(exit float64) float64 {
+		if s.Direction == SignalDirectionShort {
+			return (s.EntryPrice - exit) / risk
+		}
+		return (exit - s.EntryPrice) / risk
+	}
+
+	var r, closed float64
+	for _, t := range s.Targets {
+		if t.HitAt == nil {
+			continue
+		}
+		share := t.AllocationPercent / 100
+		r += share * rOf(t.Price)
+		closed += share
+	}
+	if s.ExitPrice > 0 && closed < 1 {
+		r += (1 - closed) * rOf(s.ExitPrice)
+	}
+	return math.Round(r*10000) / 10000
+}
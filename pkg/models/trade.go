@@ -33,6 +33,16 @@ const (
 	TradeStatusFailed    TradeStatus = "FAILED"
 )
 
+type CloseReason string
+
+const (
+	CloseReasonManual      CloseReason = "MANUAL"
+	CloseReasonStopLoss    CloseReason = "STOP_LOSS"
+	CloseReasonTakeProfit  CloseReason = "TAKE_PROFIT"
+	CloseReasonLiquidation CloseReason = "LIQUIDATION"
+	CloseReasonCopy        CloseReason = "COPY"
+)
+
 type Trader struct {
 	gorm.Model
 	Username string `gorm:"unique;not null"`
@@ -59,6 +69,7 @@ type Trade struct {
 	ClosedAt        *time.Time  `json:"closed_at,omitempty"`
 	Pnl             *float64    `gorm:"type:numeric(18,4)" json:"pnl,omitempty"`
 	Fees            float64     `gorm:"type:numeric(18,4);default:0.00" json:"fees"`
+	CloseReason     CloseReason `gorm:"size:20" json:"close_reason,omitempty"`
 
 	Trader User `gorm:"foreignKey:TraderID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
 
@@ -83,7 +94,6 @@ type TradeUpdateInput struct {
 	StopLossPrice   *float64    `json:"stop_loss_price,omitempty"`
 	TakeProfitPrice *float64    `json:"take_profit_price,omitempty"`
 	Action          string      `json:"action,omitempty" binding:"omitempty,oneof=CLOSE CANCEL"`
-	ClosePrice      *float64    `json:"close_price,omitempty"`
 	Status          TradeStatus `json:"status,omitempty"`
 }
 
@@ -98,6 +108,32 @@ func TimePtr(t time.Time) *time.Time {
 	return &t
 }
 
+// FillPrice is the price the trade was actually opened at, falling back to the
+// requested entry price when no execution price has been recorded yet.
+func (t *Trade) FillPrice() float64 {
+	if t.ExecutedPrice != nil {
+		return *t.ExecutedPrice
+	}
+	return t.EntryPrice
+}
+
+// MarginRequired is the amount of wallet funds locked while the trade is open.
+func (t *Trade) MarginRequired() float64 {
+	leverage := t.Leverage
+	if leverage == 0 {
+		leverage = 1
+	}
+	return t.FillPrice() * t.Quantity / float64(leverage)
+}
+
+// CalculatePnL returns the gross profit or loss of the trade if it were closed at closePrice.
+func (t *Trade) CalculatePnL(closePrice float64) float64 {
+	if t.Side == TradeSideSell {
+		return (t.FillPrice() - closePrice) * t.Quantity
+	}
+	return (closePrice - t.FillPrice()) * t.Quantity
+}
+
 type TradeRequest struct {
 	Symbol          string  `json:"symbol"`
 	TradeType       string  `json:"trade_type"`
@@ -0,0 +1,73 @@
+package models
+
+// MaintenanceMarginRate is the share of the posted margin that must remain
+// before a leveraged position is liquidated.
+const MaintenanceMarginRate = 0.005
+
+// FillTriggered reports whether a PENDING LIMIT or STOP order should fill at
+// the given market price. LIMIT orders fill at or better than EntryPrice; STOP
+// orders fill once the market trades through EntryPrice.
+func (t *Trade) FillTriggered(price float64) bool {
+	if t.Status != TradeStatusPending || price <= 0 {
+		return false
+	}
+
+	switch t.TradeType {
+	case TradeTypeLimit:
+		if t.Side == TradeSideSell {
+			return price >= t.EntryPrice
+		}
+		return price <= t.EntryPrice
+	case TradeTypeStop:
+		if t.Side == TradeSideSell {
+			return price <= t.EntryPrice
+		}
+		return price >= t.EntryPrice
+	}
+	return false
+}
+
+// TriggeredFillPrice is the price a triggered PENDING order fills at. A LIMIT
+// order fills at its own price; a STOP order becomes a market order and fills
+// at the market price, which may have gapped through EntryPrice.
+func (t *Trade) TriggeredFillPrice(price float64) float64 {
+	if t.TradeType == TradeTypeStop {
+		return price
+	}
+	return t.EntryPrice
+}
+
+// LiquidationPrice is the price at which the loss eats the margin down to the
+// maintenance level.
+func (t *Trade) LiquidationPrice() float64 {
+	leverage := float64(t.Leverage)
+	if leverage == 0 {
+		leverage = 1
+	}
+	move := t.FillPrice() * (1 - MaintenanceMarginRate) / leverage
+	if t.Side == TradeSideSell {
+		return t.FillPrice() + move
+	}
+	return t.FillPrice() - move
+}
+
+// ExitTriggered checks an OPEN trade against liquidation, stop loss and take
+// profit, in that order, and returns the price it should be closed at.
+func (t *Trade) ExitTriggered(price float64) (float64, CloseReason, bool) {
+	if t.Status != TradeStatusOpen || price <= 0 {
+		return 0, "", false
+	}
+
+	sell := t.Side == TradeSideSell
+	liquidation := t.LiquidationPrice()
+	if (!sell && price <= liquidation) || (sell && price >= liquidation) {
+		return liquidation, CloseReasonLiquidation, true
+	}
+	if sl := t.StopLossPrice; sl != nil && ((!sell && price <= *sl) || (sell && price >= *sl)) {
+		return price, CloseReasonStopLoss, true
+	}
+	if tp := t.TakeProfitPrice; tp != nil && ((!sell && price >= *tp) || (sell && price <= *tp)) {
+		return price, CloseReasonTakeProfit, true
+	}
+	return 0, "", false
+}
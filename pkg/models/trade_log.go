@@ -13,13 +13,22 @@ const (
 	LogStatusFailed  LogStatus = "failed"
 )
 
+type CopyAction string
+
+const (
+	CopyActionOpen   CopyAction = "OPEN"
+	CopyActionModify CopyAction = "MODIFY"
+	CopyActionClose  CopyAction = "CLOSE"
+)
+
 type TradeLog struct {
 	gorm.Model
-	CopySessionID   uint
+	CopySessionID   uint `gorm:"index"`
 	MasterTradeID   string
 	FollowerTradeID string
-	Status          LogStatus `gorm:"type:varchar(20);index"`
-	ErrorMessage    string    
-	ExecutionTime   int64  
+	Action          CopyAction `gorm:"type:varchar(10)"`
+	Status          LogStatus  `gorm:"type:varchar(20);index"`
+	ErrorMessage    string
+	ExecutionTime   int64
 	Timestamp       time.Time
 }
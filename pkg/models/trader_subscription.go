@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/fathimasithara01/tradeverse/pkg/money"
 	"gorm.io/gorm"
 )
 
@@ -36,10 +37,16 @@ type CustomerTraderSignalSubscription struct {
 	TransactionID       uint
 
 	PaymentStatus          string  `gorm:"size:50;not null" json:"payment_status"`
-	AmountPaid             float64 `gorm:"type:numeric(18,4);not null" json:"amount_paid"`
-	TraderShare            float64 `gorm:"type:numeric(18,4);not null" json:"trader_share"`
-	AdminCommission        float64 `gorm:"type:numeric(18,4);not null" json:"admin_commission"`
+	AmountPaid             money.Amount `gorm:"type:numeric(18,4);not null" json:"amount_paid"`
+	TraderShare            money.Amount `gorm:"type:numeric(18,4);not null" json:"trader_share"`
+	AdminCommission        money.Amount `gorm:"type:numeric(18,4);not null" json:"admin_commission"`
 	TransactionReferenceID string  `gorm:"size:255;not null" json:"transaction_reference_id"`
+	RefundedAmount         money.Amount `gorm:"type:numeric(18,4);not null;default:0" json:"refunded_amount"`
+	CancelledAt            *time.Time   `json:"cancelled_at,omitempty"`
+	// PaidCurrency and PaidFXRate record how the subscriber paid, as on
+	// CustomerToTraderSub.
+	PaidCurrency string      `gorm:"size:10" json:"paid_currency,omitempty"`
+	PaidFXRate   *money.Rate `gorm:"type:numeric(20,8)" json:"paid_fx_rate,omitempty"`
 }
 
 
@@ -47,9 +54,9 @@ type TraderSubscriptionResponse struct {
 	TraderSubscriptionID uint    `json:"trader_subscription_id"`
 	TraderName           string  `json:"trader_name"`
 	PlanName             string  `json:"plan_name"`
-	AmountPaid           float64 `json:"amount_paid"`
-	TraderShare          float64 `json:"trader_share"`
-	AdminCommission      float64 `json:"admin_commission"`
+	AmountPaid           money.Amount `json:"amount_paid"`
+	TraderShare          money.Amount `json:"trader_share"`
+	AdminCommission      money.Amount `json:"admin_commission"`
 	PaymentStatus        string  `json:"payment_status"`
 	TransactionID        string  `json:"transaction_id"`
 	StartDate            string  `json:"start_date"`
@@ -68,9 +75,11 @@ type TraderSubscriptionRequest struct {
 type CreateTraderSubscriptionPlanInput struct {
 	Name         string  `json:"name" binding:"required"`
 	Description  string  `json:"description"`
-	Price        float64 `json:"price" binding:"required,gt=0"`
+	Price        money.Amount `json:"price" binding:"required,gt=0"`
 	Currency     string  `json:"currency" binding:"required,oneof=INR USD"`
 	DurationDays uint    `json:"duration_days" binding:"required,gt=0"`
+	RefundPolicy     RefundPolicy `json:"refund_policy" binding:"omitempty,oneof=NONE FULL_WITHIN_WINDOW PRORATED"`
+	RefundWindowDays uint         `json:"refund_window_days" binding:"required_if=RefundPolicy FULL_WITHIN_WINDOW"`
 }
 
 type SubscribeToTraderInput struct {
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+)
+
+// WalletTransfer moves money from one user's wallet to another's inside the
+// platform. The sender's debit and the recipient's credit are posted together
+// as one journal entry.
+type WalletTransfer struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	SenderID    uint         `gorm:"not null;index" json:"sender_id"`
+	RecipientID uint         `gorm:"not null;index" json:"recipient_id"`
+	Amount      money.Amount `gorm:"type:numeric(18,4);not null" json:"amount"`
+	Currency    string       `gorm:"size:10;not null" json:"currency"`
+	// LimitAmount is Amount in the transfer policy's currency at send time;
+	// the sender's daily limit is checked against its sum.
+	LimitAmount         money.Amount `gorm:"type:numeric(18,4);not null;default:0" json:"limit_amount"`
+	Note                string       `gorm:"size:255" json:"note,omitempty"`
+	Reference           string       `gorm:"size:100;uniqueIndex" json:"reference"`
+	DebitTransactionID  *uint        `gorm:"index" json:"debit_transaction_id,omitempty"`
+	CreditTransactionID *uint        `gorm:"index" json:"credit_transaction_id,omitempty"`
+	CreatedAt           time.Time    `json:"created_at"`
+}
+
+// TransferInput names the recipient by ID or by email.
+type TransferInput struct {
+	RecipientID    uint         `json:"recipient_id"`
+	RecipientEmail string       `json:"recipient_email" binding:"omitempty,email"`
+	Amount         money.Amount `json:"amount" binding:"required,gt=0"`
+	Currency       string       `json:"currency" binding:"required,oneof=INR USD"`
+	Note           string       `json:"note" binding:"max=255"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// UserTwoFactor is a user's TOTP authenticator. It is created unconfirmed when
+// enrolment starts and only protects logins once Enabled, after the user has
+// proved the authenticator works by entering a code. The secret is stored
+// encrypted.
+type UserTwoFactor struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;uniqueIndex" json:"user_id"`
+	Secret      string     `gorm:"size:255;not null" json:"-"`
+	Enabled     bool       `gorm:"not null;default:false" json:"enabled"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+	// LastUsedStep is the TOTP time step of the last accepted code. Codes of
+	// that step or earlier are refused so a code cannot be replayed.
+	LastUsedStep int64     `gorm:"not null;default:0" json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TwoFactorRecoveryCode is a single-use code that stands in for a TOTP code
+// when the authenticator is lost. Only its hash is stored.
+type TwoFactorRecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;not null;index" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TwoFactorChallenge is the second step of a login. A correct password
+// returns the challenge token instead of a session; the session is issued once
+// a valid code is presented with it.
+type TwoFactorChallenge struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	UserID    uint   `gorm:"not null;index" json:"user_id"`
+	TokenHash string `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	// EnrolmentRequired is set when the user must use 2FA but has not set it
+	// up yet; the challenge then completes by confirming a new authenticator.
+	EnrolmentRequired bool       `gorm:"not null;default:false" json:"enrolment_required"`
+	Attempts          int        `gorm:"not null;default:0" json:"attempts"`
+	ExpiresAt         time.Time  `gorm:"not null;index" json:"expires_at"`
+	UsedAt            *time.Time `json:"used_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
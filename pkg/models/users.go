@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -28,6 +30,12 @@ type User struct {
 	IsBlocked  bool   `gorm:"default:false" json:"is_blocked"`
 	IsVerified bool   `gorm:"default:false" json:"is_verified"`
 	ProfilePic string `json:"profile_pic"`
+	// PasswordChangedAt starts the withdrawal cooling-off period.
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty"`
+	// FailedLoginAttempts counts consecutive failed logins; LockedUntil is
+	// set once they reach the lockout threshold.
+	FailedLoginAttempts int        `gorm:"not null;default:0" json:"failed_login_attempts"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`
 
 	CustomerProfile CustomerProfile `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"customer_profile,omitempty"`
 	TraderProfile   *TraderProfile  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"trader_profile,omitempty"`
@@ -66,11 +74,27 @@ func (u *User) SetPassword(password string) error {
 	return nil
 }
 
+// ChangePassword sets a new password on an existing account and records when
+// it changed.
+func (u *User) ChangePassword(password string) error {
+	if err := u.SetPassword(password); err != nil {
+		return err
+	}
+	now := time.Now()
+	u.PasswordChangedAt = &now
+	return nil
+}
+
 func (u *User) CheckPassword(password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 	return err == nil
 }
 
+// IsLocked reports whether failed logins have locked the account at now.
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
 func (u *User) IsAdmin() bool {
 	return u.Role == RoleAdmin
 }
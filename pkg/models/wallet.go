@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/fathimasithara01/tradeverse/pkg/money"
 	"gorm.io/gorm"
 )
 
@@ -44,9 +45,14 @@ const (
 type Wallet struct {
 	gorm.Model
 	WalletID    uint    `json:"wallet_id"`
-	UserID      uint    `gorm:"uniqueIndex;not null" json:"user_id"`
-	Balance     float64 `gorm:"type:numeric(18,4);default:0.00" json:"balance"`
-	Currency    string  `gorm:"size:10;not null;default:'USD'" json:"currency"`
+	UserID      uint    `gorm:"uniqueIndex:idx_wallet_user_currency;not null" json:"user_id"`
+	Balance     money.Amount `gorm:"type:numeric(18,4);default:0" json:"balance"`
+	Currency    string  `gorm:"size:10;not null;default:'USD';uniqueIndex:idx_wallet_user_currency" json:"currency"`
+	// SubBalance marks a wallet that holds one of the user's other currencies.
+	// Every user has exactly one primary wallet, the one created with the
+	// account; sub-balances are opened the first time money arrives in a new
+	// currency.
+	SubBalance  bool    `gorm:"not null;default:false;index" json:"sub_balance"`
 	LastUpdated time.Time
 
 	Transactions []WalletTransaction `gorm:"foreignKey:WalletID" json:"transactions,omitempty"`
@@ -55,7 +61,7 @@ type Wallet struct {
 type DepositRequest struct {
 	gorm.Model
 	UserID   uint              `gorm:"index;not null"`
-	Amount   float64           `gorm:"type:numeric(18,4);not null"`
+	Amount   money.Amount      `gorm:"type:numeric(18,4);not null"`
 	Currency string            `gorm:"size:3;not null"`
 	Status   TransactionStatus `gorm:"type:varchar(20);default:'PENDING'"`
 
@@ -68,10 +74,13 @@ type DepositRequest struct {
 
 	CompletionTime *time.Time
 	PaymentMethod  string `gorm:"type:varchar(50);not null;default:'unknown'"`
+	// RefundedAmount is how much of the deposit the gateway has since refunded
+	// or charged back.
+	RefundedAmount money.Amount `gorm:"type:numeric(18,4);not null;default:0" json:"refunded_amount"`
 }
 
 type DepositRequestInput struct {
-	Amount        float64 `json:"amount" binding:"required,gt=0"`
+	Amount        money.Amount `json:"amount" binding:"required,gt=0"`
 	PaymentMethod string  `json:"payment_method"` // Made not required in binding, will be defaulted by controller for admin
 	Currency      string  `json:"currency" binding:"required,oneof=INR USD"`
 }
@@ -80,7 +89,7 @@ type DepositResponse struct {
 	DepositID          uint              `json:"deposit_id"`
 	Message            string            `json:"message"`
 	RedirectURL        string            `json:"redirect_url,omitempty"`
-	Amount             float64           `json:"amount"`
+	Amount             money.Amount      `json:"amount"`
 	Currency           string            `json:"currency"`
 	Status             TransactionStatus `json:"status"`
 	PaymentGatewayTxID string            `json:"payment_gateway_tx_id,omitempty"`
@@ -90,7 +99,7 @@ type DepositVerifyInput struct {
 	PaymentStatus      string  `json:"payment_status"`
 	TransactionID      string  `json:"transaction_id"`
 	PaymentGatewayTxID string  `json:"payment_gateway_tx_id" binding:"required"`
-	Amount             float64 `json:"amount"`
+	Amount             money.Amount `json:"amount"`
 	Status             string  `json:"status"`
 	WebhookSignature   string  `json:"webhook_signature,omitempty"`
 }
@@ -105,7 +114,7 @@ type WithdrawalRequest struct {
 	ID                 uint              `gorm:"primaryKey" json:"id"`
 	UserID             uint              `gorm:"not null;index;comment:ID of the user requesting withdrawal" json:"user_id"`
 	User               User              `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"user,omitempty"` // Ensure User is preloaded
-	Amount             float64           `gorm:"type:decimal(18,4);not null" json:"amount"`
+	Amount             money.Amount      `gorm:"type:decimal(18,4);not null" json:"amount"`
 	Currency           string            `gorm:"size:3;not null;default:'USD'" json:"currency"`
 	BankAccountNumber  string            `gorm:"size:50;not null" json:"bank_account_number"`
 	BankAccountHolder  string            `gorm:"size:100;not null" json:"bank_account_holder"`
@@ -118,10 +127,28 @@ type WithdrawalRequest struct {
 	PaymentGatewayTxID string            `gorm:"size:100" json:"payment_gateway_tx_id,omitempty"`
 	CreatedAt          time.Time         `json:"created_at"`
 	UpdatedAt          time.Time         `json:"updated_at"`
+
+	// RequestedBy is who filed the withdrawal: the user, or for the platform
+	// wallet the admin who asked for the payout. Neither may approve it. It is
+	// 0 for withdrawals the platform files itself, such as trader payouts.
+	RequestedBy         uint  `gorm:"not null;default:0" json:"requested_by"`
+	WalletTransactionID *uint `gorm:"index" json:"wallet_transaction_id,omitempty"`
+	// LimitAmount is Amount in the withdrawal policy's currency at request
+	// time; the daily and monthly limits are checked against its sum.
+	LimitAmount       money.Amount `gorm:"type:numeric(18,4);not null;default:0" json:"limit_amount"`
+	ApprovalsRequired int          `gorm:"not null;default:1" json:"approvals_required"`
+	Approvals         int          `gorm:"not null;default:0" json:"approvals"`
+	// HoldUntil keeps an approved withdrawal from being paid out during the
+	// cooling-off period after a deposit or password change.
+	HoldUntil *time.Time `gorm:"index" json:"hold_until,omitempty"`
+	// LegacyWithdrawRequestID links rows copied from withdraw_requests.
+	LegacyWithdrawRequestID *uint `gorm:"uniqueIndex" json:"-"`
+
+	Events []WithdrawalEvent `gorm:"foreignKey:WithdrawalID" json:"events,omitempty"`
 }
 
 type WithdrawalRequestInput struct {
-	Amount             float64 `json:"amount" binding:"required,gt=0"`
+	Amount             money.Amount `json:"amount" binding:"required,gt=0"`
 	BankAccountNumber  string  `json:"bank_account_number" binding:"required"`
 	BankAccountHolder  string  `json:"bank_account_holder" binding:"required"`
 	IFSCCode           string  `json:"ifsc_code" binding:"required"`
@@ -131,7 +158,7 @@ type WithdrawalRequestInput struct {
 
 type WithdrawalResponse struct {
 	WithdrawalID       uint              `json:"withdrawal_id"`
-	Amount             float64           `json:"amount"`
+	Amount             money.Amount      `json:"amount"`
 	Currency           string            `json:"currency"`
 	Status             TransactionStatus `json:"status"`
 	PaymentGatewayTxID string            `json:"payment_gateway_tx_id,omitempty"`
@@ -149,15 +176,15 @@ type WalletTransaction struct {
 	User User `gorm:"foreignKey:UserID"`
 
 	TransactionType    TransactionType   `gorm:"size:30;not null"`
-	Amount             float64           `gorm:"type:numeric(18,4);not null"`
+	Amount             money.Amount      `gorm:"type:numeric(18,4);not null"`
 	Currency           string            `gorm:"size:3;not null"`
 	Status             TransactionStatus `gorm:"size:20;not null"`
 	Notes              string            `json:"notes,omitempty"`
 	ReferenceID        string            `gorm:"size:100"`
 	PaymentGatewayTxID string            `gorm:"size:100"`
 	Description        string            `gorm:"type:text"`
-	BalanceBefore      float64           `gorm:"type:numeric(18,4)"`
-	BalanceAfter       float64           `gorm:"type:numeric(18,4)"`
+	BalanceBefore      money.Amount      `gorm:"type:numeric(18,4)"`
+	BalanceAfter       money.Amount      `gorm:"type:numeric(18,4)"`
 
 	TransactionID string `gorm:"size:255" json:"transaction_id"`
 
@@ -166,13 +193,23 @@ type WalletTransaction struct {
 	ReferralID           *uint `gorm:"index" json:"referral_id,omitempty"`
 	SubscriptionID       *uint `gorm:"index" json:"subscription_id,omitempty"`
 	TraderSubscriptionID *uint `gorm:"index" json:"trader_subscription_id,omitempty"`
+
+	// Set when the amount was converted from a price in another currency:
+	// OriginalAmount in OriginalCurrency times FXRate gives Amount.
+	OriginalAmount   *money.Amount `gorm:"type:numeric(18,4)" json:"original_amount,omitempty"`
+	OriginalCurrency string        `gorm:"size:10" json:"original_currency,omitempty"`
+	FXRate           *money.Rate   `gorm:"type:numeric(20,8)" json:"fx_rate,omitempty"`
+	FXRateID         *uint         `json:"fx_rate_id,omitempty"`
 }
 
+// WithdrawRequest is the admin-side withdrawal record from before every
+// withdrawal went through WithdrawalRequest. Its rows are copied over by the
+// migrations and it is no longer written.
 type WithdrawRequest struct {
 	gorm.Model
 	UserID              uint              `gorm:"index;not null"`
 	User                User              `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Amount              float64           `gorm:"type:numeric(18,4);not null"`
+	Amount              money.Amount      `gorm:"type:numeric(18,4);not null"`
 	Currency            string            `gorm:"size:3;not null"`
 	Status              TransactionStatus `gorm:"size:20;not null"`
 	BeneficiaryAccount  string            `gorm:"type:text;not null"` 
@@ -192,9 +229,23 @@ type WithdrawRequest struct {
 type WalletSummaryResponse struct {
 	UserID      uint      `json:"user_id"`
 	WalletID    uint      `json:"wallet_id"`
-	Balance     float64   `json:"balance"`
+	Balance     money.Amount `json:"balance"`
 	Currency    string    `json:"currency"`
 	LastUpdated time.Time `json:"last_updated"`
+	// Balances lists every currency the user holds, the primary wallet first.
+	Balances []CurrencyBalance `json:"balances,omitempty"`
+}
+
+type CurrencyBalance struct {
+	WalletID uint         `json:"wallet_id"`
+	Currency string       `json:"currency"`
+	Balance  money.Amount `json:"balance"`
+	Primary  bool         `json:"primary"`
+}
+
+// PrimaryWallet limits a wallet query to users' primary wallets.
+func PrimaryWallet(db *gorm.DB) *gorm.DB {
+	return db.Where("sub_balance = ?", false)
 }
 
 type TransactionListResponse struct {
@@ -217,14 +268,14 @@ type AdminTransactionDisplayDTO struct {
 	UserEmail       string            `json:"user_email"`
 	UserPhone       string            `json:"user_phone"`
 	TransactionType TransactionType   `json:"transaction_type"`
-	Amount          float64           `json:"amount"`
+	Amount          money.Amount      `json:"amount"`
 	Currency        string            `json:"currency"`
 	Status          TransactionStatus `json:"status"`
 	ReferenceID     string            `json:"reference_id"`
 	Description     string            `json:"description"`
 	CreatedAt       time.Time         `json:"created_at"`
-	BalanceBefore   float64           `json:"balance_before"`
-	BalanceAfter    float64           `json:"balance_after"`
+	BalanceBefore   money.Amount      `json:"balance_before"`
+	BalanceAfter    money.Amount      `json:"balance_after"`
 }
 
 type AllTransactionsListResponse struct {
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// WithdrawalAction is what happened to a withdrawal in one audit event.
+type WithdrawalAction string
+
+const (
+	WithdrawalActionRequested WithdrawalAction = "REQUESTED"
+	WithdrawalActionApproved  WithdrawalAction = "APPROVED"
+	WithdrawalActionRejected  WithdrawalAction = "REJECTED"
+	WithdrawalActionSubmitted WithdrawalAction = "SUBMITTED"
+	WithdrawalActionSettled   WithdrawalAction = "SETTLED"
+	WithdrawalActionReversed  WithdrawalAction = "REVERSED"
+)
+
+// WithdrawalActor is who caused an audit event.
+type WithdrawalActor string
+
+const (
+	WithdrawalActorUser    WithdrawalActor = "USER"
+	WithdrawalActorAdmin   WithdrawalActor = "ADMIN"
+	WithdrawalActorSystem  WithdrawalActor = "SYSTEM"
+	WithdrawalActorGateway WithdrawalActor = "GATEWAY"
+)
+
+// WithdrawalEvent is one entry in a withdrawal's audit trail. Every status
+// change writes one, as does every approval.
+type WithdrawalEvent struct {
+	ID           uint              `gorm:"primaryKey" json:"id"`
+	WithdrawalID uint              `gorm:"not null;index" json:"withdrawal_id"`
+	Action       WithdrawalAction  `gorm:"type:varchar(20);not null" json:"action"`
+	FromStatus   TransactionStatus `gorm:"type:varchar(20)" json:"from_status,omitempty"`
+	ToStatus     TransactionStatus `gorm:"type:varchar(20);not null" json:"to_status"`
+	ActorType    WithdrawalActor   `gorm:"type:varchar(20);not null" json:"actor_type"`
+	ActorID      *uint             `json:"actor_id,omitempty"`
+	Note         string            `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// WithdrawalApproval records one admin's sign-off. An admin can approve a
+// withdrawal only once, so withdrawals that need two approvals need two
+// different admins.
+type WithdrawalApproval struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	WithdrawalID uint      `gorm:"not null;uniqueIndex:idx_withdrawal_approver" json:"withdrawal_id"`
+	AdminID      uint      `gorm:"not null;uniqueIndex:idx_withdrawal_approver" json:"admin_id"`
+	Note         string    `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type WithdrawalReviewInput struct {
+	Action string `json:"action" binding:"required,oneof=approve reject"`
+	Note   string `json:"note"`
+}
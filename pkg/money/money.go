@@ -0,0 +1,361 @@
+// Package money holds exact monetary amounts. Every money column in the
+// database is numeric(18,4), so an Amount is an integer count of 1/10000 units
+// and never goes through binary floating point once it has been parsed.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Scale is the number of decimal places an Amount carries.
+const Scale = 4
+
+const unitsPerWhole = 10000
+
+var (
+	ErrInvalidAmount = errors.New("invalid money amount")
+	ErrOverflow      = errors.New("money amount out of range")
+)
+
+// Amount is a fixed-point decimal with Scale decimal places. The zero value is
+// zero, and amounts can be compared with the ordinary operators.
+type Amount int64
+
+// RoundingMode decides what happens to digits dropped when an amount is
+// rounded to a currency's precision or a product is brought back to Scale.
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds halves away from zero (1.005 -> 1.01, -1.005 -> -1.01).
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds halves to the nearest even digit (1.005 -> 1.00, 1.015 -> 1.02).
+	RoundHalfEven
+	// RoundDown truncates toward zero.
+	RoundDown
+)
+
+var precisions = map[string]int{
+	"USD":  2,
+	"INR":  2,
+	"EUR":  2,
+	"GBP":  2,
+	"JPY":  0,
+	"USDT": 4,
+}
+
+// Precision returns the number of decimal places amounts in the currency are
+// settled at. Unknown currencies settle at two places.
+func Precision(currency string) int {
+	if p, ok := precisions[strings.ToUpper(currency)]; ok {
+		return p
+	}
+	return 2
+}
+
+// FromUnits builds an Amount from a count of 1/10000 units.
+func FromUnits(units int64) Amount {
+	return Amount(units)
+}
+
+// FromFloat converts a float to the nearest Amount, rounding halves away from
+// zero. It is meant for values that were floats to begin with, such as request
+// payloads and prices multiplied by quantities.
+func FromFloat(f float64) Amount {
+	return Amount(math.Round(f * unitsPerWhole))
+}
+
+// Parse reads a decimal string such as "-12.5" or "0.0001". Digits beyond Scale
+// are rounded half away from zero.
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("%w: empty string", ErrInvalidAmount)
+	}
+
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	for _, part := range []string{whole, frac} {
+		for _, r := range part {
+			if r < '0' || r > '9' {
+				return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+			}
+		}
+	}
+
+	roundUp := false
+	if len(frac) > Scale {
+		roundUp = frac[Scale] >= '5'
+		frac = frac[:Scale]
+	}
+	frac += strings.Repeat("0", Scale-len(frac))
+
+	units, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	if roundUp {
+		units++
+	}
+	if neg {
+		units = -units
+	}
+	return Amount(units), nil
+}
+
+// MustParse is Parse for constants; it panics on invalid input.
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// Units returns the amount as a count of 1/10000 units.
+func (a Amount) Units() int64 {
+	return int64(a)
+}
+
+// Float64 returns the nearest float. Use it for analytics and display only.
+func (a Amount) Float64() float64 {
+	return float64(a) / unitsPerWhole
+}
+
+func (a Amount) Add(b Amount) Amount { return a + b }
+func (a Amount) Sub(b Amount) Amount { return a - b }
+func (a Amount) Neg() Amount         { return -a }
+
+func (a Amount) Abs() Amount {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func (a Amount) IsZero() bool     { return a == 0 }
+func (a Amount) IsPositive() bool { return a > 0 }
+func (a Amount) IsNegative() bool { return a < 0 }
+
+// Min returns the smaller of a and b.
+func Min(a, b Amount) Amount {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Max returns the larger of a and b.
+func Max(a, b Amount) Amount {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Round rounds the amount to the currency's precision.
+func (a Amount) Round(currency string, mode RoundingMode) Amount {
+	step := int64(math.Pow10(Scale - Precision(currency)))
+	if step <= 1 {
+		return a
+	}
+	return Amount(divRound(big.NewInt(int64(a)), big.NewInt(step), mode) * step)
+}
+
+// Percent returns pct percent of the amount, rounded to the currency's
+// precision. pct is taken to two decimal places, the precision commission
+// percentages are stored at.
+func (a Amount) Percent(pct float64, currency string, mode RoundingMode) Amount {
+	basisPoints := big.NewInt(int64(math.Round(pct * 100)))
+	exact := new(big.Int).Mul(big.NewInt(int64(a)), basisPoints)
+	return Amount(divRound(exact, big.NewInt(10000), mode)).Round(currency, mode)
+}
+
+// Split divides the amount into pct percent (rounded half up to the currency's
+// precision) and the remainder. The two parts always add back up to a.
+func (a Amount) Split(pct float64, currency string) (share, rest Amount) {
+	share = a.Percent(pct, currency, RoundHalfUp)
+	return share, a - share
+}
+
+// Prorate returns part/whole of the amount, computed exactly and rounded to
+// the currency's precision. It is used for a share of a period, such as the
+// unused days of a subscription; part is clamped to [0, whole].
+func (a Amount) Prorate(part, whole int64, currency string, mode RoundingMode) Amount {
+	if whole <= 0 || part <= 0 {
+		return 0
+	}
+	if part >= whole {
+		return a
+	}
+	exact := new(big.Int).Mul(big.NewInt(int64(a)), big.NewInt(part))
+	return Amount(divRound(exact, big.NewInt(whole), mode)).Round(currency, mode)
+}
+
+// MulFloat multiplies the amount by a float factor, such as a quantity or an
+// exchange rate, and rounds the product back to Scale.
+func (a Amount) MulFloat(f float64, mode RoundingMode) Amount {
+	product := new(big.Float).SetPrec(128).Mul(
+		new(big.Float).SetInt64(int64(a)),
+		new(big.Float).SetFloat64(f),
+	)
+	const den = 1 << 20
+	scaled, _ := new(big.Float).Mul(product, big.NewFloat(den)).Int(nil)
+	return Amount(divRound(scaled, big.NewInt(den), mode))
+}
+
+// String formats the amount with all Scale decimal places, e.g. "12.3400".
+func (a Amount) String() string {
+	return a.format(Scale)
+}
+
+// StringFixed formats the amount with the given number of decimal places,
+// rounding half up. places is capped at Scale.
+func (a Amount) StringFixed(places int) string {
+	if places > Scale {
+		places = Scale
+	}
+	if places < 0 {
+		places = 0
+	}
+	step := int64(math.Pow10(Scale - places))
+	rounded := Amount(divRound(big.NewInt(int64(a)), big.NewInt(step), RoundHalfUp) * step)
+	return rounded.format(places)
+}
+
+// Format formats the amount at the currency's precision, e.g. "12.34".
+func (a Amount) Format(currency string) string {
+	return a.StringFixed(Precision(currency))
+}
+
+func (a Amount) format(places int) string {
+	units := int64(a)
+	sign := ""
+	if units < 0 {
+		sign = "-"
+	}
+	abs := new(big.Int).Abs(big.NewInt(units)).String()
+	if len(abs) <= Scale {
+		abs = strings.Repeat("0", Scale-len(abs)+1) + abs
+	}
+	whole, frac := abs[:len(abs)-Scale], abs[len(abs)-Scale:]
+	if places == 0 {
+		return sign + whole
+	}
+	return sign + whole + "." + frac[:places]
+}
+
+// trimmed drops trailing zeros, so JSON carries 12.5 rather than 12.5000.
+func (a Amount) trimmed() string {
+	s := a.String()
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// MarshalJSON writes the amount as a JSON number.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.trimmed()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a quoted decimal string.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidAmount, s)
+		}
+		*a = FromFloat(f)
+		return nil
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Value stores the amount as an exact decimal string.
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Scan reads numeric columns, which drivers hand over as text, as well as
+// integers and floats. NULL scans as zero.
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+	case []byte:
+		parsed, err := Parse(string(v))
+		if err != nil {
+			return err
+		}
+		*a = parsed
+	case string:
+		parsed, err := Parse(v)
+		if err != nil {
+			return err
+		}
+		*a = parsed
+	case int64:
+		*a = Amount(v * unitsPerWhole)
+	case float64:
+		*a = FromFloat(v)
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidAmount, src)
+	}
+	return nil
+}
+
+// GormDataType is the column type used when a model does not set one.
+func (Amount) GormDataType() string {
+	return "numeric(18,4)"
+}
+
+// divRound divides n by d (d > 0) and rounds the quotient with mode.
+func divRound(n, d *big.Int, mode RoundingMode) int64 {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	if r.Sign() == 0 || mode == RoundDown {
+		return q.Int64()
+	}
+
+	twice := new(big.Int).Abs(r)
+	twice.Lsh(twice, 1)
+	cmp := twice.Cmp(d)
+	away := cmp > 0 || (cmp == 0 && (mode == RoundHalfUp || q.Bit(0) == 1))
+	if away {
+		if n.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q.Int64()
+}
@@ -0,0 +1,179 @@
+package money
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RateScale is the number of decimal places a Rate carries.
+const RateScale = 8
+
+const rateUnitsPerWhole = 100_000_000
+
+// Rate is an exchange rate: how many units of one currency buy one unit of
+// another. Like Amount it is a fixed-point decimal, with RateScale places so
+// rates such as 0.01203456 survive intact.
+type Rate int64
+
+// OneRate converts a currency to itself.
+const OneRate Rate = rateUnitsPerWhole
+
+// ParseRate reads a decimal string such as "83.125". Digits beyond RateScale
+// are rounded half away from zero.
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "-") {
+		return 0, fmt.Errorf("%w: negative rate %q", ErrInvalidAmount, s)
+	}
+	s = strings.TrimPrefix(s, "+")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	for _, part := range []string{whole, frac} {
+		for _, r := range part {
+			if r < '0' || r > '9' {
+				return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+			}
+		}
+	}
+
+	roundUp := false
+	if len(frac) > RateScale {
+		roundUp = frac[RateScale] >= '5'
+		frac = frac[:RateScale]
+	}
+	frac += strings.Repeat("0", RateScale-len(frac))
+
+	units, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	if roundUp {
+		units++
+	}
+	return Rate(units), nil
+}
+
+// MustParseRate is ParseRate for constants; it panics on invalid input.
+func MustParseRate(s string) Rate {
+	r, err := ParseRate(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// RateFromFloat converts a float, such as a rate from a JSON feed, to the
+// nearest Rate.
+func RateFromFloat(f float64) Rate {
+	return Rate(math.Round(f * rateUnitsPerWhole))
+}
+
+func (r Rate) IsPositive() bool { return r > 0 }
+
+// Inverse returns the rate for the opposite direction, rounded half up.
+func (r Rate) Inverse() Rate {
+	if r <= 0 {
+		return 0
+	}
+	n := new(big.Int).Mul(big.NewInt(rateUnitsPerWhole), big.NewInt(rateUnitsPerWhole))
+	return Rate(divRound(n, big.NewInt(int64(r)), RoundHalfUp))
+}
+
+// Convert multiplies the amount by the rate and rounds the result to the
+// target currency's precision.
+func (a Amount) Convert(r Rate, currency string, mode RoundingMode) Amount {
+	exact := new(big.Int).Mul(big.NewInt(int64(a)), big.NewInt(int64(r)))
+	return Amount(divRound(exact, big.NewInt(rateUnitsPerWhole), mode)).Round(currency, mode)
+}
+
+// String formats the rate with trailing zeros dropped, e.g. "83.125".
+func (r Rate) String() string {
+	units := int64(r)
+	sign := ""
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+	s := strconv.FormatInt(units, 10)
+	if len(s) <= RateScale {
+		s = strings.Repeat("0", RateScale-len(s)+1) + s
+	}
+	whole, frac := s[:len(s)-RateScale], strings.TrimRight(s[len(s)-RateScale:], "0")
+	if frac == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + frac
+}
+
+// MarshalJSON writes the rate as a JSON number.
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a quoted decimal string.
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidAmount, s)
+		}
+		*r = RateFromFloat(f)
+		return nil
+	}
+	parsed, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Value stores the rate as an exact decimal string.
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// Scan reads numeric columns. NULL scans as zero.
+func (r *Rate) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*r = 0
+	case []byte:
+		return r.Scan(string(v))
+	case string:
+		parsed, err := ParseRate(v)
+		if err != nil {
+			return err
+		}
+		*r = parsed
+	case int64:
+		*r = Rate(v * rateUnitsPerWhole)
+	case float64:
+		*r = RateFromFloat(v)
+	default:
+		return fmt.Errorf("%w: cannot scan %T into a rate", ErrInvalidAmount, src)
+	}
+	return nil
+}
+
+// GormDataType is the column type used when a model does not set one.
+func (Rate) GormDataType() string {
+	return "numeric(20,8)"
+}
@@ -1,37 +1,226 @@
 package paymentgateway
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"math/rand"
+	"sync"
 	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/money"
 )
 
 var (
 	ErrPaymentGatewayFailed = errors.New("payment gateway operation failed")
 	ErrInvalidAmount        = errors.New("invalid amount for payment gateway")
 	ErrTransactionNotFound  = errors.New("payment gateway transaction not found")
+	ErrPaymentDeclined      = errors.New("payment declined by gateway")
+	ErrGatewayTimeout       = errors.New("payment gateway timed out")
 )
 
 type SimulatedPaymentClient interface {
-	CreateDepositInitiation(amount float64, currency, userID string) (pgTxID, redirectURL string, err error)
+	CreateDepositInitiation(amount money.Amount, currency, userID string) (pgTxID, redirectURL string, err error)
 	VerifyDeposit(pgTxID string) (isVerified bool, err error)
-	ProcessWithdrawal(amount float64, currency, beneficiaryAccount string) (pgTxID string, err error)
+	// ProcessWithdrawal submits a payout. settled reports whether the payout is
+	// final on return; when it is false the outcome arrives later as a
+	// withdrawal webhook.
+	ProcessWithdrawal(amount money.Amount, currency, beneficiaryAccount string) (pgTxID string, settled bool, err error)
+}
+
+// Scenario is what the simulator does with one payment.
+type Scenario string
+
+const (
+	ScenarioSuccess Scenario = "success"
+	// ScenarioDecline: the gateway refuses the payment outright.
+	ScenarioDecline Scenario = "decline"
+	// ScenarioTimeout: the call hangs for Config.Timeout and then fails, leaving
+	// the caller unsure whether the payment went through.
+	ScenarioTimeout Scenario = "timeout"
+	// ScenarioSettlementFailure: the payment is accepted and later reported
+	// failed.
+	ScenarioSettlementFailure Scenario = "settlement_failure"
+	// ScenarioPartialRefund: a deposit settles and half of it is refunded later.
+	ScenarioPartialRefund Scenario = "partial_refund"
+	// ScenarioChargeback: a deposit settles and is then charged back in full.
+	ScenarioChargeback Scenario = "chargeback"
+)
+
+// magicCents maps the cents of an amount to a forced scenario, so QA can pick
+// a branch by typing e.g. 100.91 instead of changing config.
+var magicCents = map[int64]Scenario{
+	91: ScenarioDecline,
+	92: ScenarioTimeout,
+	93: ScenarioSettlementFailure,
+	94: ScenarioPartialRefund,
+	95: ScenarioChargeback,
+}
+
+// Config controls the simulated gateway. When WebhookURL and WebhookSecret are
+// set it reports payment outcomes back through signed webhooks SettlementDelay
+// after accepting them, so the whole flow can be exercised offline.
+//
+// The rates are probabilities between 0 and 1, checked in the order decline,
+// timeout, settlement failure, refund, chargeback. Refunds and chargebacks
+// only apply to deposits. With MagicAmounts on, the cents of an amount
+// (.91 to .95, see magicCents) override the rates.
+type Config struct {
+	WebhookURL      string
+	WebhookSecret   string
+	SettlementDelay time.Duration
+	// AsyncSettlement leaves withdrawals unsettled until a webhook reports the
+	// outcome. It needs webhooks; without them withdrawals settle on acceptance.
+	AsyncSettlement bool
+
+	DeclineRate           float64
+	TimeoutRate           float64
+	SettlementFailureRate float64
+	RefundRate            float64
+	ChargebackRate        float64
+	Timeout               time.Duration
+	MagicAmounts          bool
+
+	// Rand returns a number in [0, 1); nil uses math/rand.
+	Rand func() float64
+}
+
+type weightedScenario struct {
+	rate     float64
+	scenario Scenario
+}
+
+// ScenarioFor picks the scenario for a payment of amount given a random roll
+// in [0, 1).
+func (c Config) ScenarioFor(amount money.Amount, roll float64, deposit bool) Scenario {
+	if c.MagicAmounts {
+		cents := (amount.Abs().Units() / 100) % 100
+		if scenario, ok := magicCents[cents]; ok {
+			if deposit || (scenario != ScenarioPartialRefund && scenario != ScenarioChargeback) {
+				return scenario
+			}
+		}
+	}
+
+	rates := []weightedScenario{
+		{c.DeclineRate, ScenarioDecline},
+		{c.TimeoutRate, ScenarioTimeout},
+		{c.SettlementFailureRate, ScenarioSettlementFailure},
+	}
+	if deposit {
+		rates = append(rates,
+			weightedScenario{c.RefundRate, ScenarioPartialRefund},
+			weightedScenario{c.ChargebackRate, ScenarioChargeback},
+		)
+	}
+
+	threshold := 0.0
+	for _, r := range rates {
+		threshold += r.rate
+		if roll < threshold {
+			return r.scenario
+		}
+	}
+	return ScenarioSuccess
 }
 
 type simulatedPaymentClient struct {
+	cfg      Config
+	webhooks *WebhookSender
+
+	mu       sync.Mutex
+	deposits map[string]Scenario
 }
 
-func NewSimulatedPaymentClient() SimulatedPaymentClient {
-	return &simulatedPaymentClient{}
+func NewSimulatedPaymentClient(cfg Config) SimulatedPaymentClient {
+	client := &simulatedPaymentClient{cfg: cfg, deposits: make(map[string]Scenario)}
+	if cfg.WebhookURL != "" && cfg.WebhookSecret != "" {
+		client.webhooks = &WebhookSender{URL: cfg.WebhookURL, Secret: cfg.WebhookSecret}
+	} else if cfg.AsyncSettlement {
+		log.Printf("Simulated PG: async settlement needs a webhook URL and secret; withdrawals will settle synchronously")
+		client.cfg.AsyncSettlement = false
+	}
+	if client.cfg.Timeout <= 0 {
+		client.cfg.Timeout = 5 * time.Second
+	}
+	if client.cfg.Rand == nil {
+		client.cfg.Rand = rand.Float64
+	}
+	return client
+}
+
+func (s *simulatedPaymentClient) scenario(amount money.Amount, deposit bool) Scenario {
+	return s.cfg.ScenarioFor(amount, s.cfg.Rand(), deposit)
+}
+
+// notify sends the events one after another in the background, waiting
+// SettlementDelay before each.
+func (s *simulatedPaymentClient) notify(events ...WebhookEvent) {
+	if s.webhooks == nil {
+		return
+	}
+	go func() {
+		for _, event := range events {
+			time.Sleep(s.cfg.SettlementDelay)
+			event.ID = fmt.Sprintf("evt_%s", NewNonce())
+			event.OccurredAt = time.Now()
+			if err := s.webhooks.Send(context.Background(), event); err != nil {
+				log.Printf("Simulated PG: %v", err)
+				return
+			}
+		}
+	}()
 }
 
-func (s *simulatedPaymentClient) CreateDepositInitiation(amount float64, currency, userID string) (string, string, error) {
-	if amount <= 0 {
+func (s *simulatedPaymentClient) CreateDepositInitiation(amount money.Amount, currency, userID string) (string, string, error) {
+	if !amount.IsPositive() {
 		return "", "", ErrInvalidAmount
 	}
+
+	scenario := s.scenario(amount, true)
+	switch scenario {
+	case ScenarioDecline:
+		log.Printf("Simulated PG: Deposit of %s %s for User %s declined", amount.Format(currency), currency, userID)
+		return "", "", ErrPaymentDeclined
+	case ScenarioTimeout:
+		log.Printf("Simulated PG: Deposit of %s %s for User %s timing out", amount.Format(currency), currency, userID)
+		time.Sleep(s.cfg.Timeout)
+		return "", "", ErrGatewayTimeout
+	}
+
 	pgTxID := fmt.Sprintf("PG_DEPOSIT_%s_%d", userID, time.Now().UnixNano())
 	redirectURL := fmt.Sprintf("https://simulated-pg.com/pay?tx=%s", pgTxID)
-	fmt.Printf("Simulated PG: Deposit initiated for User %s, Amount %.2f %s. PG Transaction ID: %s\n", userID, amount, currency, pgTxID)
+	log.Printf("Simulated PG: Deposit initiated for User %s, Amount %s %s, scenario %s. PG Transaction ID: %s", userID, amount.Format(currency), currency, scenario, pgTxID)
+
+	s.mu.Lock()
+	s.deposits[pgTxID] = scenario
+	s.mu.Unlock()
+
+	base := WebhookEvent{PaymentGatewayTxID: pgTxID, Amount: amount, Currency: currency}
+	switch scenario {
+	case ScenarioSettlementFailure:
+		failed := base
+		failed.Type, failed.Reason = EventDepositFailed, "payment not completed"
+		s.notify(failed)
+	case ScenarioPartialRefund:
+		succeeded, refund := base, base
+		succeeded.Type = EventDepositSucceeded
+		refund.Type, refund.Reason = EventRefundSucceeded, "partial refund"
+		refund.Amount = amount.Percent(50, currency, money.RoundDown)
+		refund.ReversalID = fmt.Sprintf("PG_REFUND_%d", time.Now().UnixNano())
+		s.notify(succeeded, refund)
+	case ScenarioChargeback:
+		succeeded, chargeback := base, base
+		succeeded.Type = EventDepositSucceeded
+		chargeback.Type, chargeback.Reason = EventChargeback, "cardholder dispute"
+		chargeback.ReversalID = fmt.Sprintf("PG_CHARGEBACK_%d", time.Now().UnixNano())
+		s.notify(succeeded, chargeback)
+	default:
+		succeeded := base
+		succeeded.Type = EventDepositSucceeded
+		s.notify(succeeded)
+	}
 	return pgTxID, redirectURL, nil
 }
 
@@ -39,15 +228,51 @@ func (s *simulatedPaymentClient) VerifyDeposit(pgTxID string) (bool, error) {
 	if pgTxID == "" {
 		return false, ErrTransactionNotFound
 	}
-	fmt.Printf("Simulated PG: Deposit verification requested for PG Transaction ID: %s. Assuming successful.\n", pgTxID)
-	return true, nil
+
+	s.mu.Lock()
+	scenario, known := s.deposits[pgTxID]
+	s.mu.Unlock()
+
+	if !known {
+		// Deposits initiated before a restart are no longer tracked.
+		log.Printf("Simulated PG: Deposit verification requested for unknown PG Transaction ID %s. Assuming successful.", pgTxID)
+		return true, nil
+	}
+	verified := scenario != ScenarioSettlementFailure
+	log.Printf("Simulated PG: Deposit verification requested for PG Transaction ID %s: verified=%t", pgTxID, verified)
+	return verified, nil
 }
 
-func (s *simulatedPaymentClient) ProcessWithdrawal(amount float64, currency, beneficiaryAccount string) (string, error) {
-	if amount <= 0 {
-		return "", ErrInvalidAmount
+func (s *simulatedPaymentClient) ProcessWithdrawal(amount money.Amount, currency, beneficiaryAccount string) (string, bool, error) {
+	if !amount.IsPositive() {
+		return "", false, ErrInvalidAmount
+	}
+
+	scenario := s.scenario(amount, false)
+	switch scenario {
+	case ScenarioDecline:
+		log.Printf("Simulated PG: Withdrawal of %s %s to Account %s declined", amount.Format(currency), currency, beneficiaryAccount)
+		return "", false, ErrPaymentDeclined
+	case ScenarioTimeout:
+		log.Printf("Simulated PG: Withdrawal of %s %s to Account %s timing out", amount.Format(currency), currency, beneficiaryAccount)
+		time.Sleep(s.cfg.Timeout)
+		return "", false, ErrGatewayTimeout
 	}
+
 	pgTxID := fmt.Sprintf("PG_WITHDRAW_%s_%d", beneficiaryAccount, time.Now().UnixNano())
-	fmt.Printf("Simulated PG: Withdrawal processed for Account %s, Amount %.2f %s. PG Transaction ID: %s\n", beneficiaryAccount, amount, currency, pgTxID)
-	return pgTxID, nil
+	log.Printf("Simulated PG: Withdrawal accepted for Account %s, Amount %s %s, scenario %s. PG Transaction ID: %s", beneficiaryAccount, amount.Format(currency), currency, scenario, pgTxID)
+
+	if !s.cfg.AsyncSettlement {
+		if scenario == ScenarioSettlementFailure {
+			return "", false, ErrPaymentDeclined
+		}
+		return pgTxID, true, nil
+	}
+
+	event := WebhookEvent{Type: EventWithdrawalSucceeded, PaymentGatewayTxID: pgTxID, Amount: amount, Currency: currency}
+	if scenario == ScenarioSettlementFailure {
+		event.Type, event.Reason = EventWithdrawalFailed, "beneficiary bank rejected the payout"
+	}
+	s.notify(event)
+	return pgTxID, false, nil
 }
@@ -0,0 +1,26 @@
+package paymentgateway
+
+import (
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/config"
+)
+
+// ConfigFromAppConfig builds the simulator settings from the payment_gateway
+// section of the application config.
+func ConfigFromAppConfig(cfg *config.Config) Config {
+	simulator := cfg.PaymentGateway.Simulator
+	return Config{
+		WebhookURL:            cfg.PaymentGateway.WebhookURL,
+		WebhookSecret:         cfg.PaymentGateway.WebhookSecret,
+		SettlementDelay:       time.Duration(simulator.SettlementDelaySeconds) * time.Second,
+		AsyncSettlement:       simulator.AsyncSettlement,
+		DeclineRate:           simulator.DeclineRate,
+		TimeoutRate:           simulator.TimeoutRate,
+		SettlementFailureRate: simulator.SettlementFailureRate,
+		RefundRate:            simulator.RefundRate,
+		ChargebackRate:        simulator.ChargebackRate,
+		Timeout:               time.Duration(simulator.TimeoutSeconds) * time.Second,
+		MagicAmounts:          simulator.MagicAmounts,
+	}
+}
@@ -0,0 +1,164 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+)
+
+// Headers carried by every webhook delivery. The signature covers the
+// timestamp, the nonce and the raw body, so none of them can be altered or
+// replayed under a fresh timestamp without the secret.
+const (
+	HeaderSignature = "X-PG-Signature"
+	HeaderTimestamp = "X-PG-Timestamp"
+	HeaderNonce     = "X-PG-Nonce"
+)
+
+// DefaultWebhookTolerance is how far a delivery's timestamp may be from now
+// before it is rejected as a replay.
+const DefaultWebhookTolerance = 5 * time.Minute
+
+var (
+	ErrMissingSignature = errors.New("webhook signature headers missing")
+	ErrInvalidSignature = errors.New("webhook signature invalid")
+	ErrWebhookExpired   = errors.New("webhook timestamp outside tolerance")
+	ErrInvalidPayload   = errors.New("webhook payload invalid")
+)
+
+type EventType string
+
+const (
+	EventDepositSucceeded    EventType = "deposit.succeeded"
+	EventDepositFailed       EventType = "deposit.failed"
+	EventWithdrawalSucceeded EventType = "withdrawal.succeeded"
+	EventWithdrawalFailed    EventType = "withdrawal.failed"
+	EventRefundSucceeded     EventType = "refund.succeeded"
+	EventChargeback          EventType = "chargeback.created"
+)
+
+// WebhookEvent is the body of a gateway callback. PaymentGatewayTxID is the
+// gateway's ID for the deposit or withdrawal the event is about; for a refund
+// or chargeback it is the ID of the deposit being reversed, and ReversalID is
+// the gateway's ID for the reversal itself, so a deposit can be partly
+// refunded more than once.
+type WebhookEvent struct {
+	ID                 string       `json:"id"`
+	Type               EventType    `json:"type"`
+	PaymentGatewayTxID string       `json:"payment_gateway_tx_id"`
+	Amount             money.Amount `json:"amount"`
+	Currency           string       `json:"currency"`
+	ReversalID         string       `json:"reversal_id,omitempty"`
+	Reason             string       `json:"reason,omitempty"`
+	OccurredAt         time.Time    `json:"occurred_at"`
+}
+
+func (e WebhookEvent) Validate() error {
+	switch e.Type {
+	case EventDepositSucceeded, EventDepositFailed, EventWithdrawalSucceeded, EventWithdrawalFailed:
+	case EventRefundSucceeded, EventChargeback:
+		if e.ReversalID == "" {
+			return fmt.Errorf("%w: reversal_id is required for %s", ErrInvalidPayload, e.Type)
+		}
+	default:
+		return fmt.Errorf("%w: unknown event type %q", ErrInvalidPayload, e.Type)
+	}
+	if e.ID == "" || e.PaymentGatewayTxID == "" {
+		return fmt.Errorf("%w: id and payment_gateway_tx_id are required", ErrInvalidPayload)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of "timestamp.nonce.body" under secret.
+func Sign(secret string, timestamp int64, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.%s.", timestamp, nonce)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks a delivery's signature and that its timestamp is
+// within tolerance of now. Nonce uniqueness is left to the caller, which has
+// to remember nonces for at least the tolerance window.
+func VerifySignature(secret, signature, timestamp, nonce string, body []byte, now time.Time, tolerance time.Duration) error {
+	if signature == "" || timestamp == "" || nonce == "" {
+		return ErrMissingSignature
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp %q", ErrInvalidSignature, timestamp)
+	}
+
+	expected := Sign(secret, ts, nonce, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	if tolerance <= 0 {
+		tolerance = DefaultWebhookTolerance
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > tolerance || skew < -tolerance {
+		return ErrWebhookExpired
+	}
+	return nil
+}
+
+// NewNonce returns a random 16-byte hex string.
+func NewNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// WebhookSender delivers signed events to a webhook URL, the way the real
+// gateway would.
+type WebhookSender struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+func (w *WebhookSender) Send(ctx context.Context, event WebhookEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event %s: %w", event.ID, err)
+	}
+
+	ts := time.Now().Unix()
+	nonce := NewNonce()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderNonce, nonce)
+	req.Header.Set(HeaderSignature, Sign(w.Secret, ts, nonce, body))
+
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver webhook %s: %w", event.ID, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s rejected with status %d", event.ID, resp.StatusCode)
+	}
+	return nil
+}
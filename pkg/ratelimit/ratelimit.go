@@ -0,0 +1,141 @@
+// Package ratelimit throttles requests with token buckets. Each bucket holds
+// up to Burst tokens and refills at Rate tokens per second; a request takes
+// one token or is refused. Buckets live in a Store, either in process memory
+// or in Redis so that several instances share them.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/config"
+)
+
+// Limit is the shape of a token bucket. A zero Rate disables limiting.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute returns a limit of n requests a minute with bursts of burst.
+func PerMinute(n float64, burst int) Limit {
+	return Limit{Rate: n / 60, Burst: burst}
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+type Store interface {
+	// Take takes a token from the bucket at key at time now.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// take applies a request to a bucket that held tokens at updated and returns
+// the tokens left.
+func (l Limit) take(tokens float64, updated, now time.Time) (float64, Result) {
+	if elapsed := now.Sub(updated).Seconds(); elapsed > 0 {
+		tokens = math.Min(float64(l.Burst), tokens+elapsed*l.Rate)
+	}
+	if tokens >= 1 {
+		tokens--
+		return tokens, Result{Allowed: true, Remaining: int(tokens)}
+	}
+	return tokens, l.refused(tokens)
+}
+
+func (l Limit) refused(tokens float64) Result {
+	wait := time.Duration((1 - tokens) / l.Rate * float64(time.Second))
+	return Result{Allowed: false, RetryAfter: wait}
+}
+
+// ttl is how long an untouched bucket takes to fill up again, after which it
+// is no different from a new one.
+func (l Limit) ttl() time.Duration {
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	ttl     time.Duration
+}
+
+// MemoryStore keeps buckets in process memory.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+	var result Result
+	b.tokens, result = limit.take(b.tokens, b.updated, now)
+	b.updated = now
+	b.ttl = limit.ttl()
+	return result, nil
+}
+
+// sweep drops buckets that have filled up again, at most once a minute.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if now.Sub(b.updated) >= b.ttl {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// Limiter hands out the rate limit middleware of each route group.
+type Limiter struct {
+	store  Store
+	limits map[string]Limit
+}
+
+func NewLimiter(store Store, limits map[string]Limit) *Limiter {
+	return &Limiter{store: store, limits: limits}
+}
+
+// LimiterFromAppConfig builds the store and the group limits from the
+// rate_limit and redis sections of the application config.
+func LimiterFromAppConfig(cfg *config.Config) (*Limiter, error) {
+	limits := make(map[string]Limit, len(cfg.RateLimit.Groups))
+	for name, group := range cfg.RateLimit.Groups {
+		limits[strings.ToLower(name)] = PerMinute(group.RequestsPerMinute, group.Burst)
+	}
+
+	switch strings.ToLower(cfg.RateLimit.Backend) {
+	case "", "memory":
+		return NewLimiter(NewMemoryStore(), limits), nil
+	case "redis":
+		addr := net.JoinHostPort(cfg.Redis.Host, strconv.Itoa(cfg.Redis.Port))
+		return NewLimiter(NewRedisStore(addr, cfg.Redis.Password, cfg.Redis.DB), limits), nil
+	default:
+		return NewLimiter(NewMemoryStore(), limits), fmt.Errorf("unknown rate limit backend %q", cfg.RateLimit.Backend)
+	}
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript applies a request to the bucket at KEYS[1] atomically. ARGV is
+// the refill rate in tokens per millisecond, the burst and the current time
+// in milliseconds. It returns whether the request is allowed and the tokens
+// left, as a string because Redis truncates Lua numbers to integers.
+const takeScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1])
+local updated = tonumber(state[2])
+if tokens == nil or updated == nil then
+  tokens = burst
+  updated = now
+end
+if now > updated then
+  tokens = math.min(burst, tokens + (now - updated) * rate)
+end
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate))
+return {allowed, tostring(tokens)}
+`
+
+var takeBucket = redis.NewScript(takeScript)
+
+const (
+	redisDialTimeout = 2 * time.Second
+	redisIOTimeout   = time.Second
+)
+
+// RedisStore keeps buckets in Redis, so every instance draws from the same
+// ones.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(addr, password string, db int) *RedisStore {
+	return &RedisStore{client: redis.NewClient(&redis.Options{
+		Addr:         addr,
+		Password:     password,
+		DB:           db,
+		DialTimeout:  redisDialTimeout,
+		ReadTimeout:  redisIOTimeout,
+		WriteTimeout: redisIOTimeout,
+	})}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	values, err := takeBucket.Run(ctx, s.client, []string{key},
+		strconv.FormatFloat(limit.Rate/1000, 'f', -1, 64),
+		limit.Burst,
+		now.UnixMilli(),
+	).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(values) != 2 {
+		return Result{}, fmt.Errorf("unexpected reply from rate limit script: %v", values)
+	}
+	allowed, _ := values[0].(int64)
+	text, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("unexpected token count from rate limit script: %q", text)
+	}
+	if allowed == 1 {
+		return Result{Allowed: true, Remaining: int(tokens)}, nil
+	}
+	return limit.refused(tokens), nil
+}
+
+// Close releases the store's connections.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,263 @@
+// Package refund cancels subscriptions under their plan's refund policy. The
+// refund is posted as a reversal of the original payment: it is taken back
+// from whoever was paid, the trader and the platform in the shares they
+// received, and credited to the subscriber's wallet in the currency it was
+// debited in, converted back at the rate the subscription was charged at.
+package refund
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/fx"
+	"github.com/fathimasithara01/tradeverse/pkg/ledger"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNotFound  = errors.New("subscription not found")
+	ErrNotActive = errors.New("subscription is already inactive or cancelled")
+	// ErrUnfunded is returned when the trader or the platform no longer holds
+	// their share of the refund, e.g. because it has been paid out.
+	ErrUnfunded = errors.New("the refund exceeds what the trader or platform wallet holds")
+)
+
+// Amount returns what policy gives back of paid when a subscription running
+// from start to end is cancelled at now. Prorated refunds cover the unused
+// time to the second, rounded down to the currency's precision.
+func Amount(policy models.RefundPolicy, windowDays uint, paid money.Amount, currency string, start, end, now time.Time) money.Amount {
+	if !paid.IsPositive() || !now.Before(end) {
+		return 0
+	}
+	switch policy {
+	case models.RefundPolicyFullWithinWindow:
+		if windowDays > 0 && now.Before(start.AddDate(0, 0, int(windowDays))) {
+			return paid
+		}
+	case models.RefundPolicyProrated:
+		unused := int64(end.Sub(now) / time.Second)
+		total := int64(end.Sub(start) / time.Second)
+		return paid.Prorate(unused, total, currency, money.RoundDown)
+	}
+	return 0
+}
+
+// Split divides refund between the platform and the trader in the proportion
+// paid was split when the subscription was bought, platformShare of it going
+// to the platform. The two parts always add up to refund.
+func Split(refund, paid, platformShare money.Amount, currency string) (fromPlatform, fromTrader money.Amount) {
+	if refund == paid {
+		return platformShare, paid - platformShare
+	}
+	fromPlatform = platformShare.Prorate(int64(refund), int64(paid), currency, money.RoundHalfUp)
+	return fromPlatform, refund - fromPlatform
+}
+
+// CancelSubscription cancels a platform plan subscription inside tx, which
+// must be a database transaction. The whole payment went to the platform, so
+// the whole refund comes from it. customerID restricts the cancellation to that
+// subscriber's own subscriptions; 0 lets an admin cancel any.
+func CancelSubscription(tx *gorm.DB, subscriptionID, customerID uint, now time.Time) (*models.SubscriptionCancellation, error) {
+	var sub models.CustomerToTraderSub
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sub, subscriptionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to load subscription %d: %w", subscriptionID, err)
+	}
+	if customerID != 0 && sub.UserID != customerID {
+		return nil, ErrNotFound
+	}
+	if !sub.IsActive {
+		return nil, ErrNotActive
+	}
+
+	var plan models.AdminTraderSubscriptionPlan
+	if err := tx.Unscoped().First(&plan, sub.SubscriptionPlanID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load plan %d: %w", sub.SubscriptionPlanID, err)
+	}
+
+	// Subscriptions bought before the amount was recorded on them were paid at
+	// the plan's price.
+	paid := sub.AmountPaid
+	if paid.IsZero() {
+		paid = plan.Price
+	}
+
+	refund := Amount(plan.RefundPolicy.OrNone(), plan.RefundWindowDays, paid, plan.Currency, sub.StartDate, sub.EndDate, now)
+	credit := fx.Recorded(refund, plan.Currency, sub.PaidCurrency, sub.PaidFXRate)
+	result := &models.SubscriptionCancellation{
+		SubscriptionID:   sub.ID,
+		CustomerID:       sub.UserID,
+		Policy:           plan.RefundPolicy.OrNone(),
+		Currency:         plan.Currency,
+		AmountPaid:       paid,
+		Refunded:         refund,
+		FromPlatform:     refund,
+		Credited:         credit.Amount,
+		CreditedCurrency: credit.Currency,
+		CancelledAt:      now,
+	}
+
+	reference := fmt.Sprintf("REFUND_SUB_%d", sub.ID)
+	customerTx := &models.WalletTransaction{
+		Type:            models.TxTypeReversal,
+		TransactionType: models.TxTypeCredit,
+		Name:            "Subscription Refund",
+		Description:     fmt.Sprintf("Refund for cancelled subscription to %s", plan.Name),
+		ReferenceID:     reference,
+		TransactionID:   reference,
+		SubscriptionID:  &sub.ID,
+	}
+	platformTx := &models.WalletTransaction{
+		Type:            models.TxTypeReversal,
+		TransactionType: models.TxTypeDebit,
+		Name:            "Subscription Refund",
+		Currency:        plan.Currency,
+		Description:     fmt.Sprintf("Refund to user %d for cancelled subscription %d", sub.UserID, sub.ID),
+		ReferenceID:     reference,
+		TransactionID:   reference,
+		SubscriptionID:  &sub.ID,
+	}
+	if err := post(tx, reference, plan.Currency, append([]ledger.Leg{
+		ledger.Debit(ledger.PlatformCommissionIn(plan.Currency), refund, platformTx),
+	}, credit.RefundLegs(sub.UserID, customerTx)...)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(&sub).Updates(map[string]interface{}{
+		"is_active":       false,
+		"payment_status":  paymentStatus(refund),
+		"end_date":        now,
+		"deactivated_at":  now,
+		"refunded_amount": refund,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to cancel subscription %d: %w", sub.ID, err)
+	}
+	return result, nil
+}
+
+// CancelTraderSubscription cancels a trader signal subscription inside tx,
+// taking the refund back from the trader's revenue and the platform's
+// commission. customerID works as for CancelSubscription.
+func CancelTraderSubscription(tx *gorm.DB, subscriptionID, customerID uint, now time.Time) (*models.SubscriptionCancellation, error) {
+	var sub models.CustomerTraderSignalSubscription
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sub, subscriptionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to load trader subscription %d: %w", subscriptionID, err)
+	}
+	if customerID != 0 && sub.CustomerID != customerID {
+		return nil, ErrNotFound
+	}
+	if !sub.IsActive {
+		return nil, ErrNotActive
+	}
+
+	var plan models.TraderSignalSubscriptionPlan
+	if err := tx.Unscoped().First(&plan, sub.TraderSubscriptionPlanID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load trader plan %d: %w", sub.TraderSubscriptionPlanID, err)
+	}
+
+	// Subscriptions bought before the amounts were recorded on them were
+	// paid at the plan's price and commission.
+	paid, platformShare := sub.AmountPaid, sub.AdminCommission
+	if paid.IsZero() {
+		paid = plan.Price
+		platformShare, _ = plan.Price.Split(plan.AdminCommission, plan.Currency)
+	}
+
+	refund := Amount(plan.RefundPolicy.OrNone(), plan.RefundWindowDays, paid, plan.Currency, sub.StartDate, sub.EndDate, now)
+	fromPlatform, fromTrader := Split(refund, paid, platformShare, plan.Currency)
+	credit := fx.Recorded(refund, plan.Currency, sub.PaidCurrency, sub.PaidFXRate)
+	result := &models.SubscriptionCancellation{
+		SubscriptionID:   sub.ID,
+		CustomerID:       sub.CustomerID,
+		Policy:           plan.RefundPolicy.OrNone(),
+		Currency:         plan.Currency,
+		AmountPaid:       paid,
+		Refunded:         refund,
+		FromTrader:       fromTrader,
+		FromPlatform:     fromPlatform,
+		Credited:         credit.Amount,
+		CreditedCurrency: credit.Currency,
+		CancelledAt:      now,
+	}
+
+	reference := fmt.Sprintf("REFUND_TRADER_SUB_%d", sub.ID)
+	customerTx := &models.WalletTransaction{
+		Type:                 models.TxTypeReversal,
+		TransactionType:      models.TxTypeCredit,
+		Name:                 "Trader Subscription Refund",
+		Description:          fmt.Sprintf("Refund for cancelled subscription to trader %d's plan '%s'", plan.TraderID, plan.Name),
+		ReferenceID:          reference,
+		TransactionID:        reference,
+		TraderSubscriptionID: &sub.ID,
+	}
+	traderTx := &models.WalletTransaction{
+		Type:                 models.TxTypeReversal,
+		TransactionType:      models.TxTypeDebit,
+		Name:                 "Trader Subscription Refund",
+		Currency:             plan.Currency,
+		Description:          fmt.Sprintf("Refund to customer %d for cancelled subscription to plan '%s'", sub.CustomerID, plan.Name),
+		ReferenceID:          reference,
+		TransactionID:        reference,
+		TraderSubscriptionID: &sub.ID,
+	}
+	platformTx := &models.WalletTransaction{
+		Type:                 models.TxTypeReversal,
+		TransactionType:      models.TxTypeDebit,
+		Name:                 "Trader Subscription Commission Refund",
+		Currency:             plan.Currency,
+		Description:          fmt.Sprintf("Commission refunded to customer %d for cancelled trader subscription %d", sub.CustomerID, sub.ID),
+		ReferenceID:          reference,
+		TransactionID:        reference,
+		TraderSubscriptionID: &sub.ID,
+	}
+	if err := post(tx, reference, plan.Currency, append([]ledger.Leg{
+		ledger.Debit(ledger.WalletIn(sub.TraderID, plan.Currency), fromTrader, traderTx),
+		ledger.Debit(ledger.PlatformCommissionIn(plan.Currency), fromPlatform, platformTx),
+	}, credit.RefundLegs(sub.CustomerID, customerTx)...)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(&sub).Updates(map[string]interface{}{
+		"is_active":       false,
+		"payment_status":  paymentStatus(refund),
+		"end_date":        now,
+		"cancelled_at":    now,
+		"refunded_amount": refund,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to cancel trader subscription %d: %w", sub.ID, err)
+	}
+	return result, nil
+}
+
+func post(tx *gorm.DB, reference, currency string, legs []ledger.Leg) error {
+	_, err := ledger.Post(tx, ledger.Entry{
+		Type:        models.TxTypeReversal,
+		Reference:   reference,
+		Description: "Subscription cancellation refund",
+		Currency:    currency,
+		Legs:        legs,
+	})
+	if errors.Is(err, ledger.ErrInsufficientFunds) {
+		return ErrUnfunded
+	}
+	if err != nil {
+		return fmt.Errorf("failed to post refund: %w", err)
+	}
+	return nil
+}
+
+func paymentStatus(refund money.Amount) string {
+	if refund.IsPositive() {
+		return "refunded"
+	}
+	return "cancelled"
+}
@@ -0,0 +1,61 @@
+// Package secretbox encrypts small secrets that the server must be able to
+// read back, such as TOTP seeds and API key signing secrets, before they are
+// stored. It uses AES-256-GCM with a key derived from a configured passphrase.
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+type Box struct {
+	key [32]byte
+}
+
+// New returns a box keyed by the SHA-256 of passphrase.
+func New(passphrase string) *Box {
+	return &Box{key: sha256.Sum256([]byte(passphrase))}
+}
+
+// Seal encrypts plaintext and returns it base64-encoded with its nonce.
+func (b *Box) Seal(plaintext string) (string, error) {
+	aead, err := b.aead()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
+
+// Open decrypts what Seal returned.
+func (b *Box) Open(sealed string) (string, error) {
+	aead, err := b.aead()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", errors.New("stored secret is corrupt")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt stored secret; was the encryption key changed?")
+	}
+	return string(plaintext), nil
+}
+
+func (b *Box) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(b.key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
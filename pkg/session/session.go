@@ -0,0 +1,289 @@
+// Package session issues and revokes login sessions. A session is a
+// short-lived JWT access token plus a refresh token that is stored hashed and
+// rotated on every use. Revoked sessions go on a revocation list that the
+// auth middlewares check, so logging out, changing a password or being
+// blocked takes effect on the next request rather than when tokens expire.
+package session
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/config"
+	"github.com/fathimasithara01/tradeverse/pkg/auth"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenExpired = errors.New("refresh token has expired")
+	// ErrRefreshTokenReused means a refresh token was presented after it had
+	// already been rotated, so it has probably leaked. The whole session is
+	// revoked.
+	ErrRefreshTokenReused = errors.New("refresh token was already used; the session has been revoked")
+	ErrSessionRevoked     = errors.New("session has been revoked")
+	ErrAccountBlocked     = errors.New("account is blocked")
+)
+
+type Config struct {
+	Secret     string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// ConfigFromAppConfig builds the session settings from the jwt section of the
+// application config.
+func ConfigFromAppConfig(cfg *config.Config) Config {
+	return Config{
+		Secret:     cfg.JWT.Secret,
+		AccessTTL:  time.Duration(cfg.JWT.AccessTokenMinutes) * time.Minute,
+		RefreshTTL: time.Duration(cfg.JWT.ExpireHours) * time.Hour,
+	}
+}
+
+// Meta describes the client a session was issued to.
+type Meta struct {
+	UserAgent string
+	IPAddress string
+}
+
+// MetaFrom reads the client details from a request.
+func MetaFrom(c *gin.Context) Meta {
+	return Meta{UserAgent: c.Request.UserAgent(), IPAddress: c.ClientIP()}
+}
+
+// Tokens is what a login or a refresh hands back to the client.
+type Tokens struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresIn        int       `json:"expires_in"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	SessionID        string    `json:"session_id"`
+}
+
+type Service struct {
+	db  *gorm.DB
+	cfg Config
+}
+
+func NewService(db *gorm.DB, cfg Config) *Service {
+	return &Service{db: db, cfg: cfg}
+}
+
+// AccessTTL is how long an access token stays valid.
+func (s *Service) AccessTTL() time.Duration { return s.cfg.AccessTTL }
+
+// RefreshTTL is how long a refresh token stays valid.
+func (s *Service) RefreshTTL() time.Duration { return s.cfg.RefreshTTL }
+
+// Issue starts a new session for a user who has just authenticated.
+func (s *Service) Issue(user *models.User, meta Meta) (*Tokens, error) {
+	sessionID, err := auth.RandomToken(24)
+	if err != nil {
+		return nil, err
+	}
+	var tokens *Tokens
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		tokens, err = s.issue(tx, user, sessionID, meta)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh
+// token in the same session. The presented token cannot be used again.
+func (s *Service) Refresh(refreshToken string, meta Meta) (*Tokens, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+	var tokens *Tokens
+	var revoke *models.RefreshToken
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var current models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", auth.HashToken(refreshToken)).
+			First(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return fmt.Errorf("failed to load refresh token: %w", err)
+		}
+
+		now := time.Now()
+		switch {
+		case current.RevokedAt != nil:
+			return ErrSessionRevoked
+		case current.UsedAt != nil:
+			revoke = &current
+			return ErrRefreshTokenReused
+		case !now.Before(current.ExpiresAt):
+			return ErrRefreshTokenExpired
+		}
+
+		var user models.User
+		if err := tx.First(&user, current.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return fmt.Errorf("failed to load user %d: %w", current.UserID, err)
+		}
+		if user.IsBlocked {
+			revoke = &current
+			return ErrAccountBlocked
+		}
+
+		if err := tx.Model(&current).Update("used_at", now).Error; err != nil {
+			return fmt.Errorf("failed to rotate refresh token: %w", err)
+		}
+		var err error
+		tokens, err = s.issue(tx, &user, current.SessionID, meta)
+		return err
+	})
+	if revoke != nil {
+		reason := models.RevokeReasonTokenReuse
+		if errors.Is(err, ErrAccountBlocked) {
+			reason = models.RevokeReasonBlocked
+		}
+		if revokeErr := s.revokeSessions(revoke.UserID, []string{revoke.SessionID}, reason); revokeErr != nil {
+			return nil, errors.Join(err, revokeErr)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Authenticate validates an access token and checks that its session has not
+// been revoked.
+func (s *Service) Authenticate(accessToken string) (*auth.AuthClaims, error) {
+	claims, err := auth.ValidateJWT(accessToken, s.cfg.Secret)
+	if err != nil {
+		return nil, err
+	}
+	if claims.SessionID == "" {
+		return nil, ErrSessionRevoked
+	}
+	var revoked int64
+	if err := s.db.Model(&models.RevokedSession{}).
+		Where("session_id = ?", claims.SessionID).
+		Limit(1).Count(&revoked).Error; err != nil {
+		return nil, fmt.Errorf("failed to check session revocation: %w", err)
+	}
+	if revoked > 0 {
+		return nil, ErrSessionRevoked
+	}
+	return claims, nil
+}
+
+// Logout revokes one session of the user.
+func (s *Service) Logout(userID uint, sessionID string) error {
+	return s.revokeSessions(userID, []string{sessionID}, models.RevokeReasonLogout)
+}
+
+// RevokeUser revokes every session of the user, e.g. after a password change
+// or when the account is blocked.
+func (s *Service) RevokeUser(userID uint, reason string) error {
+	var sessionIDs []string
+	if err := s.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now().Add(-s.cfg.AccessTTL)).
+		Distinct().Pluck("session_id", &sessionIDs).Error; err != nil {
+		return fmt.Errorf("failed to list sessions of user %d: %w", userID, err)
+	}
+	return s.revokeSessions(userID, sessionIDs, reason)
+}
+
+// PruneExpired drops revocations and refresh tokens that can no longer match
+// a valid token.
+func (s *Service) PruneExpired(now time.Time) (int64, error) {
+	revocations := s.db.Where("expires_at < ?", now).Delete(&models.RevokedSession{})
+	if revocations.Error != nil {
+		return 0, fmt.Errorf("failed to prune revoked sessions: %w", revocations.Error)
+	}
+	tokens := s.db.Where("expires_at < ?", now.Add(-s.cfg.AccessTTL)).Delete(&models.RefreshToken{})
+	if tokens.Error != nil {
+		return 0, fmt.Errorf("failed to prune refresh tokens: %w", tokens.Error)
+	}
+	return revocations.RowsAffected + tokens.RowsAffected, nil
+}
+
+func (s *Service) issue(tx *gorm.DB, user *models.User, sessionID string, meta Meta) (*Tokens, error) {
+	var roleID uint
+	if user.RoleID != nil {
+		roleID = *user.RoleID
+	}
+	accessToken, err := auth.GenerateJWT(user.ID, user.Email, string(user.Role), roleID, sessionID, s.cfg.Secret, s.cfg.AccessTTL)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := auth.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	row := &models.RefreshToken{
+		UserID:    user.ID,
+		SessionID: sessionID,
+		TokenHash: auth.HashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.cfg.RefreshTTL),
+		UserAgent: truncate(meta.UserAgent, 255),
+		IPAddress: truncate(meta.IPAddress, 64),
+	}
+	if err := tx.Create(row).Error; err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return &Tokens{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(s.cfg.AccessTTL / time.Second),
+		RefreshExpiresAt: row.ExpiresAt,
+		SessionID:        sessionID,
+	}, nil
+}
+
+// revokeSessions puts sessions on the revocation list and retires their
+// refresh tokens. Access tokens issued for them expire within AccessTTL, so
+// the list entries are kept that long.
+func (s *Service) revokeSessions(userID uint, sessionIDs []string, reason string) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		revocations := make([]models.RevokedSession, 0, len(sessionIDs))
+		for _, id := range sessionIDs {
+			revocations = append(revocations, models.RevokedSession{
+				SessionID: id,
+				UserID:    userID,
+				Reason:    reason,
+				ExpiresAt: now.Add(s.cfg.AccessTTL),
+			})
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&revocations).Error; err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("session_id IN ? AND revoked_at IS NULL", sessionIDs).
+			Update("revoked_at", now).Error; err != nil {
+			return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+		}
+		return nil
+	})
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
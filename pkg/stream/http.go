@@ -0,0 +1,109 @@
+package stream
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+const heartbeatInterval = 25 * time.Second
+
+// ServeSSE streams hub events to the client as server-sent events until the
+// client disconnects.
+func ServeSSE(c *gin.Context, hub *Hub, filter Filter) {
+	sub := hub.Subscribe(filter)
+	defer sub.Close()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case e, ok := <-sub.C:
+			if !ok {
+				return false
+			}
+			c.SSEvent(string(e.Type), e)
+			return true
+		case t := <-heartbeat.C:
+			c.SSEvent(string(EventHeartbeat), Event{Type: EventHeartbeat, Time: t})
+			return true
+		}
+	})
+}
+
+// ServeWebSocket upgrades the request and writes hub events as JSON text
+// frames. Incoming frames are read only to notice when the client goes away.
+func ServeWebSocket(c *gin.Context, hub *Hub, filter Filter) {
+	server := websocket.Server{
+		Handshake: checkSameOrigin,
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			sub := hub.Subscribe(filter)
+			defer sub.Close()
+
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				var discard string
+				for {
+					if err := websocket.Message.Receive(ws, &discard); err != nil {
+						return
+					}
+				}
+			}()
+
+			heartbeat := time.NewTicker(heartbeatInterval)
+			defer heartbeat.Stop()
+
+			for {
+				var e Event
+				select {
+				case <-closed:
+					return
+				case ev, ok := <-sub.C:
+					if !ok {
+						return
+					}
+					e = ev
+				case t := <-heartbeat.C:
+					e = Event{Type: EventHeartbeat, Time: t}
+				}
+				if err := websocket.JSON.Send(ws, e); err != nil {
+					return
+				}
+			}
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// checkSameOrigin rejects cross-site upgrades, which matters for the admin
+// stream since it is authenticated by cookie. Non-browser clients send no
+// Origin and are allowed.
+func checkSameOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+	if u.Host != r.Host {
+		return fmt.Errorf("websocket origin %q not allowed", origin)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+)
+
+type EventType string
+
+const (
+	EventMarketTick    EventType = "market.tick"
+	EventSignalCreated EventType = "signal.created"
+	EventSignalStatus  EventType = "signal.status"
+	EventHeartbeat     EventType = "heartbeat"
+)
+
+// Event is what subscribers receive. TraderID is set on signal events so
+// handlers can limit them to the trader's own subscribers.
+type Event struct {
+	Type     EventType   `json:"type"`
+	TraderID uint        `json:"trader_id,omitempty"`
+	Data     interface{} `json:"data"`
+	Time     time.Time   `json:"time"`
+}
+
+type MarketTick struct {
+	Symbol    string  `json:"symbol"`
+	Price     float64 `json:"price"`
+	Change24H float64 `json:"change_24h"`
+	Volume24H float64 `json:"volume_24h"`
+}
+
+type SignalStatusChange struct {
+	SignalID     uint                `json:"signal_id"`
+	Symbol       string              `json:"symbol"`
+	From         models.SignalStatus `json:"from"`
+	To           models.SignalStatus `json:"to"`
+	CurrentPrice float64             `json:"current_price"`
+	RealizedR    float64             `json:"realized_r"`
+}
+
+// Filter decides whether a subscriber receives an event. A nil Filter accepts
+// everything.
+type Filter func(Event) bool
+
+const subscriberBuffer = 64
+
+type Subscription struct {
+	C <-chan Event
+
+	ch     chan Event
+	filter Filter
+	hub    *Hub
+	once   sync.Once
+}
+
+// Close detaches the subscription from the hub and closes C.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		delete(s.hub.subs, s)
+		s.hub.mu.Unlock()
+		close(s.ch)
+	})
+}
+
+// Hub is an in-process pub/sub fan-out. Publish never blocks: a subscriber
+// whose buffer is full misses the event rather than stalling the crons.
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*Subscription]struct{})}
+}
+
+func (h *Hub) Subscribe(filter Filter) *Subscription {
+	ch := make(chan Event, subscriberBuffer)
+	sub := &Subscription{C: ch, ch: ch, filter: filter, hub: h}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// Publish is safe to call on a nil Hub, so services can be built without one.
+func (h *Hub) Publish(e Event) {
+	if h == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subs {
+		if sub.filter != nil && !sub.filter(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+		}
+	}
+}
+
+func (h *Hub) SubscriberCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs)
+}
+
+func (h *Hub) PublishTick(tick MarketTick) {
+	h.Publish(Event{Type: EventMarketTick, Data: tick})
+}
+
+func (h *Hub) PublishSignalCreated(traderID uint, signal interface{}) {
+	h.Publish(Event{Type: EventSignalCreated, TraderID: traderID, Data: signal})
+}
+
+func (h *Hub) PublishSignalStatus(traderID uint, change SignalStatusChange) {
+	h.Publish(Event{Type: EventSignalStatus, TraderID: traderID, Data: change})
+}
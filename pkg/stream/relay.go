@@ -0,0 +1,173 @@
+package stream
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"gorm.io/gorm"
+)
+
+// relayLookback re-reads rows written slightly before the last seen timestamp,
+// since the other services stamp updated_at with their own clocks.
+const relayLookback = 30 * time.Second
+
+// Relay forwards market data and signal changes written by other services into
+// the local hub. Each service runs its own hub, so without it a customer would
+// never see ticks fetched by the admin cron or signals posted by a trader.
+// Events the local process already published are not repeated.
+type Relay struct {
+	db  *gorm.DB
+	hub *Hub
+
+	mu         sync.Mutex
+	prices     map[string]float64
+	statuses   map[uint]models.SignalStatus
+	lastTick   time.Time
+	lastSignal time.Time
+}
+
+func NewRelay(db *gorm.DB, hub *Hub) *Relay {
+	return &Relay{
+		db:       db,
+		hub:      hub,
+		prices:   make(map[string]float64),
+		statuses: make(map[uint]models.SignalStatus),
+	}
+}
+
+// Start seeds the relay with the current state, so nothing is replayed, and
+// polls every interval until ctx is cancelled.
+func (r *Relay) Start(ctx context.Context, interval time.Duration) error {
+	if err := r.seed(ctx); err != nil {
+		return err
+	}
+
+	sub := r.hub.Subscribe(nil)
+	go func() {
+		for e := range sub.C {
+			r.remember(e)
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		defer sub.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.Poll(ctx); err != nil {
+					log.Printf("Stream relay poll failed: %v", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (r *Relay) seed(ctx context.Context) error {
+	var markets []models.MarketData
+	if err := r.db.WithContext(ctx).Find(&markets).Error; err != nil {
+		return err
+	}
+	var signals []models.Signal
+	if err := r.db.WithContext(ctx).Select("id", "status", "updated_at").Find(&signals).Error; err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, md := range markets {
+		r.prices[md.Symbol] = md.CurrentPrice
+		if md.UpdatedAt.After(r.lastTick) {
+			r.lastTick = md.UpdatedAt
+		}
+	}
+	for _, s := range signals {
+		r.statuses[s.ID] = s.Status
+		if s.UpdatedAt.After(r.lastSignal) {
+			r.lastSignal = s.UpdatedAt
+		}
+	}
+	return nil
+}
+
+// remember records events published in this process so Poll skips them.
+func (r *Relay) remember(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch data := e.Data.(type) {
+	case MarketTick:
+		r.prices[data.Symbol] = data.Price
+	case SignalStatusChange:
+		r.statuses[data.SignalID] = data.To
+	case models.Signal:
+		r.statuses[data.ID] = data.Status
+	case *models.Signal:
+		r.statuses[data.ID] = data.Status
+	}
+}
+
+func (r *Relay) Poll(ctx context.Context) error {
+	r.mu.Lock()
+	tickSince, signalSince := r.lastTick.Add(-relayLookback), r.lastSignal.Add(-relayLookback)
+	r.mu.Unlock()
+
+	var markets []models.MarketData
+	if err := r.db.WithContext(ctx).Where("updated_at > ?", tickSince).Order("updated_at").Find(&markets).Error; err != nil {
+		return err
+	}
+	var signals []models.Signal
+	if err := r.db.WithContext(ctx).Where("updated_at > ?", signalSince).Order("updated_at").Find(&signals).Error; err != nil {
+		return err
+	}
+
+	var events []Event
+	r.mu.Lock()
+	for _, md := range markets {
+		if md.UpdatedAt.After(r.lastTick) {
+			r.lastTick = md.UpdatedAt
+		}
+		if price, ok := r.prices[md.Symbol]; ok && price == md.CurrentPrice {
+			continue
+		}
+		r.prices[md.Symbol] = md.CurrentPrice
+		events = append(events, Event{Type: EventMarketTick, Data: MarketTick{
+			Symbol:    md.Symbol,
+			Price:     md.CurrentPrice,
+			Change24H: md.PriceChange24H,
+			Volume24H: md.Volume24H,
+		}})
+	}
+	for _, s := range signals {
+		if s.UpdatedAt.After(r.lastSignal) {
+			r.lastSignal = s.UpdatedAt
+		}
+		prev, known := r.statuses[s.ID]
+		r.statuses[s.ID] = s.Status
+		switch {
+		case !known:
+			events = append(events, Event{Type: EventSignalCreated, TraderID: s.TraderID, Data: s})
+		case prev != s.Status:
+			events = append(events, Event{Type: EventSignalStatus, TraderID: s.TraderID, Data: SignalStatusChange{
+				SignalID:     s.ID,
+				Symbol:       s.Symbol,
+				From:         prev,
+				To:           s.Status,
+				CurrentPrice: s.CurrentPrice,
+			}})
+		}
+	}
+	r.mu.Unlock()
+
+	for _, e := range events {
+		r.hub.Publish(e)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package transfer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/config"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+)
+
+// Policy holds the limits every transfer is checked against. Amounts are in
+// Currency; a zero limit is not enforced. The holds are the withdrawal
+// cooling-off periods: deposits cannot be moved on until theirs ends, and no
+// transfer is sent until the one after a password change ends.
+type Policy struct {
+	Currency     string
+	MaxAmount    money.Amount
+	DailyLimit   money.Amount
+	DepositHold  time.Duration
+	PasswordHold time.Duration
+}
+
+func PolicyFromConfig(cfg *config.Config) Policy {
+	t := cfg.Transfers
+	return Policy{
+		Currency:     strings.ToUpper(t.LimitCurrency),
+		MaxAmount:    money.FromFloat(t.MaxAmount),
+		DailyLimit:   money.FromFloat(t.DailyLimit),
+		DepositHold:  time.Duration(cfg.Withdrawals.DepositHoldHours) * time.Hour,
+		PasswordHold: time.Duration(cfg.Withdrawals.PasswordChangeHoldHours) * time.Hour,
+	}
+}
+
+// CheckLimit reports whether amount may be sent given what the sender has
+// already sent today.
+func (p Policy) CheckLimit(amount, sentToday money.Amount) error {
+	if p.MaxAmount.IsPositive() && amount > p.MaxAmount {
+		return fmt.Errorf("%w: at most %s %s per transfer", ErrLimitExceeded,
+			p.MaxAmount.Format(p.Currency), p.Currency)
+	}
+	if p.DailyLimit.IsPositive() && sentToday.Add(amount) > p.DailyLimit {
+		return fmt.Errorf("%w: at most %s %s per day, %s already sent", ErrLimitExceeded,
+			p.DailyLimit.Format(p.Currency), p.Currency, sentToday.Format(p.Currency))
+	}
+	return nil
+}
+
+// startOfDay returns the start of the UTC day that now falls in.
+func startOfDay(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,274 @@
+// Package transfer moves money between two users' wallets inside the
+// platform. A transfer needs the sender's KYC to be approved, stays within
+// the sender's limits, leaves funds in the withdrawal cooling-off period
+// alone and is posted as one journal entry with a debit wallet transaction
+// for the sender and a credit for the recipient.
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/fx"
+	"github.com/fathimasithara01/tradeverse/pkg/ledger"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidAmount        = errors.New("transfer amount must be positive")
+	ErrRecipientRequired    = errors.New("a recipient ID or email is required")
+	ErrRecipientNotFound    = errors.New("recipient not found")
+	ErrRecipientUnavailable = errors.New("recipient cannot receive transfers")
+	ErrSelfTransfer         = errors.New("cannot transfer to yourself")
+	ErrKYCRequired          = errors.New("transfers require approved KYC")
+	ErrLimitExceeded        = errors.New("transfer limit exceeded")
+	ErrInsufficientFunds    = errors.New("insufficient funds for transfer")
+	ErrWalletNotFound       = errors.New("wallet not found for transfer")
+	ErrFundsOnHold          = errors.New("funds are still in the cooling-off period")
+)
+
+type Service struct {
+	db     *gorm.DB
+	policy Policy
+}
+
+func NewService(db *gorm.DB, policy Policy) *Service {
+	return &Service{db: db, policy: policy}
+}
+
+// Send moves input.Amount in input.Currency from senderID's wallet to the
+// recipient's wallet in that currency, opening it if needed.
+func (s *Service) Send(ctx context.Context, senderID uint, input models.TransferInput) (*models.WalletTransfer, error) {
+	if !input.Amount.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+	currency := strings.ToUpper(input.Currency)
+
+	var transfer *models.WalletTransfer
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking the sender serialises their transfers, so the daily limit
+		// sees every one of them.
+		var sender models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sender, senderID).Error; err != nil {
+			return fmt.Errorf("failed to load sender %d: %w", senderID, err)
+		}
+		recipient, err := findRecipient(tx, input)
+		if err != nil {
+			return err
+		}
+		if recipient.ID == sender.ID {
+			return ErrSelfTransfer
+		}
+		// The platform wallet is not a user wallet, so it can neither send
+		// nor receive transfers.
+		platformID, err := ledger.PlatformOwnerID(tx)
+		if err != nil {
+			return err
+		}
+		if recipient.IsBlocked || recipient.ID == platformID {
+			return ErrRecipientUnavailable
+		}
+		if sender.ID == platformID {
+			return ErrKYCRequired
+		}
+		if err := requireKYC(tx, sender.ID); err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := s.checkHolds(tx, &sender, currency, input.Amount, now); err != nil {
+			return err
+		}
+
+		quote, err := fx.Lookup(tx, currency, s.policy.Currency)
+		if err != nil {
+			return err
+		}
+		limitAmount := input.Amount.Convert(quote.Rate, s.policy.Currency, money.RoundHalfUp)
+		sentToday, err := sentSince(tx, sender.ID, startOfDay(now))
+		if err != nil {
+			return err
+		}
+		if err := s.policy.CheckLimit(limitAmount, sentToday); err != nil {
+			return err
+		}
+
+		transfer = &models.WalletTransfer{
+			SenderID:    sender.ID,
+			RecipientID: recipient.ID,
+			Amount:      input.Amount,
+			Currency:    currency,
+			LimitAmount: limitAmount,
+			Note:        input.Note,
+			Reference:   fmt.Sprintf("TRANSFER_%d_%d", sender.ID, now.UnixNano()),
+			CreatedAt:   now,
+		}
+		if err := tx.Create(transfer).Error; err != nil {
+			return fmt.Errorf("failed to create transfer: %w", err)
+		}
+
+		debitTx := &models.WalletTransaction{
+			Type:            models.TxTypeTransfer,
+			TransactionType: models.TxTypeDebit,
+			Name:            "Transfer Sent",
+			Description:     fmt.Sprintf("Transfer to %s", recipient.Name),
+			Notes:           input.Note,
+			ReferenceID:     transfer.Reference,
+			TransactionID:   transfer.Reference,
+		}
+		creditTx := &models.WalletTransaction{
+			Type:            models.TxTypeTransfer,
+			TransactionType: models.TxTypeCredit,
+			Name:            "Transfer Received",
+			Description:     fmt.Sprintf("Transfer from %s", sender.Name),
+			Notes:           input.Note,
+			ReferenceID:     transfer.Reference,
+			TransactionID:   transfer.Reference,
+		}
+		if _, err := ledger.Post(tx, ledger.Entry{
+			Type:        models.TxTypeTransfer,
+			Reference:   transfer.Reference,
+			Description: fmt.Sprintf("Transfer %d from user %d to user %d", transfer.ID, sender.ID, recipient.ID),
+			Currency:    currency,
+			Legs: []ledger.Leg{
+				ledger.Debit(ledger.WalletIn(sender.ID, currency), input.Amount, debitTx),
+				ledger.Credit(ledger.WalletIn(recipient.ID, currency), input.Amount, creditTx),
+			},
+		}); err != nil {
+			if errors.Is(err, ledger.ErrInsufficientFunds) {
+				return ErrInsufficientFunds
+			}
+			if errors.Is(err, ledger.ErrWalletNotFound) {
+				return ErrWalletNotFound
+			}
+			return fmt.Errorf("failed to post transfer: %w", err)
+		}
+
+		transfer.DebitTransactionID = &debitTx.ID
+		transfer.CreditTransactionID = &creditTx.ID
+		if err := tx.Save(transfer).Error; err != nil {
+			return fmt.Errorf("failed to link transfer %d to its wallet transactions: %w", transfer.ID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// List returns the transfers userID sent or received, newest first.
+func (s *Service) List(ctx context.Context, userID uint, pagination models.PaginationParams) ([]models.WalletTransfer, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.WalletTransfer{}).
+		Where("sender_id = ? OR recipient_id = ?", userID, userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count transfers: %w", err)
+	}
+
+	page, limit := pagination.Page, pagination.Limit
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	var transfers []models.WalletTransfer
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&transfers).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list transfers: %w", err)
+	}
+	return transfers, total, nil
+}
+
+func findRecipient(tx *gorm.DB, input models.TransferInput) (*models.User, error) {
+	query := tx.Model(&models.User{})
+	switch {
+	case input.RecipientID != 0:
+		query = query.Where("id = ?", input.RecipientID)
+	case input.RecipientEmail != "":
+		query = query.Where("LOWER(email) = LOWER(?)", input.RecipientEmail)
+	default:
+		return nil, ErrRecipientRequired
+	}
+
+	var recipient models.User
+	err := query.First(&recipient).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecipientNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recipient: %w", err)
+	}
+	return &recipient, nil
+}
+
+func requireKYC(tx *gorm.DB, userID uint) error {
+	var statuses []string
+	if err := tx.Model(&models.UserKYCStatus{}).Where("user_id = ?", userID).Limit(1).Pluck("status", &statuses).Error; err != nil {
+		return fmt.Errorf("failed to load KYC status for user %d: %w", userID, err)
+	}
+	if len(statuses) == 0 || statuses[0] != models.KYCStatusApproved {
+		return ErrKYCRequired
+	}
+	return nil
+}
+
+// checkHolds applies the withdrawal cooling-off to a transfer: none may be
+// sent while the hold after a password change runs, and deposits still in
+// their hold cannot be part of the amount.
+func (s *Service) checkHolds(tx *gorm.DB, sender *models.User, currency string, amount money.Amount, now time.Time) error {
+	if sender.PasswordChangedAt != nil && s.policy.PasswordHold > 0 {
+		if until := sender.PasswordChangedAt.Add(s.policy.PasswordHold); until.After(now) {
+			return fmt.Errorf("%w: transfers resume at %s after the password change", ErrFundsOnHold, until.UTC().Format(time.RFC3339))
+		}
+	}
+	if s.policy.DepositHold <= 0 {
+		return nil
+	}
+
+	var held money.Amount
+	if err := tx.Model(&models.WalletTransaction{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("user_id = ? AND currency = ? AND type = ? AND created_at > ?", sender.ID, currency, models.TxTypeDeposit, now.Add(-s.policy.DepositHold)).
+		Row().Scan(&held); err != nil {
+		return fmt.Errorf("failed to sum recent deposits for user %d: %w", sender.ID, err)
+	}
+	if held.IsZero() {
+		return nil
+	}
+	var balances []money.Amount
+	if err := tx.Model(&models.Wallet{}).Where("user_id = ? AND currency = ?", sender.ID, currency).Limit(1).Pluck("balance", &balances).Error; err != nil {
+		return fmt.Errorf("failed to get %s wallet of user %d: %w", currency, sender.ID, err)
+	}
+	if len(balances) == 0 {
+		return ErrWalletNotFound
+	}
+	if available := balances[0].Sub(held); amount > available {
+		if !available.IsPositive() {
+			available = 0
+		}
+		return fmt.Errorf("%w: %s %s of recent deposits is on hold, %s %s can be sent now", ErrFundsOnHold,
+			held.Format(currency), currency, available.Format(currency), currency)
+	}
+	return nil
+}
+
+// sentSince sums the limit amounts of the transfers userID has sent since the
+// given time.
+func sentSince(tx *gorm.DB, userID uint, since time.Time) (money.Amount, error) {
+	var sent money.Amount
+	err := tx.Model(&models.WalletTransfer{}).
+		Select("COALESCE(SUM(limit_amount), 0)").
+		Where("sender_id = ? AND created_at >= ?", userID, since).
+		Row().Scan(&sent)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum transfers for user %d: %w", userID, err)
+	}
+	return sent, nil
+}
@@ -0,0 +1,72 @@
+package twofactor
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderCode carries the code for a step-up check.
+const HeaderCode = "X-2FA-Code"
+
+// RequiredFunc reports whether a user must have 2FA enabled.
+type RequiredFunc func(userID uint) (bool, error)
+
+// RequireCode returns a middleware that re-checks 2FA before a sensitive
+// action: users with 2FA enabled must send a current TOTP code or a recovery
+// code in the X-2FA-Code header. Users without it pass, unless required
+// reports that they must have it, in which case they are refused until they
+// enrol. required may be nil.
+//
+// It must run after the auth middleware that sets userID, and before the
+// idempotency middleware so that a rejected code is not stored as the
+// response for the key. A code is accepted only once, so retries need a new
+// one.
+func (s *Service) RequireCode(required RequiredFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("userID")
+		if userID == 0 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		enabled, err := s.Enabled(userID)
+		if err != nil {
+			log.Printf("[2FA] failed to check two-factor settings of user %d: %v", userID, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check two-factor authentication"})
+			return
+		}
+		if !enabled {
+			if required != nil {
+				mustEnrol, err := required(userID)
+				if err != nil {
+					log.Printf("[2FA] failed to check whether user %d needs two-factor authentication: %v", userID, err)
+					c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check two-factor authentication"})
+					return
+				}
+				if mustEnrol {
+					c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication must be enabled for this action"})
+					return
+				}
+			}
+			c.Next()
+			return
+		}
+
+		if err := s.Verify(userID, c.GetHeader(HeaderCode)); err != nil {
+			switch {
+			case errors.Is(err, ErrCodeRequired):
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "This action requires a two-factor code in the " + HeaderCode + " header", "two_factor_required": true})
+			case errors.Is(err, ErrInvalidCode), errors.Is(err, ErrNotEnrolled):
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code", "two_factor_required": true})
+			default:
+				log.Printf("[2FA] failed to verify step-up code of user %d: %v", userID, err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify two-factor code"})
+			}
+			return
+		}
+		c.Next()
+	}
+}
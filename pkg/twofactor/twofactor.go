@@ -0,0 +1,408 @@
+// Package twofactor implements TOTP two-factor authentication: enrolling an
+// authenticator app, single-use recovery codes, the challenge that forms the
+// second step of a login, and a middleware that asks for a fresh code before
+// sensitive actions.
+package twofactor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/config"
+	"github.com/fathimasithara01/tradeverse/pkg/auth"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/secretbox"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecoveryCodeCount is how many recovery codes are handed out at a time.
+const RecoveryCodeCount = 10
+
+// MaxChallengeAttempts is how many codes may be tried against one login
+// challenge before the password has to be entered again.
+const MaxChallengeAttempts = 5
+
+var (
+	ErrNotEnrolled        = errors.New("two-factor authentication is not enabled")
+	ErrAlreadyEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrNoPendingEnrolment = errors.New("no two-factor enrolment is in progress")
+	ErrInvalidCode        = errors.New("invalid two-factor code")
+	ErrCodeRequired       = errors.New("a two-factor code is required")
+	ErrChallengeInvalid   = errors.New("invalid or already used two-factor challenge")
+	ErrChallengeExpired   = errors.New("two-factor challenge has expired; log in again")
+	ErrTooManyAttempts    = errors.New("too many invalid codes; log in again")
+	// ErrEnrolmentRequired is returned when a user who must use 2FA tries to
+	// finish a login before setting up an authenticator.
+	ErrEnrolmentRequired = errors.New("two-factor authentication must be set up before logging in")
+)
+
+type Config struct {
+	Issuer        string
+	EncryptionKey string
+	ChallengeTTL  time.Duration
+}
+
+// ConfigFromAppConfig builds the settings from the two_factor section of the
+// application config.
+func ConfigFromAppConfig(cfg *config.Config) Config {
+	key := cfg.TwoFactor.EncryptionKey
+	if key == "" {
+		key = cfg.JWT.Secret
+	}
+	return Config{
+		Issuer:        cfg.TwoFactor.Issuer,
+		EncryptionKey: key,
+		ChallengeTTL:  time.Duration(cfg.TwoFactor.ChallengeMinutes) * time.Minute,
+	}
+}
+
+// Enrolment is what a user needs to add the account to an authenticator app.
+type Enrolment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// Challenge is handed back by the first step of a login that needs 2FA.
+type Challenge struct {
+	Token             string    `json:"challenge_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	EnrolmentRequired bool      `json:"enrolment_required"`
+}
+
+type Status struct {
+	Enabled           bool       `json:"enabled"`
+	Pending           bool       `json:"pending"`
+	Required          bool       `json:"required"`
+	ConfirmedAt       *time.Time `json:"confirmed_at,omitempty"`
+	RecoveryCodesLeft int64      `json:"recovery_codes_left"`
+}
+
+type Service struct {
+	db  *gorm.DB
+	cfg Config
+	box *secretbox.Box
+}
+
+func NewService(db *gorm.DB, cfg Config) *Service {
+	return &Service{db: db, cfg: cfg, box: secretbox.New(cfg.EncryptionKey)}
+}
+
+// Status reports whether the user has 2FA set up and how many recovery codes
+// they have left. Required is left for the caller to fill in.
+func (s *Service) Status(userID uint) (*Status, error) {
+	record, err := s.find(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{}
+	if record == nil {
+		return status, nil
+	}
+	status.Enabled = record.Enabled
+	status.Pending = !record.Enabled
+	status.ConfirmedAt = record.ConfirmedAt
+	if record.Enabled {
+		if err := s.db.Model(&models.TwoFactorRecoveryCode{}).
+			Where("user_id = ? AND used_at IS NULL", userID).
+			Count(&status.RecoveryCodesLeft).Error; err != nil {
+			return nil, fmt.Errorf("failed to count recovery codes: %w", err)
+		}
+	}
+	return status, nil
+}
+
+// Enabled reports whether the user has confirmed an authenticator.
+func (s *Service) Enabled(userID uint) (bool, error) {
+	record, err := s.find(s.db, userID)
+	if err != nil {
+		return false, err
+	}
+	return record != nil && record.Enabled, nil
+}
+
+// BeginEnrolment generates a new secret for the user. It does not protect
+// anything until ConfirmEnrolment is called with a code from it; starting
+// again replaces a secret that was never confirmed.
+func (s *Service) BeginEnrolment(userID uint, account string) (*Enrolment, error) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.box.Seal(secret)
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		record, err := s.find(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID)
+		if err != nil {
+			return err
+		}
+		if record == nil {
+			return tx.Create(&models.UserTwoFactor{UserID: userID, Secret: sealed}).Error
+		}
+		if record.Enabled {
+			return ErrAlreadyEnabled
+		}
+		return tx.Model(record).Updates(map[string]interface{}{"secret": sealed, "last_used_step": 0}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Enrolment{Secret: secret, ProvisioningURI: ProvisioningURI(s.cfg.Issuer, account, secret)}, nil
+}
+
+// ConfirmEnrolment enables 2FA once the user proves the authenticator works,
+// and returns a fresh set of recovery codes. They are shown only this once.
+func (s *Service) ConfirmEnrolment(userID uint, code string) ([]string, error) {
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = s.confirm(tx, userID, code)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify checks a TOTP code or an unused recovery code for a user with 2FA
+// enabled. A code is accepted only once.
+func (s *Service) Verify(userID uint, code string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return s.verify(tx, userID, code)
+	})
+}
+
+// Disable turns 2FA off after checking a current code.
+func (s *Service) Disable(userID uint, code string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.verify(tx, userID, code); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.TwoFactorRecoveryCode{}).Error; err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserTwoFactor{}).Error; err != nil {
+			return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+		}
+		return nil
+	})
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a
+// current code.
+func (s *Service) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.verify(tx, userID, code); err != nil {
+			return err
+		}
+		var err error
+		codes, err = s.replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// NewChallenge starts the second step of a login for a user whose password
+// has been checked.
+func (s *Service) NewChallenge(userID uint, enrolmentRequired bool) (*Challenge, error) {
+	token, err := auth.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	row := &models.TwoFactorChallenge{
+		UserID:            userID,
+		TokenHash:         auth.HashToken(token),
+		EnrolmentRequired: enrolmentRequired,
+		ExpiresAt:         time.Now().Add(s.cfg.ChallengeTTL),
+	}
+	if err := s.db.Create(row).Error; err != nil {
+		return nil, fmt.Errorf("failed to store two-factor challenge: %w", err)
+	}
+	return &Challenge{Token: token, ExpiresAt: row.ExpiresAt, EnrolmentRequired: enrolmentRequired}, nil
+}
+
+// PendingChallenge returns the challenge for token if it can still be
+// completed, e.g. to let its user enrol an authenticator during login.
+func (s *Service) PendingChallenge(token string) (*models.TwoFactorChallenge, error) {
+	var challenge models.TwoFactorChallenge
+	if err := s.db.Where("token_hash = ?", auth.HashToken(token)).First(&challenge).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChallengeInvalid
+		}
+		return nil, fmt.Errorf("failed to load two-factor challenge: %w", err)
+	}
+	switch {
+	case challenge.UsedAt != nil:
+		return nil, ErrChallengeInvalid
+	case !time.Now().Before(challenge.ExpiresAt):
+		return nil, ErrChallengeExpired
+	case challenge.Attempts >= MaxChallengeAttempts:
+		return nil, ErrTooManyAttempts
+	}
+	return &challenge, nil
+}
+
+// CompleteChallenge checks code against the challenge's user and marks the
+// challenge used. When the challenge was issued to a user still enrolling,
+// the code confirms their new authenticator and the recovery codes are
+// returned. Every attempt counts towards MaxChallengeAttempts.
+func (s *Service) CompleteChallenge(token, code string) (uint, []string, error) {
+	challenge, err := s.PendingChallenge(token)
+	if err != nil {
+		return 0, nil, err
+	}
+	// The attempt is counted outside the verification transaction so that a
+	// wrong code still uses one up.
+	counted := s.db.Model(&models.TwoFactorChallenge{}).
+		Where("id = ? AND used_at IS NULL AND attempts < ?", challenge.ID, MaxChallengeAttempts).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+	if counted.Error != nil {
+		return 0, nil, fmt.Errorf("failed to record two-factor attempt: %w", counted.Error)
+	}
+	if counted.RowsAffected == 0 {
+		return 0, nil, ErrTooManyAttempts
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		record, err := s.find(tx.Clauses(clause.Locking{Strength: "UPDATE"}), challenge.UserID)
+		if err != nil {
+			return err
+		}
+		switch {
+		case record != nil && record.Enabled:
+			err = s.verify(tx, challenge.UserID, code)
+		case record != nil:
+			codes, err = s.confirm(tx, challenge.UserID, code)
+		default:
+			err = ErrEnrolmentRequired
+		}
+		if err != nil {
+			return err
+		}
+		used := tx.Model(&models.TwoFactorChallenge{}).
+			Where("id = ? AND used_at IS NULL", challenge.ID).
+			Update("used_at", time.Now())
+		if used.Error != nil {
+			return fmt.Errorf("failed to complete two-factor challenge: %w", used.Error)
+		}
+		if used.RowsAffected == 0 {
+			return ErrChallengeInvalid
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return challenge.UserID, codes, nil
+}
+
+// PruneExpired deletes challenges that can no longer be completed.
+func (s *Service) PruneExpired(now time.Time) (int64, error) {
+	result := s.db.Where("expires_at < ?", now).Delete(&models.TwoFactorChallenge{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune two-factor challenges: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (s *Service) find(tx *gorm.DB, userID uint) (*models.UserTwoFactor, error) {
+	var record models.UserTwoFactor
+	if err := tx.Where("user_id = ?", userID).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load two-factor settings of user %d: %w", userID, err)
+	}
+	return &record, nil
+}
+
+func (s *Service) confirm(tx *gorm.DB, userID uint, code string) ([]string, error) {
+	record, err := s.find(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, ErrNoPendingEnrolment
+	}
+	if record.Enabled {
+		return nil, ErrAlreadyEnabled
+	}
+	secret, err := s.box.Open(record.Secret)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := Validate(secret, code, time.Now(), record.LastUsedStep)
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+	now := time.Now()
+	if err := tx.Model(record).Updates(map[string]interface{}{
+		"enabled":        true,
+		"confirmed_at":   now,
+		"last_used_step": step,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	return s.replaceRecoveryCodes(tx, userID)
+}
+
+func (s *Service) verify(tx *gorm.DB, userID uint, code string) error {
+	if normalize(code) == "" {
+		return ErrCodeRequired
+	}
+	record, err := s.find(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID)
+	if err != nil {
+		return err
+	}
+	if record == nil || !record.Enabled {
+		return ErrNotEnrolled
+	}
+	secret, err := s.box.Open(record.Secret)
+	if err != nil {
+		return err
+	}
+	if step, ok := Validate(secret, code, time.Now(), record.LastUsedStep); ok {
+		if err := tx.Model(record).Update("last_used_step", step).Error; err != nil {
+			return fmt.Errorf("failed to record two-factor code use: %w", err)
+		}
+		return nil
+	}
+
+	used := tx.Model(&models.TwoFactorRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, auth.HashToken(normalize(code))).
+		Update("used_at", time.Now())
+	if used.Error != nil {
+		return fmt.Errorf("failed to check recovery code: %w", used.Error)
+	}
+	if used.RowsAffected == 0 {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+func (s *Service) replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	codes, err := GenerateRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.TwoFactorRecoveryCode{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	rows := make([]models.TwoFactorRecoveryCode, 0, len(codes))
+	for _, code := range codes {
+		rows = append(rows, models.TwoFactorRecoveryCode{UserID: userID, CodeHash: auth.HashToken(normalize(code))})
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+	return codes, nil
+}
@@ -0,0 +1,539 @@
+// Package withdrawal runs every withdrawal, whoever files it, through one
+// pipeline. A request is checked against the user's KYC limits, its funds are
+// moved into gateway clearing and it waits as PENDING for admin approval and
+// for any cooling-off hold to end. It is then paid out (PROCESSING) and the
+// gateway's answer settles it (SUCCESS) or returns the funds (REVERSED); an
+// admin may reject it (REJECTED) while it is pending. Every status change and
+// approval is written to the withdrawal's audit trail.
+package withdrawal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/pkg/fx"
+	"github.com/fathimasithara01/tradeverse/pkg/ledger"
+	"github.com/fathimasithara01/tradeverse/pkg/models"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+	paymentgateway "github.com/fathimasithara01/tradeverse/pkg/payment_gateway.go"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNotFound          = errors.New("withdrawal request not found")
+	ErrInvalidAmount     = errors.New("withdrawal amount must be positive")
+	ErrLimitExceeded     = errors.New("withdrawal limit exceeded")
+	ErrInsufficientFunds = errors.New("insufficient funds for withdrawal")
+	ErrNotPending        = errors.New("withdrawal request is not pending")
+	ErrSelfApproval      = errors.New("a withdrawal cannot be approved by the person who requested it")
+	ErrAlreadyApproved   = errors.New("admin has already approved this withdrawal")
+	ErrNoGateway         = errors.New("no payment gateway configured for payouts")
+)
+
+// Gateway pays out withdrawals. settled reports whether the payout is final
+// on return; when it is false the outcome arrives later through
+// ApplyGatewayOutcome.
+type Gateway interface {
+	ProcessWithdrawal(amount money.Amount, currency, beneficiaryAccount string) (pgTxID string, settled bool, err error)
+}
+
+type Pipeline struct {
+	db      *gorm.DB
+	policy  Policy
+	gateway Gateway
+}
+
+// NewPipeline builds the pipeline. gateway may be nil in services that only
+// file withdrawals and take gateway callbacks; payouts then fail with
+// ErrNoGateway.
+func NewPipeline(db *gorm.DB, policy Policy, gateway Gateway) *Pipeline {
+	return &Pipeline{db: db, policy: policy, gateway: gateway}
+}
+
+type actor struct {
+	kind models.WithdrawalActor
+	id   *uint
+}
+
+func adminActor(id uint) actor { return actor{kind: models.WithdrawalActorAdmin, id: &id} }
+
+var (
+	systemActor  = actor{kind: models.WithdrawalActorSystem}
+	gatewayActor = actor{kind: models.WithdrawalActorGateway}
+)
+
+// Request files a withdrawal from userID's wallet in input.Currency.
+// requestedBy is the user themselves, for the platform wallet the admin
+// asking for the payout, or 0 when the platform files it.
+func (p *Pipeline) Request(ctx context.Context, userID, requestedBy uint, input models.WithdrawalRequestInput) (*models.WithdrawalRequest, error) {
+	var w *models.WithdrawalRequest
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		w, err = p.RequestTx(tx, userID, requestedBy, input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// RequestTx is Request inside tx, which must be a database transaction, so
+// the caller can record the withdrawal alongside its own changes.
+func (p *Pipeline) RequestTx(tx *gorm.DB, userID, requestedBy uint, input models.WithdrawalRequestInput) (*models.WithdrawalRequest, error) {
+	if !input.Amount.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+	currency := strings.ToUpper(input.Currency)
+
+	// Locking the user serialises their withdrawals, so the limit check
+	// sees every one of them.
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load user %d: %w", userID, err)
+	}
+	platformID, err := ledger.PlatformOwnerID(tx)
+	if err != nil {
+		return nil, err
+	}
+	platform := userID == platformID
+
+	quote, err := fx.Lookup(tx, currency, p.policy.Currency)
+	if err != nil {
+		return nil, err
+	}
+	limitAmount := input.Amount.Convert(quote.Rate, p.policy.Currency, money.RoundHalfUp)
+
+	// The platform wallet has no KYC tier or cooling-off; its payouts
+	// still need another admin's approval.
+	now := time.Now()
+	var hold *time.Time
+	if !platform {
+		if err := p.checkLimits(tx, userID, limitAmount, now); err != nil {
+			return nil, err
+		}
+		lastDeposit, err := lastDepositAt(tx, userID)
+		if err != nil {
+			return nil, err
+		}
+		hold = p.policy.HoldUntil(now, lastDeposit, user.PasswordChangedAt)
+	}
+
+	w := &models.WithdrawalRequest{
+		UserID:            userID,
+		RequestedBy:       requestedBy,
+		Amount:            input.Amount,
+		Currency:          currency,
+		BankAccountNumber: input.BankAccountNumber,
+		BankAccountHolder: input.BankAccountHolder,
+		IFSCCode:          input.IFSCCode,
+		Status:            models.TxStatusPending,
+		RequestTime:       now,
+		LimitAmount:       limitAmount,
+		ApprovalsRequired: p.policy.ApprovalsFor(limitAmount),
+		HoldUntil:         hold,
+	}
+	if err := tx.Create(w).Error; err != nil {
+		return nil, fmt.Errorf("failed to create withdrawal request: %w", err)
+	}
+
+	walletTx := &models.WalletTransaction{Status: models.TxStatusPending}
+	if _, err := ledger.Post(tx, ledger.Entry{
+		Type:        models.TxTypeWithdrawal,
+		Reference:   fmt.Sprintf("WITHDRAW_REQ_%d", w.ID),
+		Description: fmt.Sprintf("Withdrawal %d to %s (Account: %s)", w.ID, input.BankAccountHolder, input.BankAccountNumber),
+		Currency:    currency,
+		Legs: []ledger.Leg{
+			ledger.Debit(walletAccount(platform, userID, currency), input.Amount, walletTx),
+			ledger.Credit(ledger.GatewayClearing(currency), input.Amount, nil),
+		},
+	}); err != nil {
+		if errors.Is(err, ledger.ErrInsufficientFunds) {
+			return nil, ErrInsufficientFunds
+		}
+		return nil, fmt.Errorf("failed to hold withdrawal funds: %w", err)
+	}
+	w.WalletTransactionID = &walletTx.ID
+	if err := tx.Save(w).Error; err != nil {
+		return nil, fmt.Errorf("failed to link withdrawal %d to its wallet transaction: %w", w.ID, err)
+	}
+
+	by := actor{kind: models.WithdrawalActorUser, id: &requestedBy}
+	switch {
+	case requestedBy == 0:
+		by = systemActor
+	case platform || requestedBy != userID:
+		by = adminActor(requestedBy)
+	}
+	note := fmt.Sprintf("%d approval(s) required", w.ApprovalsRequired)
+	if hold != nil {
+		note += fmt.Sprintf("; on hold until %s", hold.UTC().Format(time.RFC3339))
+	}
+	if err := record(tx, w, models.WithdrawalActionRequested, "", by, note); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Approve records adminID's approval. The withdrawal is paid out as soon as it
+// has all the approvals it needs and is past any hold.
+func (p *Pipeline) Approve(ctx context.Context, id, adminID uint, note string) (*models.WithdrawalRequest, error) {
+	var due bool
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		w, err := lock(tx, id)
+		if err != nil {
+			return err
+		}
+		if w.Status != models.TxStatusPending {
+			return fmt.Errorf("%w: withdrawal %d is %s", ErrNotPending, id, w.Status)
+		}
+		if adminID == w.UserID || adminID == w.RequestedBy {
+			return ErrSelfApproval
+		}
+
+		var existing int64
+		if err := tx.Model(&models.WithdrawalApproval{}).Where("withdrawal_id = ? AND admin_id = ?", id, adminID).Count(&existing).Error; err != nil {
+			return fmt.Errorf("failed to check approvals for withdrawal %d: %w", id, err)
+		}
+		if existing > 0 {
+			return ErrAlreadyApproved
+		}
+		if err := tx.Create(&models.WithdrawalApproval{WithdrawalID: id, AdminID: adminID, Note: note}).Error; err != nil {
+			return fmt.Errorf("failed to record approval for withdrawal %d: %w", id, err)
+		}
+
+		w.Approvals++
+		if err := tx.Save(w).Error; err != nil {
+			return fmt.Errorf("failed to update withdrawal %d: %w", id, err)
+		}
+		due = isDue(w, time.Now())
+
+		auditNote := fmt.Sprintf("approval %d of %d", w.Approvals, w.ApprovalsRequired)
+		if note != "" {
+			auditNote += ": " + note
+		}
+		return record(tx, w, models.WithdrawalActionApproved, w.Status, adminActor(adminID), auditNote)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if due {
+		if err := p.payOut(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return p.Get(ctx, id)
+}
+
+// Reject returns a pending withdrawal's funds to the wallet.
+func (p *Pipeline) Reject(ctx context.Context, id, adminID uint, note string) (*models.WithdrawalRequest, error) {
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		w, err := lock(tx, id)
+		if err != nil {
+			return err
+		}
+		if w.Status != models.TxStatusPending {
+			return fmt.Errorf("%w: withdrawal %d is %s", ErrNotPending, id, w.Status)
+		}
+
+		if err := returnFunds(tx, w, fmt.Sprintf("Withdrawal request %d rejected, funds returned to wallet.", w.ID)); err != nil {
+			return err
+		}
+		now := time.Now()
+		w.Status = models.TxStatusRejected
+		w.CompletionTime = &now
+		w.AdminNotes = note
+		if err := tx.Save(w).Error; err != nil {
+			return fmt.Errorf("failed to update withdrawal %d: %w", id, err)
+		}
+		if err := setWalletTxStatus(tx, w, models.TxStatusReversed); err != nil {
+			return err
+		}
+		return record(tx, w, models.WithdrawalActionRejected, models.TxStatusPending, adminActor(adminID), note)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p.Get(ctx, id)
+}
+
+// ReleaseDue pays out every approved withdrawal whose hold has ended and
+// returns how many were submitted to the gateway.
+func (p *Pipeline) ReleaseDue(ctx context.Context) (int, error) {
+	var ids []uint
+	if err := p.db.WithContext(ctx).Model(&models.WithdrawalRequest{}).
+		Where("status = ? AND approvals >= approvals_required", models.TxStatusPending).
+		Where("hold_until IS NULL OR hold_until <= ?", time.Now()).
+		Order("id ASC").Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("failed to find withdrawals due for payout: %w", err)
+	}
+
+	released := 0
+	for _, id := range ids {
+		if err := p.payOut(ctx, id); err != nil {
+			log.Printf("Failed to pay out withdrawal %d: %v", id, err)
+			continue
+		}
+		released++
+	}
+	return released, nil
+}
+
+// ApplyGatewayOutcome settles or reverses a withdrawal the gateway has
+// reported on. w must be locked by tx. It reports false when the withdrawal
+// already reflects the outcome.
+func (p *Pipeline) ApplyGatewayOutcome(tx *gorm.DB, w *models.WithdrawalRequest, succeeded bool, reason string) (bool, error) {
+	switch w.Status {
+	case models.TxStatusReversed, models.TxStatusRejected, models.TxStatusFailed:
+		return false, nil
+	}
+	if succeeded {
+		if w.Status == models.TxStatusSuccess {
+			return false, nil
+		}
+		return true, settle(tx, w, gatewayActor, "")
+	}
+	// A failure after SUCCESS means the payout bounced.
+	return true, reverse(tx, w, gatewayActor, reason)
+}
+
+// Get returns the withdrawal with its audit trail.
+func (p *Pipeline) Get(ctx context.Context, id uint) (*models.WithdrawalRequest, error) {
+	var w models.WithdrawalRequest
+	err := p.db.WithContext(ctx).
+		Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		First(&w, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load withdrawal %d: %w", id, err)
+	}
+	return &w, nil
+}
+
+// payOut submits a due withdrawal to the gateway. The gateway is called
+// outside any database transaction; a decline returns the funds, a timeout
+// leaves the withdrawal PROCESSING since the payout may still go through.
+func (p *Pipeline) payOut(ctx context.Context, id uint) error {
+	if p.gateway == nil {
+		return ErrNoGateway
+	}
+
+	var w *models.WithdrawalRequest
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		locked, err := lock(tx, id)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if !isDue(locked, now) {
+			return nil
+		}
+		locked.Status = models.TxStatusProcessing
+		locked.ProcessingTime = &now
+		if err := tx.Save(locked).Error; err != nil {
+			return fmt.Errorf("failed to update withdrawal %d: %w", id, err)
+		}
+		w = locked
+		return record(tx, w, models.WithdrawalActionSubmitted, models.TxStatusPending, systemActor, "")
+	})
+	if err != nil || w == nil {
+		return err
+	}
+
+	pgTxID, settled, pgErr := p.gateway.ProcessWithdrawal(w.Amount, w.Currency, w.BankAccountNumber)
+
+	err = p.db.WithContext(context.WithoutCancel(ctx)).Transaction(func(tx *gorm.DB) error {
+		w, err := lock(tx, id)
+		if err != nil {
+			return err
+		}
+		if w.Status != models.TxStatusProcessing {
+			return nil
+		}
+		switch {
+		case errors.Is(pgErr, paymentgateway.ErrGatewayTimeout):
+			w.AdminNotes = "Payment gateway timed out; payout outcome unknown."
+			return tx.Save(w).Error
+		case pgErr != nil:
+			return reverse(tx, w, gatewayActor, pgErr.Error())
+		case settled:
+			w.PaymentGatewayTxID = pgTxID
+			return settle(tx, w, gatewayActor, "")
+		default:
+			w.PaymentGatewayTxID = pgTxID
+			return tx.Save(w).Error
+		}
+	})
+	if err != nil {
+		log.Printf("Failed to record gateway outcome for withdrawal %d (pg tx %q, gateway error %v): %v", id, pgTxID, pgErr, err)
+		return fmt.Errorf("failed to record payout of withdrawal %d: %w", id, err)
+	}
+	return nil
+}
+
+func (p *Pipeline) checkLimits(tx *gorm.DB, userID uint, amount money.Amount, now time.Time) error {
+	var statuses []string
+	if err := tx.Model(&models.UserKYCStatus{}).Where("user_id = ?", userID).Limit(1).Pluck("status", &statuses).Error; err != nil {
+		return fmt.Errorf("failed to load KYC status for user %d: %w", userID, err)
+	}
+	tier := models.KYCStatusNotSubmitted
+	if len(statuses) > 0 && statuses[0] != "" {
+		tier = statuses[0]
+	}
+
+	day, month := windows(now)
+	usedToday, err := withdrawnSince(tx, userID, day)
+	if err != nil {
+		return err
+	}
+	usedThisMonth, err := withdrawnSince(tx, userID, month)
+	if err != nil {
+		return err
+	}
+	return p.policy.CheckLimit(tier, amount, usedToday, usedThisMonth)
+}
+
+// withdrawnSince sums the limit amounts of the user's withdrawals since the
+// given time that still take or hold money.
+func withdrawnSince(tx *gorm.DB, userID uint, since time.Time) (money.Amount, error) {
+	var used money.Amount
+	err := tx.Model(&models.WithdrawalRequest{}).
+		Select("COALESCE(SUM(limit_amount), 0)").
+		Where("user_id = ? AND request_time >= ?", userID, since).
+		Where("status NOT IN ?", []models.TransactionStatus{models.TxStatusRejected, models.TxStatusReversed, models.TxStatusFailed}).
+		Row().Scan(&used)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum withdrawals for user %d: %w", userID, err)
+	}
+	return used, nil
+}
+
+func lastDepositAt(tx *gorm.DB, userID uint) (*time.Time, error) {
+	var last sql.NullTime
+	err := tx.Model(&models.WalletTransaction{}).
+		Select("MAX(created_at)").
+		Where("user_id = ? AND type = ?", userID, models.TxTypeDeposit).
+		Row().Scan(&last)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find last deposit for user %d: %w", userID, err)
+	}
+	if !last.Valid {
+		return nil, nil
+	}
+	return &last.Time, nil
+}
+
+func isDue(w *models.WithdrawalRequest, now time.Time) bool {
+	return w.Status == models.TxStatusPending &&
+		w.Approvals >= w.ApprovalsRequired &&
+		(w.HoldUntil == nil || !w.HoldUntil.After(now))
+}
+
+func lock(tx *gorm.DB, id uint) (*models.WithdrawalRequest, error) {
+	var w models.WithdrawalRequest
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&w, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock withdrawal %d: %w", id, err)
+	}
+	return &w, nil
+}
+
+func walletAccount(platform bool, userID uint, currency string) ledger.Account {
+	if platform {
+		return ledger.PlatformCommissionIn(currency)
+	}
+	return ledger.WalletIn(userID, currency)
+}
+
+// returnFunds moves the withdrawal's amount out of gateway clearing and back
+// into the wallet it came from.
+func returnFunds(tx *gorm.DB, w *models.WithdrawalRequest, description string) error {
+	platformID, err := ledger.PlatformOwnerID(tx)
+	if err != nil {
+		return err
+	}
+	reversalTx := &models.WalletTransaction{Type: models.TxTypeReversal}
+	if _, err := ledger.Post(tx, ledger.Entry{
+		Type:        models.TxTypeReversal,
+		Reference:   fmt.Sprintf("REVERSAL-WITHDRAW-%d", w.ID),
+		Description: description,
+		Currency:    w.Currency,
+		Legs: []ledger.Leg{
+			ledger.Debit(ledger.GatewayClearing(w.Currency), w.Amount, nil),
+			ledger.Credit(walletAccount(w.UserID == platformID, w.UserID, w.Currency), w.Amount, reversalTx),
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to return funds for withdrawal %d: %w", w.ID, err)
+	}
+	return nil
+}
+
+func settle(tx *gorm.DB, w *models.WithdrawalRequest, by actor, note string) error {
+	from := w.Status
+	now := time.Now()
+	w.Status = models.TxStatusSuccess
+	w.CompletionTime = &now
+	if err := tx.Save(w).Error; err != nil {
+		return fmt.Errorf("failed to update withdrawal %d: %w", w.ID, err)
+	}
+	if err := setWalletTxStatus(tx, w, models.TxStatusSuccess); err != nil {
+		return err
+	}
+	return record(tx, w, models.WithdrawalActionSettled, from, by, note)
+}
+
+func reverse(tx *gorm.DB, w *models.WithdrawalRequest, by actor, reason string) error {
+	from := w.Status
+	if err := returnFunds(tx, w, fmt.Sprintf("Withdrawal %d failed at payment gateway", w.ID)); err != nil {
+		return err
+	}
+	now := time.Now()
+	w.Status = models.TxStatusReversed
+	w.CompletionTime = &now
+	w.AdminNotes = reason
+	if err := tx.Save(w).Error; err != nil {
+		return fmt.Errorf("failed to update withdrawal %d: %w", w.ID, err)
+	}
+	if err := setWalletTxStatus(tx, w, models.TxStatusReversed); err != nil {
+		return err
+	}
+	return record(tx, w, models.WithdrawalActionReversed, from, by, reason)
+}
+
+func setWalletTxStatus(tx *gorm.DB, w *models.WithdrawalRequest, status models.TransactionStatus) error {
+	if w.WalletTransactionID == nil {
+		return nil
+	}
+	if err := tx.Model(&models.WalletTransaction{}).Where("id = ?", *w.WalletTransactionID).Update("status", status).Error; err != nil {
+		return fmt.Errorf("failed to update wallet transaction for withdrawal %d: %w", w.ID, err)
+	}
+	return nil
+}
+
+func record(tx *gorm.DB, w *models.WithdrawalRequest, action models.WithdrawalAction, from models.TransactionStatus, by actor, note string) error {
+	event := models.WithdrawalEvent{
+		WithdrawalID: w.ID,
+		Action:       action,
+		FromStatus:   from,
+		ToStatus:     w.Status,
+		ActorType:    by.kind,
+		ActorID:      by.id,
+		Note:         note,
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		return fmt.Errorf("failed to record %s for withdrawal %d: %w", action, w.ID, err)
+	}
+	return nil
+}
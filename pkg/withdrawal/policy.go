@@ -0,0 +1,96 @@
+package withdrawal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fathimasithara01/tradeverse/config"
+	"github.com/fathimasithara01/tradeverse/pkg/money"
+)
+
+// Limit caps how much a user may withdraw per calendar day and month (UTC).
+type Limit struct {
+	Daily   money.Amount
+	Monthly money.Amount
+}
+
+// Policy holds the rules every withdrawal is checked against. Amounts are in
+// Currency.
+type Policy struct {
+	Currency string
+	// Limits is keyed by KYC status.
+	Limits map[string]Limit
+	// SecondApprovalAbove is the amount above which two admins must approve.
+	SecondApprovalAbove money.Amount
+	DepositHold         time.Duration
+	PasswordHold        time.Duration
+}
+
+func PolicyFromConfig(cfg *config.Config) Policy {
+	w := cfg.Withdrawals
+	policy := Policy{
+		Currency:            strings.ToUpper(w.LimitCurrency),
+		Limits:              make(map[string]Limit, len(w.Limits)),
+		SecondApprovalAbove: money.FromFloat(w.SecondApprovalThreshold),
+		DepositHold:         time.Duration(w.DepositHoldHours) * time.Hour,
+		PasswordHold:        time.Duration(w.PasswordChangeHoldHours) * time.Hour,
+	}
+	// Viper lower-cases map keys; KYC statuses are upper case.
+	for tier, limit := range w.Limits {
+		policy.Limits[strings.ToUpper(tier)] = Limit{
+			Daily:   money.FromFloat(limit.Daily),
+			Monthly: money.FromFloat(limit.Monthly),
+		}
+	}
+	return policy
+}
+
+// CheckLimit reports whether amount fits in the tier's limits given what was
+// already withdrawn today and this month.
+func (p Policy) CheckLimit(tier string, amount, usedToday, usedThisMonth money.Amount) error {
+	limit := p.Limits[tier]
+	if usedToday.Add(amount) > limit.Daily {
+		return fmt.Errorf("%w: %s KYC allows %s %s per day, %s already used", ErrLimitExceeded,
+			tier, limit.Daily.Format(p.Currency), p.Currency, usedToday.Format(p.Currency))
+	}
+	if usedThisMonth.Add(amount) > limit.Monthly {
+		return fmt.Errorf("%w: %s KYC allows %s %s per month, %s already used", ErrLimitExceeded,
+			tier, limit.Monthly.Format(p.Currency), p.Currency, usedThisMonth.Format(p.Currency))
+	}
+	return nil
+}
+
+// ApprovalsFor returns how many admins must approve a withdrawal of amount.
+func (p Policy) ApprovalsFor(amount money.Amount) int {
+	if amount > p.SecondApprovalAbove {
+		return 2
+	}
+	return 1
+}
+
+// HoldUntil returns when the cooling-off period after the user's last deposit
+// or password change ends, or nil when neither applies.
+func (p Policy) HoldUntil(now time.Time, lastDeposit, passwordChanged *time.Time) *time.Time {
+	var until time.Time
+	if lastDeposit != nil && p.DepositHold > 0 {
+		until = lastDeposit.Add(p.DepositHold)
+	}
+	if passwordChanged != nil && p.PasswordHold > 0 {
+		if t := passwordChanged.Add(p.PasswordHold); t.After(until) {
+			until = t
+		}
+	}
+	if !until.After(now) {
+		return nil
+	}
+	return &until
+}
+
+// windows returns the start of the calendar day and month that now falls in.
+func windows(now time.Time) (day, month time.Time) {
+	now = now.UTC()
+	day = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return day, month
+}